@@ -36,3 +36,19 @@ type Auditor interface {
 		raftIndex uint64,
 		message string)
 }
+
+// Flusher is an optional interface for the Auditor,
+// that delivers the events buffered by the asynchronous auditor
+type Flusher interface {
+	// Flush blocks until the events audited before the call are persisted
+	Flush() error
+}
+
+// Flush flushes the auditor, if it implements Flusher,
+// otherwise it's no-op, as the events are delivered synchronously
+func Flush(a Auditor) error {
+	if f, ok := a.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
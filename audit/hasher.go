@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// hashedIdentityPrefix is the prefix of the pseudonymized identity,
+// to distinguish it from the clear identity in the audit logs
+const hashedIdentityPrefix = "hmac:"
+
+// IdentityHasher pseudonymizes the identity of the audit events
+// for the configured sources, by replacing it with HMAC-SHA256 of the identity.
+// The same identity is always hashed to the same value with the same key,
+// so the events can still be correlated.
+// The identity of the events from other sources is kept clear.
+type IdentityHasher struct {
+	key     []byte
+	sources map[string]bool
+}
+
+// NewIdentityHasher returns IdentityHasher with the HMAC key,
+// for the event sources to pseudonymize
+func NewIdentityHasher(key []byte, sources ...string) *IdentityHasher {
+	h := &IdentityHasher{
+		key:     key,
+		sources: map[string]bool{},
+	}
+	for _, s := range sources {
+		h.sources[s] = true
+	}
+	return h
+}
+
+// Identity returns the identity to audit for the event source,
+// the empty identity is never hashed
+func (h *IdentityHasher) Identity(source, identity string) string {
+	if identity == "" || !h.sources[source] {
+		return identity
+	}
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(identity))
+	return hashedIdentityPrefix + hex.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -0,0 +1,23 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IdentityHasher(t *testing.T) {
+	h := NewIdentityHasher([]byte("secret"), "http", "status")
+
+	hashed := h.Identity("http", "client/bob")
+	assert.True(t, strings.HasPrefix(hashed, "hmac:"), hashed)
+	assert.Len(t, hashed, len("hmac:")+32)
+	assert.NotContains(t, hashed, "bob")
+	assert.Equal(t, hashed, h.Identity("status", "client/bob"), "the same identity must hash the same")
+	assert.NotEqual(t, hashed, h.Identity("http", "client/alice"))
+	assert.NotEqual(t, hashed, NewIdentityHasher([]byte("other"), "http").Identity("http", "client/bob"))
+
+	assert.Equal(t, "client/bob", h.Identity("authz", "client/bob"))
+	assert.Equal(t, "", h.Identity("http", ""))
+}
@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"sync"
+
+	"github.com/go-phorce/dolly/metrics"
+)
+
+var keyForSampledDrop = []string{"audit", "sampled", "dropped"}
+
+// EventKey specifies the source and event type for sampling,
+// if EventType is empty, then the key matches all event types of the source
+type EventKey struct {
+	Source    string
+	EventType string
+}
+
+// Sampler is an Auditor that reduces the volume of high-frequency events,
+// by keeping 1 in N events of the configured source and event type.
+// The events not configured for sampling are always sent to the Destination auditor.
+type Sampler struct {
+	Destination Auditor
+
+	rates   map[EventKey]uint64
+	exempt  map[EventKey]bool
+	counts  map[EventKey]uint64
+	dropped uint64
+	lock    sync.Mutex
+}
+
+// NewSampler returns a Sampler with the rates,
+// where the rate N specifies to keep 1 in N events,
+// the rates lower than 2 keep all events
+func NewSampler(destination Auditor, rates map[EventKey]int) *Sampler {
+	s := &Sampler{
+		Destination: destination,
+		rates:       map[EventKey]uint64{},
+		exempt:      map[EventKey]bool{},
+		counts:      map[EventKey]uint64{},
+	}
+	for k, n := range rates {
+		if n > 1 {
+			s.rates[k] = uint64(n)
+		}
+	}
+	return s
+}
+
+// Exempt specifies the critical events that are never sampled,
+// regardless of the configured rates
+func (s *Sampler) Exempt(source, eventType string) *Sampler {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.exempt[EventKey{Source: source, EventType: eventType}] = true
+	return s
+}
+
+// Dropped returns the number of events dropped by sampling
+func (s *Sampler) Dropped() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.dropped
+}
+
+// Audit sends the event to the Destination auditor,
+// unless it's dropped by sampling
+func (s *Sampler) Audit(source string,
+	eventType string,
+	identity string,
+	contextID string,
+	raftIndex uint64,
+	message string) {
+	if !s.keep(source, eventType) {
+		metrics.IncrCounter(keyForSampledDrop, 1,
+			metrics.Tag{Name: "source", Value: source},
+			metrics.Tag{Name: "type", Value: eventType},
+		)
+		return
+	}
+	s.Destination.Audit(source, eventType, identity, contextID, raftIndex, message)
+}
+
+// keep returns true if the event should be sent,
+// the first of every N events is kept
+func (s *Sampler) keep(source, eventType string) bool {
+	key := EventKey{Source: source, EventType: eventType}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.exempt[key] || s.exempt[EventKey{Source: source}] {
+		return true
+	}
+	n, ok := s.rates[key]
+	if !ok {
+		key = EventKey{Source: source}
+		if n, ok = s.rates[key]; !ok {
+			return true
+		}
+	}
+
+	count := s.counts[key]
+	s.counts[key] = count + 1
+	if count%n == 0 {
+		return true
+	}
+	s.dropped++
+	return false
+}
+
+// Flush flushes the Destination auditor
+func (s *Sampler) Flush() error {
+	return Flush(s.Destination)
+}
+
+// Close closes the Destination auditor
+func (s *Sampler) Close() error {
+	return s.Destination.Close()
+}
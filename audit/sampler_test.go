@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Sampler(t *testing.T) {
+	dest := &testAuditor{}
+	s := NewSampler(dest, map[EventKey]int{
+		{Source: "http", EventType: "request"}: 3,
+		{Source: "authz"}:                      10,
+		{Source: "status", EventType: "noop"}:  1,
+	}).Exempt("authz", "denied")
+
+	for i := 0; i < 7; i++ {
+		s.Audit("http", "request", "id", "ctx", 0, "msg")
+		s.Audit("authz", "allowed", "id", "ctx", 0, "msg")
+		s.Audit("authz", "denied", "id", "ctx", 0, "msg")
+		s.Audit("status", "noop", "id", "ctx", 0, "msg")
+		s.Audit("status", "stopped", "id", "ctx", 0, "msg")
+	}
+
+	count := func(source, eventType string) int {
+		c := 0
+		for _, e := range dest.events {
+			if e.source == source && e.eventType == eventType {
+				c++
+			}
+		}
+		return c
+	}
+	// 1st, 4th, 7th
+	assert.Equal(t, 3, count("http", "request"))
+	assert.Equal(t, 1, count("authz", "allowed"))
+	assert.Equal(t, 7, count("authz", "denied"))
+	assert.Equal(t, 7, count("status", "noop"))
+	assert.Equal(t, 7, count("status", "stopped"))
+	assert.Equal(t, uint64(4+6), s.Dropped())
+
+	assert.NoError(t, s.Close())
+}
+
+type testAuditor struct {
+	events []*eventInfo
+}
+
+func (a *testAuditor) Audit(source string,
+	eventType string,
+	identity string,
+	contextID string,
+	raftIndex uint64,
+	message string) {
+	a.events = append(a.events, &eventInfo{
+		source:    source,
+		eventType: eventType,
+		identity:  identity,
+		contextID: contextID,
+		raftIndex: raftIndex,
+		message:   message,
+	})
+}
+
+func (a *testAuditor) Close() error {
+	return nil
+}
+
+type flushAuditor struct {
+	testAuditor
+	flushed int
+}
+
+func (a *flushAuditor) Flush() error {
+	a.flushed++
+	return nil
+}
+
+func Test_Flush(t *testing.T) {
+	assert.NoError(t, Flush(&testAuditor{}))
+
+	dest := &flushAuditor{}
+	s := NewSampler(dest, nil)
+	assert.NoError(t, Flush(s))
+	assert.Equal(t, 1, dest.flushed)
+
+	assert.NoError(t, NewSampler(&testAuditor{}, nil).Flush())
+}
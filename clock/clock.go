@@ -0,0 +1,108 @@
+// Package clock provides an abstraction of the time source,
+// that allows to use a fake clock in unit tests.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time and timers
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time
+	// on the returned channel
+	After(d time.Duration) <-chan time.Time
+}
+
+// New returns the real clock
+func New() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Mock is a fake clock, which time is advanced only by Add or Set
+type Mock struct {
+	lock    sync.Mutex
+	now     time.Time
+	waiters []*waiter
+}
+
+type waiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewMock returns a fake clock set to the specified time
+func NewMock(now time.Time) *Mock {
+	return &Mock{now: now}
+}
+
+// Now returns the current time of the fake clock
+func (m *Mock) Now() time.Time {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.now
+}
+
+// After returns a channel, that receives the time
+// when the fake clock is advanced by the duration
+func (m *Mock) After(d time.Duration) <-chan time.Time {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	c := make(chan time.Time, 1)
+	if d <= 0 {
+		c <- m.now
+		return c
+	}
+	m.waiters = append(m.waiters, &waiter{at: m.now.Add(d), c: c})
+	return c
+}
+
+// Add advances the fake clock by the duration,
+// and fires the expired timers
+func (m *Mock) Add(d time.Duration) {
+	m.lock.Lock()
+	m.set(m.now.Add(d))
+	m.lock.Unlock()
+}
+
+// Set sets the time of the fake clock,
+// and fires the expired timers
+func (m *Mock) Set(t time.Time) {
+	m.lock.Lock()
+	m.set(t)
+	m.lock.Unlock()
+}
+
+// Waiters returns the number of the pending timers,
+// it allows tests to wait until a goroutine is blocked on After
+func (m *Mock) Waiters() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.waiters)
+}
+
+func (m *Mock) set(t time.Time) {
+	m.now = t
+	pending := m.waiters[:0]
+	for _, w := range m.waiters {
+		if !w.at.After(t) {
+			w.c <- t
+		} else {
+			pending = append(pending, w)
+		}
+	}
+	m.waiters = pending
+}
@@ -0,0 +1,47 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Real(t *testing.T) {
+	c := clock.New()
+	now := time.Now()
+	assert.False(t, c.Now().Before(now))
+
+	select {
+	case <-c.After(time.Millisecond):
+	case <-time.After(time.Second):
+		assert.Fail(t, "timer did not fire")
+	}
+}
+
+func Test_Mock(t *testing.T) {
+	start := time.Date(2020, time.May, 1, 10, 0, 0, 0, time.UTC)
+	c := clock.NewMock(start)
+	assert.Equal(t, start, c.Now())
+
+	now := <-c.After(0)
+	assert.Equal(t, start, now)
+
+	t1 := c.After(time.Second)
+	t2 := c.After(time.Minute)
+	assert.Equal(t, 2, c.Waiters())
+
+	c.Add(500 * time.Millisecond)
+	assert.Len(t, t1, 0)
+
+	c.Add(500 * time.Millisecond)
+	assert.Equal(t, start.Add(time.Second), <-t1)
+	assert.Len(t, t2, 0)
+	assert.Equal(t, 1, c.Waiters())
+
+	c.Set(start.Add(time.Hour))
+	assert.Equal(t, start.Add(time.Hour), <-t2)
+	assert.Equal(t, 0, c.Waiters())
+	assert.Equal(t, start.Add(time.Hour), c.Now())
+}
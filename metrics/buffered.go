@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBufferSize specifies the default size of the BufferedSink queue
+const DefaultBufferSize = 4096
+
+type metricKind int
+
+const (
+	kindGauge metricKind = iota
+	kindCounter
+	kindSample
+)
+
+type bufferedMetric struct {
+	kind metricKind
+	key  []string
+	val  float32
+	tags []Tag
+}
+
+// BufferedSink is a Sink, that delivers the metrics to the underlying sink
+// asynchronously, from a bounded queue.
+// When the underlying sink is slow or unavailable, and the queue is full,
+// the metrics are dropped and counted, instead of blocking the caller.
+// The panics of the underlying sink are recovered, and the metric is counted as dropped.
+type BufferedSink struct {
+	sink    Sink
+	queue   chan bufferedMetric
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+	dropped uint64
+}
+
+// NewBufferedSink returns BufferedSink, that delivers the metrics to sink,
+// where size specifies the size of the queue, DefaultBufferSize if not positive
+func NewBufferedSink(sink Sink, size int) *BufferedSink {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	s := &BufferedSink{
+		sink:    sink,
+		queue:   make(chan bufferedMetric, size),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go s.deliver()
+	return s
+}
+
+// SetGauge should retain the last value it is set to
+func (s *BufferedSink) SetGauge(key []string, val float32, tags []Tag) {
+	s.push(bufferedMetric{kind: kindGauge, key: key, val: val, tags: tags})
+}
+
+// IncrCounter should accumulate values
+func (s *BufferedSink) IncrCounter(key []string, val float32, tags []Tag) {
+	s.push(bufferedMetric{kind: kindCounter, key: key, val: val, tags: tags})
+}
+
+// AddSample is for timing information, where quantiles are used
+func (s *BufferedSink) AddSample(key []string, val float32, tags []Tag) {
+	s.push(bufferedMetric{kind: kindSample, key: key, val: val, tags: tags})
+}
+
+// Dropped returns the number of the metrics,
+// dropped because the queue was full, or the underlying sink panicked
+func (s *BufferedSink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Shutdown stops the delivery, the queued metrics are delivered before it returns,
+// and the metrics published after Shutdown are dropped
+func (s *BufferedSink) Shutdown() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	<-s.stopped
+}
+
+// push does a non-blocking push to the queue
+func (s *BufferedSink) push(m bufferedMetric) {
+	select {
+	case <-s.done:
+		s.drop("shutdown")
+		return
+	default:
+	}
+
+	select {
+	case s.queue <- m:
+	default:
+		s.drop("queue_full")
+	}
+}
+
+// drop counts the dropped metric, the drops are logged periodically,
+// to not flood the log while the sink is unavailable
+func (s *BufferedSink) drop(reason string) {
+	if n := atomic.AddUint64(&s.dropped, 1); n == 1 || n%1024 == 0 {
+		logger.Warningf("api=BufferedSink, reason=%s, dropped=%d", reason, n)
+	}
+}
+
+// deliver sends the queued metrics to the sink, until Shutdown
+func (s *BufferedSink) deliver() {
+	defer close(s.stopped)
+	for {
+		select {
+		case m := <-s.queue:
+			s.send(m)
+		case <-s.done:
+			for {
+				select {
+				case m := <-s.queue:
+					s.send(m)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// send delivers the metric to the sink, recovering from its panic
+func (s *BufferedSink) send(m bufferedMetric) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("api=BufferedSink, reason=panic, key=%v, err=%v", m.key, r)
+			s.drop("panic")
+		}
+	}()
+
+	switch m.kind {
+	case kindGauge:
+		s.sink.SetGauge(m.key, m.val, m.tags)
+	case kindCounter:
+		s.sink.IncrCounter(m.key, m.val, m.tags)
+	case kindSample:
+		s.sink.AddSample(m.key, m.val, m.tags)
+	}
+}
@@ -0,0 +1,86 @@
+package metrics_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingSink blocks the delivery until released
+type blockingSink struct {
+	metrics.BlackholeSink
+	release chan struct{}
+	lock    sync.Mutex
+	count   int
+}
+
+func (s *blockingSink) IncrCounter(key []string, val float32, tags []metrics.Tag) {
+	<-s.release
+	s.lock.Lock()
+	s.count++
+	s.lock.Unlock()
+}
+
+func (s *blockingSink) AddSample(key []string, val float32, tags []metrics.Tag) {
+	panic("sink failed")
+}
+
+func Test_BufferedSink(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{})}
+	bs := metrics.NewBufferedSink(sink, 2)
+
+	done := make(chan struct{})
+	go func() {
+		// the publish must not block while the sink is blocked
+		for i := 0; i < 10; i++ {
+			bs.IncrCounter([]string{"test"}, 1, nil)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "publish blocked")
+	}
+	// at most one metric is in delivery, and two are queued
+	assert.True(t, bs.Dropped() >= 7, "dropped=%d", bs.Dropped())
+
+	close(sink.release)
+	dropped := bs.Dropped()
+
+	// the panic of the sink is recovered and counted
+	bs.AddSample([]string{"test"}, 1, nil)
+	bs.Shutdown()
+	assert.Equal(t, dropped+1, bs.Dropped())
+	sink.lock.Lock()
+	assert.Equal(t, 10-int(dropped), sink.count)
+	sink.lock.Unlock()
+
+	// published after shutdown
+	bs.SetGauge([]string{"test"}, 1, nil)
+	assert.Equal(t, dropped+2, bs.Dropped())
+	bs.Shutdown()
+}
+
+func Test_BufferedSinkWithInmem(t *testing.T) {
+	im := metrics.NewInmemSink(time.Minute, time.Minute*5)
+	bs := metrics.NewBufferedSink(im, 0)
+	m, err := metrics.New(&metrics.Config{ServiceName: "buffered", FilterDefault: true}, bs)
+	require.NoError(t, err)
+
+	m.IncrCounter([]string{"counter"}, 1)
+	m.SetGauge([]string{"gauge"}, 2)
+	m.AddSample([]string{"sample"}, 3)
+	bs.Shutdown()
+
+	data := im.Data()
+	require.NotEmpty(t, data)
+	assert.Equal(t, 1, data[0].Counters["buffered.counter"].Count)
+	assert.Equal(t, float32(2), data[0].Gauges["buffered.gauge"].Value)
+	assert.Equal(t, 1, data[0].Samples["buffered.sample"].Count)
+	assert.Equal(t, uint64(0), bs.Dropped())
+}
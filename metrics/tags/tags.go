@@ -9,4 +9,6 @@ const (
 	Role = "role"
 	// Status is the name of the metrics tag used for response status code
 	Status = "status"
+	// Service is the name of the metrics tag used for the service name
+	Service = "service"
 )
@@ -12,4 +12,5 @@ func Test_Tags(t *testing.T) {
 	assert.Equal(t, "method", tags.Method)
 	assert.Equal(t, "status", tags.Status)
 	assert.Equal(t, "role", tags.Role)
+	assert.Equal(t, "service", tags.Service)
 }
@@ -12,6 +12,14 @@ import (
 var (
 	keyForCertExpiry = []string{"cert", "expiry", "days"}
 	keyForCrlExpiry  = []string{"crl", "expiry", "days"}
+	keyForCertAlert  = []string{"cert", "expiry", "alert"}
+)
+
+const (
+	// CertExpiryWarning specifies the level of the certificate near expiry
+	CertExpiryWarning = "warning"
+	// CertExpiryCritical specifies the level of the certificate close to expiry
+	CertExpiryCritical = "critical"
 )
 
 // PublishShortLivedCertExpirationInDays publish cert expiration time in Days for short lived certificates
@@ -42,6 +50,18 @@ func PublishCertExpirationInDays(c *x509.Certificate, typ string) float32 {
 	return expiresInDays
 }
 
+// PublishCertExpiryAlert publish the alert for the certificate near expiry,
+// the level is CertExpiryWarning or CertExpiryCritical
+func PublishCertExpiryAlert(c *x509.Certificate, typ, level string) {
+	metrics.IncrCounter(
+		keyForCertAlert,
+		1,
+		metrics.Tag{Name: "CN", Value: c.Subject.CommonName},
+		metrics.Tag{Name: "type", Value: typ},
+		metrics.Tag{Name: "level", Value: level},
+	)
+}
+
 // PublishCRLExpirationInDays publish CRL expiration time in Days
 func PublishCRLExpirationInDays(c *pkix.CertificateList, issuer *x509.Certificate) float32 {
 	PublishCertExpirationInDays(issuer, "issuer")
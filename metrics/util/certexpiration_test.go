@@ -42,6 +42,8 @@ func Test_PublishCertExpiration(t *testing.T) {
 	assert.True(t, expiresInDays > 0)
 	assert.True(t, expiresInDays <= 1)
 
+	PublishCertExpiryAlert(crt, "longlived", CertExpiryCritical)
+
 	// get samples in memory
 	data := im.Data()
 	require.NotEqual(t, 0, len(data))
@@ -49,6 +51,9 @@ func Test_PublishCertExpiration(t *testing.T) {
 	for k := range data[0].Gauges {
 		t.Log("Gauge:", k)
 	}
+	for k := range data[0].Counters {
+		t.Log("Counter:", k)
+	}
 
 	assertGauge := func(key string) {
 		s, exists := data[0].Gauges[key]
@@ -68,4 +73,7 @@ func Test_PublishCertExpiration(t *testing.T) {
 	assertGauge(
 		fmt.Sprintf("service.%s.crl.expiry.days;CN=%s;Serial=%s;SKI=%s",
 			hostname, crt.Subject.CommonName, crt.SerialNumber.String(), hex.EncodeToString(crt.SubjectKeyId)))
+	_, exists := data[0].Counters[fmt.Sprintf("service.cert.expiry.alert;CN=%s;type=longlived;level=critical",
+		crt.Subject.CommonName)]
+	assert.True(t, exists)
 }
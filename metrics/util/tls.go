@@ -0,0 +1,66 @@
+package util
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strconv"
+
+	"github.com/go-phorce/dolly/metrics"
+)
+
+var (
+	keyForTLSHandshake = []string{"tls", "handshake"}
+)
+
+// TLS handshake failure reasons
+const (
+	// TLSFailedHandshake specifies the handshake failure
+	TLSFailedHandshake = "handshake"
+	// TLSFailedClientCert specifies the rejected client certificate
+	TLSFailedClientCert = "client_cert"
+	// TLSFailedNoClientCert specifies the client did not provide the required certificate
+	TLSFailedNoClientCert = "no_client_cert"
+	// TLSFailedExpiredClientCert specifies the expired or not yet valid client certificate
+	TLSFailedExpiredClientCert = "expired_client_cert"
+	// TLSFailedUntrustedClientCert specifies the client certificate issued by untrusted CA
+	TLSFailedUntrustedClientCert = "untrusted_client_cert"
+)
+
+var tlsVersions = map[uint16]string{
+	tls.VersionTLS10: "TLS1.0",
+	tls.VersionTLS11: "TLS1.1",
+	tls.VersionTLS12: "TLS1.2",
+	tls.VersionTLS13: "TLS1.3",
+}
+
+// TLSVersionName returns the name of TLS version
+func TLSVersionName(version uint16) string {
+	if name, ok := tlsVersions[version]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04X", version)
+}
+
+// PublishTLSHandshake publishes the successful TLS handshake,
+// tagged with the negotiated protocol version and cipher suite
+func PublishTLSHandshake(state *tls.ConnectionState) {
+	metrics.IncrCounter(
+		keyForTLSHandshake,
+		1,
+		metrics.Tag{Name: "status", Value: "ok"},
+		metrics.Tag{Name: "version", Value: TLSVersionName(state.Version)},
+		metrics.Tag{Name: "cipher", Value: tls.CipherSuiteName(state.CipherSuite)},
+		metrics.Tag{Name: "client_cert", Value: strconv.FormatBool(len(state.PeerCertificates) > 0)},
+	)
+}
+
+// PublishTLSHandshakeFailed publishes the failed TLS handshake,
+// the reason is TLSFailedHandshake, or one of TLSFailed*ClientCert
+func PublishTLSHandshakeFailed(reason string) {
+	metrics.IncrCounter(
+		keyForTLSHandshake,
+		1,
+		metrics.Tag{Name: "status", Value: "failed"},
+		metrics.Tag{Name: "reason", Value: reason},
+	)
+}
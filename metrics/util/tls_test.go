@@ -0,0 +1,48 @@
+package util
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TLSVersionName(t *testing.T) {
+	assert.Equal(t, "TLS1.2", TLSVersionName(tls.VersionTLS12))
+	assert.Equal(t, "TLS1.3", TLSVersionName(tls.VersionTLS13))
+	assert.Equal(t, "0x0300", TLSVersionName(0x0300))
+}
+
+func Test_PublishTLSHandshake(t *testing.T) {
+	im := metrics.NewInmemSink(time.Minute, time.Minute*5)
+	_, err := metrics.NewGlobal(metrics.DefaultConfig("svc1"), im)
+	require.NoError(t, err)
+
+	PublishTLSHandshake(&tls.ConnectionState{
+		Version:     tls.VersionTLS12,
+		CipherSuite: tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	})
+	PublishTLSHandshake(&tls.ConnectionState{
+		Version:          tls.VersionTLS13,
+		CipherSuite:      tls.TLS_AES_128_GCM_SHA256,
+		PeerCertificates: []*x509.Certificate{{}},
+	})
+	PublishTLSHandshakeFailed(TLSFailedClientCert)
+	PublishTLSHandshakeFailed(TLSFailedClientCert)
+
+	data := im.Data()
+	require.NotEqual(t, 0, len(data))
+
+	assertCounter := func(key string, expectedCount int) {
+		s, exists := data[0].Counters[key]
+		require.True(t, exists, "Expected metric with key %s to exist, but it doesn't", key)
+		assert.Equal(t, expectedCount, s.Count, "Unexpected count for metric %s", key)
+	}
+	assertCounter("svc1.tls.handshake;status=ok;version=TLS1.2;cipher=TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256;client_cert=false", 1)
+	assertCounter("svc1.tls.handshake;status=ok;version=TLS1.3;cipher=TLS_AES_128_GCM_SHA256;client_cert=true", 1)
+	assertCounter("svc1.tls.handshake;status=failed;reason=client_cert", 2)
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/go-phorce/dolly/metrics"
@@ -9,6 +10,8 @@ import (
 var (
 	keyForHeartbeat = []string{"heartbeat"}
 	keyForUptime    = []string{"uptime", "seconds"}
+
+	keyForShutdownInFlight = []string{"shutdown", "inflight"}
 )
 
 // PublishHeartbeat publishes heartbeat of the service
@@ -16,7 +19,27 @@ func PublishHeartbeat(service string) {
 	metrics.IncrCounter(keyForHeartbeat, 1, metrics.Tag{Name: "service", Value: service})
 }
 
+// PublishHeartbeatStatus publishes heartbeat of the service,
+// tagged with the readiness state and the cluster role of the node.
+// The role tag is omitted if the role is not known.
+func PublishHeartbeatStatus(service string, ready bool, role string) {
+	tags := []metrics.Tag{
+		{Name: "service", Value: service},
+		{Name: "ready", Value: strconv.FormatBool(ready)},
+	}
+	if role != "" {
+		tags = append(tags, metrics.Tag{Name: "role", Value: role})
+	}
+	metrics.IncrCounter(keyForHeartbeat, 1, tags...)
+}
+
 // PublishUptime publishes uptime of the service
 func PublishUptime(service string, uptime time.Duration) {
 	metrics.SetGauge(keyForUptime, float32(uptime/time.Second), metrics.Tag{Name: "service", Value: service})
 }
+
+// PublishShutdownInFlight publishes the number of the in-flight requests,
+// that are still draining during the shutdown of the service
+func PublishShutdownInFlight(service string, inFlight int) {
+	metrics.SetGauge(keyForShutdownInFlight, float32(inFlight), metrics.Tag{Name: "service", Value: service})
+}
@@ -17,7 +17,10 @@ func Test_PublishUptime(t *testing.T) {
 	require.NoError(t, err)
 
 	PublishHeartbeat("svc1")
+	PublishHeartbeatStatus("svc1", true, "")
+	PublishHeartbeatStatus("svc1", false, "leader")
 	PublishUptime("svc1", time.Second)
+	PublishShutdownInFlight("svc1", 1)
 
 	// get samples in memory
 	data := im.Data()
@@ -42,5 +45,8 @@ func Test_PublishUptime(t *testing.T) {
 	}
 	hostname, _ := os.Hostname()
 	assertGauge(fmt.Sprintf("svc1.%s.uptime.seconds;service=svc1", hostname))
+	assertGauge(fmt.Sprintf("svc1.%s.shutdown.inflight;service=svc1", hostname))
 	assertCounter(fmt.Sprintf("svc1.heartbeat;service=svc1"), 1)
+	assertCounter("svc1.heartbeat;service=svc1;ready=true", 1)
+	assertCounter("svc1.heartbeat;service=svc1;ready=false;role=leader", 1)
 }
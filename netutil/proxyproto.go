@@ -0,0 +1,235 @@
+package netutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-phorce/dolly/xlog"
+	"github.com/juju/errors"
+)
+
+var logger = xlog.NewPackageLogger("github.com/go-phorce/dolly", "netutil")
+
+var (
+	proxyV1Prefix    = []byte("PROXY ")
+	proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+const (
+	// proxyV1MaxLen is the max length of v1 header, including CRLF
+	proxyV1MaxLen = 107
+	// proxyV2HeaderLen is the length of the fixed part of v2 header
+	proxyV2HeaderLen = 16
+)
+
+// ErrNoProxyHeader is returned in strict mode,
+// when a connection does not start with a PROXY protocol header
+var ErrNoProxyHeader = errors.New("PROXY protocol header is missing")
+
+// ProxyProtoListener wraps a listener to decode PROXY protocol v1 and v2 headers,
+// sent by L4 load balancers to preserve the client's address.
+// The RemoteAddr of the accepted connections returns the client's address
+// from the header.
+type ProxyProtoListener struct {
+	net.Listener
+	// Strict specifies to reject the connections without a valid header,
+	// otherwise the connections without the header are accepted as is
+	Strict bool
+	// HeaderTimeout specifies the timeout to read the header,
+	// if not set, then 5 seconds is used
+	HeaderTimeout time.Duration
+}
+
+// NewProxyProtoListener returns a listener that decodes PROXY protocol headers
+func NewProxyProtoListener(ln net.Listener, strict bool) *ProxyProtoListener {
+	return &ProxyProtoListener{
+		Listener:      ln,
+		Strict:        strict,
+		HeaderTimeout: 5 * time.Second,
+	}
+}
+
+// Accept waits for and returns the next connection to the listener.
+// The header is read on the first Read or RemoteAddr call,
+// so a slow client does not block the Accept loop.
+func (ln *ProxyProtoListener) Accept() (net.Conn, error) {
+	c, err := ln.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	timeout := ln.HeaderTimeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	return &proxyConn{
+		Conn:    c,
+		r:       bufio.NewReaderSize(c, 256),
+		strict:  ln.Strict,
+		timeout: timeout,
+	}, nil
+}
+
+// proxyConn is a connection with PROXY protocol header
+type proxyConn struct {
+	net.Conn
+	r       *bufio.Reader
+	strict  bool
+	timeout time.Duration
+
+	once       sync.Once
+	remoteAddr net.Addr
+	err        error
+}
+
+// Read reads data from the connection, after the PROXY header
+func (c *proxyConn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.r.Read(b)
+}
+
+// RemoteAddr returns the client's address from the PROXY header,
+// or the remote address of the connection if the header is not provided
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *proxyConn) readHeader() {
+	c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	defer c.Conn.SetReadDeadline(time.Time{})
+
+	c.remoteAddr, c.err = readProxyHeader(c.r)
+	if c.err == ErrNoProxyHeader && !c.strict {
+		c.err = nil
+	}
+	if c.err != nil {
+		logger.Warningf("api=ProxyProtoListener, remote=%s, err=[%v]", c.Conn.RemoteAddr(), c.err)
+		c.Conn.Close()
+	}
+}
+
+// readProxyHeader reads PROXY protocol header, and returns the source address.
+// The returned address is nil, if the header does not provide the address,
+// for example for LOCAL command or UNKNOWN protocol.
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	b, err := r.Peek(1)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	switch b[0] {
+	case proxyV1Prefix[0]:
+		b, err = r.Peek(len(proxyV1Prefix))
+		if err != nil || !bytes.Equal(b, proxyV1Prefix) {
+			return nil, ErrNoProxyHeader
+		}
+		return readProxyV1(r)
+	case proxyV2Signature[0]:
+		b, err = r.Peek(len(proxyV2Signature))
+		if err != nil || !bytes.Equal(b, proxyV2Signature) {
+			return nil, ErrNoProxyHeader
+		}
+		return readProxyV2(r)
+	}
+	return nil, ErrNoProxyHeader
+}
+
+// readProxyV1 reads the text header in format:
+// PROXY TCP4|TCP6|UNKNOWN <src> <dst> <srcport> <dstport>\r\n
+func readProxyV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < proxyV1MaxLen {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.NotValidf("PROXY v1 header")
+	}
+
+	parts := strings.Split(string(line[:len(line)-2]), " ")
+	if len(parts) >= 2 && parts[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(parts) != 6 || (parts[1] != "TCP4" && parts[1] != "TCP6") {
+		return nil, errors.NotValidf("PROXY v1 header %q", line)
+	}
+	ip := net.ParseIP(parts[2])
+	if ip == nil {
+		return nil, errors.NotValidf("PROXY v1 source address %q", parts[2])
+	}
+	port, err := strconv.ParseUint(parts[4], 10, 16)
+	if err != nil {
+		return nil, errors.NotValidf("PROXY v1 source port %q", parts[4])
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2 reads the binary header
+func readProxyV2(r *bufio.Reader) (net.Addr, error) {
+	hdr := make([]byte, proxyV2HeaderLen)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, errors.Trace(err)
+	}
+	verCmd := hdr[12]
+	if verCmd>>4 != 2 {
+		return nil, errors.NotValidf("PROXY v2 version %d", verCmd>>4)
+	}
+	famProto := hdr[13]
+	length := int(binary.BigEndian.Uint16(hdr[14:16]))
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	switch verCmd & 0x0F {
+	case 0x00:
+		// LOCAL command, the connection is established by the proxy itself
+		return nil, nil
+	case 0x01:
+		// PROXY command
+	default:
+		return nil, errors.NotValidf("PROXY v2 command %d", verCmd&0x0F)
+	}
+
+	switch famProto >> 4 {
+	case 0x1:
+		// AF_INET
+		if length < 12 {
+			return nil, errors.NotValidf("PROXY v2 IPv4 address length %d", length)
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:4]),
+			Port: int(binary.BigEndian.Uint16(payload[8:10])),
+		}, nil
+	case 0x2:
+		// AF_INET6
+		if length < 36 {
+			return nil, errors.NotValidf("PROXY v2 IPv6 address length %d", length)
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:16]),
+			Port: int(binary.BigEndian.Uint16(payload[32:34])),
+		}, nil
+	}
+	// AF_UNSPEC or AF_UNIX
+	return nil, nil
+}
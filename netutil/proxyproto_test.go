@@ -0,0 +1,101 @@
+package netutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func proxyV2Header(cmd byte, ip net.IP, port uint16) []byte {
+	b := bytes.NewBuffer(nil)
+	b.Write(proxyV2Signature)
+	b.WriteByte(0x20 | cmd)
+	b.WriteByte(0x11)
+	binary.Write(b, binary.BigEndian, uint16(12))
+	b.Write(ip.To4())
+	b.Write(net.IPv4(10, 0, 0, 1).To4())
+	binary.Write(b, binary.BigEndian, port)
+	binary.Write(b, binary.BigEndian, uint16(443))
+	return b.Bytes()
+}
+
+func Test_readProxyHeader(t *testing.T) {
+	tcs := []struct {
+		name   string
+		header []byte
+		addr   string
+		err    string
+	}{
+		{"v1 tcp4", []byte("PROXY TCP4 192.168.1.10 10.0.0.1 56324 443\r\n"), "192.168.1.10:56324", ""},
+		{"v1 tcp6", []byte("PROXY TCP6 ::1 ::1 56324 443\r\n"), "[::1]:56324", ""},
+		{"v1 unknown", []byte("PROXY UNKNOWN\r\n"), "", ""},
+		{"v1 no crlf", []byte("PROXY TCP4 192.168.1.10 10.0.0.1 56324 443\n"), "", "PROXY v1 header not valid"},
+		{"v1 bad ip", []byte("PROXY TCP4 192.168.1 10.0.0.1 56324 443\r\n"), "", `PROXY v1 source address "192.168.1" not valid`},
+		{"v2 proxy", proxyV2Header(0x1, net.IPv4(192, 168, 1, 10), 56324), "192.168.1.10:56324", ""},
+		{"v2 local", proxyV2Header(0x0, net.IPv4(192, 168, 1, 10), 56324), "", ""},
+		{"no header", []byte("GET / HTTP/1.1\r\n"), "", ErrNoProxyHeader.Error()},
+		{"partial v2 signature", []byte("\r\n\r\nGET"), "", ErrNoProxyHeader.Error()},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := bufio.NewReader(bytes.NewReader(append(tc.header, []byte("body")...)))
+			addr, err := readProxyHeader(r)
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Equal(t, tc.err, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			if tc.addr == "" {
+				assert.Nil(t, addr)
+			} else {
+				require.NotNil(t, addr)
+				assert.Equal(t, tc.addr, addr.String())
+			}
+			rest, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, "body", string(rest))
+		})
+	}
+}
+
+func Test_ProxyProtoListener(t *testing.T) {
+	accept := func(strict bool, data string) (net.Conn, []byte) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		ln := NewProxyProtoListener(l, strict)
+		defer ln.Close()
+
+		go func() {
+			c, err := net.Dial("tcp", ln.Addr().String())
+			if err == nil {
+				c.Write([]byte(data))
+				c.Close()
+			}
+		}()
+
+		c, err := ln.Accept()
+		require.NoError(t, err)
+		defer c.Close()
+		b, _ := ioutil.ReadAll(c)
+		return c, b
+	}
+
+	c, b := accept(true, "PROXY TCP4 192.168.1.10 10.0.0.1 56324 443\r\nhello")
+	assert.Equal(t, "192.168.1.10:56324", c.RemoteAddr().String())
+	assert.Equal(t, "hello", string(b))
+
+	c, b = accept(false, "hello")
+	assert.Contains(t, c.RemoteAddr().String(), "127.0.0.1:")
+	assert.Equal(t, "hello", string(b))
+
+	c, b = accept(true, "hello")
+	assert.Contains(t, c.RemoteAddr().String(), "127.0.0.1:")
+	assert.Empty(t, b)
+}
@@ -0,0 +1,279 @@
+// Package admin provides a built-in service for operational debugging,
+// that exposes the server's status, scheduled tasks and registered routes,
+// and allows to start draining the server before the shutdown.
+//
+// The end-points are not protected by the service itself,
+// the server must be configured with Authz to allow
+// the admin roles only access to /v1/admin path.
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/tasks"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+	"github.com/go-phorce/dolly/xlog"
+)
+
+var logger = xlog.NewPackageLogger("github.com/go-phorce/dolly", "rest/admin")
+
+const (
+	// ServiceName provides the service name
+	ServiceName = "admin"
+
+	// URITasks specifies the end-point to list the scheduled tasks
+	URITasks = "/v1/admin/tasks"
+	// URITask specifies the end-point to run the task immediately
+	URITask = "/v1/admin/tasks/:name"
+	// URIRoutes specifies the end-point to list the registered routes
+	URIRoutes = "/v1/admin/routes"
+	// URIStatus specifies the end-point to return the consolidated status of the server
+	URIStatus = "/v1/admin/status"
+	// URIDrain specifies the end-point to start draining the server,
+	// and to return the drain status
+	URIDrain = "/v1/admin/drain"
+)
+
+// StatusProvider is an optional interface for the service,
+// that contributes its status to the server status, such as queue depth or cache size.
+// The returned value must be serializable to JSON.
+type StatusProvider interface {
+	Status() interface{}
+}
+
+// StatusResponse provides the response for the server status
+type StatusResponse struct {
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	HostName  string    `json:"hostname"`
+	StartedAt time.Time `json:"started_at"`
+	Uptime    string    `json:"uptime"`
+	Ready     bool      `json:"ready"`
+	// Services provides the status of the services implementing StatusProvider,
+	// by the service name
+	Services map[string]interface{} `json:"services,omitempty"`
+}
+
+// Drainer is an optional interface for the server,
+// that supports draining before the shutdown, implemented by rest.HTTPServer
+type Drainer interface {
+	// StartDraining reports not ready to remove the server from the load balancer,
+	// and closes the connections after the responses
+	StartDraining()
+	// IsDraining returns true if the server is draining
+	IsDraining() bool
+	// InFlight returns the number of the requests in progress
+	InFlight() int
+}
+
+// DrainResponse provides the response for the drain status
+type DrainResponse struct {
+	Draining bool `json:"draining"`
+	// InFlight specifies the number of the requests in progress,
+	// including the drain status request
+	InFlight int `json:"in_flight"`
+}
+
+// ServiceStatusError is reported as the service status,
+// if the service failed to provide the status
+type ServiceStatusError struct {
+	Error string `json:"error"`
+}
+
+// TaskInfo provides the scheduled task info
+type TaskInfo struct {
+	Name         string    `json:"name"`
+	Interval     string    `json:"interval"`
+	RunCount     uint32    `json:"run_count"`
+	LastRun      time.Time `json:"last_run"`
+	NextRun      time.Time `json:"next_run"`
+	LastDuration string    `json:"last_duration"`
+	LastError    string    `json:"last_error,omitempty"`
+}
+
+// TasksResponse provides the response for the tasks list
+type TasksResponse struct {
+	Tasks []TaskInfo `json:"tasks"`
+}
+
+// RoutesResponse provides the response for the routes list
+type RoutesResponse struct {
+	Routes []rest.Route `json:"routes"`
+}
+
+// Service defines the admin service
+type Service struct {
+	server rest.Server
+}
+
+// NewService returns an instance of the admin service
+func NewService(server rest.Server) *Service {
+	if server == nil {
+		logger.Panic("invalid parameter to admin.NewService")
+	}
+	return &Service{
+		server: server,
+	}
+}
+
+// Name returns the service name
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// IsReady indicates that the service is ready to serve its end-points
+func (s *Service) IsReady() bool {
+	return true
+}
+
+// Close the subservices and it's resources
+func (s *Service) Close() {
+}
+
+// Register adds the endpoints to the overall URL router
+func (s *Service) Register(r rest.Router) {
+	r.GET(URITasks, s.listTasks())
+	r.POST(URITask, s.runTask())
+	r.GET(URIRoutes, s.listRoutes())
+	r.GET(URIStatus, s.status())
+	r.GET(URIDrain, s.drainStatus())
+	r.POST(URIDrain, s.startDraining())
+}
+
+func (s *Service) status() rest.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ rest.Params) {
+		res := StatusResponse{
+			Name:      s.server.Name(),
+			Version:   s.server.Version(),
+			HostName:  s.server.HostName(),
+			StartedAt: s.server.StartedAt().UTC(),
+			Uptime:    (s.server.Uptime() / time.Second * time.Second).String(),
+			Ready:     s.server.IsReady(),
+		}
+		for _, svc := range s.server.Services() {
+			if sp, ok := svc.(StatusProvider); ok {
+				if res.Services == nil {
+					res.Services = map[string]interface{}{}
+				}
+				res.Services[svc.Name()] = serviceStatus(svc.Name(), sp)
+			}
+		}
+		marshal.WriteJSON(w, r, res)
+	}
+}
+
+// serviceStatus returns the status of the service,
+// the error or panic of one service is reported in its status,
+// and does not fail the whole response
+func serviceStatus(name string, sp StatusProvider) (res interface{}) {
+	defer func() {
+		if p := recover(); p != nil {
+			logger.Errorf("api=status, service=%s, reason=panic, err=[%v]", name, p)
+			res = ServiceStatusError{Error: fmt.Sprintf("status failed: %v", p)}
+		}
+	}()
+
+	res = sp.Status()
+	if err, ok := res.(error); ok {
+		res = ServiceStatusError{Error: err.Error()}
+	}
+	return res
+}
+
+func (s *Service) listRoutes() rest.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ rest.Params) {
+		marshal.WriteJSON(w, r, RoutesResponse{
+			Routes: s.server.Routes(),
+		})
+	}
+}
+
+func (s *Service) listTasks() rest.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ rest.Params) {
+		res := TasksResponse{
+			Tasks: []TaskInfo{},
+		}
+		if scheduler := s.server.Scheduler(); scheduler != nil {
+			for _, t := range scheduler.Tasks() {
+				res.Tasks = append(res.Tasks, taskInfo(t))
+			}
+		}
+		marshal.WriteJSON(w, r, res)
+	}
+}
+
+func (s *Service) runTask() rest.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p rest.Params) {
+		name := p.ByName("name")
+		scheduler := s.server.Scheduler()
+		var t tasks.Task
+		if scheduler != nil {
+			t = scheduler.Task(name)
+		}
+		if t == nil {
+			marshal.WriteJSON(w, r, httperror.WithNotFound("task %q not found", name))
+			return
+		}
+
+		ctx := identity.ForRequest(r)
+		logger.Noticef("api=runTask, task=%q, identity=%q, ctx=%q", name, ctx.Identity(), ctx.CorrelationID())
+
+		// the task error is returned in last_error
+		_ = scheduler.RunNow(name)
+		marshal.WriteJSON(w, r, taskInfo(t))
+	}
+}
+
+func (s *Service) drainStatus() rest.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ rest.Params) {
+		d, ok := s.server.(Drainer)
+		if !ok {
+			marshal.WriteJSON(w, r, httperror.WithNotFound("drain is not supported by the server"))
+			return
+		}
+		marshal.WriteJSON(w, r, drainResponse(d))
+	}
+}
+
+func (s *Service) startDraining() rest.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ rest.Params) {
+		d, ok := s.server.(Drainer)
+		if !ok {
+			marshal.WriteJSON(w, r, httperror.WithNotFound("drain is not supported by the server"))
+			return
+		}
+
+		ctx := identity.ForRequest(r)
+		logger.Noticef("api=startDraining, identity=%q, ctx=%q", ctx.Identity(), ctx.CorrelationID())
+
+		d.StartDraining()
+		marshal.WriteJSON(w, r, drainResponse(d))
+	}
+}
+
+func drainResponse(d Drainer) DrainResponse {
+	return DrainResponse{
+		Draining: d.IsDraining(),
+		InFlight: d.InFlight(),
+	}
+}
+
+func taskInfo(t tasks.Task) TaskInfo {
+	info := TaskInfo{
+		Name:         t.Name(),
+		Interval:     t.Duration().String(),
+		RunCount:     t.RunCount(),
+		LastRun:      t.LastRunTime().UTC(),
+		NextRun:      t.NextScheduledTime().UTC(),
+		LastDuration: t.LastDuration().String(),
+	}
+	if err := t.LastError(); err != nil {
+		info.LastError = err.Error()
+	}
+	return info
+}
@@ -0,0 +1,216 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/admin"
+	"github.com/go-phorce/dolly/tasks"
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testServer struct {
+	rest.Server
+	scheduler tasks.Scheduler
+	routes    []rest.Route
+	services  []rest.Service
+}
+
+func (s *testServer) Name() string     { return "testserver" }
+func (s *testServer) Version() string  { return "v1.0.123" }
+func (s *testServer) HostName() string { return "host1" }
+func (s *testServer) StartedAt() time.Time {
+	return time.Date(2020, time.May, 1, 10, 0, 0, 0, time.UTC)
+}
+func (s *testServer) Uptime() time.Duration    { return time.Hour + 1500*time.Millisecond }
+func (s *testServer) IsReady() bool            { return true }
+func (s *testServer) Services() []rest.Service { return s.services }
+
+func (s *testServer) Routes() []rest.Route {
+	return s.routes
+}
+
+func (s *testServer) Scheduler() tasks.Scheduler {
+	return s.scheduler
+}
+
+func Test_Tasks(t *testing.T) {
+	scheduler := tasks.NewScheduler()
+	task := tasks.NewTaskAtIntervals(1, tasks.Hours).Do("failing", func() error {
+		return errors.New("task failed")
+	})
+	scheduler.Add(task)
+
+	svc := admin.NewService(&testServer{scheduler: scheduler})
+	assert.Equal(t, admin.ServiceName, svc.Name())
+	assert.True(t, svc.IsReady())
+	defer svc.Close()
+
+	router := rest.NewRouter(nil)
+	svc.Register(router)
+	handler := router.Handler()
+
+	t.Run("list", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, admin.URITasks, nil)
+		require.NoError(t, err)
+		handler.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var res admin.TasksResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Tasks, 1)
+		assert.Equal(t, task.Name(), res.Tasks[0].Name)
+		assert.Equal(t, "1h0m0s", res.Tasks[0].Interval)
+		assert.Equal(t, uint32(0), res.Tasks[0].RunCount)
+		assert.Empty(t, res.Tasks[0].LastError)
+	})
+
+	t.Run("run", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodPost, admin.URITasks+"/"+task.Name(), nil)
+		require.NoError(t, err)
+		handler.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var res admin.TaskInfo
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, uint32(1), res.RunCount)
+		assert.Equal(t, "task failed", res.LastError)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodPost, admin.URITasks+"/unknown", nil)
+		require.NoError(t, err)
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, `{"code":"not_found","message":"task \"unknown\" not found"}`, w.Body.String())
+	})
+}
+
+func Test_Routes(t *testing.T) {
+	server := &testServer{}
+	svc := admin.NewService(server)
+
+	router := rest.NewRouter(nil)
+	svc.Register(router)
+	server.routes = router.Routes()
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest(http.MethodGet, admin.URIRoutes, nil)
+	require.NoError(t, err)
+	router.Handler().ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res admin.RoutesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, []rest.Route{
+		{Method: http.MethodGet, Path: admin.URIDrain},
+		{Method: http.MethodPost, Path: admin.URIDrain},
+		{Method: http.MethodGet, Path: admin.URIRoutes},
+		{Method: http.MethodGet, Path: admin.URIStatus},
+		{Method: http.MethodGet, Path: admin.URITasks},
+		{Method: http.MethodPost, Path: admin.URITask},
+	}, res.Routes)
+}
+
+type statusService struct {
+	name   string
+	status func() interface{}
+}
+
+func (s *statusService) Name() string         { return s.name }
+func (s *statusService) IsReady() bool        { return true }
+func (s *statusService) Close()               {}
+func (s *statusService) Register(rest.Router) {}
+func (s *statusService) Status() interface{}  { return s.status() }
+
+type plainService struct{}
+
+func (s *plainService) Name() string         { return "plain" }
+func (s *plainService) IsReady() bool        { return true }
+func (s *plainService) Close()               {}
+func (s *plainService) Register(rest.Router) {}
+
+func Test_Status(t *testing.T) {
+	server := &testServer{
+		services: []rest.Service{
+			&statusService{name: "queue", status: func() interface{} {
+				return map[string]int{"depth": 5}
+			}},
+			&statusService{name: "failing", status: func() interface{} {
+				return errors.New("cache not loaded")
+			}},
+			&statusService{name: "panicking", status: func() interface{} {
+				panic("nil map")
+			}},
+			&plainService{},
+		},
+	}
+	svc := admin.NewService(server)
+
+	router := rest.NewRouter(nil)
+	svc.Register(router)
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest(http.MethodGet, admin.URIStatus, nil)
+	require.NoError(t, err)
+	router.Handler().ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "testserver", res["name"])
+	assert.Equal(t, "v1.0.123", res["version"])
+	assert.Equal(t, "host1", res["hostname"])
+	assert.Equal(t, "2020-05-01T10:00:00Z", res["started_at"])
+	assert.Equal(t, "1h0m1s", res["uptime"])
+	assert.Equal(t, true, res["ready"])
+	assert.Equal(t, map[string]interface{}{
+		"queue":     map[string]interface{}{"depth": float64(5)},
+		"failing":   map[string]interface{}{"error": "cache not loaded"},
+		"panicking": map[string]interface{}{"error": "status failed: nil map"},
+	}, res["services"])
+}
+
+type drainServer struct {
+	testServer
+	draining bool
+}
+
+func (s *drainServer) StartDraining()   { s.draining = true }
+func (s *drainServer) IsDraining() bool { return s.draining }
+func (s *drainServer) InFlight() int    { return 1 }
+
+func Test_Drain(t *testing.T) {
+	serve := func(server rest.Server, method string) *httptest.ResponseRecorder {
+		router := rest.NewRouter(nil)
+		admin.NewService(server).Register(router)
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(method, admin.URIDrain, nil)
+		require.NoError(t, err)
+		router.Handler().ServeHTTP(w, r)
+		return w
+	}
+
+	server := &drainServer{}
+
+	w := serve(server, http.MethodGet)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"draining":false,"in_flight":1}`, w.Body.String())
+
+	w = serve(server, http.MethodPost)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"draining":true,"in_flight":1}`, w.Body.String())
+	assert.True(t, server.draining)
+
+	w = serve(&testServer{}, http.MethodPost)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -2,7 +2,9 @@ package rest
 
 import "io"
 
-// Auditor defines an interface that can receive information about audit events
+// Auditor defines an interface that can receive information about audit events.
+// The asynchronous auditor should implement audit.Flusher,
+// that is called after the service started and stopped events.
 type Auditor interface {
 	// Call at shutdown to cleanly close the audit destination
 	io.Closer
@@ -22,3 +24,24 @@ type Auditor interface {
 		raftIndex uint64,
 		message string)
 }
+
+// serverAuditor adapts the server to audit.Auditor,
+// the events are recorded by the server's auditor, if configured
+type serverAuditor struct {
+	server *HTTPServer
+}
+
+// Audit records the event with the server's auditor
+func (a serverAuditor) Audit(source string,
+	eventType string,
+	identity string,
+	contextID string,
+	raftIndex uint64,
+	message string) {
+	a.server.Audit(source, eventType, identity, contextID, raftIndex, message)
+}
+
+// Close does not close the server's auditor, it's closed by the server
+func (a serverAuditor) Close() error {
+	return nil
+}
@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/xhttp"
+	"github.com/julienschmidt/httprouter"
+)
+
+// ConcurrencyLimitProvider is an optional interface for the Service,
+// that limits the number of concurrent requests to its routes,
+// so a slow service can not consume the server concurrency
+// and starve other services.
+// The requests exceeded the limit are replied with 503 status and Retry-After header.
+type ConcurrencyLimitProvider interface {
+	// MaxConcurrency returns the max number of concurrent requests to the service routes,
+	// zero value disables the limit
+	MaxConcurrency() int
+}
+
+// newBulkheadHandler returns a http.Handler that limits the concurrent requests
+// for the routes of the services with ConcurrencyLimitProvider,
+// and passes the request to the delegate handler
+func (server *HTTPServer) newBulkheadHandler(services []Service, delegate http.Handler) http.Handler {
+	tree := newRouteTree()
+	limited := false
+	for _, s := range services {
+		p, ok := s.(ConcurrencyLimitProvider)
+		if !ok || p.MaxConcurrency() <= 0 {
+			continue
+		}
+
+		router := NewRouter(nil)
+		if err := registerService(router, s); err != nil {
+			logger.Errorf("api=newBulkheadHandler, service=%s, err=[%v]", s.Name(), err)
+			continue
+		}
+		logger.Infof("api=newBulkheadHandler, service=%s, max=%d", s.Name(), p.MaxConcurrency())
+
+		limiter := xhttp.NewConcurrencyLimiter(delegate, p.MaxConcurrency()).
+			WithService(s.Name())
+		if server.rejectAuditor != nil {
+			limiter.WithAuditor(server.rejectAuditor)
+		}
+		for _, route := range router.Routes() {
+			tree.Handle(route.Method, route.Path, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+				limiter.ServeHTTP(w, r)
+			})
+			limited = true
+		}
+	}
+
+	if !limited {
+		return delegate
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, _, _ := tree.Lookup(r.Method, r.URL.Path); h != nil {
+			h(w, r, nil)
+			return
+		}
+		delegate.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,80 @@
+package rest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bulkheadService struct {
+	name    string
+	path    string
+	max     int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *bulkheadService) Name() string        { return s.name }
+func (s *bulkheadService) IsReady() bool       { return true }
+func (s *bulkheadService) Close()              {}
+func (s *bulkheadService) MaxConcurrency() int { return s.max }
+func (s *bulkheadService) Register(r rest.Router) {
+	r.GET(s.path, func(w http.ResponseWriter, _ *http.Request, _ rest.Params) {
+		if s.started != nil {
+			s.started <- struct{}{}
+			<-s.release
+		}
+		w.Write([]byte("ok"))
+	})
+}
+
+func Test_ServiceConcurrencyLimit(t *testing.T) {
+	heavy := &bulkheadService{
+		name:    "heavy",
+		path:    "/v1/heavy",
+		max:     1,
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+	light := &bulkheadService{
+		name: "light",
+		path: "/v1/light",
+		max:  1,
+	}
+	_, url, cleanup := resttest.Start(t, resttest.Options{
+		Services: []resttest.ServiceFactory{
+			func(rest.Server) rest.Service { return heavy },
+			func(rest.Server) rest.Service { return light },
+		},
+	})
+	defer cleanup()
+
+	get := func(path string) *http.Response {
+		resp, err := http.Get(url + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	done := make(chan int)
+	go func() {
+		done <- get("/v1/heavy").StatusCode
+	}()
+	<-heavy.started
+
+	resp := get("/v1/heavy")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get(header.RetryAfter))
+
+	// other services remain responsive
+	assert.Equal(t, http.StatusOK, get("/v1/light").StatusCode)
+
+	close(heavy.release)
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Equal(t, http.StatusOK, get("/v1/heavy").StatusCode)
+}
@@ -0,0 +1,184 @@
+package rest
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-phorce/dolly/clock"
+	metricsutil "github.com/go-phorce/dolly/metrics/util"
+	"github.com/go-phorce/dolly/rest/tlsconfig"
+	"github.com/go-phorce/dolly/xpki/certutil"
+)
+
+const (
+	// DefaultCertExpiryWarning specifies the default threshold of the certificate near expiry
+	DefaultCertExpiryWarning = 30 * 24 * time.Hour
+	// DefaultCertExpiryCritical specifies the default critical threshold of the certificate expiry
+	DefaultCertExpiryCritical = 7 * 24 * time.Hour
+)
+
+// CertExpiryMonitor publishes the expiration of the server certificate,
+// and the Trusted CA certificates, if configured.
+// When a certificate crosses the warning or critical threshold,
+// the monitor logs it on every run, and audits EvtCertExpiring event once per level.
+//
+// Run the monitor periodically with the scheduler:
+//
+//	monitor := rest.NewCertExpiryMonitor(server, tlsInfo)
+//	scheduler.Add(tasks.NewTaskAtIntervals(1, tasks.Hours).Do("cert-expiry", monitor.Run))
+//
+// If the keypair reloader is specified, then the monitor also reports
+// the stale key pair, that was not rotated within the reloader's rotation window,
+// and audits EvtCertStale event once per stale period.
+type CertExpiryMonitor struct {
+	server   Server
+	cfg      TLSInfoConfig
+	warning  time.Duration
+	critical time.Duration
+	clock    clock.Clock
+	reloader *tlsconfig.KeypairReloader
+	// alerted specifies the level of the last audited event by certificate
+	alerted map[string]string
+	lock    sync.Mutex
+}
+
+// NewCertExpiryMonitor returns CertExpiryMonitor for the server TLS configuration,
+// the default thresholds are used, if not specified in the config
+func NewCertExpiryMonitor(server Server, cfg TLSInfoConfig) *CertExpiryMonitor {
+	m := &CertExpiryMonitor{
+		server:   server,
+		cfg:      cfg,
+		warning:  cfg.GetCertExpiryWarning(),
+		critical: cfg.GetCertExpiryCritical(),
+		clock:    clock.New(),
+		alerted:  map[string]string{},
+	}
+	if m.warning <= 0 {
+		m.warning = DefaultCertExpiryWarning
+	}
+	if m.critical <= 0 {
+		m.critical = DefaultCertExpiryCritical
+	}
+	return m
+}
+
+// WithClock allows to specify the clock
+func (m *CertExpiryMonitor) WithClock(c clock.Clock) *CertExpiryMonitor {
+	m.clock = c
+	return m
+}
+
+// WithKeypairReloader allows to specify the reloader of the server key pair,
+// to report the stale key pair
+func (m *CertExpiryMonitor) WithKeypairReloader(reloader *tlsconfig.KeypairReloader) *CertExpiryMonitor {
+	m.reloader = reloader
+	return m
+}
+
+// Run checks the expiration of the certificates
+func (m *CertExpiryMonitor) Run() {
+	if m.reloader != nil {
+		m.checkStale()
+	}
+
+	certFile := m.cfg.GetCertFile()
+	chain, err := certutil.LoadChainFromPEM(certFile)
+	if err != nil || len(chain) == 0 {
+		logger.Errorf("api=CertExpiryMonitor, reason=unable_load_cert, file=%q, err=[%v]", certFile, err)
+	} else {
+		m.check(chain[0], "server")
+	}
+
+	caFile := m.cfg.GetTrustedCAFile()
+	if caFile == "" {
+		return
+	}
+	cas, err := certutil.LoadChainFromPEM(caFile)
+	if err != nil {
+		logger.Errorf("api=CertExpiryMonitor, reason=unable_load_ca, file=%q, err=[%v]", caFile, err)
+		return
+	}
+	for _, ca := range cas {
+		m.check(ca, "ca")
+	}
+}
+
+func (m *CertExpiryMonitor) check(c *x509.Certificate, typ string) {
+	metricsutil.PublishCertExpirationInDays(c, typ)
+
+	expiresIn := c.NotAfter.Sub(m.clock.Now())
+	key := typ + ":" + c.SerialNumber.String()
+
+	var level string
+	switch {
+	case expiresIn <= m.critical:
+		level = metricsutil.CertExpiryCritical
+		logger.Errorf("api=CertExpiryMonitor, level=%s, type=%s, CN=%q, serial=%s, expires=%s",
+			level, typ, c.Subject.CommonName, c.SerialNumber.String(), c.NotAfter.Format(time.RFC3339))
+	case expiresIn <= m.warning:
+		level = metricsutil.CertExpiryWarning
+		logger.Warningf("api=CertExpiryMonitor, level=%s, type=%s, CN=%q, serial=%s, expires=%s",
+			level, typ, c.Subject.CommonName, c.SerialNumber.String(), c.NotAfter.Format(time.RFC3339))
+	default:
+		m.lock.Lock()
+		delete(m.alerted, key)
+		m.lock.Unlock()
+		return
+	}
+
+	metricsutil.PublishCertExpiryAlert(c, typ, level)
+
+	m.lock.Lock()
+	crossed := m.alerted[key] != level
+	m.alerted[key] = level
+	m.lock.Unlock()
+
+	if crossed {
+		m.server.Audit(
+			EvtSourceStatus,
+			EvtCertExpiring,
+			m.server.AuditIdentity(),
+			m.server.LocalIP(),
+			0,
+			fmt.Sprintf("level=%s, type=%s, CN=%q, serial=%s, expires=%s",
+				level, typ, c.Subject.CommonName, c.SerialNumber.String(), c.NotAfter.Format(time.RFC3339)),
+		)
+	}
+}
+
+// staleKey is the key of the audited stale key pair
+const staleKey = "stale"
+
+func (m *CertExpiryMonitor) checkStale() {
+	since := m.reloader.StaleSince()
+	if since.IsZero() {
+		m.lock.Lock()
+		delete(m.alerted, staleKey)
+		m.lock.Unlock()
+		return
+	}
+
+	certFile, _ := m.reloader.CertAndKeyFiles()
+	modifiedAt := m.reloader.LastModified().Format(time.RFC3339)
+	logger.Warningf("api=CertExpiryMonitor, level=stale, file=%q, modifiedAt=%s, staleSince=%s",
+		certFile, modifiedAt, since.Format(time.RFC3339))
+
+	m.lock.Lock()
+	_, alerted := m.alerted[staleKey]
+	m.alerted[staleKey] = staleKey
+	m.lock.Unlock()
+
+	if !alerted {
+		m.server.Audit(
+			EvtSourceStatus,
+			EvtCertStale,
+			m.server.AuditIdentity(),
+			m.server.LocalIP(),
+			0,
+			fmt.Sprintf("file=%q, modifiedAt=%s, staleSince=%s",
+				certFile, modifiedAt, since.Format(time.RFC3339)),
+		)
+	}
+}
@@ -0,0 +1,105 @@
+package rest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/clock"
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/tlsconfig"
+	"github.com/go-phorce/dolly/testify"
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type expiryTLSConfig struct {
+	certFile string
+	caFile   string
+}
+
+func (c *expiryTLSConfig) GetCertFile() string                  { return c.certFile }
+func (c *expiryTLSConfig) GetKeyFile() string                   { return "" }
+func (c *expiryTLSConfig) GetTrustedCAFile() string             { return c.caFile }
+func (c *expiryTLSConfig) GetClientCertAuth() *bool             { return nil }
+func (c *expiryTLSConfig) GetCertExpiryWarning() time.Duration  { return 0 }
+func (c *expiryTLSConfig) GetCertExpiryCritical() time.Duration { return 0 }
+
+func Test_CertExpiryMonitor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certexpiry")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	certPem, _, err := testify.MakeSelfCertRSAPem(40 * 24)
+	require.NoError(t, err)
+	cfg := &expiryTLSConfig{
+		certFile: filepath.Join(dir, "cert.pem"),
+		caFile:   filepath.Join(dir, "ca.pem"),
+	}
+	require.NoError(t, ioutil.WriteFile(cfg.certFile, certPem, 0644))
+	require.NoError(t, ioutil.WriteFile(cfg.caFile, certPem, 0644))
+
+	au := auditor.NewInMemory()
+	server, err := rest.New("v1.0.123", "", &serverConfig{}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(au)
+
+	mock := clock.NewMock(time.Now())
+	monitor := rest.NewCertExpiryMonitor(server, cfg).WithClock(mock)
+
+	monitor.Run()
+	assert.Nil(t, au.Find(rest.EvtSourceStatus, rest.EvtCertExpiring))
+
+	// warning for the server and CA, audited once
+	mock.Add(15 * 24 * time.Hour)
+	monitor.Run()
+	monitor.Run()
+	require.Equal(t, 2, au.Len())
+	assert.Contains(t, au.Get(0).Message, "level=warning, type=server")
+	assert.Contains(t, au.Get(1).Message, "level=warning, type=ca")
+
+	mock.Add(20 * 24 * time.Hour)
+	monitor.Run()
+	require.Equal(t, 4, au.Len())
+	assert.Contains(t, au.Get(2).Message, "level=critical, type=server")
+	assert.Contains(t, au.Get(3).Message, "level=critical, type=ca")
+}
+
+func Test_CertExpiryMonitorStale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certstale")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	certPem, keyPem, err := testify.MakeSelfCertRSAPem(40 * 24)
+	require.NoError(t, err)
+	cfg := &expiryTLSConfig{
+		certFile: filepath.Join(dir, "cert.pem"),
+	}
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, ioutil.WriteFile(cfg.certFile, certPem, 0644))
+	require.NoError(t, ioutil.WriteFile(keyFile, keyPem, 0600))
+
+	reloader, err := tlsconfig.NewKeypairReloader(cfg.certFile, keyFile, time.Hour)
+	require.NoError(t, err)
+	defer reloader.Close()
+
+	au := auditor.NewInMemory()
+	server, err := rest.New("v1.0.123", "", &serverConfig{}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(au)
+
+	monitor := rest.NewCertExpiryMonitor(server, cfg).WithKeypairReloader(reloader)
+	monitor.Run()
+	assert.Nil(t, au.Find(rest.EvtSourceStatus, rest.EvtCertStale))
+
+	reloader.WithRotationWindow(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	monitor.Run()
+	monitor.Run()
+	require.Len(t, au.FindAll(rest.EvtSourceStatus, rest.EvtCertStale), 1, "must be audited once")
+	assert.Contains(t, au.Find(rest.EvtSourceStatus, rest.EvtCertStale).Message, "staleSince=")
+	assert.Nil(t, au.Find(rest.EvtSourceStatus, rest.EvtCertExpiring))
+}
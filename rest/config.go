@@ -4,6 +4,9 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
+
+	"github.com/go-phorce/dolly/xhttp/authz"
 )
 
 // Authz represents an Authorization provider interface,
@@ -23,6 +26,13 @@ type Authz interface {
 	NewHandler(delegate http.Handler) (http.Handler, error)
 }
 
+// AuditableAuthz is an optional interface for the Authz provider,
+// that records the authorization decisions with the server's auditor
+type AuditableAuthz interface {
+	// SetAuditor configures the auditor to record the authorization decisions
+	SetAuditor(auditor authz.Auditor)
+}
+
 // TLSInfoConfig contains configuration info for the TLS
 type TLSInfoConfig interface {
 	// GetCertFile returns location of the cert
@@ -33,6 +43,11 @@ type TLSInfoConfig interface {
 	GetTrustedCAFile() string
 	// ClientCertAuth controls client auth
 	GetClientCertAuth() *bool
+	// CertExpiryWarning specifies the threshold of the certificate near expiry,
+	// to log the warning and audit the event
+	GetCertExpiryWarning() time.Duration
+	// CertExpiryCritical specifies the critical threshold of the certificate expiry
+	GetCertExpiryCritical() time.Duration
 }
 
 // HTTPServerConfig contains the configuration of the HTTPS API Service
@@ -47,14 +62,80 @@ type HTTPServerConfig interface {
 	GetBindAddr() string
 	// PackageLogger if set, specifies name of the package logger
 	GetPackageLogger() string
+	// AccessLogFile if set, specifies the file to write the access logs of the requests,
+	// separately from the application logs, otherwise the access logs are written
+	// to the package logger. The file is opened on StartHTTP, and closed on StopHTTP.
+	GetAccessLogFile() string
 	// AllowProfiling if set, will allow for per request CPU/Memory profiling triggered by the URI QueryString
 	GetAllowProfiling() bool
 	// ProfilerDir specifies the directories where per-request profile information is written, if not set will write to a TMP dir
 	GetProfilerDir() string
+	// ProfilerMaxFiles specifies the maximum number of the profile files to keep,
+	// the oldest files are removed, if not set, the number is not limited
+	GetProfilerMaxFiles() int
+	// ProfilerMaxSize specifies the maximum total size in bytes of the profile files to keep,
+	// the oldest files are removed, if not set, the size is not limited
+	GetProfilerMaxSize() int64
+	// ProfilerMaxAge specifies the maximum age of the profile files to keep,
+	// the older files are removed, if not set, the age is not limited
+	GetProfilerMaxAge() time.Duration
 	// Services is a list of services to enable for this HTTP Service
 	GetServices() []string
 	// HeartbeatSecs specifies heartbeat GetHeartbeatSecserval in seconds [30 secs is a minimum]
 	GetHeartbeatSecs() int
+	// AuditHeartbeatSecs specifies the interval in seconds to record the heartbeat
+	// audit event with uptime and readiness, independently of the metrics heartbeat.
+	// If not set, the heartbeat is not audited.
+	GetAuditHeartbeatSecs() int
+	// ListenBacklog specifies the maximum length of the queue of pending connections,
+	// if not set, the system default is used. Supported on Linux only.
+	GetListenBacklog() int
+	// ReusePort specifies to set SO_REUSEPORT option on the listener,
+	// to allow multiple processes to bind the same port. Supported on Linux only.
+	GetReusePort() bool
+	// TCPKeepAlive specifies to set TCP keep-alive with TCPKeepAlivePeriod
+	// on the accepted connections, if not set, the Go default is used,
+	// which enables TCP keep-alive with 15 seconds period
+	GetTCPKeepAlive() bool
+	// TCPKeepAlivePeriod specifies the TCP keep-alive period,
+	// if not set, the system default is used
+	GetTCPKeepAlivePeriod() time.Duration
+	// ProxyProtocol specifies to decode PROXY protocol v1/v2 header
+	// on the accepted connections, to preserve the client's address
+	// when the server is behind L4 load balancer
+	GetProxyProtocol() bool
+	// ProxyProtocolStrict specifies to reject the connections without PROXY protocol header
+	GetProxyProtocolStrict() bool
+	// MaxConnsPerIP specifies the maximum number of concurrent connections
+	// from a client IP, if not set, the connections are not limited.
+	// With ProxyProtocol, the limit applies to the client's address from the header.
+	GetMaxConnsPerIP() int
+	// TrustedProxies specifies the list of CIDRs of the trusted L7 proxies,
+	// to resolve the client's IP from X-Forwarded-For header.
+	// If not set, the headers are ignored and the client's IP is the peer address.
+	GetTrustedProxies() []string
+	// AdvertiseIP specifies the IP address of the server to advertise and audit,
+	// for example POD_IP from the downward API in containers.
+	// If not set, the IP address is detected.
+	GetAdvertiseIP() string
+	// MaxHeaderBytes specifies the maximum size of the request headers,
+	// if not set, DefaultMaxHeaderBytes is used.
+	// The requests exceeding the limit are rejected with 431 status code.
+	GetMaxHeaderBytes() int
+	// MaxURILength specifies the maximum length of the request URI,
+	// if not set, DefaultMaxURILength is used.
+	GetMaxURILength() int
+	// MaxHeaderCount specifies the maximum number of the request header fields,
+	// if not set, DefaultMaxHeaderCount is used.
+	// The requests exceeding the limit are rejected with 431 status code.
+	GetMaxHeaderCount() int
+	// MaxBodyBytes specifies the maximum size of the request body,
+	// if not set, the size is not limited.
+	GetMaxBodyBytes() int64
+	// MaxDecompressedBodyBytes specifies the maximum size of the decompressed request body,
+	// if not set, the request bodies with Content-Encoding are not decompressed.
+	// MaxBodyBytes applies to the body before decompression.
+	GetMaxDecompressedBodyBytes() int64
 }
 
 // GetPort returns the port from HTTP bind address,
@@ -3,9 +3,11 @@ package rest_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/go-phorce/dolly/rest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_GetPortAndHost(t *testing.T) {
@@ -31,3 +33,59 @@ func Test_GetPortAndHost(t *testing.T) {
 	assert.Equal(t, "7865", rest.GetPort(bindAddr))
 	assert.Equal(t, "hostname", rest.GetHostName(bindAddr))
 }
+
+func Test_ServerConfigValidate(t *testing.T) {
+	tcases := []struct {
+		cfg rest.ServerConfig
+		err string
+	}{
+		{rest.ServerConfig{}, ""},
+		{rest.ServerConfig{BindAddr: ":8443", AdvertiseIP: "10.0.0.1", TrustedProxies: []string{"10.0.0.0/8"}}, ""},
+		{rest.ServerConfig{BindAddr: "localhost:https"}, `invalid port in BindAddr: "localhost:https"`},
+		{rest.ServerConfig{AdvertiseIP: "host"}, `invalid AdvertiseIP: "host"`},
+		{rest.ServerConfig{TrustedProxies: []string{"10.0.0.1"}}, `invalid TrustedProxies CIDR: "10.0.0.1"`},
+		{rest.ServerConfig{ProxyProtocolStrict: true}, "ProxyProtocol is required with ProxyProtocolStrict"},
+		{rest.ServerConfig{TCPKeepAlivePeriod: time.Minute}, "TCPKeepAlive is required with TCPKeepAlivePeriod"},
+		{rest.ServerConfig{MaxBodyBytes: -1}, "MaxBodyBytes must not be negative"},
+		{rest.ServerConfig{ProfilerMaxAge: -time.Hour}, "ProfilerMaxAge must not be negative"},
+	}
+	for _, tc := range tcases {
+		err := tc.cfg.Validate()
+		if tc.err == "" {
+			assert.NoError(t, err)
+		} else if assert.Error(t, err) {
+			assert.Equal(t, tc.err, err.Error())
+		}
+	}
+
+	_, err := rest.New("v1.0.123", "10.0.0.1", &rest.ServerConfig{ProxyProtocolStrict: true}, nil)
+	require.Error(t, err)
+	assert.Equal(t, "invalid configuration: ProxyProtocol is required with ProxyProtocolStrict", err.Error())
+
+	_, err = rest.New("v1.0.123", "10.0.0.1", &rest.ServerConfig{BindAddr: ":8443"}, nil)
+	assert.NoError(t, err)
+}
+
+func Test_TLSInfoValidate(t *testing.T) {
+	yes := true
+	tcases := []struct {
+		cfg rest.TLSInfo
+		err string
+	}{
+		{rest.TLSInfo{}, ""},
+		{rest.TLSInfo{CertFile: "cert.pem", KeyFile: "key.pem", TrustedCAFile: "ca.pem", ClientCertAuth: &yes}, ""},
+		{rest.TLSInfo{CertFile: "cert.pem"}, "tls: KeyFile is required with CertFile"},
+		{rest.TLSInfo{KeyFile: "key.pem"}, "tls: CertFile is required with KeyFile"},
+		{rest.TLSInfo{CertFile: "cert.pem", KeyFile: "key.pem", ClientCertAuth: &yes}, "tls: TrustedCAFile is required with ClientCertAuth"},
+		{rest.TLSInfo{CertExpiryWarning: time.Hour, CertExpiryCritical: 2 * time.Hour}, "tls: CertExpiryCritical 2h0m0s must not exceed CertExpiryWarning 1h0m0s"},
+		{rest.TLSInfo{CertExpiryCritical: -time.Hour}, "tls: CertExpiryWarning and CertExpiryCritical must not be negative"},
+	}
+	for _, tc := range tcases {
+		err := tc.cfg.Validate()
+		if tc.err == "" {
+			assert.NoError(t, err)
+		} else if assert.Error(t, err) {
+			assert.Equal(t, tc.err, err.Error())
+		}
+	}
+}
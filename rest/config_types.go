@@ -0,0 +1,340 @@
+package rest
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// Validator is an optional interface for the configuration,
+// that validates the values on the server creation
+type Validator interface {
+	// Validate returns an error if the configuration is not valid
+	Validate() error
+}
+
+var (
+	_ HTTPServerConfig = (*ServerConfig)(nil)
+	_ TLSInfoConfig    = (*TLSInfo)(nil)
+	_ Validator        = (*ServerConfig)(nil)
+	_ Validator        = (*TLSInfo)(nil)
+)
+
+// TLSInfo provides the implementation of TLSInfoConfig
+type TLSInfo struct {
+	// CertFile specifies location of the cert
+	CertFile string `json:"cert,omitempty" yaml:"cert,omitempty"`
+	// KeyFile specifies location of the key
+	KeyFile string `json:"key,omitempty" yaml:"key,omitempty"`
+	// TrustedCAFile specifies location of the Trusted CA file
+	TrustedCAFile string `json:"trusted_ca,omitempty" yaml:"trusted_ca,omitempty"`
+	// ClientCertAuth controls client auth
+	ClientCertAuth *bool `json:"client_cert_auth,omitempty" yaml:"client_cert_auth,omitempty"`
+	// CertExpiryWarning specifies the threshold of the certificate near expiry
+	CertExpiryWarning time.Duration `json:"cert_expiry_warning,omitempty" yaml:"cert_expiry_warning,omitempty"`
+	// CertExpiryCritical specifies the critical threshold of the certificate expiry
+	CertExpiryCritical time.Duration `json:"cert_expiry_critical,omitempty" yaml:"cert_expiry_critical,omitempty"`
+}
+
+// GetCertFile returns location of the cert
+func (c *TLSInfo) GetCertFile() string {
+	return c.CertFile
+}
+
+// GetKeyFile returns location of the key
+func (c *TLSInfo) GetKeyFile() string {
+	return c.KeyFile
+}
+
+// GetTrustedCAFile specifies location of the Trusted CA file
+func (c *TLSInfo) GetTrustedCAFile() string {
+	return c.TrustedCAFile
+}
+
+// GetClientCertAuth controls client auth
+func (c *TLSInfo) GetClientCertAuth() *bool {
+	return c.ClientCertAuth
+}
+
+// GetCertExpiryWarning specifies the threshold of the certificate near expiry
+func (c *TLSInfo) GetCertExpiryWarning() time.Duration {
+	return c.CertExpiryWarning
+}
+
+// GetCertExpiryCritical specifies the critical threshold of the certificate expiry
+func (c *TLSInfo) GetCertExpiryCritical() time.Duration {
+	return c.CertExpiryCritical
+}
+
+// Validate returns an error if the configuration is not valid
+func (c *TLSInfo) Validate() error {
+	if c.CertFile != "" && c.KeyFile == "" {
+		return errors.New("tls: KeyFile is required with CertFile")
+	}
+	if c.KeyFile != "" && c.CertFile == "" {
+		return errors.New("tls: CertFile is required with KeyFile")
+	}
+	if c.ClientCertAuth != nil && *c.ClientCertAuth && c.TrustedCAFile == "" {
+		return errors.New("tls: TrustedCAFile is required with ClientCertAuth")
+	}
+	if c.CertExpiryWarning < 0 || c.CertExpiryCritical < 0 {
+		return errors.New("tls: CertExpiryWarning and CertExpiryCritical must not be negative")
+	}
+	if c.CertExpiryWarning > 0 && c.CertExpiryCritical > c.CertExpiryWarning {
+		return errors.Errorf("tls: CertExpiryCritical %v must not exceed CertExpiryWarning %v",
+			c.CertExpiryCritical, c.CertExpiryWarning)
+	}
+	return nil
+}
+
+// ServerConfig provides the implementation of HTTPServerConfig,
+// the zero values of the optional settings use the server defaults
+type ServerConfig struct {
+	// ServiceName specifies name of the service: HTTP|HTTPS|WebAPI
+	ServiceName string `json:"service_name,omitempty" yaml:"service_name,omitempty"`
+	// Disabled specifies if the service is disabled
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	// VIPName is the FQ name of the VIP to the cluster
+	VIPName string `json:"vip_name,omitempty" yaml:"vip_name,omitempty"`
+	// BindAddr is the address that the HTTPS service should be exposed on
+	BindAddr string `json:"bind_addr,omitempty" yaml:"bind_addr,omitempty"`
+	// PackageLogger if set, specifies name of the package logger
+	PackageLogger string `json:"package_logger,omitempty" yaml:"package_logger,omitempty"`
+	// AccessLogFile if set, specifies the file to write the access logs of the requests
+	AccessLogFile string `json:"access_log_file,omitempty" yaml:"access_log_file,omitempty"`
+	// AllowProfiling if set, will allow for per request CPU/Memory profiling
+	AllowProfiling bool `json:"allow_profiling,omitempty" yaml:"allow_profiling,omitempty"`
+	// ProfilerDir specifies the directories where per-request profile information is written
+	ProfilerDir string `json:"profile_dir,omitempty" yaml:"profile_dir,omitempty"`
+	// ProfilerMaxFiles specifies the maximum number of the profile files to keep
+	ProfilerMaxFiles int `json:"profile_max_files,omitempty" yaml:"profile_max_files,omitempty"`
+	// ProfilerMaxSize specifies the maximum total size in bytes of the profile files to keep
+	ProfilerMaxSize int64 `json:"profile_max_size,omitempty" yaml:"profile_max_size,omitempty"`
+	// ProfilerMaxAge specifies the maximum age of the profile files to keep
+	ProfilerMaxAge time.Duration `json:"profile_max_age,omitempty" yaml:"profile_max_age,omitempty"`
+	// Services is a list of services to enable for this HTTP Service
+	Services []string `json:"services,omitempty" yaml:"services,omitempty"`
+	// HeartbeatSecs specifies heartbeat interval in seconds
+	HeartbeatSecs int `json:"heartbeat_secs,omitempty" yaml:"heartbeat_secs,omitempty"`
+	// AuditHeartbeatSecs specifies the heartbeat audit interval in seconds
+	AuditHeartbeatSecs int `json:"audit_heartbeat_secs,omitempty" yaml:"audit_heartbeat_secs,omitempty"`
+	// ListenBacklog specifies the maximum length of the queue of pending connections
+	ListenBacklog int `json:"listen_backlog,omitempty" yaml:"listen_backlog,omitempty"`
+	// ReusePort specifies to set SO_REUSEPORT option on the listener
+	ReusePort bool `json:"reuse_port,omitempty" yaml:"reuse_port,omitempty"`
+	// TCPKeepAlive specifies to enable TCP keep-alive on the accepted connections
+	TCPKeepAlive bool `json:"tcp_keep_alive,omitempty" yaml:"tcp_keep_alive,omitempty"`
+	// TCPKeepAlivePeriod specifies the TCP keep-alive period
+	TCPKeepAlivePeriod time.Duration `json:"tcp_keep_alive_period,omitempty" yaml:"tcp_keep_alive_period,omitempty"`
+	// ProxyProtocol specifies to decode PROXY protocol header on the accepted connections
+	ProxyProtocol bool `json:"proxy_protocol,omitempty" yaml:"proxy_protocol,omitempty"`
+	// ProxyProtocolStrict specifies to reject the connections without PROXY protocol header
+	ProxyProtocolStrict bool `json:"proxy_protocol_strict,omitempty" yaml:"proxy_protocol_strict,omitempty"`
+	// MaxConnsPerIP specifies the maximum number of concurrent connections from a client IP
+	MaxConnsPerIP int `json:"max_conns_per_ip,omitempty" yaml:"max_conns_per_ip,omitempty"`
+	// TrustedProxies specifies the list of CIDRs of the trusted L7 proxies
+	TrustedProxies []string `json:"trusted_proxies,omitempty" yaml:"trusted_proxies,omitempty"`
+	// AdvertiseIP specifies the IP address of the server to advertise and audit
+	AdvertiseIP string `json:"advertise_ip,omitempty" yaml:"advertise_ip,omitempty"`
+	// MaxHeaderBytes specifies the maximum size of the request headers
+	MaxHeaderBytes int `json:"max_header_bytes,omitempty" yaml:"max_header_bytes,omitempty"`
+	// MaxURILength specifies the maximum length of the request URI
+	MaxURILength int `json:"max_uri_length,omitempty" yaml:"max_uri_length,omitempty"`
+	// MaxHeaderCount specifies the maximum number of the request header fields
+	MaxHeaderCount int `json:"max_header_count,omitempty" yaml:"max_header_count,omitempty"`
+	// MaxBodyBytes specifies the maximum size of the request body
+	MaxBodyBytes int64 `json:"max_body_bytes,omitempty" yaml:"max_body_bytes,omitempty"`
+	// MaxDecompressedBodyBytes specifies the maximum size of the decompressed request body
+	MaxDecompressedBodyBytes int64 `json:"max_decompressed_body_bytes,omitempty" yaml:"max_decompressed_body_bytes,omitempty"`
+}
+
+// GetServiceName specifies name of the service: HTTP|HTTPS|WebAPI
+func (c *ServerConfig) GetServiceName() string {
+	return c.ServiceName
+}
+
+// GetDisabled specifies if the service is disabled
+func (c *ServerConfig) GetDisabled() bool {
+	return c.Disabled
+}
+
+// GetVIPName is the FQ name of the VIP to the cluster
+func (c *ServerConfig) GetVIPName() string {
+	return c.VIPName
+}
+
+// GetBindAddr is the address that the HTTPS service should be exposed on
+func (c *ServerConfig) GetBindAddr() string {
+	return c.BindAddr
+}
+
+// GetPackageLogger if set, specifies name of the package logger
+func (c *ServerConfig) GetPackageLogger() string {
+	return c.PackageLogger
+}
+
+// GetAccessLogFile if set, specifies the file to write the access logs of the requests
+func (c *ServerConfig) GetAccessLogFile() string {
+	return c.AccessLogFile
+}
+
+// GetAllowProfiling if set, will allow for per request CPU/Memory profiling
+func (c *ServerConfig) GetAllowProfiling() bool {
+	return c.AllowProfiling
+}
+
+// GetProfilerDir specifies the directories where per-request profile information is written
+func (c *ServerConfig) GetProfilerDir() string {
+	return c.ProfilerDir
+}
+
+// GetProfilerMaxFiles specifies the maximum number of the profile files to keep
+func (c *ServerConfig) GetProfilerMaxFiles() int {
+	return c.ProfilerMaxFiles
+}
+
+// GetProfilerMaxSize specifies the maximum total size in bytes of the profile files to keep
+func (c *ServerConfig) GetProfilerMaxSize() int64 {
+	return c.ProfilerMaxSize
+}
+
+// GetProfilerMaxAge specifies the maximum age of the profile files to keep
+func (c *ServerConfig) GetProfilerMaxAge() time.Duration {
+	return c.ProfilerMaxAge
+}
+
+// GetServices is a list of services to enable for this HTTP Service
+func (c *ServerConfig) GetServices() []string {
+	return c.Services
+}
+
+// GetHeartbeatSecs specifies heartbeat interval in seconds
+func (c *ServerConfig) GetHeartbeatSecs() int {
+	return c.HeartbeatSecs
+}
+
+// GetAuditHeartbeatSecs specifies the heartbeat audit interval in seconds
+func (c *ServerConfig) GetAuditHeartbeatSecs() int {
+	return c.AuditHeartbeatSecs
+}
+
+// GetListenBacklog specifies the maximum length of the queue of pending connections
+func (c *ServerConfig) GetListenBacklog() int {
+	return c.ListenBacklog
+}
+
+// GetReusePort specifies to set SO_REUSEPORT option on the listener
+func (c *ServerConfig) GetReusePort() bool {
+	return c.ReusePort
+}
+
+// GetTCPKeepAlive specifies to enable TCP keep-alive on the accepted connections
+func (c *ServerConfig) GetTCPKeepAlive() bool {
+	return c.TCPKeepAlive
+}
+
+// GetTCPKeepAlivePeriod specifies the TCP keep-alive period
+func (c *ServerConfig) GetTCPKeepAlivePeriod() time.Duration {
+	return c.TCPKeepAlivePeriod
+}
+
+// GetProxyProtocol specifies to decode PROXY protocol header on the accepted connections
+func (c *ServerConfig) GetProxyProtocol() bool {
+	return c.ProxyProtocol
+}
+
+// GetProxyProtocolStrict specifies to reject the connections without PROXY protocol header
+func (c *ServerConfig) GetProxyProtocolStrict() bool {
+	return c.ProxyProtocolStrict
+}
+
+// GetMaxConnsPerIP specifies the maximum number of concurrent connections from a client IP
+func (c *ServerConfig) GetMaxConnsPerIP() int {
+	return c.MaxConnsPerIP
+}
+
+// GetTrustedProxies specifies the list of CIDRs of the trusted L7 proxies
+func (c *ServerConfig) GetTrustedProxies() []string {
+	return c.TrustedProxies
+}
+
+// GetAdvertiseIP specifies the IP address of the server to advertise and audit
+func (c *ServerConfig) GetAdvertiseIP() string {
+	return c.AdvertiseIP
+}
+
+// GetMaxHeaderBytes specifies the maximum size of the request headers
+func (c *ServerConfig) GetMaxHeaderBytes() int {
+	return c.MaxHeaderBytes
+}
+
+// GetMaxURILength specifies the maximum length of the request URI
+func (c *ServerConfig) GetMaxURILength() int {
+	return c.MaxURILength
+}
+
+// GetMaxHeaderCount specifies the maximum number of the request header fields
+func (c *ServerConfig) GetMaxHeaderCount() int {
+	return c.MaxHeaderCount
+}
+
+// GetMaxBodyBytes specifies the maximum size of the request body
+func (c *ServerConfig) GetMaxBodyBytes() int64 {
+	return c.MaxBodyBytes
+}
+
+// GetMaxDecompressedBodyBytes specifies the maximum size of the decompressed request body
+func (c *ServerConfig) GetMaxDecompressedBodyBytes() int64 {
+	return c.MaxDecompressedBodyBytes
+}
+
+// Validate returns an error if the configuration is not valid
+func (c *ServerConfig) Validate() error {
+	if c.BindAddr != "" {
+		if port := GetPort(c.BindAddr); port != "" {
+			if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+				return errors.Errorf("invalid port in BindAddr: %q", c.BindAddr)
+			}
+		}
+	}
+	if c.AdvertiseIP != "" && net.ParseIP(c.AdvertiseIP) == nil {
+		return errors.Errorf("invalid AdvertiseIP: %q", c.AdvertiseIP)
+	}
+	for _, cidr := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return errors.Errorf("invalid TrustedProxies CIDR: %q", cidr)
+		}
+	}
+	if c.ProxyProtocolStrict && !c.ProxyProtocol {
+		return errors.New("ProxyProtocol is required with ProxyProtocolStrict")
+	}
+	if c.TCPKeepAlivePeriod != 0 && !c.TCPKeepAlive {
+		return errors.New("TCPKeepAlive is required with TCPKeepAlivePeriod")
+	}
+
+	for _, v := range []struct {
+		name  string
+		value int64
+	}{
+		{"ProfilerMaxFiles", int64(c.ProfilerMaxFiles)},
+		{"ProfilerMaxSize", c.ProfilerMaxSize},
+		{"ProfilerMaxAge", int64(c.ProfilerMaxAge)},
+		{"HeartbeatSecs", int64(c.HeartbeatSecs)},
+		{"AuditHeartbeatSecs", int64(c.AuditHeartbeatSecs)},
+		{"ListenBacklog", int64(c.ListenBacklog)},
+		{"TCPKeepAlivePeriod", int64(c.TCPKeepAlivePeriod)},
+		{"MaxConnsPerIP", int64(c.MaxConnsPerIP)},
+		{"MaxHeaderBytes", int64(c.MaxHeaderBytes)},
+		{"MaxURILength", int64(c.MaxURILength)},
+		{"MaxHeaderCount", int64(c.MaxHeaderCount)},
+		{"MaxBodyBytes", c.MaxBodyBytes},
+		{"MaxDecompressedBodyBytes", c.MaxDecompressedBodyBytes},
+	} {
+		if v.value < 0 {
+			return errors.Errorf("%s must not be negative", v.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/xhttp"
+)
+
+// RouteContentLength specifies the route, that requires
+// the declared size of the request body
+type RouteContentLength struct {
+	// Method specifies the HTTP method of the route,
+	// if empty, then the requirement applies to all methods registered for the Path
+	Method string
+	// Path specifies the path template, as registered with the router,
+	// such as /v1/certs/:id
+	Path string
+}
+
+// RouteContentLengthProvider is an optional interface for the Service,
+// that declares the routes requiring Content-Length header.
+// The requests with Transfer-Encoding: chunked, or without Content-Length,
+// are rejected with 411. GET, HEAD, OPTIONS requests are not validated.
+//
+// The requirement is validated before the decompression of the request body,
+// so for the compressed requests Content-Length specifies the size of the compressed body,
+// and the decompressed size is limited by GetMaxDecompressedBodyBytes.
+type RouteContentLengthProvider interface {
+	// RouteContentLengths returns the service routes requiring Content-Length
+	RouteContentLengths() []RouteContentLength
+}
+
+// routeContentLengths returns the registered routes requiring Content-Length
+func routeContentLengths(routes []Route, services []Service) map[Route]bool {
+	res := map[Route]bool{}
+	for _, s := range services {
+		p, ok := s.(RouteContentLengthProvider)
+		if !ok {
+			continue
+		}
+		for _, cl := range p.RouteContentLengths() {
+			for _, route := range registeredRoutes("routeContentLengths", routes, s, cl.Method, cl.Path) {
+				res[route] = true
+			}
+		}
+	}
+	return res
+}
+
+// newContentLengthHandler returns a http.Handler that rejects the requests
+// without Content-Length for the specified routes,
+// and passes the request to the delegate handler
+func newContentLengthHandler(routes map[Route]bool, delegate http.Handler) http.Handler {
+	validator := xhttp.NewContentLengthRequired(delegate)
+	handlers := map[Route]http.Handler{}
+	for route := range routes {
+		logger.Infof("api=newContentLengthHandler, method=%s, path=%s", route.Method, route.Path)
+		handlers[route] = validator
+	}
+	return newRouteHandler(handlers, delegate)
+}
@@ -0,0 +1,69 @@
+package rest_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type contentLengthService struct{}
+
+func (s *contentLengthService) Name() string  { return "contentlengthtest" }
+func (s *contentLengthService) IsReady() bool { return true }
+func (s *contentLengthService) Close()        {}
+func (s *contentLengthService) Register(r rest.Router) {
+	ok := func(w http.ResponseWriter, _ *http.Request, _ rest.Params) {
+		w.Write([]byte("ok"))
+	}
+	r.GET("/v1/upload/:id", ok)
+	r.PUT("/v1/upload/:id", ok)
+	r.POST("/v1/other", ok)
+}
+
+func (s *contentLengthService) RouteContentLengths() []rest.RouteContentLength {
+	return []rest.RouteContentLength{
+		{Path: "/v1/upload/:id"},
+		{Method: http.MethodPost, Path: "/v1/notregistered"},
+	}
+}
+
+func Test_RouteContentLengths(t *testing.T) {
+	_, url, cleanup := resttest.Start(t, resttest.Options{
+		Services: []resttest.ServiceFactory{
+			func(rest.Server) rest.Service { return &contentLengthService{} },
+		},
+	})
+	defer cleanup()
+
+	tcases := []struct {
+		method  string
+		path    string
+		chunked bool
+		status  int
+	}{
+		{http.MethodPut, "/v1/upload/1", false, http.StatusOK},
+		{http.MethodPut, "/v1/upload/1", true, http.StatusLengthRequired},
+		{http.MethodGet, "/v1/upload/1", false, http.StatusOK},
+		// not declared
+		{http.MethodPost, "/v1/other", true, http.StatusOK},
+	}
+
+	for _, tc := range tcases {
+		req, err := http.NewRequest(tc.method, url+tc.path, strings.NewReader("{}"))
+		require.NoError(t, err)
+		if tc.chunked {
+			req.Body = ioutil.NopCloser(strings.NewReader("{}"))
+			req.ContentLength = -1
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, tc.status, resp.StatusCode, "%s %s chunked=%t", tc.method, tc.path, tc.chunked)
+	}
+}
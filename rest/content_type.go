@@ -0,0 +1,58 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/xhttp"
+)
+
+// RouteContentType specifies the accepted content types of the route requests
+type RouteContentType struct {
+	// Method specifies the HTTP method of the route,
+	// if empty, then the content types apply to all methods registered for the Path
+	Method string
+	// Path specifies the path template, as registered with the router,
+	// such as /v1/certs/:id
+	Path string
+	// ContentTypes specifies the accepted media types, such as application/json.
+	// The requests with other Content-Type are rejected with 415
+	ContentTypes []string
+}
+
+// RouteContentTypeProvider is an optional interface for the Service,
+// that declares the accepted content types of its routes.
+// The requests without body, and GET, HEAD, OPTIONS requests are not validated.
+type RouteContentTypeProvider interface {
+	// RouteContentTypes returns the accepted content types of the service routes
+	RouteContentTypes() []RouteContentType
+}
+
+// routeContentTypes returns the accepted content types of the registered routes
+func routeContentTypes(routes []Route, services []Service) map[Route][]string {
+	res := map[Route][]string{}
+	for _, s := range services {
+		p, ok := s.(RouteContentTypeProvider)
+		if !ok {
+			continue
+		}
+		for _, ct := range p.RouteContentTypes() {
+			for _, route := range registeredRoutes("routeContentTypes", routes, s, ct.Method, ct.Path) {
+				res[route] = ct.ContentTypes
+			}
+		}
+	}
+	return res
+}
+
+// newContentTypeHandler returns a http.Handler that validates the Content-Type
+// of the requests for the routes with the accepted content types,
+// and passes the request to the delegate handler
+func newContentTypeHandler(contentTypes map[Route][]string, delegate http.Handler) http.Handler {
+	handlers := map[Route]http.Handler{}
+	for route, types := range contentTypes {
+		logger.Infof("api=newContentTypeHandler, method=%s, path=%s, content_types=%v",
+			route.Method, route.Path, types)
+		handlers[route] = xhttp.NewContentTypeValidator(delegate, types...)
+	}
+	return newRouteHandler(handlers, delegate)
+}
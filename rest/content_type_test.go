@@ -0,0 +1,65 @@
+package rest_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type contentTypeService struct{}
+
+func (s *contentTypeService) Name() string  { return "contenttypetest" }
+func (s *contentTypeService) IsReady() bool { return true }
+func (s *contentTypeService) Close()        {}
+func (s *contentTypeService) Register(r rest.Router) {
+	ok := func(w http.ResponseWriter, _ *http.Request, _ rest.Params) {
+		w.Write([]byte("ok"))
+	}
+	r.GET("/v1/items/:id", ok)
+	r.PUT("/v1/items/:id", ok)
+	r.POST("/v1/upload", ok)
+}
+
+func (s *contentTypeService) RouteContentTypes() []rest.RouteContentType {
+	return []rest.RouteContentType{
+		{Path: "/v1/items/:id", ContentTypes: []string{"application/json"}},
+		{Method: http.MethodPost, Path: "/v1/notregistered", ContentTypes: []string{"application/json"}},
+	}
+}
+
+func Test_RouteContentTypes(t *testing.T) {
+	_, url, cleanup := resttest.Start(t, resttest.Options{
+		Services: []resttest.ServiceFactory{
+			func(rest.Server) rest.Service { return &contentTypeService{} },
+		},
+	})
+	defer cleanup()
+
+	tcases := []struct {
+		method      string
+		path        string
+		contentType string
+		status      int
+	}{
+		{http.MethodPut, "/v1/items/1", "application/json; charset=utf-8", http.StatusOK},
+		{http.MethodPut, "/v1/items/1", "text/plain", http.StatusUnsupportedMediaType},
+		{http.MethodGet, "/v1/items/1", "text/plain", http.StatusOK},
+		// not declared
+		{http.MethodPost, "/v1/upload", "application/octet-stream", http.StatusOK},
+	}
+
+	for _, tc := range tcases {
+		req, err := http.NewRequest(tc.method, url+tc.path, strings.NewReader("{}"))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", tc.contentType)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, tc.status, resp.StatusCode, "%s %s %q", tc.method, tc.path, tc.contentType)
+	}
+}
@@ -0,0 +1,57 @@
+package rest_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type emptyFieldsResponse struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+type emptyFieldsService struct{}
+
+func (s *emptyFieldsService) Name() string  { return "emptyfieldstest" }
+func (s *emptyFieldsService) IsReady() bool { return true }
+func (s *emptyFieldsService) Close()        {}
+func (s *emptyFieldsService) Register(r rest.Router) {
+	r.GET("/v1/default", func(w http.ResponseWriter, r *http.Request, _ rest.Params) {
+		marshal.WriteJSON(w, r, &emptyFieldsResponse{Name: "bob"})
+	})
+	r.GET("/v1/compact", func(w http.ResponseWriter, r *http.Request, _ rest.Params) {
+		marshal.WriteJSON(w, r, marshal.Compact(&emptyFieldsResponse{Name: "bob"}))
+	})
+}
+
+func Test_ServerEmptyFields(t *testing.T) {
+	_, url, cleanup := resttest.Start(t, resttest.Options{
+		Services: []resttest.ServiceFactory{
+			func(s rest.Server) rest.Service {
+				s.(*rest.HTTPServer).WithEmptyFields(marshal.IncludeEmptyFields)
+				return &emptyFieldsService{}
+			},
+		},
+	})
+	defer cleanup()
+
+	get := func(path string) string {
+		resp, err := http.Get(url + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	assert.Equal(t, `{"name":"bob","email":""}`, get("/v1/default"))
+	assert.Equal(t, `{"name":"bob"}`, get("/v1/compact"))
+}
@@ -48,6 +48,18 @@ func ExampleServer() {
 	server.WithAuditor(auditor.NewInMemory()).
 		WithScheduler(scheduler)
 
+	// the last good certificate is served until the rotation is fixed
+	tlsloader.OnReloadFailure(func(label string, failures uint32, err error) {
+		server.Audit(
+			rest.EvtSourceStatus,
+			rest.EvtCertReloadFailed,
+			server.AuditIdentity(),
+			server.LocalIP(),
+			0,
+			fmt.Sprintf("label=%s, failures=%d, err=[%v]", label, failures, err),
+		)
+	})
+
 	// execute and schedule
 	go certExpirationPublisherTask(tlsloader)
 	server.Scheduler().Add(tasks.NewTaskAtIntervals(1, tasks.Hours).Do("servertls", certExpirationPublisherTask, tlsloader))
@@ -0,0 +1,66 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+)
+
+// handlerMethods specifies the methods to route to the handler of HandlerService
+var handlerMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// handlerService adapts http.Handler to the Service
+type handlerService struct {
+	name    string
+	prefix  string
+	handler http.Handler
+}
+
+// HandlerService returns the service that serves the handler at the route prefix,
+// for example "/debug/pprof" for net/http/pprof, or "/debug/vars" for expvar.
+// The service is always ready, and Close is no-op.
+//
+// The handler receives the request with the full path,
+// use http.StripPrefix if the handler expects the path relative to the prefix.
+// The prefix must not conflict with the routes of other services.
+func HandlerService(name, prefix string, handler http.Handler) Service {
+	return &handlerService{
+		name:    name,
+		prefix:  strings.TrimSuffix(prefix, "/"),
+		handler: handler,
+	}
+}
+
+// Name returns the service name
+func (s *handlerService) Name() string {
+	return s.name
+}
+
+// IsReady indicates that the service is ready to serve its end-points
+func (s *handlerService) IsReady() bool {
+	return true
+}
+
+// Close the subservices and it's resources
+func (s *handlerService) Close() {
+}
+
+// Register adds the endpoints to the overall URL router
+func (s *handlerService) Register(r Router) {
+	serve := func(w http.ResponseWriter, r *http.Request, _ Params) {
+		s.handler.ServeHTTP(w, r)
+	}
+	for _, method := range handlerMethods {
+		if s.prefix != "" {
+			r.Handle(method, s.prefix, serve)
+		}
+		r.Handle(method, s.prefix+"/*path", serve)
+	}
+}
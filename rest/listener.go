@@ -0,0 +1,201 @@
+package rest
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/netutil"
+	"github.com/juju/errors"
+)
+
+var keyForConnRejected = []string{"http", "conn", "rejected"}
+
+// errTooManyConns is returned by the connection rejected by connLimitListener
+var errTooManyConns = errors.New("too many connections from the client")
+
+// listen creates the TCP listener on the bind address,
+// with the socket options specified by the server config.
+//
+// If ReusePort is enabled, then the bind does not fail when another process,
+// that also enabled ReusePort, is already listening on the same address,
+// and the kernel balances the incoming connections between the processes.
+// In this case StartHTTP will not detect the port already in use by another
+// instance of the server, otherwise the bind error is returned by StartHTTP.
+func (server *HTTPServer) listen(bindAddr string) (net.Listener, error) {
+	// if TCPKeepAlive is not set, the Go default keep-alive of the listener is used
+	lc := net.ListenConfig{}
+	if server.httpConfig.GetReusePort() {
+		lc.Control = reusePortControl
+	}
+
+	ln, err := lc.Listen(context.Background(), "tcp", bindAddr)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if backlog := server.httpConfig.GetListenBacklog(); backlog > 0 {
+		if err = setListenBacklog(ln, backlog); err != nil {
+			ln.Close()
+			return nil, errors.Trace(err)
+		}
+	}
+
+	if server.httpConfig.GetTCPKeepAlive() {
+		ln = &keepAliveListener{
+			Listener: ln,
+			period:   server.httpConfig.GetTCPKeepAlivePeriod(),
+		}
+	}
+
+	if server.httpConfig.GetProxyProtocol() {
+		// the header is sent by the load balancer before TLS handshake,
+		// so it must be decoded before the TLS listener
+		ln = netutil.NewProxyProtoListener(ln, server.httpConfig.GetProxyProtocolStrict())
+	}
+
+	if max := server.httpConfig.GetMaxConnsPerIP(); max > 0 {
+		ln = &connLimitListener{
+			Listener: ln,
+			max:      max,
+			// the client's address is known after PROXY protocol header is read,
+			// the header must not be read in the Accept loop
+			lazy:  server.httpConfig.GetProxyProtocol(),
+			conns: map[string]int{},
+		}
+	}
+	return ln, nil
+}
+
+// keepAliveListener sets TCP keep-alive on the accepted connections,
+// so dead peers are detected at the socket layer.
+// It is not related to HTTP keep-alive.
+type keepAliveListener struct {
+	net.Listener
+	period time.Duration
+}
+
+// Accept waits for and returns the next connection to the listener
+func (ln *keepAliveListener) Accept() (net.Conn, error) {
+	c, err := ln.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	// the keep-alive is not applicable to non-TCP connections, such as unix sockets
+	if tc, ok := c.(*net.TCPConn); ok {
+		tc.SetKeepAlive(true)
+		if ln.period > 0 {
+			tc.SetKeepAlivePeriod(ln.period)
+		}
+	}
+	return c, nil
+}
+
+// connLimitListener limits the number of concurrent connections per client IP.
+// The connections over the limit are closed immediately,
+// without affecting the connections from other clients.
+// If lazy is set, then the limit is checked on the first Read or Write,
+// otherwise in Accept.
+type connLimitListener struct {
+	net.Listener
+	max   int
+	lazy  bool
+	conns map[string]int
+	lock  sync.Mutex
+}
+
+// Accept waits for and returns the next connection to the listener
+func (ln *connLimitListener) Accept() (net.Conn, error) {
+	for {
+		c, err := ln.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		lc := &limitedConn{Conn: c, ln: ln}
+		if ln.lazy || lc.acquire() {
+			return lc, nil
+		}
+		c.Close()
+	}
+}
+
+func (ln *connLimitListener) acquire(ip string) bool {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+	if ln.conns[ip] >= ln.max {
+		return false
+	}
+	ln.conns[ip]++
+	return true
+}
+
+func (ln *connLimitListener) release(ip string) {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+	if ln.conns[ip] <= 1 {
+		delete(ln.conns, ip)
+	} else {
+		ln.conns[ip]--
+	}
+}
+
+// limitedConn is the connection accounted by connLimitListener
+type limitedConn struct {
+	net.Conn
+	ln *connLimitListener
+
+	checkOnce sync.Once
+	closeOnce sync.Once
+	ip        string
+	allowed   bool
+}
+
+// acquire returns false if the client exceeded the limit of connections
+func (c *limitedConn) acquire() bool {
+	c.checkOnce.Do(func() {
+		c.ip = c.Conn.RemoteAddr().String()
+		if host, _, err := net.SplitHostPort(c.ip); err == nil {
+			c.ip = host
+		}
+		c.allowed = c.ln.acquire(c.ip)
+		if !c.allowed {
+			metrics.IncrCounter(keyForConnRejected, 1)
+			logger.Debugf("api=connLimitListener, reason=too_many_conns, ip=%s, limit=%d", c.ip, c.ln.max)
+		}
+	})
+	return c.allowed
+}
+
+// Read reads data from the connection
+func (c *limitedConn) Read(b []byte) (int, error) {
+	if !c.acquire() {
+		c.Close()
+		return 0, errTooManyConns
+	}
+	return c.Conn.Read(b)
+}
+
+// Write writes data to the connection
+func (c *limitedConn) Write(b []byte) (int, error) {
+	if !c.acquire() {
+		c.Close()
+		return 0, errTooManyConns
+	}
+	return c.Conn.Write(b)
+}
+
+// Close closes the connection, and releases the client's connection slot
+func (c *limitedConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		err = c.Conn.Close()
+		// the connection closed before the check does not take the slot
+		c.checkOnce.Do(func() {})
+		if c.allowed {
+			c.ln.release(c.ip)
+		}
+	})
+	return err
+}
@@ -0,0 +1,127 @@
+package rest
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_keepAliveListener(t *testing.T) {
+	accept := func(network, address string) {
+		l, err := net.Listen(network, address)
+		require.NoError(t, err)
+		ln := &keepAliveListener{Listener: l, period: time.Minute}
+		defer ln.Close()
+
+		go func() {
+			c, err := net.Dial(network, ln.Addr().String())
+			if err == nil {
+				c.Close()
+			}
+		}()
+
+		c, err := ln.Accept()
+		require.NoError(t, err)
+		assert.NotNil(t, c)
+		c.Close()
+	}
+
+	accept("tcp", "127.0.0.1:0")
+
+	dir, err := ioutil.TempDir("", "keepalive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// unix sockets are accepted without keep-alive
+	accept("unix", filepath.Join(dir, "test.sock"))
+}
+
+func Test_connLimitListener(t *testing.T) {
+	test := func(lazy bool) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		ln := &connLimitListener{Listener: l, max: 2, lazy: lazy, conns: map[string]int{}}
+		defer ln.Close()
+
+		accepted := make(chan net.Conn, 10)
+		go func() {
+			for {
+				c, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				accepted <- c
+			}
+		}()
+
+		// serve reads the first byte, as http.Server does
+		serve := func(c net.Conn) error {
+			_, err := c.Read(make([]byte, 1))
+			return err
+		}
+
+		var clients []net.Conn
+		for i := 0; i < 3; i++ {
+			c, err := net.Dial("tcp", ln.Addr().String())
+			require.NoError(t, err)
+			defer c.Close()
+			_, err = c.Write([]byte("x"))
+			require.NoError(t, err)
+			clients = append(clients, c)
+		}
+
+		var served []net.Conn
+		rejected := 0
+		for len(served) < 2 || (lazy && rejected < 1) {
+			select {
+			case c := <-accepted:
+				if serve(c) == nil {
+					served = append(served, c)
+				} else {
+					assert.True(t, lazy)
+					rejected++
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("connections are not accepted")
+			}
+		}
+
+		// the third connection is closed
+		clients[2].SetReadDeadline(time.Now().Add(time.Second))
+		_, err = clients[2].Read(make([]byte, 1))
+		assert.Error(t, err)
+		if ne, ok := err.(net.Error); ok {
+			assert.False(t, ne.Timeout(), "the connection must be closed")
+		}
+
+		// the slot is released on close
+		served[0].Close()
+		c, err := net.Dial("tcp", ln.Addr().String())
+		require.NoError(t, err)
+		defer c.Close()
+		_, err = c.Write([]byte("x"))
+		require.NoError(t, err)
+
+		for {
+			select {
+			case ac := <-accepted:
+				if serve(ac) == nil {
+					ln.lock.Lock()
+					assert.Equal(t, 2, ln.conns["127.0.0.1"])
+					ln.lock.Unlock()
+					return
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("connection is not accepted after release")
+			}
+		}
+	}
+
+	test(false)
+	test(true)
+}
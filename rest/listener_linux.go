@@ -0,0 +1,45 @@
+// +build linux
+
+package rest
+
+import (
+	"net"
+	"syscall"
+
+	"github.com/juju/errors"
+	"golang.org/x/sys/unix"
+)
+
+// reusePortControl sets SO_REUSEPORT option on the socket
+func reusePortControl(network, address string, c syscall.RawConn) error {
+	var serr error
+	err := c.Control(func(fd uintptr) {
+		serr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(serr)
+}
+
+// setListenBacklog changes the backlog of the listening socket,
+// Linux allows to call listen again on the socket to update the backlog.
+// The backlog is capped by net.core.somaxconn system setting.
+func setListenBacklog(ln net.Listener, backlog int) error {
+	tl, ok := ln.(*net.TCPListener)
+	if !ok {
+		return errors.NotSupportedf("listen backlog for %T", ln)
+	}
+	rc, err := tl.SyscallConn()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var serr error
+	err = rc.Control(func(fd uintptr) {
+		serr = unix.Listen(int(fd), backlog)
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(serr)
+}
@@ -0,0 +1,63 @@
+// +build linux
+
+package rest
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func Test_listenTCPKeepAlive(t *testing.T) {
+	keepAlive := func(enabled bool, period time.Duration) (int, int) {
+		cfg := &ServerConfig{BindAddr: "127.0.0.1:0", TCPKeepAlive: enabled, TCPKeepAlivePeriod: period}
+		server, err := New("v1.0.123", "127.0.0.1", cfg, nil)
+		require.NoError(t, err)
+
+		ln, err := server.listen(cfg.BindAddr)
+		require.NoError(t, err)
+		defer ln.Close()
+
+		go func() {
+			c, err := net.Dial("tcp", ln.Addr().String())
+			if err == nil {
+				c.Close()
+			}
+		}()
+
+		c, err := ln.Accept()
+		require.NoError(t, err)
+		defer c.Close()
+
+		tc, ok := c.(*net.TCPConn)
+		require.True(t, ok)
+		raw, err := tc.SyscallConn()
+		require.NoError(t, err)
+
+		var val, idle int
+		var serr, ierr error
+		err = raw.Control(func(fd uintptr) {
+			val, serr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_KEEPALIVE)
+			idle, ierr = unix.GetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_KEEPIDLE)
+		})
+		require.NoError(t, err)
+		require.NoError(t, serr)
+		require.NoError(t, ierr)
+		return val, idle
+	}
+
+	val, idle := keepAlive(false, 0)
+	assert.NotEqual(t, 0, val, "keep-alive must be enabled by default")
+	assert.Equal(t, 15, idle)
+
+	val, idle = keepAlive(true, 0)
+	assert.NotEqual(t, 0, val, "keep-alive must be enabled")
+
+	val, idle = keepAlive(true, time.Minute)
+	assert.NotEqual(t, 0, val, "keep-alive must be enabled")
+	assert.Equal(t, 60, idle)
+}
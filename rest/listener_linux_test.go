@@ -0,0 +1,68 @@
+// +build linux
+
+package rest_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ListenerReusePort(t *testing.T) {
+	cfg := &serverConfig{
+		BindAddr:      ":8085",
+		ReusePort:     true,
+		ListenBacklog: 1024,
+	}
+
+	start := func() *rest.HTTPServer {
+		server, err := rest.New("v1.0.123", "", cfg, nil)
+		require.NoError(t, err)
+		server.WithAuditor(auditor.NewInMemory())
+		server.AddService(NewService(server))
+		require.NoError(t, server.StartHTTP())
+		return server
+	}
+
+	server1 := start()
+	defer server1.StopHTTP()
+	server2 := start()
+	defer server2.StopHTTP()
+
+	client := &http.Client{Timeout: time.Second}
+	var res *http.Response
+	var err error
+	for i := 0; i < 10; i++ {
+		res, err = client.Get("http://localhost:8085" + testURL)
+		if err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func Test_ListenerAddrInUse(t *testing.T) {
+	cfg := &serverConfig{
+		BindAddr: ":8086",
+	}
+
+	server, err := rest.New("v1.0.123", "", cfg, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory())
+	require.NoError(t, server.StartHTTP())
+	defer server.StopHTTP()
+
+	server2, err := rest.New("v1.0.123", "", cfg, nil)
+	require.NoError(t, err)
+	err = server2.StartHTTP()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "address already in use")
+}
@@ -0,0 +1,22 @@
+// +build !linux
+
+package rest
+
+import (
+	"net"
+	"syscall"
+
+	"github.com/juju/errors"
+)
+
+// reusePortControl is supported on Linux only
+func reusePortControl(network, address string, c syscall.RawConn) error {
+	return errors.NotSupportedf("SO_REUSEPORT on this platform")
+}
+
+// setListenBacklog is supported on Linux only,
+// on other platforms the system default is used
+func setListenBacklog(ln net.Listener, backlog int) error {
+	logger.Warningf("api=setListenBacklog, reason=not_supported, backlog=%d", backlog)
+	return nil
+}
@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"net/http"
+)
+
+// middleware wraps the delegate handler
+type middleware func(delegate http.Handler) http.Handler
+
+// muxLayers specifies the layers of the server handler,
+// the handlers in each layer are listed from the outermost to the innermost.
+type muxLayers struct {
+	// missingHost applies the policy to the requests without Host header
+	missingHost middleware
+	// context extracts the identity, the correlation ID and the client IP
+	context middleware
+	// recovery replies to the panics of the inner layers
+	recovery middleware
+	// headers set the Server and security headers of the responses
+	headers []middleware
+	// limits reject the requests exceeding the header count, URI length and body size
+	limits []middleware
+	// routing normalizes the path, matches the route, and decodes the body
+	routing []middleware
+	// availability serves the probes, and rejects the requests
+	// while the server is not ready, draining or overloaded
+	availability []middleware
+	// logging records the metrics and the access logs
+	logging []middleware
+	// authz audits and authorizes the requests
+	authz []middleware
+	// routes are the per-route handlers, such as the timeout, cache and validation
+	routes []middleware
+}
+
+// compose returns the router wrapped with the layers, from the outermost:
+//
+//	missing host → context → recovery → security headers → limits → routing →
+//	availability → logging → authz → per-route handlers → router
+//
+// The recovery is installed inside the context, but outside everything else,
+// so a panic in any inner layer is replied with 500 and audited
+// with the correlation ID of the request.
+//
+// The missing Host policy is the only layer outside the context,
+// as the default host is assigned before the tenant of the request
+// is resolved from the host by the context.
+func (l *muxLayers) compose(router http.Handler) http.Handler {
+	h := router
+	for _, layer := range [][]middleware{
+		l.routes,
+		l.authz,
+		l.logging,
+		l.availability,
+		l.routing,
+		l.limits,
+		l.headers,
+		{l.recovery},
+		{l.context},
+		{l.missingHost},
+	} {
+		for i := len(layer) - 1; i >= 0; i-- {
+			if layer[i] != nil {
+				h = layer[i](h)
+			}
+		}
+	}
+	return h
+}
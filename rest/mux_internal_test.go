@@ -0,0 +1,102 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/go-phorce/dolly/xhttp"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_muxLayersCompose(t *testing.T) {
+	var order []string
+	layer := func(name string) middleware {
+		return func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				h.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	layers := &muxLayers{
+		missingHost:  layer("missingHost"),
+		context:      layer("context"),
+		recovery:     layer("recovery"),
+		headers:      []middleware{layer("server"), layer("security")},
+		limits:       []middleware{layer("headerCount"), nil, layer("bodySize")},
+		routing:      []middleware{layer("slash"), layer("matcher")},
+		availability: []middleware{layer("probes"), layer("ready")},
+		logging:      []middleware{layer("metrics"), layer("logger")},
+		authz:        []middleware{layer("audit"), layer("authz")},
+		routes:       []middleware{layer("timeout"), layer("schema")},
+	}
+	h := layers.compose(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "router")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/test", nil))
+
+	assert.Equal(t, []string{
+		"missingHost",
+		"context",
+		"recovery",
+		"server", "security",
+		"headerCount", "bodySize",
+		"slash", "matcher",
+		"probes", "ready",
+		"metrics", "logger",
+		"audit", "authz",
+		"timeout", "schema",
+		"router",
+	}, order)
+}
+
+func Test_muxLayersRecovery(t *testing.T) {
+	audit := auditor.NewInMemory()
+	panics := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("layer failed")
+		})
+	}
+	router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	tcs := []struct {
+		name   string
+		layers *muxLayers
+	}{
+		{"headers", &muxLayers{headers: []middleware{panics}}},
+		{"limits", &muxLayers{limits: []middleware{panics}}},
+		{"routing", &muxLayers{routing: []middleware{panics}}},
+		{"logging", &muxLayers{logging: []middleware{panics}}},
+		{"router", &muxLayers{routes: []middleware{panics}}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			audit.Reset()
+			tc.layers.context = identity.NewContextHandler
+			tc.layers.recovery = func(h http.Handler) http.Handler {
+				return xhttp.NewRecovery(h).WithAuditor(audit)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/v1/test", nil)
+			r.Header.Set(header.XCorrelationID, "panic-1234")
+			tc.layers.compose(router).ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+			assert.Equal(t, "panic-1234", w.Header().Get(header.XCorrelationID))
+			assert.Contains(t, w.Body.String(), "correlation ID: panic-1234")
+
+			evt := audit.Find(xhttp.EvtSourceRecovery, xhttp.EvtPanic)
+			require.NotNil(t, evt)
+			assert.Equal(t, "panic-1234", evt.ContextID)
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-phorce/dolly/xhttp/authz"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+	"github.com/julienschmidt/httprouter"
+)
+
+// DefaultPublicRoutes specifies the paths of the health, readiness and version end-points,
+// that are public, unless a service declares a policy for the route
+var DefaultPublicRoutes = []string{
+	"/availability",
+	"/healthz",
+	"/readyz",
+	"/startupz",
+	"/version",
+}
+
+// RoutePolicy specifies the authorization requirements of the route
+type RoutePolicy struct {
+	// Method specifies the HTTP method of the route,
+	// if empty, then the policy applies to all methods registered for the Path
+	Method string
+	// Path specifies the path template, as registered with the router,
+	// such as /v1/admin/tasks/:name
+	Path string
+	// Public specifies that the route is accessible without authorization
+	Public bool
+	// Roles specifies the roles allowed to access the route,
+	// if empty, then any role except the guest is allowed.
+	// The request with other role is rejected with 403
+	Roles []string
+}
+
+// RoutePolicyProvider is an optional interface for the Service,
+// that declares the authorization policies of its routes.
+// The routes with a policy are authorized by the policy,
+// instead of the server's Authz provider.
+type RoutePolicyProvider interface {
+	// RoutePolicies returns the authorization policies of the service routes
+	RoutePolicies() []RoutePolicy
+}
+
+// RoleProvider is an optional interface for the Authz provider,
+// that returns the role of the request to match against the route policies
+type RoleProvider interface {
+	// Role returns the role of the request
+	Role(r *http.Request) string
+}
+
+// routePolicies returns the policies of the registered routes,
+// the policies declared by the services override the default public routes
+func routePolicies(routes []Route, services []Service) map[Route]RoutePolicy {
+	policies := map[Route]RoutePolicy{}
+	for _, route := range routes {
+		for _, path := range DefaultPublicRoutes {
+			if route.Path == path {
+				policies[route] = RoutePolicy{Method: route.Method, Path: path, Public: true}
+			}
+		}
+	}
+
+	for _, s := range services {
+		p, ok := s.(RoutePolicyProvider)
+		if !ok {
+			continue
+		}
+		for _, policy := range p.RoutePolicies() {
+			for _, route := range registeredRoutes("routePolicies", routes, s, policy.Method, policy.Path) {
+				policies[route] = policy
+			}
+		}
+	}
+	return policies
+}
+
+// newRoutePolicyHandler returns a http.Handler that authorizes the routes with a policy,
+// and passes the request to the delegate handler if allowed.
+// The requests for other routes are passed to the fallback handler.
+func (server *HTTPServer) newRoutePolicyHandler(policies map[Route]RoutePolicy, delegate, fallback http.Handler) http.Handler {
+	handlers := map[Route]http.Handler{}
+	for route, policy := range policies {
+		policy := policy
+		logger.Infof("api=newRoutePolicyHandler, method=%s, path=%s, public=%t, roles=%v",
+			route.Method, route.Path, policy.Public, policy.Roles)
+		handlers[route] = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			server.authorizeRoute(w, r, &policy, delegate)
+		})
+	}
+	return newRouteHandler(handlers, fallback)
+}
+
+// registeredRoutes returns the registered routes, that match the method and path
+// declared by the service for a per-route option,
+// the empty method matches all methods registered for the path.
+// The declaration without the registered route is logged with the api.
+func registeredRoutes(api string, routes []Route, s Service, method, path string) []Route {
+	var res []Route
+	for _, route := range routes {
+		if route.Path == servicePath(s, path) && (method == "" || method == route.Method) {
+			res = append(res, route)
+		}
+	}
+	if len(res) == 0 {
+		logger.Warningf("api=%s, service=%s, reason=not_registered, method=%q, path=%q",
+			api, s.Name(), method, path)
+	}
+	return res
+}
+
+// routeContextKey is the context key of the route matched by newRouteMatcher
+type routeContextKey struct{}
+
+// newRouteMatcher returns a http.Handler that matches the request against the registered routes,
+// and passes the request with the matched route to the delegate handler,
+// so the per-route handlers do not match the request again
+func newRouteMatcher(routes []Route, delegate http.Handler) http.Handler {
+	tree := newRouteTree()
+	for i := range routes {
+		route := &routes[i]
+		tree.Handle(route.Method, route.Path, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			delegate.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeContextKey{}, route)))
+		})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ps, _ := tree.Lookup(r.Method, r.URL.Path); h != nil {
+			h(w, r, ps)
+			return
+		}
+		// the request does not match any route
+		delegate.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeContextKey{}, (*Route)(nil))))
+	})
+}
+
+// newRouteHandler returns a http.Handler that serves the requests for the routes
+// with their handlers of a per-route option, and other requests with the fallback handler.
+// The route matched by newRouteMatcher is used, otherwise the request is matched
+// against the routes, for example when the handler is installed outside of the matcher.
+func newRouteHandler(handlers map[Route]http.Handler, fallback http.Handler) http.Handler {
+	if len(handlers) == 0 {
+		return fallback
+	}
+
+	tree := newRouteTree()
+	for route, h := range handlers {
+		h := h
+		tree.Handle(route.Method, route.Path, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			h.ServeHTTP(w, r)
+		})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route, ok := r.Context().Value(routeContextKey{}).(*Route); ok {
+			if route != nil {
+				if h, ok := handlers[*route]; ok {
+					h.ServeHTTP(w, r)
+					return
+				}
+			}
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		if h, ps, _ := tree.Lookup(r.Method, r.URL.Path); h != nil {
+			h(w, r, ps)
+			return
+		}
+		fallback.ServeHTTP(w, r)
+	})
+}
+
+// newRouteTree returns the router to match the request against the route templates,
+// without redirects and automatic responses
+func newRouteTree() *httprouter.Router {
+	tree := httprouter.New()
+	tree.RedirectTrailingSlash = false
+	tree.RedirectFixedPath = false
+	tree.HandleMethodNotAllowed = false
+	tree.HandleOPTIONS = false
+	return tree
+}
+
+// authorizeRoute matches the role of the request against the route policy,
+// and returns 403 for the insufficient role
+func (server *HTTPServer) authorizeRoute(w http.ResponseWriter, r *http.Request, policy *RoutePolicy, delegate http.Handler) {
+	if policy.Public {
+		delegate.ServeHTTP(w, r)
+		return
+	}
+
+	role := server.requestRole(r)
+	if isRoleAllowed(policy, role) {
+		delegate.ServeHTTP(w, r)
+		return
+	}
+
+	ctx := identity.ForRequest(r)
+	var id string
+	if ctx.Identity() != nil {
+		id = ctx.Identity().String()
+	}
+	server.Audit(
+		authz.EvtSourceAuthz,
+		authz.EvtDenied,
+		id,
+		ctx.CorrelationID(),
+		0,
+		authz.AuditMessage(r, role),
+	)
+	marshal.WriteJSON(w, r, httperror.WithForbidden("the %q role is not allowed", role))
+}
+
+// requestRole returns the role of the request,
+// as provided by the Authz provider, or by the request identity
+func (server *HTTPServer) requestRole(r *http.Request) string {
+	if rp, ok := server.authz.(RoleProvider); ok {
+		return rp.Role(r)
+	}
+	if id := identity.ForRequest(r).Identity(); id != nil && id.Role() != "" {
+		return id.Role()
+	}
+	return identity.GuestRoleName
+}
+
+func isRoleAllowed(policy *RoutePolicy, role string) bool {
+	if len(policy.Roles) == 0 {
+		return role != identity.GuestRoleName
+	}
+	for _, allowed := range policy.Roles {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
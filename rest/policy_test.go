@@ -0,0 +1,101 @@
+package rest_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/go-phorce/dolly/xhttp/authz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type policyService struct{}
+
+func (s *policyService) Name() string  { return "policytest" }
+func (s *policyService) IsReady() bool { return true }
+func (s *policyService) Close()        {}
+func (s *policyService) Register(r rest.Router) {
+	ok := func(w http.ResponseWriter, _ *http.Request, _ rest.Params) {
+		w.Write([]byte("ok"))
+	}
+	r.GET("/healthz", ok)
+	r.GET("/v1/public", ok)
+	r.GET("/v1/admin/status", ok)
+	r.POST("/v1/admin/status", ok)
+	r.GET("/v1/admin/users/:id", ok)
+	r.GET("/v1/other", ok)
+}
+
+func (s *policyService) RoutePolicies() []rest.RoutePolicy {
+	return []rest.RoutePolicy{
+		{Path: "/v1/public", Public: true},
+		{Path: "/v1/admin/status", Roles: []string{"admin", "ops"}},
+		{Method: http.MethodGet, Path: "/v1/admin/users/:id"},
+		{Path: "/v1/notregistered", Public: true},
+	}
+}
+
+func Test_RoutePolicies(t *testing.T) {
+	az, err := authz.New(&authz.Config{
+		Allow: []string{"/v1:admin"},
+	})
+	require.NoError(t, err)
+	az.SetRoleMapper(func(r *http.Request) string {
+		return r.Header.Get("X-Test-Role")
+	})
+
+	audit := auditor.NewInMemory()
+	_, url, cleanup := resttest.Start(t, resttest.Options{
+		Auditor: audit,
+		Authz:   az,
+		Services: []resttest.ServiceFactory{
+			func(rest.Server) rest.Service { return &policyService{} },
+		},
+	})
+	defer cleanup()
+
+	tcases := []struct {
+		method string
+		path   string
+		role   string
+		status int
+	}{
+		{http.MethodGet, "/healthz", "", http.StatusOK},
+		{http.MethodGet, "/readyz", "", http.StatusOK},
+		{http.MethodGet, "/v1/public", "", http.StatusOK},
+		{http.MethodGet, "/v1/admin/status", "ops", http.StatusOK},
+		{http.MethodPost, "/v1/admin/status", "admin", http.StatusOK},
+		{http.MethodGet, "/v1/admin/status", "client", http.StatusForbidden},
+		{http.MethodGet, "/v1/admin/status", "", http.StatusForbidden},
+		{http.MethodGet, "/v1/admin/users/123", "client", http.StatusOK},
+		{http.MethodGet, "/v1/admin/users/123", "", http.StatusForbidden},
+		// authorized by the Authz provider
+		{http.MethodGet, "/v1/other", "admin", http.StatusOK},
+		{http.MethodGet, "/v1/other", "ops", http.StatusUnauthorized},
+	}
+
+	for _, tc := range tcases {
+		req, err := http.NewRequest(tc.method, url+tc.path, nil)
+		require.NoError(t, err)
+		if tc.role != "" {
+			req.Header.Set("X-Test-Role", tc.role)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, tc.status, resp.StatusCode, "%s %s role=%q: %s", tc.method, tc.path, tc.role, string(body))
+		if tc.status == http.StatusForbidden {
+			assert.Contains(t, string(body), `"code":"forbidden"`)
+		}
+	}
+
+	evt := audit.Find(authz.EvtSourceAuthz, authz.EvtDenied)
+	require.NotNil(t, evt)
+	// the event is formatted as by the Authz provider
+	assert.Regexp(t, `^method=\w+, path=\S+, role=\S*$`, evt.Message)
+}
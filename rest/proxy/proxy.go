@@ -0,0 +1,180 @@
+// Package proxy provides a reverse-proxy service,
+// that forwards the requests under the route prefixes to upstream servers.
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/xhttp"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+	"github.com/go-phorce/dolly/xlog"
+	"github.com/juju/errors"
+)
+
+var logger = xlog.NewPackageLogger("github.com/go-phorce/dolly", "rest/proxy")
+
+var keyForProxyReqPerf = []string{"http", "proxy", "perf"}
+
+// Upstream specifies the mapping of the route prefix to the upstream server
+type Upstream struct {
+	// Prefix specifies the route prefix, for example "/v1/backend"
+	Prefix string
+	// URL specifies the upstream server URL,
+	// the path after the prefix is appended to the URL path
+	URL string
+	// Timeout specifies the timeout for the upstream request,
+	// if not set, then the request is not limited
+	Timeout time.Duration
+}
+
+type route struct {
+	prefix  string
+	target  *url.URL
+	timeout time.Duration
+	proxy   *httputil.ReverseProxy
+}
+
+// Service defines the reverse-proxy service
+type Service struct {
+	name   string
+	routes []*route
+}
+
+// NewService returns an instance of the reverse-proxy service,
+// if transport is nil, then http.DefaultTransport is used
+func NewService(name string, upstreams []Upstream, transport http.RoundTripper) (*Service, error) {
+	s := &Service{
+		name: name,
+	}
+	for _, u := range upstreams {
+		target, err := url.Parse(u.URL)
+		if err != nil || target.Scheme == "" || target.Host == "" {
+			return nil, errors.NotValidf("upstream URL %q", u.URL)
+		}
+		if !strings.HasPrefix(u.Prefix, "/") {
+			return nil, errors.NotValidf("upstream prefix %q", u.Prefix)
+		}
+
+		rt := &route{
+			prefix:  strings.TrimSuffix(u.Prefix, "/"),
+			target:  target,
+			timeout: u.Timeout,
+		}
+		rt.proxy = &httputil.ReverseProxy{
+			Director:     rt.director,
+			Transport:    transport,
+			ErrorHandler: rt.errorHandler,
+		}
+		s.routes = append(s.routes, rt)
+	}
+	return s, nil
+}
+
+// Name returns the service name
+func (s *Service) Name() string {
+	return s.name
+}
+
+// IsReady indicates that the service is ready to serve its end-points
+func (s *Service) IsReady() bool {
+	return true
+}
+
+// Close the subservices and it's resources
+func (s *Service) Close() {
+}
+
+// Register adds the endpoints to the overall URL router
+func (s *Service) Register(r rest.Router) {
+	for _, rt := range s.routes {
+		p := rt.prefix + "/*path"
+		h := rt.handle
+		r.GET(p, h)
+		r.HEAD(p, h)
+		r.POST(p, h)
+		r.PUT(p, h)
+		r.PATCH(p, h)
+		r.DELETE(p, h)
+		r.OPTIONS(p, h)
+	}
+}
+
+func (rt *route) handle(w http.ResponseWriter, r *http.Request, p rest.Params) {
+	start := time.Now()
+	if rt.timeout > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), rt.timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+
+	rc := xhttp.NewResponseCapture(w)
+	rt.proxy.ServeHTTP(rc, r)
+
+	metrics.MeasureSince(keyForProxyReqPerf, start,
+		metrics.Tag{Name: tags.Method, Value: r.Method},
+		metrics.Tag{Name: tags.Status, Value: strconv.Itoa(rc.StatusCode())},
+		metrics.Tag{Name: tags.URI, Value: rt.prefix},
+	)
+}
+
+// director rewrites the request to the upstream
+func (rt *route) director(r *http.Request) {
+	suffix := strings.TrimPrefix(r.URL.Path, rt.prefix)
+
+	r.URL.Scheme = rt.target.Scheme
+	r.URL.Host = rt.target.Host
+	r.URL.Path = singleJoiningSlash(rt.target.Path, suffix)
+	r.URL.RawPath = ""
+	if rt.target.RawQuery == "" || r.URL.RawQuery == "" {
+		r.URL.RawQuery = rt.target.RawQuery + r.URL.RawQuery
+	} else {
+		r.URL.RawQuery = rt.target.RawQuery + "&" + r.URL.RawQuery
+	}
+	r.Host = rt.target.Host
+
+	if _, ok := r.Header[header.UserAgent]; !ok {
+		// explicitly disable User-Agent so it's not set to default value
+		r.Header.Set(header.UserAgent, "")
+	}
+	r.Header.Set(header.XCorrelationID, identity.ForRequest(r).CorrelationID())
+}
+
+// errorHandler replies with 504 on timeout, and with 502 on other upstream errors
+func (rt *route) errorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	logger.Errorf("api=proxy, prefix=%s, upstream=%s, method=%s, path=%s, ctx=%q, err=[%v]",
+		rt.prefix, rt.target.Host, r.Method, r.URL.Path,
+		r.Header.Get(header.XCorrelationID), err)
+
+	if r.Context().Err() == context.DeadlineExceeded {
+		marshal.WriteJSON(w, r, httperror.WithGatewayTimeout("the upstream did not reply within %v", rt.timeout))
+		return
+	}
+	marshal.WriteJSON(w, r, httperror.WithBadGateway("the upstream request failed").WithCause(err))
+}
+
+func singleJoiningSlash(a, b string) string {
+	if b == "" || b == "/" {
+		if a == "" {
+			return "/"
+		}
+		return a
+	}
+	joined := path.Join("/", a, b)
+	if strings.HasSuffix(b, "/") {
+		joined += "/"
+	}
+	return joined
+}
@@ -0,0 +1,80 @@
+package proxy_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/proxy"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewService(t *testing.T) {
+	_, err := proxy.NewService("proxy", []proxy.Upstream{{Prefix: "/v1", URL: "localhost"}}, nil)
+	assert.EqualError(t, err, `upstream URL "localhost" not valid`)
+
+	_, err = proxy.NewService("proxy", []proxy.Upstream{{Prefix: "v1", URL: "http://localhost"}}, nil)
+	assert.EqualError(t, err, `upstream prefix "v1" not valid`)
+}
+
+func Test_Proxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/slow" {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Header().Set(header.ContentType, header.TextPlain)
+		w.Write([]byte(r.Method + " " + r.URL.RequestURI() + " " + r.Header.Get(header.XCorrelationID)))
+	}))
+	defer backend.Close()
+
+	svc, err := proxy.NewService("proxy", []proxy.Upstream{
+		{Prefix: "/v1/backend", URL: backend.URL + "/api", Timeout: 100 * time.Millisecond},
+		{Prefix: "/v1/down/", URL: "http://127.0.0.1:1"},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "proxy", svc.Name())
+	assert.True(t, svc.IsReady())
+	defer svc.Close()
+
+	router := rest.NewRouter(nil)
+	svc.Register(router)
+	handler := identity.NewContextHandler(router.Handler())
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(method, path, nil)
+		require.NoError(t, err)
+		r.Header.Set(header.XCorrelationID, "corr1234")
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("forward", func(t *testing.T) {
+		w := serve(http.MethodPost, "/v1/backend/items/1?q=a")
+		require.Equal(t, http.StatusOK, w.Code)
+		body, _ := ioutil.ReadAll(w.Body)
+		assert.Equal(t, "POST /api/items/1?q=a corr1234", string(body))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		w := serve(http.MethodGet, "/v1/backend/slow")
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Equal(t, `{"code":"gateway_timeout","message":"the upstream did not reply within 100ms"}`, w.Body.String())
+	})
+
+	t.Run("bad gateway", func(t *testing.T) {
+		w := serve(http.MethodGet, "/v1/down/items")
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.Equal(t, `{"code":"bad_gateway","message":"the upstream request failed"}`, w.Body.String())
+	})
+}
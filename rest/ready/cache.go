@@ -0,0 +1,125 @@
+package ready
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-phorce/dolly/clock"
+)
+
+// CheckFunc returns true if the service is ready,
+// otherwise false and the reason
+type CheckFunc func() (bool, string)
+
+// StatusFunc returns the readiness status,
+// the CheckedAt time is set by the Cache
+type StatusFunc func() Status
+
+// Status provides the result of the readiness check
+type Status struct {
+	Ready     bool      `json:"ready"`
+	CheckedAt time.Time `json:"checked_at"`
+	Reason    string    `json:"reason,omitempty"`
+	// Dependencies provides the status of the dependency checks
+	Dependencies []DependencyStatus `json:"dependencies,omitempty"`
+}
+
+// StatusProvider specifies an interface to provide the readiness status
+type StatusProvider interface {
+	ServiceStatus
+	ReadyStatus() Status
+}
+
+// Cache is a ServiceStatus that caches the result of the readiness check,
+// so the check on the request path is O(1).
+// The result older than TTL is refreshed in the background,
+// and the result older than max age is treated as not ready.
+type Cache struct {
+	check  CheckFunc
+	status StatusFunc
+	ttl    time.Duration
+	maxAge time.Duration
+	clock  clock.Clock
+
+	cached     atomic.Value
+	refreshing int32
+	lock       sync.Mutex
+}
+
+// NewCache returns a Cache for the check,
+// if maxAge is 0, then the stale result is used until refreshed
+func NewCache(check CheckFunc, ttl, maxAge time.Duration) *Cache {
+	return &Cache{
+		check:  check,
+		ttl:    ttl,
+		maxAge: maxAge,
+		clock:  clock.New(),
+	}
+}
+
+// NewStatusCache returns a Cache for the status function,
+// if maxAge is 0, then the stale result is used until refreshed
+func NewStatusCache(status StatusFunc, ttl, maxAge time.Duration) *Cache {
+	return &Cache{
+		status: status,
+		ttl:    ttl,
+		maxAge: maxAge,
+		clock:  clock.New(),
+	}
+}
+
+// WithClock sets the clock, it's used in tests
+func (c *Cache) WithClock(clk clock.Clock) *Cache {
+	c.clock = clk
+	return c
+}
+
+// IsReady returns the cached readiness,
+// the first call runs the check synchronously
+func (c *Cache) IsReady() bool {
+	return c.ReadyStatus().Ready
+}
+
+// ReadyStatus returns the cached readiness status,
+// and schedules the refresh if the result is older than TTL
+func (c *Cache) ReadyStatus() Status {
+	s, ok := c.cached.Load().(Status)
+	if !ok {
+		return c.Refresh()
+	}
+
+	age := c.clock.Now().Sub(s.CheckedAt)
+	if age >= c.ttl && atomic.CompareAndSwapInt32(&c.refreshing, 0, 1) {
+		go func() {
+			defer atomic.StoreInt32(&c.refreshing, 0)
+			c.Refresh()
+		}()
+	}
+	if c.maxAge > 0 && age > c.maxAge {
+		return Status{
+			CheckedAt: s.CheckedAt,
+			Reason:    "readiness check is stale",
+		}
+	}
+	return s
+}
+
+// Refresh runs the check and caches the result
+func (c *Cache) Refresh() Status {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var s Status
+	if c.status != nil {
+		s = c.status()
+	} else {
+		s.Ready, s.Reason = c.check()
+	}
+	s.CheckedAt = c.clock.Now().UTC()
+	if s.Ready {
+		s.Reason = ""
+	}
+	c.cached.Store(s)
+	return s
+}
@@ -0,0 +1,102 @@
+package ready
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Cache(t *testing.T) {
+	started := time.Date(2020, time.May, 1, 10, 0, 0, 0, time.UTC)
+	mock := clock.NewMock(started)
+
+	var calls int32
+	var ready int32
+	check := func() (bool, string) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&ready) == 1 {
+			return true, ""
+		}
+		return false, "service \"test\" is not ready"
+	}
+
+	c := NewCache(check, time.Second, 5*time.Second).WithClock(mock)
+	assert.False(t, c.IsReady())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	s := c.ReadyStatus()
+	assert.Equal(t, started, s.CheckedAt)
+	assert.Equal(t, "service \"test\" is not ready", s.Reason)
+
+	// cached within TTL
+	atomic.StoreInt32(&ready, 1)
+	assert.False(t, c.IsReady())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// refreshed in the background after TTL
+	mock.Add(time.Second)
+	c.IsReady()
+	for i := 0; i < 100 && !c.IsReady(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, c.IsReady())
+	assert.Equal(t, started.Add(time.Second), c.ReadyStatus().CheckedAt)
+
+	// the result older than max age is not ready
+	c.check = func() (bool, string) {
+		time.Sleep(100 * time.Millisecond)
+		return true, ""
+	}
+	mock.Add(10 * time.Second)
+	s = c.ReadyStatus()
+	assert.False(t, s.Ready)
+	assert.Equal(t, "readiness check is stale", s.Reason)
+
+	// on demand
+	s = c.Refresh()
+	assert.True(t, s.Ready)
+	assert.Equal(t, started.Add(11*time.Second), s.CheckedAt)
+}
+
+type testStatus struct {
+	Status
+}
+
+func (s *testStatus) IsReady() bool {
+	return s.Ready
+}
+
+func (s *testStatus) ReadyStatus() Status {
+	return s.Status
+}
+
+func Test_StatusHandler(t *testing.T) {
+	checked := time.Date(2020, time.May, 1, 10, 0, 0, 0, time.UTC)
+	s := &testStatus{Status{CheckedAt: checked, Reason: "starting"}}
+	h := NewStatusHandler(s)
+
+	r, err := http.NewRequest(http.MethodGet, URIReadyz, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var res Status
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, s.Status, res)
+
+	s.Ready = true
+	s.Reason = ""
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "reason")
+}
@@ -0,0 +1,153 @@
+package ready
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-phorce/dolly/clock"
+)
+
+const (
+	// DefaultDependencyTimeout specifies the default timeout of the dependency check
+	DefaultDependencyTimeout = 2 * time.Second
+	// DefaultDependencyCacheTTL specifies the default period to cache the result of the dependency check
+	DefaultDependencyCacheTTL = 10 * time.Second
+)
+
+// DependencyCheckFunc returns nil if the dependency is reachable
+type DependencyCheckFunc func(ctx context.Context) error
+
+// Dependency specifies the check of the downstream dependency,
+// such as a database or HTTP service
+type Dependency struct {
+	// Name specifies the unique name of the dependency
+	Name string
+	// Critical specifies that the service is not ready,
+	// if the dependency check fails.
+	// The failures of the non-critical dependencies are only reported.
+	Critical bool
+	// Timeout specifies the timeout of the check,
+	// if not set, DefaultDependencyTimeout is used
+	Timeout time.Duration
+	// CacheTTL specifies the period to cache the result of the check,
+	// if not set, DefaultDependencyCacheTTL is used
+	CacheTTL time.Duration
+	// Check specifies the check function
+	Check DependencyCheckFunc
+}
+
+// DependencyStatus provides the result of the dependency check
+type DependencyStatus struct {
+	Name      string    `json:"name"`
+	Critical  bool      `json:"critical"`
+	Healthy   bool      `json:"healthy"`
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// DependencyProvider is an optional interface for the service,
+// that declares the dependencies to check for the readiness
+type DependencyProvider interface {
+	// Dependencies returns the dependencies of the service
+	Dependencies() []Dependency
+}
+
+// DependencyChecker runs the dependency checks concurrently,
+// and caches the results by the dependency name
+type DependencyChecker struct {
+	clock   clock.Clock
+	results map[string]DependencyStatus
+	lock    sync.Mutex
+}
+
+// NewDependencyChecker returns DependencyChecker
+func NewDependencyChecker() *DependencyChecker {
+	return &DependencyChecker{
+		clock:   clock.New(),
+		results: map[string]DependencyStatus{},
+	}
+}
+
+// WithClock sets the clock, it's used in tests
+func (c *DependencyChecker) WithClock(clk clock.Clock) *DependencyChecker {
+	c.clock = clk
+	return c
+}
+
+// Check returns the status of the dependencies, in the order of the dependencies.
+// The checks with the cached result within TTL are not run.
+func (c *DependencyChecker) Check(ctx context.Context, deps []Dependency) []DependencyStatus {
+	res := make([]DependencyStatus, len(deps))
+
+	var wg sync.WaitGroup
+	for i, d := range deps {
+		if s, ok := c.cached(&d); ok {
+			res[i] = s
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, d Dependency) {
+			defer wg.Done()
+			res[i] = c.run(ctx, &d)
+		}(i, d)
+	}
+	wg.Wait()
+
+	return res
+}
+
+func (c *DependencyChecker) cached(d *Dependency) (DependencyStatus, bool) {
+	ttl := d.CacheTTL
+	if ttl <= 0 {
+		ttl = DefaultDependencyCacheTTL
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s, ok := c.results[d.Name]
+	if !ok || c.clock.Now().Sub(s.CheckedAt) >= ttl {
+		return s, false
+	}
+	// the criticality may change with the service
+	s.Critical = d.Critical
+	return s, true
+}
+
+func (c *DependencyChecker) run(ctx context.Context, d *Dependency) DependencyStatus {
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = DefaultDependencyTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- d.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	s := DependencyStatus{
+		Name:      d.Name,
+		Critical:  d.Critical,
+		Healthy:   err == nil,
+		CheckedAt: c.clock.Now().UTC(),
+	}
+	if err != nil {
+		s.Error = err.Error()
+		logger.Warningf("api=DependencyChecker, name=%s, critical=%t, err=[%v]", d.Name, d.Critical, err)
+	}
+
+	c.lock.Lock()
+	c.results[d.Name] = s
+	c.lock.Unlock()
+	return s
+}
@@ -0,0 +1,98 @@
+package ready
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DependencyChecker(t *testing.T) {
+	started := time.Date(2020, time.May, 1, 10, 0, 0, 0, time.UTC)
+	mock := clock.NewMock(started)
+
+	var calls int32
+	var dbUp int32
+
+	deps := []Dependency{
+		{
+			Name:     "db",
+			Critical: true,
+			CacheTTL: time.Second,
+			Check: func(ctx context.Context) error {
+				atomic.AddInt32(&calls, 1)
+				if atomic.LoadInt32(&dbUp) == 0 {
+					return errors.New("connection refused")
+				}
+				return nil
+			},
+		},
+		{
+			Name:    "slow",
+			Timeout: 50 * time.Millisecond,
+			Check: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		},
+		{
+			Name:  "cache",
+			Check: func(ctx context.Context) error { return nil },
+		},
+	}
+
+	c := NewDependencyChecker().WithClock(mock)
+	res := c.Check(context.Background(), deps)
+	require.Len(t, res, 3)
+	assert.Equal(t, DependencyStatus{
+		Name:      "db",
+		Critical:  true,
+		Healthy:   false,
+		CheckedAt: started,
+		Error:     "connection refused",
+	}, res[0])
+	assert.Equal(t, "slow", res[1].Name)
+	assert.False(t, res[1].Healthy)
+	assert.Equal(t, context.DeadlineExceeded.Error(), res[1].Error)
+	assert.True(t, res[2].Healthy)
+	assert.Empty(t, res[2].Error)
+
+	// cached within TTL
+	atomic.StoreInt32(&dbUp, 1)
+	res = c.Check(context.Background(), deps)
+	assert.False(t, res[0].Healthy)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// checked after TTL
+	mock.Add(time.Second)
+	res = c.Check(context.Background(), deps)
+	assert.True(t, res[0].Healthy)
+	assert.Empty(t, res[0].Error)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func Test_StatusCache(t *testing.T) {
+	started := time.Date(2020, time.May, 1, 10, 0, 0, 0, time.UTC)
+	mock := clock.NewMock(started)
+
+	c := NewStatusCache(func() Status {
+		return Status{
+			Ready: true,
+			// the reason of the ready status is ignored
+			Reason:       "ignored",
+			Dependencies: []DependencyStatus{{Name: "db", Healthy: true}},
+		}
+	}, time.Second, 0).WithClock(mock)
+
+	s := c.ReadyStatus()
+	assert.True(t, s.Ready)
+	assert.Empty(t, s.Reason)
+	assert.Equal(t, started, s.CheckedAt)
+	require.Len(t, s.Dependencies, 1)
+	assert.Equal(t, "db", s.Dependencies[0].Name)
+}
@@ -3,19 +3,92 @@ package ready
 import (
 	"net/http"
 
+	"github.com/go-phorce/dolly/xhttp/header"
 	"github.com/go-phorce/dolly/xhttp/httperror"
 	"github.com/go-phorce/dolly/xhttp/marshal"
+	"github.com/go-phorce/dolly/xlog"
 )
 
-var (
-	errUnavailable = httperror.New(http.StatusServiceUnavailable, "not_ready", "the service is not ready yet")
+var logger = xlog.NewPackageLogger("github.com/go-phorce/dolly", "rest/ready")
+
+const (
+	// URIReadyz specifies the readiness end-point,
+	// which is served regardless of the readiness
+	URIReadyz = "/readyz"
+	// URIStartupz specifies the startup end-point,
+	// which is served regardless of the readiness
+	URIStartupz = "/startupz"
 )
 
+// DefaultRetryAfter specifies the default value of Retry-After header,
+// in seconds, for the not ready response
+const DefaultRetryAfter = "5"
+
+// NotReadyResponse specifies the response to the requests,
+// while the service is not ready
+type NotReadyResponse struct {
+	// StatusCode specifies the HTTP status code, 503 if not set
+	StatusCode int
+	// Header specifies the headers of the response, such as Retry-After
+	Header http.Header
+	// Body builds the JSON body of the response from the readiness status,
+	// if not set, then the response has no body
+	Body func(r *http.Request, status Status) interface{}
+}
+
+// NotReadyError provides the default body of the not ready response
+type NotReadyError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Reason specifies the reason, such as the services not ready yet
+	Reason string `json:"reason,omitempty"`
+}
+
+// DefaultNotReadyResponse returns the default not ready response,
+// with 503 status code, Retry-After header and NotReadyError body
+func DefaultNotReadyResponse() *NotReadyResponse {
+	return &NotReadyResponse{
+		StatusCode: http.StatusServiceUnavailable,
+		Header: http.Header{
+			header.RetryAfter: []string{DefaultRetryAfter},
+		},
+		Body: func(_ *http.Request, status Status) interface{} {
+			return &NotReadyError{
+				Code:    httperror.NotReady,
+				Message: "the service is not ready yet",
+				Reason:  status.Reason,
+			}
+		},
+	}
+}
+
+// ServeHTTP writes the not ready response for the readiness status
+func (n *NotReadyResponse) ServeHTTP(w http.ResponseWriter, r *http.Request, status Status) {
+	for k, v := range n.Header {
+		w.Header()[k] = v
+	}
+	code := n.StatusCode
+	if code == 0 {
+		code = http.StatusServiceUnavailable
+	}
+	if n.Body == nil {
+		w.WriteHeader(code)
+		return
+	}
+	marshal.WritePlainJSON(w, code, n.Body(r, status), marshal.DontPrettyPrint)
+}
+
 // ServiceStatus specifies an interface to check if the service is ready to serve requests
 type ServiceStatus interface {
 	IsReady() bool
 }
 
+// StartupStatus specifies an interface to check if the service has started,
+// that is all the subservices were ready at least once
+type StartupStatus interface {
+	HasStarted() bool
+}
+
 // ServiceReadyVerifier is a http.Handler that checks if the service is ready to serve,
 // and if so, chain the Delegate handler, otherwise call's the Error handler
 type ServiceReadyVerifier struct {
@@ -34,11 +107,22 @@ func (c *ServiceReadyVerifier) ServeHTTP(w http.ResponseWriter, r *http.Request)
 }
 
 // NewServiceStatusVerifier is a http.Handler that checks if the service is ready to serve,
-// and if so, chain the Delegate handler, otherwise call's the Error handler
-// it returns an error
+// and if so, chain the Delegate handler, otherwise writes the default not ready response
 func NewServiceStatusVerifier(s ServiceStatus, delegate http.Handler) http.Handler {
+	return NewServiceStatusVerifierWithResponse(s, delegate, DefaultNotReadyResponse())
+}
+
+// NewServiceStatusVerifierWithResponse is a http.Handler that checks if the service is ready to serve,
+// and if so, chain the Delegate handler, otherwise writes the not ready response.
+// If the service implements StatusProvider, then the response is built
+// with the reason of the readiness status.
+func NewServiceStatusVerifierWithResponse(s ServiceStatus, delegate http.Handler, resp *NotReadyResponse) http.Handler {
 	unavailable := func(w http.ResponseWriter, r *http.Request) {
-		marshal.WriteJSON(w, r, errUnavailable)
+		status := Status{Ready: false}
+		if sp, ok := s.(StatusProvider); ok {
+			status = sp.ReadyStatus()
+		}
+		resp.ServeHTTP(w, r, status)
 	}
 	v := ServiceReadyVerifier{
 		Status:   s,
@@ -47,3 +131,29 @@ func NewServiceStatusVerifier(s ServiceStatus, delegate http.Handler) http.Handl
 	}
 	return &v
 }
+
+// NewStatusHandler returns a http.Handler that writes the readiness status,
+// with 503 status code if not ready
+func NewStatusHandler(s StatusProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := s.ReadyStatus()
+		code := http.StatusOK
+		if !status.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		marshal.WritePlainJSON(w, code, status, marshal.DontPrettyPrint)
+	})
+}
+
+// NewStartupHandler returns a http.Handler that writes the startup status,
+// with 503 status code if not started yet
+func NewStartupHandler(s StartupStatus) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := s.HasStarted()
+		code := http.StatusOK
+		if !started {
+			code = http.StatusServiceUnavailable
+		}
+		marshal.WritePlainJSON(w, code, map[string]bool{"started": started}, marshal.DontPrettyPrint)
+	})
+}
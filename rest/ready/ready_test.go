@@ -64,3 +64,58 @@ func (th *testHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(th.statusCode)
 	w.Write(th.responseBody)
 }
+
+type serviceWithStatus struct {
+	serviceWithReady
+}
+
+func (s *serviceWithStatus) ReadyStatus() Status {
+	return Status{Ready: s.IsReady(), Reason: `service "db" is not ready`}
+}
+
+func Test_NotReadyResponse(t *testing.T) {
+	handler := testHandler{t, http.StatusOK, []byte("OK")}
+	req, err := http.NewRequest(http.MethodGet, "/foo", nil)
+	require.NoError(t, err)
+
+	t.Run("default", func(t *testing.T) {
+		sv := NewServiceStatusVerifier(new(serviceWithStatus), &handler)
+		res := httptest.NewRecorder()
+		sv.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+		assert.Equal(t, DefaultRetryAfter, res.Header().Get("Retry-After"))
+		assert.Equal(t, `{"code":"not_ready","message":"the service is not ready yet","reason":"service \"db\" is not ready"}`, res.Body.String())
+	})
+
+	t.Run("without status", func(t *testing.T) {
+		sv := NewServiceStatusVerifier(new(serviceWithReady), &handler)
+		res := httptest.NewRecorder()
+		sv.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+		assert.Equal(t, `{"code":"not_ready","message":"the service is not ready yet"}`, res.Body.String())
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		resp := &NotReadyResponse{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{"30"}},
+			Body: func(r *http.Request, status Status) interface{} {
+				return map[string]string{"path": r.URL.Path, "reason": status.Reason}
+			},
+		}
+		sv := NewServiceStatusVerifierWithResponse(new(serviceWithStatus), &handler, resp)
+		res := httptest.NewRecorder()
+		sv.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusTooManyRequests, res.Code)
+		assert.Equal(t, "30", res.Header().Get("Retry-After"))
+		assert.Equal(t, `{"path":"/foo","reason":"service \"db\" is not ready"}`, res.Body.String())
+	})
+
+	t.Run("no body", func(t *testing.T) {
+		sv := NewServiceStatusVerifierWithResponse(new(serviceWithStatus), &handler, &NotReadyResponse{})
+		res := httptest.NewRecorder()
+		sv.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+		assert.Empty(t, res.Body.String())
+	})
+}
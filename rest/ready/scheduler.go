@@ -0,0 +1,34 @@
+package ready
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-phorce/dolly/tasks"
+	"github.com/juju/errors"
+)
+
+// SchedulerDependencyName specifies the name of the scheduler dependency
+const SchedulerDependencyName = "scheduler"
+
+// SchedulerDependency returns the critical dependency, that fails
+// if the scheduler is not running, or has not ticked within the window,
+// for example if the scheduler go-routine died,
+// so the scheduled tasks silently stopped.
+// The scheduler ticks every second, so the window should be a few seconds.
+func SchedulerDependency(s tasks.Scheduler, window time.Duration) Dependency {
+	return Dependency{
+		Name:     SchedulerDependencyName,
+		Critical: true,
+		CacheTTL: time.Second,
+		Check: func(_ context.Context) error {
+			if !s.IsRunning() {
+				return errors.New("scheduler is not running")
+			}
+			if since := time.Since(s.LastTick()); since > window {
+				return errors.Errorf("scheduler has not ticked for %s", since.Truncate(time.Millisecond))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package ready
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/tasks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SchedulerDependency(t *testing.T) {
+	s := tasks.NewScheduler()
+	d := SchedulerDependency(s, 100*time.Millisecond)
+	assert.Equal(t, SchedulerDependencyName, d.Name)
+	assert.True(t, d.Critical)
+
+	err := d.Check(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "scheduler is not running", err.Error())
+
+	require.NoError(t, s.Start())
+	defer s.Stop()
+	assert.NoError(t, d.Check(context.Background()))
+
+	// the scheduler ticks every second
+	time.Sleep(200 * time.Millisecond)
+	err = d.Check(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "scheduler has not ticked for")
+
+	assert.NoError(t, SchedulerDependency(s, 5*time.Second).Check(context.Background()))
+}
@@ -0,0 +1,69 @@
+package ready
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/go-phorce/dolly/metrics"
+)
+
+var (
+	keyForServiceReady           = []string{"service", "ready"}
+	keyForServiceReadyTransition = []string{"service", "ready", "transition"}
+)
+
+// TransitionTracker tracks the readiness of the services across the checks,
+// to detect the services flapping between ready and not ready states
+type TransitionTracker struct {
+	lock   sync.Mutex
+	states map[string]bool
+}
+
+// NewTransitionTracker returns a new TransitionTracker
+func NewTransitionTracker() *TransitionTracker {
+	return &TransitionTracker{
+		states: map[string]bool{},
+	}
+}
+
+// Observe records the readiness of the service,
+// and publishes the gauge of the current readiness.
+// On the change of the readiness, the transition counter is published,
+// and the transition is logged with the reason.
+// Returns true if the readiness changed since the previous check,
+// the first check of the service is not a transition.
+func (t *TransitionTracker) Observe(service string, ready bool, reason string) bool {
+	t.lock.Lock()
+	prev, known := t.states[service]
+	t.states[service] = ready
+	t.lock.Unlock()
+
+	tag := metrics.Tag{Name: "service", Value: service}
+	var val float32
+	if ready {
+		val = 1
+	}
+	metrics.SetGauge(keyForServiceReady, val, tag)
+
+	if !known || prev == ready {
+		return false
+	}
+
+	metrics.IncrCounter(keyForServiceReadyTransition, 1,
+		tag,
+		metrics.Tag{Name: "ready", Value: strconv.FormatBool(ready)},
+	)
+	if ready {
+		logger.Noticef("api=TransitionTracker, service=%s, ready=true", service)
+	} else {
+		logger.Warningf("api=TransitionTracker, service=%s, ready=false, reason=%q", service, reason)
+	}
+	return true
+}
+
+// Remove stops tracking the service, for example when the service is removed
+func (t *TransitionTracker) Remove(service string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.states, service)
+}
@@ -0,0 +1,53 @@
+package ready
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TransitionTracker(t *testing.T) {
+	im := metrics.NewInmemSink(time.Minute, time.Minute*5)
+	_, err := metrics.NewGlobal(metrics.DefaultConfig("test"), im)
+	require.NoError(t, err)
+
+	tracker := NewTransitionTracker()
+	assert.False(t, tracker.Observe("svc1", false, "starting"))
+	assert.False(t, tracker.Observe("svc1", false, "starting"))
+	assert.True(t, tracker.Observe("svc1", true, ""))
+	assert.True(t, tracker.Observe("svc1", false, "db is down"))
+	assert.True(t, tracker.Observe("svc1", true, ""))
+	assert.False(t, tracker.Observe("svc2", true, ""))
+
+	tracker.Remove("svc1")
+	assert.False(t, tracker.Observe("svc1", false, "restarted"))
+
+	data := im.Data()
+	require.NotEmpty(t, data)
+
+	c, exists := data[0].Counters["test.service.ready.transition;service=svc1;ready=true"]
+	require.True(t, exists)
+	assert.Equal(t, 2, c.Count)
+	c, exists = data[0].Counters["test.service.ready.transition;service=svc1;ready=false"]
+	require.True(t, exists)
+	assert.Equal(t, 1, c.Count)
+	_, exists = data[0].Counters["test.service.ready.transition;service=svc2;ready=true"]
+	assert.False(t, exists)
+
+	found := 0
+	for k, g := range data[0].Gauges {
+		switch {
+		case strings.HasSuffix(k, ".service.ready;service=svc1"):
+			found++
+			assert.Equal(t, float32(0), g.Value)
+		case strings.HasSuffix(k, ".service.ready;service=svc2"):
+			found++
+			assert.Equal(t, float32(1), g.Value)
+		}
+	}
+	assert.Equal(t, 2, found, "gauges must be published: %v", data[0].Gauges)
+}
@@ -0,0 +1,74 @@
+package rest_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/go-phorce/dolly/xhttp"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type panicService struct{}
+
+func (s *panicService) Name() string  { return "panictest" }
+func (s *panicService) IsReady() bool { return true }
+func (s *panicService) Close()        {}
+func (s *panicService) Register(r rest.Router) {
+	r.GET("/v1/handler", func(w http.ResponseWriter, r *http.Request, _ rest.Params) {
+		panic("handler failed")
+	})
+	r.GET("/v1/authz", func(w http.ResponseWriter, r *http.Request, _ rest.Params) {
+		w.Write([]byte("ok"))
+	})
+}
+
+// panicAuthz panics on /v1/authz
+type panicAuthz struct{}
+
+func (a *panicAuthz) SetRoleMapper(func(*http.Request) string) {}
+func (a *panicAuthz) NewHandler(delegate http.Handler) (http.Handler, error) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/authz" {
+			panic("authz failed")
+		}
+		delegate.ServeHTTP(w, r)
+	}), nil
+}
+
+func Test_ServerRecovery(t *testing.T) {
+	audit := auditor.NewInMemory()
+	_, url, cleanup := resttest.Start(t, resttest.Options{
+		Auditor: audit,
+		Authz:   &panicAuthz{},
+		Services: []resttest.ServiceFactory{
+			func(rest.Server) rest.Service { return &panicService{} },
+		},
+	})
+	defer cleanup()
+
+	for _, path := range []string{"/v1/handler", "/v1/authz"} {
+		audit.Reset()
+		req, err := http.NewRequest(http.MethodGet, url+path, nil)
+		require.NoError(t, err)
+		req.Header.Set(header.XCorrelationID, "panic-1234")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode, path)
+		assert.Equal(t, "panic-1234", resp.Header.Get(header.XCorrelationID), path)
+		assert.Contains(t, string(body), "correlation ID: panic-1234", path)
+
+		evt := audit.Find(xhttp.EvtSourceRecovery, xhttp.EvtPanic)
+		require.NotNil(t, evt, path)
+		assert.Equal(t, "panic-1234", evt.ContextID)
+		assert.Contains(t, evt.Message, "path="+path)
+	}
+}
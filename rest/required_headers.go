@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/xhttp"
+)
+
+// RouteRequiredHeaders specifies the headers required in the route requests
+type RouteRequiredHeaders struct {
+	// Method specifies the HTTP method of the route,
+	// if empty, then the headers are required for all methods registered for the Path
+	Method string
+	// Path specifies the path template, as registered with the router,
+	// such as /v1/certs/:id
+	Path string
+	// Headers specifies the required headers.
+	// The requests missing a header, or with the value not matching its Format,
+	// are rejected with 400
+	Headers []xhttp.RequiredHeader
+}
+
+// RouteRequiredHeadersProvider is an optional interface for the Service,
+// that declares the required headers of its routes,
+// such as X-Idempotency-Key for POST requests.
+type RouteRequiredHeadersProvider interface {
+	// RouteRequiredHeaders returns the required headers of the service routes
+	RouteRequiredHeaders() []RouteRequiredHeaders
+}
+
+// routeRequiredHeaders returns the required headers of the registered routes,
+// the headers declared for the same route are combined
+func routeRequiredHeaders(routes []Route, services []Service) map[Route][]xhttp.RequiredHeader {
+	res := map[Route][]xhttp.RequiredHeader{}
+	for _, s := range services {
+		p, ok := s.(RouteRequiredHeadersProvider)
+		if !ok {
+			continue
+		}
+		for _, rh := range p.RouteRequiredHeaders() {
+			for _, route := range registeredRoutes("routeRequiredHeaders", routes, s, rh.Method, rh.Path) {
+				res[route] = append(res[route], rh.Headers...)
+			}
+		}
+	}
+	return res
+}
+
+// newRequiredHeadersHandler returns a http.Handler that validates the required headers
+// of the requests for the routes with the required headers,
+// and passes the request to the delegate handler
+func newRequiredHeadersHandler(headers map[Route][]xhttp.RequiredHeader, delegate http.Handler) http.Handler {
+	handlers := map[Route]http.Handler{}
+	for route, required := range headers {
+		names := make([]string, len(required))
+		for i, h := range required {
+			names[i] = h.Name
+		}
+		logger.Infof("api=newRequiredHeadersHandler, method=%s, path=%s, headers=%v",
+			route.Method, route.Path, names)
+		handlers[route] = xhttp.NewRequiredHeaders(delegate, required...)
+	}
+	return newRouteHandler(handlers, delegate)
+}
@@ -0,0 +1,75 @@
+package rest_test
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
+	"github.com/go-phorce/dolly/xhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type requiredHeadersService struct{}
+
+func (s *requiredHeadersService) Name() string  { return "requiredheaderstest" }
+func (s *requiredHeadersService) IsReady() bool { return true }
+func (s *requiredHeadersService) Close()        {}
+func (s *requiredHeadersService) Register(r rest.Router) {
+	ok := func(w http.ResponseWriter, _ *http.Request, _ rest.Params) {
+		w.Write([]byte("ok"))
+	}
+	r.GET("/v1/orders/:id", ok)
+	r.POST("/v1/orders/:id", ok)
+}
+
+func (s *requiredHeadersService) RouteRequiredHeaders() []rest.RouteRequiredHeaders {
+	return []rest.RouteRequiredHeaders{
+		{Method: http.MethodPost, Path: "/v1/orders/:id", Headers: []xhttp.RequiredHeader{
+			{Name: "X-Idempotency-Key", Format: regexp.MustCompile(`^[a-zA-Z0-9-]{8,64}$`)},
+		}},
+		{Path: "/v1/orders/:id", Headers: []xhttp.RequiredHeader{
+			{Name: "X-Tenant"},
+		}},
+		{Path: "/v1/notregistered", Headers: []xhttp.RequiredHeader{
+			{Name: "X-Tenant"},
+		}},
+	}
+}
+
+func Test_RouteRequiredHeaders(t *testing.T) {
+	_, url, cleanup := resttest.Start(t, resttest.Options{
+		Services: []resttest.ServiceFactory{
+			func(rest.Server) rest.Service { return &requiredHeadersService{} },
+		},
+	})
+	defer cleanup()
+
+	tcases := []struct {
+		method  string
+		headers map[string]string
+		status  int
+	}{
+		{http.MethodGet, map[string]string{"X-Tenant": "t1"}, http.StatusOK},
+		{http.MethodGet, nil, http.StatusBadRequest},
+		{http.MethodPost, map[string]string{"X-Tenant": "t1", "X-Idempotency-Key": "key-12345678"}, http.StatusOK},
+		{http.MethodPost, map[string]string{"X-Tenant": "t1"}, http.StatusBadRequest},
+		{http.MethodPost, map[string]string{"X-Tenant": "t1", "X-Idempotency-Key": "bad key"}, http.StatusBadRequest},
+		{http.MethodPost, map[string]string{"X-Idempotency-Key": "key-12345678"}, http.StatusBadRequest},
+	}
+
+	for _, tc := range tcases {
+		req, err := http.NewRequest(tc.method, url+"/v1/orders/1", strings.NewReader("{}"))
+		require.NoError(t, err)
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, tc.status, resp.StatusCode, "%s headers=%v", tc.method, tc.headers)
+	}
+}
@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-phorce/dolly/xhttp"
+)
+
+// RouteCache specifies the GET route, where the responses are cached for TTL
+type RouteCache struct {
+	// Path specifies the path template, as registered with the router,
+	// such as /v1/reports/:id
+	Path string
+	// TTL specifies the time to cache the response,
+	// the response's Cache-Control max-age limits the time
+	TTL time.Duration
+	// Vary specifies the request headers, that select the cached response,
+	// in addition to Accept and Accept-Encoding
+	Vary []string
+	// Shared specifies to share the cached responses between the callers,
+	// otherwise the responses are cached by the caller's identity and tenant
+	Shared bool
+	// MaxBodySize specifies the max size of the response body to cache,
+	// if not set, xhttp.DefaultResponseCacheMaxBodySize is used
+	MaxBodySize int
+}
+
+// RouteCacheProvider is an optional interface for the Service,
+// that declares the read-heavy GET routes to cache.
+// The responses are stored in the server's ResponseCacheStore, see xhttp.ResponseCache.
+type RouteCacheProvider interface {
+	// RouteCaches returns the service routes to cache
+	RouteCaches() []RouteCache
+}
+
+// routeCaches returns the registered GET routes to cache
+func routeCaches(routes []Route, services []Service) map[Route]RouteCache {
+	res := map[Route]RouteCache{}
+	for _, s := range services {
+		p, ok := s.(RouteCacheProvider)
+		if !ok {
+			continue
+		}
+		for _, rc := range p.RouteCaches() {
+			for _, route := range registeredRoutes("routeCaches", routes, s, http.MethodGet, rc.Path) {
+				res[route] = rc
+			}
+		}
+	}
+	return res
+}
+
+// newResponseCacheHandler returns a http.Handler that caches the responses
+// for the specified routes in the store, and passes other requests to the delegate handler
+func newResponseCacheHandler(routes map[Route]RouteCache, store xhttp.ResponseCacheStore, delegate http.Handler) http.Handler {
+	if len(routes) == 0 {
+		return delegate
+	}
+	if store == nil {
+		store = xhttp.NewMemoryCacheStore(xhttp.DefaultResponseCacheSize)
+	}
+
+	handlers := map[Route]http.Handler{}
+	for route, rc := range routes {
+		logger.Infof("api=newResponseCacheHandler, method=%s, path=%s, ttl=%v, shared=%t",
+			route.Method, route.Path, rc.TTL, rc.Shared)
+		cache := xhttp.NewResponseCache(delegate, store, rc.TTL).
+			WithVary(rc.Vary...).
+			WithShared(rc.Shared)
+		if rc.MaxBodySize > 0 {
+			cache.WithMaxBodySize(rc.MaxBodySize)
+		}
+		handlers[route] = cache
+	}
+	return newRouteHandler(handlers, delegate)
+}
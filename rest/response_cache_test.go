@@ -0,0 +1,80 @@
+package rest_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
+	"github.com/go-phorce/dolly/xhttp"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cacheService struct {
+	calls int32
+}
+
+func (s *cacheService) Name() string  { return "cachetest" }
+func (s *cacheService) IsReady() bool { return true }
+func (s *cacheService) Close()        {}
+func (s *cacheService) Register(r rest.Router) {
+	handle := func(w http.ResponseWriter, r *http.Request, p rest.Params) {
+		n := atomic.AddInt32(&s.calls, 1)
+		w.Write([]byte(strconv.Itoa(int(n))))
+	}
+	r.GET("/v1/items/:id", handle)
+	r.GET("/v1/other", handle)
+}
+
+func (s *cacheService) RouteCaches() []rest.RouteCache {
+	return []rest.RouteCache{
+		{Path: "/v1/items/:id", TTL: time.Minute},
+		{Path: "/v1/notregistered", TTL: time.Minute},
+	}
+}
+
+func Test_RouteCaches(t *testing.T) {
+	svc := &cacheService{}
+	store := xhttp.NewMemoryCacheStore(10)
+	_, url, cleanup := resttest.Start(t, resttest.Options{
+		Services: []resttest.ServiceFactory{
+			func(s rest.Server) rest.Service {
+				s.(*rest.HTTPServer).WithResponseCacheStore(store)
+				return svc
+			},
+		},
+	})
+	defer cleanup()
+
+	get := func(path string) (string, string) {
+		resp, err := http.Get(url + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		b, _ := ioutil.ReadAll(resp.Body)
+		return string(b), resp.Header.Get(header.XCache)
+	}
+
+	body, cache := get("/v1/items/1")
+	assert.Equal(t, "1", body)
+	assert.Equal(t, xhttp.CacheMiss, cache)
+
+	body, cache = get("/v1/items/1")
+	assert.Equal(t, "1", body)
+	assert.Equal(t, xhttp.CacheHit, cache)
+	assert.Equal(t, 1, store.Len())
+
+	body, _ = get("/v1/items/2")
+	assert.Equal(t, "2", body)
+
+	// not cached route
+	body, cache = get("/v1/other")
+	assert.Equal(t, "3", body)
+	assert.Empty(t, cache)
+}
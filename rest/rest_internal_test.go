@@ -1,8 +1,11 @@
 package rest
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"syscall"
 	"testing"
 
 	"github.com/go-phorce/dolly/xhttp/header"
@@ -19,3 +22,113 @@ func Test_notFoundHandler(t *testing.T) {
 	assert.Equal(t, header.ApplicationJSON, w.Header().Get(header.ContentType))
 	assert.Equal(t, `{"code":"not_found","message":"/blah"}`, string(w.Body.Bytes()))
 }
+
+type namedConfig struct {
+	HTTPServerConfig
+}
+
+func (c *namedConfig) GetServiceName() string {
+	return "dolly-test"
+}
+
+func Test_onServeError(t *testing.T) {
+	server := &HTTPServer{httpConfig: &namedConfig{}}
+
+	failure := errors.New("accept failed")
+	assert.Panics(t, func() { server.onServeError(failure) })
+	assert.NotPanics(t, func() { server.onServeError(http.ErrServerClosed) })
+
+	server.WithServeErrorPolicy(ServeErrorLog)
+	assert.NotPanics(t, func() { server.onServeError(failure) })
+
+	var received error
+	server.WithServeErrorHandler(func(err error) { received = err })
+	server.onServeError(http.ErrServerClosed)
+	assert.NoError(t, received)
+	server.onServeError(failure)
+	assert.Equal(t, failure, received)
+}
+
+type lifecycleServer struct {
+	Server
+	started chan struct{}
+	stop    chan struct{}
+}
+
+func (s *lifecycleServer) Name() string {
+	return "dolly-test"
+}
+
+func (s *lifecycleServer) StartHTTP() error {
+	close(s.started)
+	return nil
+}
+
+func (s *lifecycleServer) StopHTTP() {
+	<-s.stop
+}
+
+type reloadingServer struct {
+	lifecycleServer
+	reloaded chan struct{}
+	count    int
+}
+
+func (s *reloadingServer) Reload() error {
+	s.count++
+	defer func() { s.reloaded <- struct{}{} }()
+	if s.count > 1 {
+		return errors.New("reload failed")
+	}
+	return nil
+}
+
+func Test_runUntilSignal(t *testing.T) {
+	t.Run("graceful", func(t *testing.T) {
+		server := &lifecycleServer{started: make(chan struct{}), stop: make(chan struct{})}
+		close(server.stop)
+
+		sigs := make(chan os.Signal, 2)
+		go func() {
+			<-server.started
+			sigs <- syscall.SIGTERM
+		}()
+		assert.NoError(t, runUntilSignal(server, sigs))
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		server := &reloadingServer{
+			lifecycleServer: lifecycleServer{started: make(chan struct{}), stop: make(chan struct{})},
+			reloaded:        make(chan struct{}, 2),
+		}
+		close(server.stop)
+
+		sigs := make(chan os.Signal, 2)
+		go func() {
+			<-server.started
+			sigs <- syscall.SIGHUP
+			<-server.reloaded
+			// the failed reload does not stop the server
+			sigs <- syscall.SIGHUP
+			<-server.reloaded
+			sigs <- syscall.SIGTERM
+		}()
+		assert.NoError(t, runUntilSignal(server, sigs))
+		assert.Len(t, server.reloaded, 0)
+	})
+
+	t.Run("force", func(t *testing.T) {
+		server := &lifecycleServer{started: make(chan struct{}), stop: make(chan struct{})}
+		defer close(server.stop)
+
+		sigs := make(chan os.Signal, 2)
+		go func() {
+			<-server.started
+			sigs <- syscall.SIGTERM
+			sigs <- syscall.SIGINT
+		}()
+		err := runUntilSignal(server, sigs)
+		require.Error(t, err)
+		assert.Equal(t, "forced to quit on interrupt signal", err.Error())
+	})
+}
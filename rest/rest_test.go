@@ -213,17 +213,70 @@ type serverConfig struct {
 	// PackageLogger if set, specifies name of the package logger
 	PackageLogger string
 
+	// AccessLogFile if set, specifies the file to write the access logs of the requests
+	AccessLogFile string
+
 	// AllowProfiling if set, will allow for per request CPU/Memory profiling triggered by the URI QueryString
 	AllowProfiling *bool
 
 	// ProfilerDir specifies the directories where per-request profile information is written, if not set will write to a TMP dir
 	ProfilerDir string
 
+	// ProfilerMaxFiles specifies the maximum number of the profile files to keep
+	ProfilerMaxFiles int
+
+	// ProfilerMaxSize specifies the maximum total size in bytes of the profile files to keep
+	ProfilerMaxSize int64
+
+	// ProfilerMaxAge specifies the maximum age of the profile files to keep
+	ProfilerMaxAge time.Duration
+
 	// Services is a list of services to enable for this HTTP Service
 	Services []string
 
 	// HeartbeatSecs specifies heartbeat interval in seconds [30 secs is a minimum]
 	HeartbeatSecs int
+
+	// AuditHeartbeatSecs specifies the heartbeat audit interval in seconds
+	AuditHeartbeatSecs int
+
+	// ListenBacklog specifies the maximum length of the queue of pending connections
+	ListenBacklog int
+
+	// ReusePort specifies to set SO_REUSEPORT option on the listener
+	ReusePort bool
+
+	// TCPKeepAlive specifies to enable TCP keep-alive on the accepted connections
+	TCPKeepAlive bool
+
+	// TCPKeepAlivePeriod specifies the TCP keep-alive period
+	TCPKeepAlivePeriod time.Duration
+
+	// ProxyProtocol specifies to decode PROXY protocol header
+	ProxyProtocol bool
+
+	// ProxyProtocolStrict specifies to reject the connections without PROXY protocol header
+	ProxyProtocolStrict bool
+
+	// TrustedProxies specifies the list of CIDRs of the trusted proxies
+	TrustedProxies []string
+
+	// AdvertiseIP specifies the IP address of the server to advertise
+	AdvertiseIP string
+
+	// MaxHeaderBytes specifies the maximum size of the request headers
+	MaxHeaderBytes int
+
+	// MaxURILength specifies the maximum length of the request URI
+	MaxURILength int
+	// MaxHeaderCount specifies the maximum number of the request header fields
+	MaxHeaderCount int
+	// MaxBodyBytes specifies the maximum size of the request body
+	MaxBodyBytes int64
+	// MaxDecompressedBodyBytes specifies the maximum size of the decompressed request body
+	MaxDecompressedBodyBytes int64
+	// MaxConnsPerIP specifies the maximum number of concurrent connections from a client IP
+	MaxConnsPerIP int
 }
 
 // GetServiceName specifies name of the service: HTTP|HTTPS|WebAPI
@@ -251,6 +304,11 @@ func (c *serverConfig) GetPackageLogger() string {
 	return c.PackageLogger
 }
 
+// GetAccessLogFile if set, specifies the file to write the access logs of the requests
+func (c *serverConfig) GetAccessLogFile() string {
+	return c.AccessLogFile
+}
+
 // GetAllowProfiling if set, will allow for per request CPU/Memory profiling triggered by the URI QueryString
 func (c *serverConfig) GetAllowProfiling() bool {
 	return c.AllowProfiling != nil && *c.AllowProfiling
@@ -261,6 +319,21 @@ func (c *serverConfig) GetProfilerDir() string {
 	return c.ProfilerDir
 }
 
+// GetProfilerMaxFiles specifies the maximum number of the profile files to keep
+func (c *serverConfig) GetProfilerMaxFiles() int {
+	return c.ProfilerMaxFiles
+}
+
+// GetProfilerMaxSize specifies the maximum total size in bytes of the profile files to keep
+func (c *serverConfig) GetProfilerMaxSize() int64 {
+	return c.ProfilerMaxSize
+}
+
+// GetProfilerMaxAge specifies the maximum age of the profile files to keep
+func (c *serverConfig) GetProfilerMaxAge() time.Duration {
+	return c.ProfilerMaxAge
+}
+
 // GetServices is a list of services to enable for this HTTP Service
 func (c *serverConfig) GetServices() []string {
 	return c.Services
@@ -271,6 +344,81 @@ func (c *serverConfig) GetHeartbeatSecs() int {
 	return c.HeartbeatSecs
 }
 
+// GetListenBacklog specifies the maximum length of the queue of pending connections
+func (c *serverConfig) GetListenBacklog() int {
+	return c.ListenBacklog
+}
+
+// GetReusePort specifies to set SO_REUSEPORT option on the listener
+func (c *serverConfig) GetReusePort() bool {
+	return c.ReusePort
+}
+
+// GetTCPKeepAlive specifies to enable TCP keep-alive on the accepted connections
+func (c *serverConfig) GetTCPKeepAlive() bool {
+	return c.TCPKeepAlive
+}
+
+// GetTCPKeepAlivePeriod specifies the TCP keep-alive period
+func (c *serverConfig) GetTCPKeepAlivePeriod() time.Duration {
+	return c.TCPKeepAlivePeriod
+}
+
+// GetProxyProtocol specifies to decode PROXY protocol header
+func (c *serverConfig) GetProxyProtocol() bool {
+	return c.ProxyProtocol
+}
+
+// GetProxyProtocolStrict specifies to reject the connections without PROXY protocol header
+func (c *serverConfig) GetProxyProtocolStrict() bool {
+	return c.ProxyProtocolStrict
+}
+
+// GetAuditHeartbeatSecs specifies the heartbeat audit interval in seconds
+func (c *serverConfig) GetAuditHeartbeatSecs() int {
+	return c.AuditHeartbeatSecs
+}
+
+// GetTrustedProxies specifies the list of CIDRs of the trusted proxies
+func (c *serverConfig) GetTrustedProxies() []string {
+	return c.TrustedProxies
+}
+
+// GetAdvertiseIP specifies the IP address of the server to advertise
+func (c *serverConfig) GetAdvertiseIP() string {
+	return c.AdvertiseIP
+}
+
+// GetMaxHeaderBytes specifies the maximum size of the request headers
+func (c *serverConfig) GetMaxHeaderBytes() int {
+	return c.MaxHeaderBytes
+}
+
+// GetMaxURILength specifies the maximum length of the request URI
+func (c *serverConfig) GetMaxURILength() int {
+	return c.MaxURILength
+}
+
+// GetMaxHeaderCount specifies the maximum number of the request header fields
+func (c *serverConfig) GetMaxHeaderCount() int {
+	return c.MaxHeaderCount
+}
+
+// GetMaxBodyBytes specifies the maximum size of the request body
+func (c *serverConfig) GetMaxBodyBytes() int64 {
+	return c.MaxBodyBytes
+}
+
+// GetMaxDecompressedBodyBytes specifies the maximum size of the decompressed request body
+func (c *serverConfig) GetMaxDecompressedBodyBytes() int64 {
+	return c.MaxDecompressedBodyBytes
+}
+
+// GetMaxConnsPerIP specifies the maximum number of concurrent connections from a client IP
+func (c *serverConfig) GetMaxConnsPerIP() int {
+	return c.MaxConnsPerIP
+}
+
 func createServerTLSInfo(cfg *tlsConfig) (*tls.Config, *tlsconfig.KeypairReloader, error) {
 	certFile := cfg.GetCertFile()
 	keyFile := cfg.GetKeyFile()
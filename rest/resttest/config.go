@@ -0,0 +1,174 @@
+package resttest
+
+import (
+	"time"
+)
+
+// Config provides the default implementation of rest.HTTPServerConfig,
+// for the test servers
+type Config struct {
+	// ServiceName specifies name of the service
+	ServiceName string
+	// BindAddr is the address that the service should be exposed on
+	BindAddr string
+	// Services is a list of services to enable
+	Services []string
+	// HeartbeatSecs specifies heartbeat interval in seconds
+	HeartbeatSecs int
+	// AuditHeartbeatSecs specifies the heartbeat audit interval in seconds
+	AuditHeartbeatSecs int
+	// TrustedProxies specifies the list of CIDRs of the trusted proxies
+	TrustedProxies []string
+	// MaxHeaderBytes specifies the maximum size of the request headers
+	MaxHeaderBytes int
+	// MaxURILength specifies the maximum length of the request URI
+	MaxURILength int
+	// MaxHeaderCount specifies the maximum number of the request header fields
+	MaxHeaderCount int
+	// MaxBodyBytes specifies the maximum size of the request body
+	MaxBodyBytes int64
+	// MaxDecompressedBodyBytes specifies the maximum size of the decompressed request body
+	MaxDecompressedBodyBytes int64
+	// MaxConnsPerIP specifies the maximum number of concurrent connections from a client IP
+	MaxConnsPerIP int
+}
+
+// GetServiceName specifies name of the service
+func (c *Config) GetServiceName() string {
+	return c.ServiceName
+}
+
+// GetDisabled specifies if the service is disabled
+func (c *Config) GetDisabled() bool {
+	return false
+}
+
+// GetVIPName is the FQ name of the VIP to the cluster
+func (c *Config) GetVIPName() string {
+	return ""
+}
+
+// GetBindAddr is the address that the service should be exposed on
+func (c *Config) GetBindAddr() string {
+	return c.BindAddr
+}
+
+// GetPackageLogger if set, specifies name of the package logger
+func (c *Config) GetPackageLogger() string {
+	return ""
+}
+
+// GetAccessLogFile if set, specifies the file to write the access logs of the requests
+func (c *Config) GetAccessLogFile() string {
+	return ""
+}
+
+// GetAllowProfiling if set, will allow for per request CPU/Memory profiling
+func (c *Config) GetAllowProfiling() bool {
+	return false
+}
+
+// GetProfilerDir specifies the directories where per-request profile information is written
+func (c *Config) GetProfilerDir() string {
+	return ""
+}
+
+// GetProfilerMaxFiles specifies the maximum number of the profile files to keep
+func (c *Config) GetProfilerMaxFiles() int {
+	return 0
+}
+
+// GetProfilerMaxSize specifies the maximum total size in bytes of the profile files to keep
+func (c *Config) GetProfilerMaxSize() int64 {
+	return 0
+}
+
+// GetProfilerMaxAge specifies the maximum age of the profile files to keep
+func (c *Config) GetProfilerMaxAge() time.Duration {
+	return 0
+}
+
+// GetServices is a list of services to enable
+func (c *Config) GetServices() []string {
+	return c.Services
+}
+
+// GetHeartbeatSecs specifies heartbeat interval in seconds
+func (c *Config) GetHeartbeatSecs() int {
+	return c.HeartbeatSecs
+}
+
+// GetAuditHeartbeatSecs specifies the heartbeat audit interval in seconds
+func (c *Config) GetAuditHeartbeatSecs() int {
+	return c.AuditHeartbeatSecs
+}
+
+// GetListenBacklog specifies the maximum length of the queue of pending connections
+func (c *Config) GetListenBacklog() int {
+	return 0
+}
+
+// GetReusePort specifies to set SO_REUSEPORT option on the listener
+func (c *Config) GetReusePort() bool {
+	return false
+}
+
+// GetTCPKeepAlive specifies to enable TCP keep-alive on the accepted connections
+func (c *Config) GetTCPKeepAlive() bool {
+	return false
+}
+
+// GetTCPKeepAlivePeriod specifies the TCP keep-alive period
+func (c *Config) GetTCPKeepAlivePeriod() time.Duration {
+	return 0
+}
+
+// GetProxyProtocol specifies to decode PROXY protocol header
+func (c *Config) GetProxyProtocol() bool {
+	return false
+}
+
+// GetProxyProtocolStrict specifies to reject the connections without PROXY protocol header
+func (c *Config) GetProxyProtocolStrict() bool {
+	return false
+}
+
+// GetTrustedProxies specifies the list of CIDRs of the trusted proxies
+func (c *Config) GetTrustedProxies() []string {
+	return c.TrustedProxies
+}
+
+// GetAdvertiseIP specifies the IP address of the server to advertise
+func (c *Config) GetAdvertiseIP() string {
+	return ""
+}
+
+// GetMaxHeaderBytes specifies the maximum size of the request headers
+func (c *Config) GetMaxHeaderBytes() int {
+	return c.MaxHeaderBytes
+}
+
+// GetMaxURILength specifies the maximum length of the request URI
+func (c *Config) GetMaxURILength() int {
+	return c.MaxURILength
+}
+
+// GetMaxHeaderCount specifies the maximum number of the request header fields
+func (c *Config) GetMaxHeaderCount() int {
+	return c.MaxHeaderCount
+}
+
+// GetMaxBodyBytes specifies the maximum size of the request body
+func (c *Config) GetMaxBodyBytes() int64 {
+	return c.MaxBodyBytes
+}
+
+// GetMaxDecompressedBodyBytes specifies the maximum size of the decompressed request body
+func (c *Config) GetMaxDecompressedBodyBytes() int64 {
+	return c.MaxDecompressedBodyBytes
+}
+
+// GetMaxConnsPerIP specifies the maximum number of concurrent connections from a client IP
+func (c *Config) GetMaxConnsPerIP() int {
+	return c.MaxConnsPerIP
+}
@@ -0,0 +1,87 @@
+// Package resttest provides a harness for integration tests,
+// that starts rest.HTTPServer with the full middleware chain
+// on an ephemeral port.
+package resttest
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/testify/auditor"
+)
+
+// ServiceFactory creates a service for the server
+type ServiceFactory func(server rest.Server) rest.Service
+
+// Options provides the options for the test server
+type Options struct {
+	// Config specifies the server config,
+	// if not set, then the default Config is used.
+	// The server is bound to an ephemeral port, if BindAddr port is 0.
+	Config rest.HTTPServerConfig
+	// TLS specifies the server TLS config, to start HTTPS server
+	TLS *tls.Config
+	// Auditor specifies the auditor, if not set,
+	// then the in-memory auditor from testify/auditor is used
+	Auditor rest.Auditor
+	// Authz specifies the optional authorization provider
+	Authz rest.Authz
+	// Services specifies the factories of the services to register
+	Services []ServiceFactory
+	// ReadyTimeout specifies the timeout to wait for the server to be ready,
+	// if not set, 5 seconds is used
+	ReadyTimeout time.Duration
+}
+
+// Start starts the test server, and waits until it's ready.
+// It returns the server, the base URL, such as http://localhost:41234,
+// and the cleanup function to stop the server.
+func Start(t testing.TB, opts Options) (*rest.HTTPServer, string, func()) {
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = &Config{
+			ServiceName: "resttest",
+			BindAddr:    "localhost:0",
+		}
+	}
+
+	server, err := rest.New("v0.0.0-test", "127.0.0.1", cfg, opts.TLS)
+	if err != nil {
+		t.Fatalf("unable to create server: %v", err)
+	}
+
+	if opts.Auditor != nil {
+		server.WithAuditor(opts.Auditor)
+	} else {
+		server.WithAuditor(auditor.NewInMemory())
+	}
+	if opts.Authz != nil {
+		server.WithAuthz(opts.Authz)
+	}
+	for _, f := range opts.Services {
+		if err = server.AddService(f(server)); err != nil {
+			t.Fatalf("unable to add service: %v", err)
+		}
+	}
+
+	if err = server.StartHTTP(); err != nil {
+		t.Fatalf("unable to start server: %v", err)
+	}
+
+	timeout := opts.ReadyTimeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	for deadline := time.Now().Add(timeout); !server.IsReady(); {
+		if time.Now().After(deadline) {
+			server.StopHTTP()
+			t.Fatalf("server is not ready in %v: %s", timeout, server.ReadyStatus().Reason)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	url := server.Protocol() + "://localhost:" + server.Port()
+	return server, url, server.StopHTTP
+}
@@ -0,0 +1,51 @@
+package resttest_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pingService struct{}
+
+func (s *pingService) Name() string  { return "ping" }
+func (s *pingService) IsReady() bool { return true }
+func (s *pingService) Close()        {}
+func (s *pingService) Register(r rest.Router) {
+	r.GET("/v1/ping", func(w http.ResponseWriter, _ *http.Request, _ rest.Params) {
+		w.Write([]byte("pong"))
+	})
+}
+
+func Test_Start(t *testing.T) {
+	audit := auditor.NewInMemory()
+	server, url, cleanup := resttest.Start(t, resttest.Options{
+		Auditor: audit,
+		Services: []resttest.ServiceFactory{
+			func(rest.Server) rest.Service { return &pingService{} },
+		},
+	})
+	assert.NotEqual(t, "0", server.Port())
+
+	resp, err := http.Get(url + "/v1/ping")
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "pong", string(body))
+	assert.Equal(t, "resttest", resp.Header.Get("Server"))
+
+	cleanup()
+	assert.NotNil(t, audit.Find(rest.EvtSourceStatus, rest.EvtServiceStarted))
+	assert.NotNil(t, audit.Find(rest.EvtSourceStatus, rest.EvtServiceStopped))
+
+	_, err = http.Get(url + "/v1/ping")
+	assert.Error(t, err)
+}
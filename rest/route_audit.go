@@ -0,0 +1,167 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-phorce/dolly/xhttp"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/identity"
+)
+
+const (
+	// EvtSourceAPI specifies source for the audited API requests
+	EvtSourceAPI = "api"
+	// EvtRequest specifies the event of the completed audited request
+	EvtRequest = "request"
+)
+
+// maxAuditBodySize specifies the max size of the request body to summarize,
+// the larger bodies are reported by the size only
+const maxAuditBodySize = 4096
+
+// maxAuditBodySummary specifies the max length of the body summary in the audit event
+const maxAuditBodySummary = 512
+
+// redactedValue replaces the values of the redacted fields in the body summary
+const redactedValue = "[REDACTED]"
+
+// RouteAudit specifies the audit of the route requests
+type RouteAudit struct {
+	// Method specifies the HTTP method of the route,
+	// if empty, then the audit applies to all methods registered for the Path
+	Method string
+	// Path specifies the path template, as registered with the router,
+	// such as /v1/admin/users/:id
+	Path string
+	// Body specifies to include the summary of the JSON request body
+	Body bool
+	// Redact specifies the top level fields of the JSON request body,
+	// which values are not included in the summary, such as password
+	Redact []string
+}
+
+// RouteAuditProvider is an optional interface for the Service,
+// that declares the routes to audit.
+// The event is recorded by the server's Auditor on completion of the request,
+// with the method, path, status, identity and the correlation ID.
+type RouteAuditProvider interface {
+	// RouteAudits returns the audit configuration of the service routes
+	RouteAudits() []RouteAudit
+}
+
+// routeAudits returns the audit configuration of the registered routes
+func routeAudits(routes []Route, services []Service) map[Route]RouteAudit {
+	res := map[Route]RouteAudit{}
+	for _, s := range services {
+		p, ok := s.(RouteAuditProvider)
+		if !ok {
+			continue
+		}
+		for _, ra := range p.RouteAudits() {
+			for _, route := range registeredRoutes("routeAudits", routes, s, ra.Method, ra.Path) {
+				res[route] = ra
+			}
+		}
+	}
+	return res
+}
+
+// newRouteAuditHandler returns a http.Handler that audits the requests
+// for the routes with the audit configuration,
+// and passes the request to the delegate handler
+func (server *HTTPServer) newRouteAuditHandler(audits map[Route]RouteAudit, delegate http.Handler) http.Handler {
+	handlers := map[Route]http.Handler{}
+	for route, ra := range audits {
+		ra := ra
+		// the audit reports the full path template of the route
+		ra.Path = route.Path
+		logger.Infof("api=newRouteAuditHandler, method=%s, path=%s, body=%t, redact=%v",
+			route.Method, route.Path, ra.Body, ra.Redact)
+		handlers[route] = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			server.auditRoute(w, r, &ra, delegate)
+		})
+	}
+	return newRouteHandler(handlers, delegate)
+}
+
+// auditRoute serves the request, and records the audit event on completion
+func (server *HTTPServer) auditRoute(w http.ResponseWriter, r *http.Request, ra *RouteAudit, delegate http.Handler) {
+	var body string
+	if ra.Body {
+		body = auditBodySummary(r, ra.Redact)
+	}
+
+	rc := xhttp.NewResponseCapture(w)
+	delegate.ServeHTTP(rc, r)
+
+	ctx := identity.ForRequest(r)
+	var id string
+	if ctx.Identity() != nil {
+		id = ctx.Identity().String()
+	}
+	msg := fmt.Sprintf("method=%s, path=%s, route=%s, status=%d", r.Method, r.URL.Path, ra.Path, rc.StatusCode())
+	if tenant := ctx.Tenant(); tenant != "" {
+		msg += ", tenant=" + tenant
+	}
+	if body != "" {
+		msg += ", body=" + body
+	}
+	server.Audit(
+		EvtSourceAPI,
+		EvtRequest,
+		id,
+		ctx.CorrelationID(),
+		0,
+		msg,
+	)
+}
+
+// auditBodySummary returns the summary of the request body,
+// with the values of the redact fields replaced.
+// The body is restored for the handler.
+func auditBodySummary(r *http.Request, redact []string) string {
+	if r.Body == nil || r.Body == http.NoBody {
+		return ""
+	}
+
+	buf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxAuditBodySize+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+	if err != nil {
+		return "unreadable"
+	}
+	if len(buf) > maxAuditBodySize {
+		return fmt.Sprintf("size>%d", maxAuditBodySize)
+	}
+	if len(buf) == 0 {
+		return ""
+	}
+
+	var fields map[string]interface{}
+	if err = json.Unmarshal(buf, &fields); err != nil {
+		return fmt.Sprintf("size=%d, type=%q", len(buf), r.Header.Get(header.ContentType))
+	}
+	for _, name := range redact {
+		if _, ok := fields[name]; ok {
+			fields[name] = redactedValue
+		}
+	}
+	js, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Sprintf("size=%d", len(buf))
+	}
+
+	summary := strings.TrimSpace(string(js))
+	if len(summary) > maxAuditBodySummary {
+		summary = summary[:maxAuditBodySummary] + "..."
+	}
+	return summary
+}
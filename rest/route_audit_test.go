@@ -0,0 +1,75 @@
+package rest_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type auditService struct{}
+
+func (s *auditService) Name() string  { return "audittest" }
+func (s *auditService) IsReady() bool { return true }
+func (s *auditService) Close()        {}
+func (s *auditService) Register(r rest.Router) {
+	echo := func(w http.ResponseWriter, r *http.Request, _ rest.Params) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}
+	r.GET("/v1/users/:id", echo)
+	r.PUT("/v1/users/:id", echo)
+	r.POST("/v1/other", echo)
+}
+
+func (s *auditService) RouteAudits() []rest.RouteAudit {
+	return []rest.RouteAudit{
+		{Method: http.MethodPut, Path: "/v1/users/:id", Body: true, Redact: []string{"password"}},
+		{Path: "/v1/notregistered"},
+	}
+}
+
+func Test_RouteAudits(t *testing.T) {
+	audit := auditor.NewInMemory()
+	_, url, cleanup := resttest.Start(t, resttest.Options{
+		Auditor: audit,
+		Services: []resttest.ServiceFactory{
+			func(rest.Server) rest.Service { return &auditService{} },
+		},
+	})
+	defer cleanup()
+
+	do := func(method, path, body string) string {
+		req, err := http.NewRequest(method, url+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(header.XCorrelationID, "audit-1234")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		b, _ := ioutil.ReadAll(resp.Body)
+		return string(b)
+	}
+
+	do(http.MethodGet, "/v1/users/1", "")
+	do(http.MethodPost, "/v1/other", `{}`)
+	assert.Nil(t, audit.Find(rest.EvtSourceAPI, rest.EvtRequest))
+
+	body := `{"name":"bob","password":"secret"}`
+	assert.Equal(t, body, do(http.MethodPut, "/v1/users/1", body), "the body must be restored for the handler")
+
+	evt := audit.Find(rest.EvtSourceAPI, rest.EvtRequest)
+	require.NotNil(t, evt)
+	assert.Equal(t, "audit-1234", evt.ContextID)
+	assert.Contains(t, evt.Message, "method=PUT, path=/v1/users/1, route=/v1/users/:id, status=200")
+	assert.Contains(t, evt.Message, `"name":"bob"`)
+	assert.Contains(t, evt.Message, `"password":"[REDACTED]"`)
+	assert.NotContains(t, evt.Message, "secret")
+}
@@ -2,7 +2,9 @@ package rest
 
 import (
 	"net/http"
+	"sort"
 
+	"github.com/go-phorce/dolly/xhttp"
 	"github.com/julienschmidt/httprouter"
 	"github.com/rs/cors"
 )
@@ -69,9 +71,21 @@ func (ps Params) ByName(name string) string {
 // wildcards (variables).
 type Handle func(http.ResponseWriter, *http.Request, Params)
 
+// Route provides the registered route
+type Route struct {
+	Method string `json:"method"`
+	// Path specifies the path template, such as /v1/admin/tasks/:name
+	Path string `json:"path"`
+}
+
 // Router provides a router interface
 type Router interface {
 	Handler() http.Handler
+	// Routes returns the registered routes, sorted by path and method
+	Routes() []Route
+	// Handle registers the handle for the method and path,
+	// the method is not limited to the standard methods, for example PROPFIND
+	Handle(method, path string, handle Handle)
 	GET(path string, handle Handle)
 	HEAD(path string, handle Handle)
 	OPTIONS(path string, handle Handle)
@@ -85,6 +99,7 @@ type Router interface {
 type proxy struct {
 	router *httprouter.Router
 	cors   *cors.Cors
+	routes []Route
 }
 
 // NewRouter returns a new initialized Router.
@@ -124,12 +139,56 @@ func NewRouterWithCORS(notfoundhandler http.HandlerFunc, opt *CORSOptions) Route
 	return r
 }
 
+// setMethodNotAllowed configures the handler for the requests,
+// which path is registered with a different method
+func (p *proxy) setMethodNotAllowed(h http.Handler) {
+	p.router.MethodNotAllowed = h
+}
+
 func proxyHandle(handle Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 		handle(w, r, Params(p))
 	}
 }
 
+// WithBasicAuth returns a route handler that requires HTTP basic authentication,
+// so it can be applied only to specific routes, for example /v1/admin
+func WithBasicAuth(auth *xhttp.BasicAuth, handle Handle) Handle {
+	return func(w http.ResponseWriter, r *http.Request, p Params) {
+		if r, ok := auth.Authenticate(w, r); ok {
+			handle(w, r, p)
+		}
+	}
+}
+
+// WithIPFilter returns a route handler that allows the requests only from the permitted IPs,
+// so it can be applied only to specific routes, for example /v1/admin
+func WithIPFilter(filter *xhttp.IPFilter, handle Handle) Handle {
+	return func(w http.ResponseWriter, r *http.Request, p Params) {
+		if filter.Check(w, r) {
+			handle(w, r, p)
+		}
+	}
+}
+
+func (p *proxy) handle(method, path string, handle Handle) {
+	p.router.Handle(method, path, proxyHandle(handle))
+	p.routes = append(p.routes, Route{Method: method, Path: path})
+}
+
+// Routes returns the registered routes, sorted by path and method
+func (p *proxy) Routes() []Route {
+	routes := make([]Route, len(p.routes))
+	copy(routes, p.routes)
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
 func (p *proxy) Handler() http.Handler {
 	if p.cors != nil {
 		return p.cors.Handler(p.router)
@@ -137,42 +196,47 @@ func (p *proxy) Handler() http.Handler {
 	return p.router
 }
 
+// Handle registers the handle for the method and path
+func (p *proxy) Handle(method, path string, handle Handle) {
+	p.handle(method, path, handle)
+}
+
 // GET is a shortcut for router.Handle("GET", path, handle)
 func (p *proxy) GET(path string, handle Handle) {
-	p.router.Handle("GET", path, proxyHandle(handle))
+	p.handle("GET", path, handle)
 }
 
 // HEAD is a shortcut for router.Handle("HEAD", path, handle)
 func (p *proxy) HEAD(path string, handle Handle) {
-	p.router.Handle("HEAD", path, proxyHandle(handle))
+	p.handle("HEAD", path, handle)
 }
 
 // OPTIONS is a shortcut for router.Handle("OPTIONS", path, handle)
 func (p *proxy) OPTIONS(path string, handle Handle) {
-	p.router.Handle("OPTIONS", path, proxyHandle(handle))
+	p.handle("OPTIONS", path, handle)
 }
 
 // POST is a shortcut for router.Handle("POST", path, handle)
 func (p *proxy) POST(path string, handle Handle) {
-	p.router.Handle("POST", path, proxyHandle(handle))
+	p.handle("POST", path, handle)
 }
 
 // PUT is a shortcut for router.Handle("PUT", path, handle)
 func (p *proxy) PUT(path string, handle Handle) {
-	p.router.Handle("PUT", path, proxyHandle(handle))
+	p.handle("PUT", path, handle)
 }
 
 // PATCH is a shortcut for router.Handle("PATCH", path, handle)
 func (p *proxy) PATCH(path string, handle Handle) {
-	p.router.Handle("PATCH", path, proxyHandle(handle))
+	p.handle("PATCH", path, handle)
 }
 
 // DELETE is a shortcut for router.Handle("DELETE", path, handle)
 func (p *proxy) DELETE(path string, handle Handle) {
-	p.router.Handle("DELETE", path, proxyHandle(handle))
+	p.handle("DELETE", path, handle)
 }
 
 // CONNECT is a shortcut for router.Handle("CONNECT", path, handle)
 func (p *proxy) CONNECT(path string, handle Handle) {
-	p.router.Handle("CONNECT", path, proxyHandle(handle))
+	p.handle("CONNECT", path, handle)
 }
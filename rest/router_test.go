@@ -1,11 +1,14 @@
 package rest_test
 
 import (
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/xhttp"
+	"github.com/go-phorce/dolly/xhttp/header"
 	"github.com/go-phorce/dolly/xhttp/httperror"
 	"github.com/go-phorce/dolly/xhttp/marshal"
 	"github.com/stretchr/testify/assert"
@@ -45,6 +48,18 @@ func Test_Router(t *testing.T) {
 	router.DELETE("/del", h.handle)
 	router.CONNECT("/", h.handle)
 
+	assert.Equal(t, []rest.Route{
+		{Method: http.MethodConnect, Path: "/"},
+		{Method: http.MethodDelete, Path: "/del"},
+		{Method: http.MethodGet, Path: "/get"},
+		{Method: http.MethodGet, Path: "/get/:GET"},
+		{Method: http.MethodHead, Path: "/head"},
+		{Method: http.MethodOptions, Path: "/options"},
+		{Method: http.MethodPatch, Path: "/patch"},
+		{Method: http.MethodPost, Path: "/post"},
+		{Method: http.MethodPut, Path: "/put"},
+	}, router.Routes())
+
 	assert.Equal(t, 0, h.methods[http.MethodGet])
 	assert.Equal(t, 0, h.methods[http.MethodHead])
 	assert.Equal(t, 0, h.methods[http.MethodOptions])
@@ -98,3 +113,97 @@ func Test_Router(t *testing.T) {
 	assert.Equal(t, 0, h.parameters["DELETE"])
 	assert.Equal(t, 0, h.parameters["OTHER"])
 }
+
+func Test_RouterCustomMethod(t *testing.T) {
+	router := rest.NewRouter(notFoundHandler)
+	h := &handler{
+		methods:    map[string]int{},
+		parameters: map[string]int{},
+	}
+	router.Handle("PROPFIND", "/dav/:PROPFIND", h.handle)
+	router.GET("/dav/:GET", h.handle)
+	assert.Equal(t, []rest.Route{
+		{Method: http.MethodGet, Path: "/dav/:GET"},
+		{Method: "PROPFIND", Path: "/dav/:PROPFIND"},
+	}, router.Routes())
+
+	rh := router.Handler()
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("PROPFIND", "/dav/files", nil)
+	require.NoError(t, err)
+	rh.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, h.methods["PROPFIND"])
+	assert.Equal(t, 1, h.parameters["files"])
+
+	w = httptest.NewRecorder()
+	r, err = http.NewRequest(http.MethodPut, "/dav/files", nil)
+	require.NoError(t, err)
+	rh.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Contains(t, w.Header().Get(header.Allow), "PROPFIND")
+	assert.Contains(t, w.Header().Get(header.Allow), http.MethodGet)
+}
+
+func Test_RouterWithBasicAuth(t *testing.T) {
+	router := rest.NewRouter(notFoundHandler)
+	h := &handler{
+		methods:    map[string]int{},
+		parameters: map[string]int{},
+	}
+	auth := xhttp.NewBasicAuth(nil, xhttp.NewBasicAuthVerifier(map[string]xhttp.BasicAuthUser{
+		"admin": {Password: "secret", Role: "admin"},
+	}))
+	router.GET("/public", h.handle)
+	router.GET("/admin/:GET", rest.WithBasicAuth(auth, h.handle))
+	rh := router.Handler()
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest(http.MethodGet, "/public", nil)
+	require.NoError(t, err)
+	rh.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, h.methods[http.MethodGet])
+
+	w = httptest.NewRecorder()
+	r, err = http.NewRequest(http.MethodGet, "/admin/GET", nil)
+	require.NoError(t, err)
+	rh.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, 1, h.methods[http.MethodGet])
+
+	w = httptest.NewRecorder()
+	r.SetBasicAuth("admin", "secret")
+	rh.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, h.methods[http.MethodGet])
+	assert.Equal(t, 1, h.parameters["GET"])
+}
+
+func Test_RouterWithIPFilter(t *testing.T) {
+	router := rest.NewRouter(notFoundHandler)
+	h := &handler{
+		methods:    map[string]int{},
+		parameters: map[string]int{},
+	}
+	_, internal, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	filter := xhttp.NewIPFilter(nil, []net.IPNet{*internal}, nil)
+	router.GET("/admin/:GET", rest.WithIPFilter(filter, h.handle))
+	rh := router.Handler()
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest(http.MethodGet, "/admin/GET", nil)
+	require.NoError(t, err)
+	r.RemoteAddr = "192.168.1.1:1234"
+	rh.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, 0, h.methods[http.MethodGet])
+
+	w = httptest.NewRecorder()
+	r.RemoteAddr = "10.1.1.1:1234"
+	rh.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, h.methods[http.MethodGet])
+}
@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/xhttp"
+)
+
+// RouteSchema specifies JSON Schema of the route request and response bodies
+type RouteSchema struct {
+	// Method specifies the HTTP method of the route,
+	// if empty, then the schemas apply to all methods registered for the Path
+	Method string
+	// Path specifies the path template, as registered with the router,
+	// such as /v1/certs/:id
+	Path string
+	// Request specifies the schema of the request body.
+	// The requests not matching the schema are rejected with 400
+	Request xhttp.Schema
+	// Response specifies the schema of the successful response body,
+	// validated only when the server is configured WithResponseSchemaValidation.
+	// The mismatches are logged, the responses are not changed
+	Response xhttp.Schema
+}
+
+// RouteSchemaProvider is an optional interface for the Service,
+// that declares JSON Schema of its routes.
+// The requests without body, and GET, HEAD, OPTIONS requests are not validated.
+type RouteSchemaProvider interface {
+	// RouteSchemas returns the schemas of the service routes
+	RouteSchemas() []RouteSchema
+}
+
+// routeSchemas returns the schemas of the registered routes
+func routeSchemas(routes []Route, services []Service) map[Route]RouteSchema {
+	res := map[Route]RouteSchema{}
+	for _, s := range services {
+		p, ok := s.(RouteSchemaProvider)
+		if !ok {
+			continue
+		}
+		for _, rs := range p.RouteSchemas() {
+			for _, route := range registeredRoutes("routeSchemas", routes, s, rs.Method, rs.Path) {
+				res[route] = rs
+			}
+		}
+	}
+	return res
+}
+
+// newSchemaHandler returns a http.Handler that validates the requests
+// for the routes with the schemas, and passes the request to the delegate handler.
+// If validateResponse is true, then the responses are validated as well.
+func newSchemaHandler(schemas map[Route]RouteSchema, validateResponse bool, delegate http.Handler) http.Handler {
+	handlers := map[Route]http.Handler{}
+	for route, rs := range schemas {
+		if rs.Request == nil && (rs.Response == nil || !validateResponse) {
+			continue
+		}
+		logger.Infof("api=newSchemaHandler, method=%s, path=%s, request=%t, response=%t",
+			route.Method, route.Path, rs.Request != nil, rs.Response != nil && validateResponse)
+
+		validator := xhttp.NewSchemaValidator(delegate, rs.Request)
+		if validateResponse {
+			validator.WithResponseSchema(rs.Response)
+		}
+		handlers[route] = validator
+	}
+	return newRouteHandler(handlers, delegate)
+}
@@ -0,0 +1,76 @@
+package rest_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requiredName is a schema that requires the name field
+type requiredName struct{}
+
+func (requiredName) Validate(v interface{}) error {
+	if obj, ok := v.(map[string]interface{}); ok && obj["name"] != nil {
+		return nil
+	}
+	return fmt.Errorf("name is required")
+}
+
+type schemaService struct{}
+
+func (s *schemaService) Name() string  { return "schematest" }
+func (s *schemaService) IsReady() bool { return true }
+func (s *schemaService) Close()        {}
+func (s *schemaService) Register(r rest.Router) {
+	ok := func(w http.ResponseWriter, _ *http.Request, _ rest.Params) {
+		w.Write([]byte("ok"))
+	}
+	r.GET("/v1/items/:id", ok)
+	r.PUT("/v1/items/:id", ok)
+	r.POST("/v1/upload", ok)
+}
+
+func (s *schemaService) RouteSchemas() []rest.RouteSchema {
+	return []rest.RouteSchema{
+		{Method: http.MethodPut, Path: "/v1/items/:id", Request: requiredName{}},
+		{Method: http.MethodPost, Path: "/v1/notregistered", Request: requiredName{}},
+	}
+}
+
+func Test_RouteSchemas(t *testing.T) {
+	_, url, cleanup := resttest.Start(t, resttest.Options{
+		Services: []resttest.ServiceFactory{
+			func(rest.Server) rest.Service { return &schemaService{} },
+		},
+	})
+	defer cleanup()
+
+	tcases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodPut, "/v1/items/1", `{"name":"test"}`, http.StatusOK},
+		{http.MethodPut, "/v1/items/1", `{"id":1}`, http.StatusBadRequest},
+		{http.MethodGet, "/v1/items/1", `{"id":1}`, http.StatusOK},
+		// not declared
+		{http.MethodPost, "/v1/upload", `{"id":1}`, http.StatusOK},
+	}
+
+	for _, tc := range tcases {
+		req, err := http.NewRequest(tc.method, url+tc.path, strings.NewReader(tc.body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, tc.status, resp.StatusCode, "%s %s %s", tc.method, tc.path, tc.body)
+	}
+}
@@ -7,13 +7,20 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-phorce/dolly/audit"
+	"github.com/go-phorce/dolly/clock"
 	metricsutil "github.com/go-phorce/dolly/metrics/util"
 	"github.com/go-phorce/dolly/netutil"
 	"github.com/go-phorce/dolly/rest/ready"
+	"github.com/go-phorce/dolly/rest/tlsconfig"
 	"github.com/go-phorce/dolly/tasks"
 	"github.com/go-phorce/dolly/xhttp"
 	"github.com/go-phorce/dolly/xhttp/header"
@@ -29,6 +36,15 @@ var logger = xlog.NewPackageLogger("github.com/go-phorce/dolly", "rest")
 // MaxRequestSize specifies max size of regular HTTP Post requests in bytes, 64 Mb
 const MaxRequestSize = 64 * 1024 * 1024
 
+// DefaultMaxHeaderBytes specifies the default max size of HTTP request headers in bytes, 1 Mb
+const DefaultMaxHeaderBytes = 1 << 20
+
+// DefaultMaxURILength specifies the default max length of HTTP request URI, 8 Kb
+const DefaultMaxURILength = 8 << 10
+
+// DefaultMaxHeaderCount specifies the default max number of HTTP request header fields
+const DefaultMaxHeaderCount = 100
+
 const (
 	// EvtSourceStatus specifies source for service Status
 	EvtSourceStatus = "status"
@@ -36,6 +52,20 @@ const (
 	EvtServiceStarted = "service started"
 	// EvtServiceStopped specifies Service Stopped event
 	EvtServiceStopped = "service stopped"
+	// EvtHeartbeat specifies Service Heartbeat event
+	EvtHeartbeat = "heartbeat"
+	// EvtCertExpiring specifies the event of the certificate near expiry
+	EvtCertExpiring = "cert expiring"
+	// EvtCertStale specifies the event of the key pair not rotated within the expected window
+	EvtCertStale = "cert stale"
+	// EvtCertReloadFailed specifies the event of the consecutive failures to reload the certificate
+	EvtCertReloadFailed = "cert reload failed"
+	// EvtServiceReloaded specifies Service Reloaded event
+	EvtServiceReloaded = "service reloaded"
+	// EvtServiceDraining specifies Service Draining event
+	EvtServiceDraining = "service draining"
+	// EvtWorkerFailed specifies the event of the background worker failed with an error or panic
+	EvtWorkerFailed = "worker failed"
 )
 
 // ServerEvent specifies server event type
@@ -48,11 +78,30 @@ const (
 	ServerStoppedEvent
 	// ServerStoppingEvent is fired before server stopped
 	ServerStoppingEvent
+	// ServerReloadingEvent is fired on Reload, before the handler is rebuilt
+	ServerReloadingEvent
 )
 
 // ServerEventFunc is a callback to handle server events
 type ServerEventFunc func(evt ServerEvent)
 
+// ShutdownProgressFunc is a callback to report the shutdown progress,
+// with the number of the in-flight requests that are still draining
+type ShutdownProgressFunc func(inFlight int)
+
+// ServeErrorPolicy specifies the behavior on a fatal error of the Serve loop
+type ServeErrorPolicy int
+
+const (
+	// ServeErrorPanic panics on the error, this is the default
+	ServeErrorPanic ServeErrorPolicy = iota
+	// ServeErrorLog logs the error only
+	ServeErrorLog
+	// ServeErrorCallback logs the error and passes it to the handler,
+	// specified by WithServeErrorHandler
+	ServeErrorCallback
+)
+
 // Server is an interface to provide server status
 type Server interface {
 	http.Handler
@@ -60,16 +109,24 @@ type Server interface {
 	Version() string
 	HostName() string
 	LocalIP() string
+	// AuditIdentity returns the identity of the server lifecycle audit events
+	AuditIdentity() string
 	Port() string
 	Protocol() string
 	StartedAt() time.Time
 	Uptime() time.Duration
 	Service(name string) Service
+	// Services returns the registered services, in the order of the priority
+	Services() []Service
+	// Routes returns the routes registered by the services
+	Routes() []Route
 	HTTPConfig() HTTPServerConfig
 	TLSConfig() *tls.Config
 
 	// IsReady indicates that all subservices are ready to serve
 	IsReady() bool
+	// HasStarted indicates that all subservices were ready at least once
+	HasStarted() bool
 
 	// Audit records an auditable event.
 	// 	source indicates the area that the event was triggered by
@@ -85,18 +142,25 @@ type Server interface {
 		raftIndex uint64,
 		message string)
 
-	AddService(s Service)
+	AddService(s Service, priority ...int) error
+	AddOrReplaceService(s Service, priority ...int)
+	RemoveService(name string)
 	StartHTTP() error
 	StopHTTP()
 
 	Scheduler() tasks.Scheduler
 
+	// Context returns the server's lifecycle context,
+	// which is canceled when the server is stopping
+	Context() context.Context
+
 	OnEvent(evt ServerEvent, handler ServerEventFunc)
 }
 
 // MuxFactory creates http handlers.
 type MuxFactory interface {
-	NewMux() http.Handler
+	// NewMux returns the handler, or an error if the configuration is not valid
+	NewMux() (http.Handler, error)
 }
 
 // HTTPServer is responsible for exposing the collection of the services
@@ -104,9 +168,14 @@ type MuxFactory interface {
 type HTTPServer struct {
 	Server
 	auditor         Auditor
+	auditHasher     *audit.IdentityHasher
+	auditIdentity   string
 	authz           Authz
 	httpConfig      HTTPServerConfig
+	trustedProxies  identity.TrustedProxies
 	tlsConfig       *tls.Config
+	tlsReloader     *tlsconfig.KeypairReloader
+	tlsConfigHook   func(*tls.Config)
 	httpServer      *http.Server
 	cors            *CORSOptions
 	muxFactory      MuxFactory
@@ -114,17 +183,81 @@ type HTTPServer struct {
 	port            string
 	ipaddr          string
 	version         string
-	serving         bool
+	serving         int32
+	draining        int32
+	inFlight        int32
 	startedAt       time.Time
+	clock           clock.Clock
 	clientAuth      string
 	scheduler       tasks.Scheduler
 	services        map[string]Service
+	priorities      map[string]int
 	evtHandlers     map[ServerEvent][]ServerEventFunc
 	lock            sync.RWMutex
 	shutdownTimeout time.Duration
+	shutdownTick    time.Duration
+	shutdownNotify  ShutdownProgressFunc
+	requestTimeout  time.Duration
+	routeTimeouts   map[string]time.Duration
+	clientTimeout   time.Duration
+	maxInFlight     int
+	rejectAuditor   xhttp.Auditor
+	handshakes      *handshakeMetrics
+	clientAuthAudit xhttp.Auditor
+	validateResp    bool
+	slashPolicy     xhttp.TrailingSlashPolicy
+	slashExclude    []string
+	hostPolicy      xhttp.MissingHostPolicy
+	cacheStore      xhttp.ResponseCacheStore
+	defaultHost     string
+	notFound        http.Handler
+	notAllowed      http.Handler
+	exemptStreaming bool
+	serverHeader    string
+	securityHeaders *xhttp.SecurityHeadersConfig
+	headerLogger    *xhttp.HeaderLogger
+	accessLogger    xlog.Logger
+	accessLogFile   *os.File
+	logConnID       bool
+	emptyFields     marshal.EmptyFields
+	capture         bool
+	captureRate     int
+	captureDir      string
+	clusterRole     func() string
+	readyCache      *ready.Cache
+	dependencies    []ready.Dependency
+	depChecker      *ready.DependencyChecker
+	readyTracker    *ready.TransitionTracker
+	notReady        *ready.NotReadyResponse
+	drainReject     bool
+	drainAvailable  []string
+	started         int32
+	serveErrPolicy  ServeErrorPolicy
+	serveErrHandler func(error)
+	ctx             context.Context
+	cancel          context.CancelFunc
+	workers         []*worker
+	workersStarted  bool
+	workersWG       sync.WaitGroup
+
+	// handler holds the live muxHandler, rebuilt when services are changed
+	handler      atomic.Value
+	routes       atomic.Value
+	rebuildDelay time.Duration
+	rebuildTimer *time.Timer
+}
+
+// muxHandler wraps http.Handler to be stored in atomic.Value,
+// which requires the values of the same concrete type
+type muxHandler struct {
+	http.Handler
 }
 
-// New creates a new instance of the server
+// New creates a new instance of the server.
+// If ipaddr is empty, then AdvertiseIP from the config is used,
+// otherwise the IP address is detected.
+// If the config implements Validator, such as ServerConfig,
+// then it's validated before the server is created.
 func New(
 	version string,
 	ipaddr string,
@@ -133,27 +266,56 @@ func New(
 ) (*HTTPServer, error) {
 	var err error
 
+	if v, ok := httpConfig.(Validator); ok {
+		if err = v.Validate(); err != nil {
+			return nil, errors.Annotate(err, "invalid configuration")
+		}
+	}
+
+	source := "parameter"
+	if ipaddr == "" {
+		ipaddr = httpConfig.GetAdvertiseIP()
+		source = "config"
+	}
 	if ipaddr == "" {
 		ipaddr, err = netutil.GetLocalIP()
 		if err != nil {
-			ipaddr = "127.0.0.1"
-			logger.Errorf("api=rest.New, reason=unable_determine_ipaddr, use=%q, err=[%v]", ipaddr, errors.ErrorStack(err))
+			return nil, errors.Annotate(err, "unable to determine IP address, specify AdvertiseIP in the config")
 		}
+		source = "detected"
+	}
+	logger.Infof("api=rest.New, ipaddr=%q, source=%s", ipaddr, source)
+
+	trustedProxies, err := identity.ParseTrustedProxies(httpConfig.GetTrustedProxies())
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
 
 	s := &HTTPServer{
 		services:        map[string]Service{},
-		startedAt:       time.Now().UTC(),
+		priorities:      map[string]int{},
+		clock:           clock.New(),
 		version:         version,
 		ipaddr:          ipaddr,
+		trustedProxies:  trustedProxies,
 		evtHandlers:     make(map[ServerEvent][]ServerEventFunc),
+		routeTimeouts:   make(map[string]time.Duration),
 		clientAuth:      tlsClientAuthToStrMap[tls.NoClientCert],
 		httpConfig:      httpConfig,
 		hostname:        GetHostName(httpConfig.GetBindAddr()),
 		port:            GetPort(httpConfig.GetBindAddr()),
 		tlsConfig:       tlsConfig,
 		shutdownTimeout: time.Duration(5) * time.Second,
+		shutdownTick:    time.Second,
+		rebuildDelay:    100 * time.Millisecond,
+		notFound:        http.HandlerFunc(notFoundHandler),
+		notAllowed:      http.HandlerFunc(methodNotAllowedHandler),
+		serverHeader:    httpConfig.GetServiceName(),
+		depChecker:      ready.NewDependencyChecker(),
+		readyTracker:    ready.NewTransitionTracker(),
 	}
+	s.startedAt = s.clock.Now().UTC()
+	s.ctx, s.cancel = context.WithCancel(context.Background())
 	s.muxFactory = s
 	if tlsConfig != nil {
 		s.clientAuth = tlsClientAuthToStrMap[tlsConfig.ClientAuth]
@@ -168,6 +330,13 @@ func (server *HTTPServer) WithAuditor(auditor Auditor) *HTTPServer {
 	return server
 }
 
+// WithAuditIdentityHasher enables to pseudonymize the identity of the audit events,
+// for the sources configured in the hasher
+func (server *HTTPServer) WithAuditIdentityHasher(hasher *audit.IdentityHasher) *HTTPServer {
+	server.auditHasher = hasher
+	return server
+}
+
 // WithAuthz enables to use Authz
 func (server *HTTPServer) WithAuthz(authz Authz) *HTTPServer {
 	server.authz = authz
@@ -176,11 +345,24 @@ func (server *HTTPServer) WithAuthz(authz Authz) *HTTPServer {
 
 // WithScheduler enables to schedule tasks, such as heartbeat, uptime etc
 // If a scheduler is not provided, then tasks will not be schedduled.
+// Use WithDependency(ready.SchedulerDependency(scheduler, window)) to fail
+// the readiness, if the scheduler stopped ticking.
 func (server *HTTPServer) WithScheduler(scheduler tasks.Scheduler) *HTTPServer {
 	server.scheduler = scheduler
 	return server
 }
 
+// WithTLSConfigHook sets the hook to modify the TLS configuration,
+// such as NextProtos, ClientCAs or VerifyPeerCertificate, before the server starts listening.
+// Note that GetCertificate is already set by the keypair reloader,
+// the hook overriding it disables the certificate reload.
+// GetConfigForClient set by the hook is called after the server records
+// the client SNI, to report it with the client auth failures.
+func (server *HTTPServer) WithTLSConfigHook(hook func(*tls.Config)) *HTTPServer {
+	server.tlsConfigHook = hook
+	return server
+}
+
 // WithCORS enables CORS options
 func (server *HTTPServer) WithCORS(cors *CORSOptions) *HTTPServer {
 	server.cors = cors
@@ -193,6 +375,284 @@ func (server *HTTPServer) WithShutdownTimeout(timeout time.Duration) *HTTPServer
 	return server
 }
 
+// WithShutdownProgress sets the callback, that is called at the interval
+// while the in-flight requests are drained by Drain or StopHTTP,
+// and once more when the drain completed or timed out.
+// The shutdown.inflight gauge is published at the same interval,
+// by default every second.
+func (server *HTTPServer) WithShutdownProgress(interval time.Duration, callback ShutdownProgressFunc) *HTTPServer {
+	if interval > 0 {
+		server.shutdownTick = interval
+	}
+	server.shutdownNotify = callback
+	return server
+}
+
+// WithRequestTimeout sets the default timeout for processing a request,
+// the requests not processed within the timeout are replied with 503 status.
+// Zero timeout disables the limit.
+func (server *HTTPServer) WithRequestTimeout(timeout time.Duration) *HTTPServer {
+	server.requestTimeout = timeout
+	return server
+}
+
+// WithRouteTimeout overrides the request timeout for the paths with the specified prefix.
+// Zero timeout disables the limit for the route, for example for streaming end-points.
+func (server *HTTPServer) WithRouteTimeout(prefix string, timeout time.Duration) *HTTPServer {
+	server.routeTimeouts[prefix] = timeout
+	return server
+}
+
+// WithClientRequestTimeout allows the clients to specify the timeout of the request
+// in X-Request-Timeout header, in Go duration format, such as 2s.
+// The timeout over max, or invalid, is ignored and the server's request timeout is used.
+// Zero max disables the header, that is the default.
+func (server *HTTPServer) WithClientRequestTimeout(max time.Duration) *HTTPServer {
+	server.clientTimeout = max
+	return server
+}
+
+// WithMaxInFlightRequests limits the number of concurrent in-flight requests,
+// the requests exceeded the limit are replied with 503 status and Retry-After header.
+// If exemptStreaming is true, then SSE and WebSocket requests are not limited.
+// Zero value disables the limit.
+func (server *HTTPServer) WithMaxInFlightRequests(max int, exemptStreaming bool) *HTTPServer {
+	server.maxInFlight = max
+	server.exemptStreaming = exemptStreaming
+	return server
+}
+
+// WithRejectedRequestsAudit enables the audit of the requests rejected by the concurrency limit,
+// or by the max header count,
+// with the identity, route and reason, where rate specifies to keep 1 in rate events,
+// to investigate the abuse without flooding the audit log.
+// The rate lower than 1 disables the audit, that is the default.
+func (server *HTTPServer) WithRejectedRequestsAudit(rate int) *HTTPServer {
+	server.rejectAuditor = nil
+	if rate > 0 {
+		server.rejectAuditor = audit.NewSampler(serverAuditor{server}, map[audit.EventKey]int{
+			{Source: xhttp.EvtSourceThrottle}: rate,
+		})
+	}
+	return server
+}
+
+// WithClientAuthAudit enables the audit of the client certificates,
+// rejected at the TLS handshake, with the reason, client IP and SNI,
+// where rate specifies to keep 1 in rate events.
+// The rate lower than 1 disables the audit, that is the default.
+// The failures are logged and counted in tls.handshake metric regardless of the audit.
+func (server *HTTPServer) WithClientAuthAudit(rate int) *HTTPServer {
+	server.clientAuthAudit = nil
+	if rate > 0 {
+		server.clientAuthAudit = audit.NewSampler(serverAuditor{server}, map[audit.EventKey]int{
+			{Source: EvtSourceTLS}: rate,
+		})
+	}
+	return server
+}
+
+// WithEmptyFields sets the default encoding of the empty fields tagged with omitempty,
+// in the responses written by marshal package, such as WriteJSON.
+// The handlers can override it per response with marshal.Explicit or marshal.Compact.
+func (server *HTTPServer) WithEmptyFields(mode marshal.EmptyFields) *HTTPServer {
+	server.emptyFields = mode
+	return server
+}
+
+// WithResponseSchemaValidation enables the validation of the responses
+// against the Response schema of RouteSchemaProvider services,
+// the mismatches are logged, but the responses are not changed.
+// The validation buffers the response body, so it's intended for testing and debugging.
+func (server *HTTPServer) WithResponseSchemaValidation(enable bool) *HTTPServer {
+	server.validateResp = enable
+	return server
+}
+
+// WithTrailingSlashPolicy specifies how the request paths with the trailing slash
+// are handled before routing, by default the paths are kept as is.
+// The exclude paths are not changed, the path ending with slash excludes all paths with the prefix.
+// ACME HTTP-01 challenge requests are always excluded.
+func (server *HTTPServer) WithTrailingSlashPolicy(policy xhttp.TrailingSlashPolicy, exclude ...string) *HTTPServer {
+	server.slashPolicy = policy
+	server.slashExclude = exclude
+	return server
+}
+
+// WithResponseCacheStore specifies the store of the cached responses
+// of the routes declared by RouteCacheProvider,
+// by default the responses are stored in xhttp.MemoryCacheStore
+func (server *HTTPServer) WithResponseCacheStore(store xhttp.ResponseCacheStore) *HTTPServer {
+	server.cacheStore = store
+	return server
+}
+
+// WithAccessLogger specifies the logger of the access logs of the requests,
+// to write the access logs separately from the application logs,
+// for example created by xlog.NewFormatterLogger with a different format.
+// The logger overrides AccessLogFile of the config,
+// which is opened only when the server is started without the logger.
+func (server *HTTPServer) WithAccessLogger(l xlog.Logger) *HTTPServer {
+	server.accessLogger = l
+	return server
+}
+
+// WithMissingHostPolicy specifies how the requests without Host header,
+// sent by the legacy HTTP/1.0 clients, are handled before any processing,
+// by default the requests are passed as is.
+// With xhttp.MissingHostDefault policy the host is assigned to the requests,
+// if the host is empty, then VIPName of the config, or the host name of the server is used.
+func (server *HTTPServer) WithMissingHostPolicy(policy xhttp.MissingHostPolicy, host string) *HTTPServer {
+	server.hostPolicy = policy
+	server.defaultHost = host
+	return server
+}
+
+// WithRequestCapture enables the capture of the requests and responses for debugging,
+// to the files in dir, or GetProfilerDir if dir is empty.
+// The requests with X-Capture-Request header are captured,
+// and 1 in rate of other requests, zero rate captures only the requests with the header.
+// The headers redacted by the header logger are not captured.
+// Use xhttp.LoadCapturedExchange to replay the captured requests.
+func (server *HTTPServer) WithRequestCapture(dir string, rate int) *HTTPServer {
+	server.capture = true
+	server.captureDir = dir
+	server.captureRate = rate
+	return server
+}
+
+// WithNotFoundHandler overrides the handler for not found routes,
+// by default JSON error with 404 status is returned.
+func (server *HTTPServer) WithNotFoundHandler(h http.Handler) *HTTPServer {
+	server.notFound = h
+	return server
+}
+
+// WithMethodNotAllowedHandler overrides the handler for the routes
+// registered with a different method,
+// by default JSON error with 405 status is returned.
+func (server *HTTPServer) WithMethodNotAllowedHandler(h http.Handler) *HTTPServer {
+	server.notAllowed = h
+	return server
+}
+
+// WithConnectionIDLogging enables logging of the connection ID of the requests,
+// as conn=<id> field, to find the requests that shared a keep-alive connection.
+// Use xhttp.ConnectionIDFromRequest to get the connection ID in the handlers.
+func (server *HTTPServer) WithConnectionIDLogging(enable bool) *HTTPServer {
+	server.logConnID = enable
+	return server
+}
+
+// WithServerHeader sets the value of the Server response header,
+// by default the service name is used.
+// Empty value disables the header, to not disclose the server details.
+func (server *HTTPServer) WithServerHeader(value string) *HTTPServer {
+	server.serverHeader = value
+	return server
+}
+
+// WithSecurityHeaders enables the security headers of the responses,
+// such as Strict-Transport-Security and X-Content-Type-Options.
+// Use xhttp.DefaultSecurityHeadersConfig for the conservative defaults.
+// By default the security headers are not set.
+func (server *HTTPServer) WithSecurityHeaders(cfg *xhttp.SecurityHeadersConfig) *HTTPServer {
+	server.securityHeaders = cfg
+	return server
+}
+
+// WithHeaderLogger enables logging of the allowed request and response headers,
+// in addition to the correlation ID.
+// The values of the sensitive headers are redacted.
+func (server *HTTPServer) WithHeaderLogger(l *xhttp.HeaderLogger) *HTTPServer {
+	server.headerLogger = l
+	return server
+}
+
+// WithClock sets the time source for StartedAt and Uptime,
+// and resets the start time to the current time of the clock.
+// A fake clock allows to advance the time in unit tests.
+func (server *HTTPServer) WithClock(c clock.Clock) *HTTPServer {
+	if server.readyCache != nil {
+		server.readyCache.WithClock(c)
+	}
+	server.depChecker.WithClock(c)
+	server.clock = c
+	server.startedAt = c.Now().UTC()
+	return server
+}
+
+// WithClusterRole sets the function that returns the cluster role of the node,
+// such as leader or follower, published with the heartbeat metric
+func (server *HTTPServer) WithClusterRole(role func() string) *HTTPServer {
+	server.clusterRole = role
+	return server
+}
+
+// WithRebuildDelay sets the delay to rebuild the server handler,
+// after services are added or removed while the server is running.
+// The changes made within the delay are applied by a single rebuild.
+func (server *HTTPServer) WithRebuildDelay(delay time.Duration) *HTTPServer {
+	server.rebuildDelay = delay
+	return server
+}
+
+// WithReadyCache enables caching of the aggregated readiness of the services,
+// for the services with expensive readiness checks.
+// The result older than ttl is refreshed in the background,
+// and the result older than maxAge is treated as not ready.
+func (server *HTTPServer) WithReadyCache(ttl, maxAge time.Duration) *HTTPServer {
+	server.readyCache = ready.NewStatusCache(server.checkStatus, ttl, maxAge).WithClock(server.clock)
+	return server
+}
+
+// WithDependency adds the downstream dependencies to check for the readiness,
+// in addition to the dependencies declared by the services
+// with ready.DependencyProvider interface.
+// The node is not ready, if a critical dependency check fails.
+func (server *HTTPServer) WithDependency(deps ...ready.Dependency) *HTTPServer {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	server.dependencies = append(server.dependencies, deps...)
+	return server
+}
+
+// WithNotReadyResponse overrides the response to the requests,
+// while the server is not ready
+func (server *HTTPServer) WithNotReadyResponse(resp *ready.NotReadyResponse) *HTTPServer {
+	server.notReady = resp
+	return server
+}
+
+// WithDrainRejection specifies to reject the new requests while the server is draining,
+// with the not ready response, except the always available paths,
+// by default DefaultPublicRoutes, so the load balancer and the orchestration
+// can still probe the node. The /readyz and /startupz probes are always served.
+// Without the rejection, the requests are served until the server is stopped.
+func (server *HTTPServer) WithDrainRejection(available ...string) *HTTPServer {
+	server.drainReject = true
+	server.drainAvailable = available
+	return server
+}
+
+// WithServeErrorPolicy sets the behavior on a fatal error of the Serve loop,
+// by default the server panics.
+// Embedders running the server in-process, may use ServeErrorLog
+// or ServeErrorCallback, to keep the process running.
+func (server *HTTPServer) WithServeErrorPolicy(policy ServeErrorPolicy) *HTTPServer {
+	server.serveErrPolicy = policy
+	return server
+}
+
+// WithServeErrorHandler sets the handler to receive a fatal error of the Serve loop,
+// and sets ServeErrorCallback policy.
+// The handler is called on the Serve goroutine.
+func (server *HTTPServer) WithServeErrorHandler(handler func(error)) *HTTPServer {
+	server.serveErrHandler = handler
+	server.serveErrPolicy = ServeErrorCallback
+	return server
+}
+
 var tlsClientAuthToStrMap = map[tls.ClientAuthType]string{
 	tls.NoClientCert:               "NoClientCert",
 	tls.RequestClientCert:          "RequestClientCert",
@@ -201,11 +661,203 @@ var tlsClientAuthToStrMap = map[tls.ClientAuthType]string{
 	tls.RequireAndVerifyClientCert: "RequireAndVerifyClientCert",
 }
 
-// AddService provides a service registration for the server
-func (server *HTTPServer) AddService(s Service) {
+// AddService provides a service registration for the server,
+// with optional priority, the default priority is 0.
+// The routes of the services are registered in the order of priority,
+// the services with higher priority are registered first,
+// and the services with the same priority are registered in the order of names.
+// If the server is already running, then the handler is rebuilt
+// to serve the routes of the added service.
+// An error is returned if a service with the same name is already registered,
+// use AddOrReplaceService to replace the registration.
+func (server *HTTPServer) AddService(s Service, priority ...int) error {
 	server.lock.Lock()
 	defer server.lock.Unlock()
+	if _, ok := server.services[s.Name()]; ok {
+		logger.Errorf("api=AddService, reason=duplicate, service=%q", s.Name())
+		return errors.AlreadyExistsf("service %q", s.Name())
+	}
+	server.addService(s, priority...)
+	return nil
+}
+
+// AddOrReplaceService provides a service registration for the server,
+// replacing the service with the same name if already registered.
+// The priority of the replaced service is not inherited.
+func (server *HTTPServer) AddOrReplaceService(s Service, priority ...int) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	if _, ok := server.services[s.Name()]; ok {
+		logger.Noticef("api=AddOrReplaceService, reason=replaced, service=%q", s.Name())
+	}
+	server.addService(s, priority...)
+}
+
+// addService registers the service, the caller must hold the lock
+func (server *HTTPServer) addService(s Service, priority ...int) {
 	server.services[s.Name()] = s
+	if len(priority) > 0 {
+		server.priorities[s.Name()] = priority[0]
+	} else {
+		delete(server.priorities, s.Name())
+	}
+	server.scheduleRebuild()
+}
+
+// RemoveService removes a service registration from the server.
+// If the server is already running, then the handler is rebuilt
+// without the routes of the removed service.
+// The service is not closed, it's the caller's responsibility.
+func (server *HTTPServer) RemoveService(name string) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	if _, ok := server.services[name]; ok {
+		delete(server.services, name)
+		delete(server.priorities, name)
+		server.readyTracker.Remove(name)
+		server.scheduleRebuild()
+	}
+}
+
+// validateRoutes registers the services with a new router,
+// to detect the conflicting routes before the server is started
+func (server *HTTPServer) validateRoutes() error {
+	router := NewRouter(nil)
+	for _, s := range server.servicesList() {
+		if err := registerService(router, s); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// registerService registers the service routes,
+// and returns the error if the router panics on the conflicting route
+func registerService(router Router, s Service) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("service %q has conflicting route: %v", s.Name(), r)
+		}
+	}()
+	s.Register(serviceRouter(router, s))
+	return nil
+}
+
+// servicesList returns a snapshot of the registered services,
+// sorted by priority then name
+func (server *HTTPServer) servicesList() []Service {
+	server.lock.RLock()
+	defer server.lock.RUnlock()
+	list := make([]Service, 0, len(server.services))
+	for _, s := range server.services {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		pi, pj := server.priorities[list[i].Name()], server.priorities[list[j].Name()]
+		if pi != pj {
+			return pi > pj
+		}
+		return list[i].Name() < list[j].Name()
+	})
+	return list
+}
+
+// scheduleRebuild schedules the handler rebuild, if the server is running.
+// The caller must hold the lock.
+func (server *HTTPServer) scheduleRebuild() {
+	if server.handler.Load() == nil || server.ctx.Err() != nil {
+		return
+	}
+	if server.rebuildTimer == nil {
+		server.rebuildTimer = time.AfterFunc(server.rebuildDelay, server.rebuildHandler)
+	} else {
+		server.rebuildTimer.Reset(server.rebuildDelay)
+	}
+}
+
+// rebuildHandler creates a new handler and swaps it with the live one,
+// the requests in progress are completed by the previous handler.
+func (server *HTTPServer) rebuildHandler() {
+	if server.ctx.Err() != nil {
+		return
+	}
+	handler, err := server.newHandler()
+	if err != nil {
+		logger.KV(xlog.ERROR, "api", "rebuildHandler", "service", server.Name(), "err", errors.ErrorStack(err))
+		return
+	}
+	server.handler.Store(muxHandler{handler})
+	logger.KV(xlog.INFO, "api", "rebuildHandler", "service", server.Name(), "status", "rebuilt")
+}
+
+// newHandler creates the server handler
+func (server *HTTPServer) newHandler() (http.Handler, error) {
+	httpHandler, err := server.muxFactory.NewMux()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if server.httpConfig.GetAllowProfiling() {
+		created := xhttp.LogProfile()
+		if retention := server.profileRetention(); !retention.IsZero() {
+			created = xhttp.WithProfileRetention(created, retention)
+		}
+		if httpHandler, err = xhttp.NewRequestProfiler(httpHandler, server.httpConfig.GetProfilerDir(), nil, created); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	if server.capture {
+		dir := server.captureDir
+		if dir == "" {
+			dir = server.httpConfig.GetProfilerDir()
+		}
+		capture, err := xhttp.NewRequestCapture(httpHandler, dir)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		capture.WithSampleRate(server.captureRate)
+		if server.headerLogger != nil {
+			capture.WithRedacted(server.headerLogger.RedactedHeaders()...)
+		}
+		httpHandler = capture
+	}
+	return httpHandler, nil
+}
+
+// newDrainHandler returns a http.Handler that writes the not ready response
+// while the server is draining, except for the always available paths
+func (server *HTTPServer) newDrainHandler(notReady *ready.NotReadyResponse, delegate http.Handler) http.Handler {
+	paths := server.drainAvailable
+	if len(paths) == 0 {
+		paths = DefaultPublicRoutes
+	}
+	available := map[string]bool{}
+	for _, path := range paths {
+		available[path] = true
+	}
+	logger.KV(xlog.INFO, "api", "newDrainHandler", "service", server.Name(), "available", paths)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if server.IsDraining() && !available[r.URL.Path] {
+			notReady.ServeHTTP(w, r, server.ReadyStatus())
+			return
+		}
+		delegate.ServeHTTP(w, r)
+	})
+}
+
+// serveLive serves the request with the live handler
+func (server *HTTPServer) serveLive(w http.ResponseWriter, r *http.Request) {
+	defer xhttp.RestoreDeadline(r)
+	atomic.AddInt32(&server.inFlight, 1)
+	defer atomic.AddInt32(&server.inFlight, -1)
+
+	if atomic.LoadInt32(&server.draining) == 1 {
+		// force the clients to re-connect, hopefully to a different instance
+		w.Header().Set(header.Connection, "close")
+	}
+	server.handler.Load().(muxHandler).ServeHTTP(w, r)
 }
 
 // OnEvent accepts a callback to handle server events
@@ -216,23 +868,119 @@ func (server *HTTPServer) OnEvent(evt ServerEvent, handler ServerEventFunc) {
 	server.evtHandlers[evt] = append(server.evtHandlers[evt], handler)
 }
 
+// WithKeypairReloader sets the reloader of the server's TLS key pair,
+// to reload the certificate on Reload, without waiting for the reloader's check interval
+func (server *HTTPServer) WithKeypairReloader(reloader *tlsconfig.KeypairReloader) *HTTPServer {
+	server.tlsReloader = reloader
+	return server
+}
+
+// Reload reloads the TLS key pair and rebuilds the handler of the running server,
+// without dropping the connections.
+// The requests in progress are completed by the previous handler.
+//
+// ServerReloadingEvent handlers are called first,
+// so the application can re-read its configuration,
+// and update the values returned by HTTPServerConfig.
+//
+// The reloadable settings are:
+//
+//	the TLS key pair, set by WithKeypairReloader,
+//	the routes of the services,
+//	MaxURILength, MaxHeaderCount, MaxBodyBytes, MaxDecompressedBodyBytes and PackageLogger.
+//
+// Other settings require the restart, such as BindAddr, MaxHeaderBytes,
+// the listener options, the TLS trusted CA and client auth.
+func (server *HTTPServer) Reload() error {
+	logger.KV(xlog.INFO, "api", "Reload", "service", server.Name(), "status", "reloading")
+
+	server.lock.RLock()
+	handlers := server.evtHandlers[ServerReloadingEvent]
+	server.lock.RUnlock()
+	for _, handler := range handlers {
+		handler(ServerReloadingEvent)
+	}
+
+	var err error
+	if server.tlsReloader != nil {
+		// on failure the previous key pair is kept
+		if err = server.tlsReloader.Reload(); err != nil {
+			logger.KV(xlog.ERROR, "api", "Reload", "service", server.Name(), "reason", "keypair", "err", errors.ErrorStack(err))
+			err = errors.Annotate(err, "failed to reload TLS key pair")
+		}
+	}
+
+	if server.handler.Load() != nil {
+		server.rebuildHandler()
+	}
+
+	server.Audit(
+		EvtSourceStatus,
+		EvtServiceReloaded,
+		server.AuditIdentity(),
+		server.LocalIP(),
+		0,
+		fmt.Sprintf("success=%t", err == nil),
+	)
+	return err
+}
+
 // Scheduler returns task scheduler for the server
 func (server *HTTPServer) Scheduler() tasks.Scheduler {
 	return server.scheduler
 }
 
+// Context returns the server's lifecycle context.
+// The context is canceled at the beginning of StopHTTP,
+// before the services are closed, so the long-running operations started
+// by the services can be aborted promptly.
+func (server *HTTPServer) Context() context.Context {
+	return server.ctx
+}
+
 // Service returns a registered server
 func (server *HTTPServer) Service(name string) Service {
-	server.lock.Lock()
-	defer server.lock.Unlock()
+	server.lock.RLock()
+	defer server.lock.RUnlock()
 	return server.services[name]
 }
 
+// Services returns the registered services, in the order of the priority
+func (server *HTTPServer) Services() []Service {
+	return server.servicesList()
+}
+
+// Routes returns the routes registered by the services,
+// the list is updated when the handler is built
+func (server *HTTPServer) Routes() []Route {
+	routes, _ := server.routes.Load().([]Route)
+	res := make([]Route, len(routes))
+	copy(res, routes)
+	return res
+}
+
 // HostName returns the host name of the server
 func (server *HTTPServer) HostName() string {
 	return server.hostname
 }
 
+// WithAuditIdentity sets the identity of the server lifecycle audit events,
+// such as the node name or the service account.
+// By default the host name of the server is used.
+func (server *HTTPServer) WithAuditIdentity(identity string) *HTTPServer {
+	server.auditIdentity = identity
+	return server
+}
+
+// AuditIdentity returns the identity of the server lifecycle audit events,
+// the started, stopped and heartbeat events
+func (server *HTTPServer) AuditIdentity() string {
+	if server.auditIdentity != "" {
+		return server.auditIdentity
+	}
+	return server.HostName()
+}
+
 // Port returns the port name of the server
 func (server *HTTPServer) Port() string {
 	return server.port
@@ -258,7 +1006,7 @@ func (server *HTTPServer) StartedAt() time.Time {
 
 // Uptime returns the duration the server was up
 func (server *HTTPServer) Uptime() time.Duration {
-	return time.Now().UTC().Sub(server.startedAt)
+	return server.clock.Now().UTC().Sub(server.startedAt)
 }
 
 // Version returns the version of the server
@@ -281,17 +1029,127 @@ func (server *HTTPServer) TLSConfig() *tls.Config {
 	return server.tlsConfig
 }
 
+// StartDraining prepares the server for the shutdown,
+// without closing the listener and the services:
+// the readiness probe at /readyz reports "server is draining",
+// so the load balancer removes the instance from the pool,
+// while the requests are still served, with Connection: close header,
+// to force the clients to re-connect.
+// With WithDrainRejection, the new requests are rejected with the not ready response,
+// except the always available paths.
+// The draining can not be canceled, the server is expected to be stopped later.
+func (server *HTTPServer) StartDraining() {
+	if !atomic.CompareAndSwapInt32(&server.draining, 0, 1) {
+		return
+	}
+	if server.httpServer != nil {
+		server.httpServer.SetKeepAlivesEnabled(false)
+	}
+
+	logger.KV(xlog.NOTICE, "api", "StartDraining", "service", server.Name(), "in_flight", server.InFlight())
+	server.Audit(
+		EvtSourceStatus,
+		EvtServiceDraining,
+		server.AuditIdentity(),
+		server.LocalIP(),
+		0,
+		fmt.Sprintf("in_flight=%d", server.InFlight()),
+	)
+}
+
+// IsDraining returns true if the server is draining
+func (server *HTTPServer) IsDraining() bool {
+	return atomic.LoadInt32(&server.draining) == 1
+}
+
+// InFlight returns the number of the requests in progress
+func (server *HTTPServer) InFlight() int {
+	return int(atomic.LoadInt32(&server.inFlight))
+}
+
 // IsReady returns true when the server is ready to serve
 func (server *HTTPServer) IsReady() bool {
-	if !server.serving {
-		return false
+	var isReady bool
+	if server.readyCache != nil {
+		isReady = server.readyCache.IsReady()
+	} else {
+		isReady = server.checkStatus().Ready
+	}
+	if isReady {
+		atomic.StoreInt32(&server.started, 1)
+	}
+	return isReady
+}
+
+// HasStarted indicates that all subservices were ready at least once.
+// Unlike IsReady, it does not change back once all subservices
+// completed the initialization, so a transient not ready state
+// does not fail the startup probe at /startupz,
+// while /readyz and the requests reflect the live readiness.
+func (server *HTTPServer) HasStarted() bool {
+	if atomic.LoadInt32(&server.started) == 1 {
+		return true
+	}
+	return server.IsReady()
+}
+
+// ReadyStatus returns the readiness status with the time of the check,
+// and the reason if not ready
+func (server *HTTPServer) ReadyStatus() ready.Status {
+	if server.IsDraining() {
+		return ready.Status{Reason: "server is draining", CheckedAt: server.clock.Now().UTC()}
+	}
+	if server.readyCache != nil {
+		return server.readyCache.ReadyStatus()
+	}
+	status := server.checkStatus()
+	status.CheckedAt = server.clock.Now().UTC()
+	return status
+}
+
+// checkStatus returns the ready status if all subservices are ready,
+// and the critical dependencies are healthy,
+// otherwise the reason listing the services not ready yet,
+// and the dependencies not healthy
+func (server *HTTPServer) checkStatus() ready.Status {
+	if atomic.LoadInt32(&server.serving) == 0 {
+		return ready.Status{Reason: "server is not serving"}
 	}
-	for _, ss := range server.services {
-		if !ss.IsReady() {
-			return false
+
+	services := server.servicesList()
+
+	server.lock.RLock()
+	deps := append([]ready.Dependency{}, server.dependencies...)
+	server.lock.RUnlock()
+
+	var reasons []string
+	for _, ss := range services {
+		var reason string
+		isReady := ss.IsReady()
+		if !isReady {
+			reason = fmt.Sprintf("service %q is not ready", ss.Name())
+			reasons = append(reasons, reason)
+		}
+		// the transitions are published as metrics to detect flapping services
+		server.readyTracker.Observe(ss.Name(), isReady, reason)
+		if dp, ok := ss.(ready.DependencyProvider); ok {
+			deps = append(deps, dp.Dependencies()...)
 		}
 	}
-	return true
+
+	status := ready.Status{}
+	if len(deps) > 0 {
+		status.Dependencies = server.depChecker.Check(server.ctx, deps)
+		for _, ds := range status.Dependencies {
+			if ds.Critical && !ds.Healthy {
+				reasons = append(reasons, fmt.Sprintf("dependency %q is not healthy", ds.Name))
+			}
+		}
+	}
+
+	status.Ready = len(reasons) == 0
+	status.Reason = strings.Join(reasons, "; ")
+	return status
 }
 
 // Audit create an audit event
@@ -301,6 +1159,9 @@ func (server *HTTPServer) Audit(source string,
 	contextID string,
 	raftIndex uint64,
 	message string) {
+	if server.auditHasher != nil {
+		identity = server.auditHasher.Identity(source, identity)
+	}
 	if server.auditor != nil {
 		server.auditor.Audit(source, eventType, identity, contextID, raftIndex, message)
 	} else {
@@ -310,6 +1171,17 @@ func (server *HTTPServer) Audit(source string,
 	}
 }
 
+// flushAudit flushes the auditor, if it buffers the events,
+// to deliver the events that bracket the server lifecycle
+func (server *HTTPServer) flushAudit() {
+	if server.auditor == nil {
+		return
+	}
+	if err := audit.Flush(server.auditor); err != nil {
+		logger.Errorf("api=flushAudit, reason=flush, err=[%v]", err)
+	}
+}
+
 // WithMuxFactory requires the server to use `muxFactory` to create server handler.
 func (server *HTTPServer) WithMuxFactory(muxFactory MuxFactory) {
 	server.muxFactory = muxFactory
@@ -326,42 +1198,127 @@ func (server *HTTPServer) StartHTTP() error {
 			server.Name(), bindAddr)
 	}
 
-	server.httpServer = &http.Server{
-		IdleTimeout: time.Hour * 2,
-		ErrorLog:    xlog.Stderr,
+	if err = server.validateRoutes(); err != nil {
+		return errors.Trace(err)
 	}
 
-	var httpsListener net.Listener
+	listener, err := server.listen(bindAddr)
+	if err != nil {
+		return errors.Annotatef(err, "api=StartHTTP, reason=unable_listen, service=%s, address=%q",
+			server.Name(), bindAddr)
+	}
 
-	if server.tlsConfig != nil {
-		// Start listening on main server over TLS
-		httpsListener, err = tls.Listen("tcp", bindAddr, server.tlsConfig)
-		if err != nil {
-			return errors.Annotatef(err, "api=StartHTTP, reason=unable_listen, service=%s, address=%q",
-				server.Name(), bindAddr)
+	// the port is assigned by the system, if not specified in the bind address
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok && server.port == "0" {
+		server.port = strconv.Itoa(addr.Port)
+	}
+
+	return server.serve(listener, bindAddr)
+}
+
+// StartHTTPWithListener starts the server on the provided listener,
+// such as the listener created from the file descriptor passed by systemd
+// socket activation, or by the parent process on the graceful restart.
+// The listener is wrapped with TLS, if the server is configured with TLS,
+// but the listener options of the config, such as backlog and keep-alive, are not applied.
+// HostName and Port of the server are set from the listener address.
+// The server closes the listener on StopHTTP.
+func (server *HTTPServer) StartHTTPWithListener(listener net.Listener) error {
+	if err := server.validateRoutes(); err != nil {
+		return errors.Trace(err)
+	}
+
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok {
+		server.port = strconv.Itoa(addr.Port)
+		if !addr.IP.IsUnspecified() {
+			server.hostname = addr.IP.String()
 		}
+	}
 
-		server.httpServer.TLSConfig = server.tlsConfig
-	} else {
-		server.httpServer.Addr = bindAddr
+	return server.serve(listener, listener.Addr().String())
+}
+
+// openAccessLog opens AccessLogFile of the config, when the server is started,
+// unless the access logger is specified by WithAccessLogger
+func (server *HTTPServer) openAccessLog() error {
+	file := server.httpConfig.GetAccessLogFile()
+	if file == "" || server.accessLogger != nil {
+		return nil
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Annotatef(err, "unable to open access log file: %q", file)
+	}
+	server.accessLogFile = f
+	server.accessLogger = xlog.NewFormatterLogger("access", xlog.NewDefaultFormatter(f))
+	return nil
+}
+
+// closeAccessLog closes the access log file opened by openAccessLog
+func (server *HTTPServer) closeAccessLog() {
+	if server.accessLogFile == nil {
+		return
+	}
+	if err := server.accessLogFile.Close(); err != nil {
+		logger.KV(xlog.ERROR, "api", "closeAccessLog", "reason", "accessLogFile", "err", err.Error())
 	}
+	server.accessLogFile = nil
+	server.accessLogger = nil
+}
 
-	httpHandler := server.muxFactory.NewMux()
+// serve starts serving the listener
+func (server *HTTPServer) serve(listener net.Listener, bindAddr string) error {
+	maxHeaderBytes := server.httpConfig.GetMaxHeaderBytes()
+	if maxHeaderBytes <= 0 {
+		maxHeaderBytes = DefaultMaxHeaderBytes
+	}
 
-	if server.httpConfig.GetAllowProfiling() {
-		if httpHandler, err = xhttp.NewRequestProfiler(httpHandler, server.httpConfig.GetProfilerDir(), nil, xhttp.LogProfile()); err != nil {
-			return errors.Trace(err)
+	// Note that the requests exceeding MaxHeaderBytes are rejected by http.Server,
+	// before the handler is called, so they are not logged by the middleware.
+	// The long-poll and streaming handlers can extend the connection deadline
+	// with xhttp.ExtendDeadline, the IdleTimeout applies only between the requests.
+	server.httpServer = &http.Server{
+		IdleTimeout:    time.Hour * 2,
+		ErrorLog:       xlog.Stderr,
+		MaxHeaderBytes: maxHeaderBytes,
+		ConnContext:    xhttp.ConnContext,
+	}
+
+	if server.tlsConfig != nil {
+		if server.tlsConfigHook != nil {
+			server.tlsConfigHook(server.tlsConfig)
+		}
+		// Start listening on main server over TLS
+		listener = tls.NewListener(listener, server.tlsConfig)
+		server.httpServer.TLSConfig = server.tlsConfig
+		if server.handshakes == nil {
+			server.handshakes = new(handshakeMetrics)
+			server.tlsConfig.GetConfigForClient = server.handshakes.trackClientHello(server.tlsConfig.GetConfigForClient)
 		}
+		server.handshakes.auditor = server.clientAuthAudit
+		server.httpServer.ConnState = server.handshakes.ConnState
+		server.httpServer.ErrorLog = server.handshakes.errorLog(xlog.Stderr)
+	}
+	server.httpServer.Addr = bindAddr
+
+	if err := server.openAccessLog(); err != nil {
+		listener.Close()
+		return errors.Trace(err)
 	}
 
-	server.httpServer.Handler = httpHandler
+	httpHandler, err := server.newHandler()
+	if err != nil {
+		listener.Close()
+		server.closeAccessLog()
+		return errors.Trace(err)
+	}
+	server.handler.Store(muxHandler{httpHandler})
+
+	server.httpServer.Handler = http.HandlerFunc(server.serveLive)
 
 	serve := func() error {
-		server.serving = true
-		if httpsListener != nil {
-			return server.httpServer.Serve(httpsListener)
-		}
-		return server.httpServer.ListenAndServe()
+		atomic.StoreInt32(&server.serving, 1)
+		return server.httpServer.Serve(listener)
 	}
 
 	go func() {
@@ -369,18 +1326,13 @@ func (server *HTTPServer) StartHTTP() error {
 			handler(ServerStartedEvent)
 		}
 
-		logger.Infof("api=StartHTTP, service=%s, port=%v, status=starting, protocol=%s",
-			server.Name(), bindAddr, server.Protocol())
+		logger.KV(xlog.INFO, "api", "StartHTTP", "service", server.Name(), "port", bindAddr,
+			"status", "starting", "protocol", server.Protocol())
 
 		// this is a blocking call to serve
 		if err := serve(); err != nil {
-			server.serving = false
-			//panic, only if not Serve error while stopping the server,
-			// which is a valid error
-			if netutil.IsAddrInUse(err) || err != http.ErrServerClosed {
-				logger.Panicf("api=StartHTTP, service=%s, err=[%v]", server.Name(), errors.Trace(err))
-			}
-			logger.Warningf("api=StartHTTP, service=%s, status=stopped, reason=[%s]", server.Name(), err.Error())
+			atomic.StoreInt32(&server.serving, 0)
+			server.onServeError(err)
 		}
 	}()
 
@@ -391,26 +1343,132 @@ func (server *HTTPServer) StartHTTP() error {
 			server.Scheduler().Add(task)
 			task.Run()
 		}
+		if server.httpConfig.GetAuditHeartbeatSecs() > 0 {
+			task := tasks.NewTaskAtIntervals(uint64(server.httpConfig.GetAuditHeartbeatSecs()), tasks.Seconds).
+				Do("hearbeat-audit", hearbeatAuditTask, server)
+			server.Scheduler().Add(task)
+		}
+		// the profiles are created on demand, so the old ones are removed periodically
+		if maxAge := server.httpConfig.GetProfilerMaxAge(); maxAge > 0 &&
+			server.httpConfig.GetAllowProfiling() && server.httpConfig.GetProfilerDir() != "" {
+			minutes := uint64(maxAge / time.Minute)
+			if minutes < 1 {
+				minutes = 1
+			} else if minutes > 60 {
+				minutes = 60
+			}
+			task := tasks.NewTaskAtIntervals(minutes, tasks.Minutes).
+				Do("profiler-retention", profilerRetentionTask, server)
+			server.Scheduler().Add(task)
+		}
 	}
 
+	server.startWorkers()
+
 	server.Audit(
 		EvtSourceStatus,
 		EvtServiceStarted,
-		server.HostName(),
+		server.AuditIdentity(),
 		server.LocalIP(),
 		0,
 		fmt.Sprintf("address=%q, ClientAuth=%s",
 			strings.TrimPrefix(bindAddr, ":"), server.clientAuth),
 	)
+	server.flushAudit()
 
 	return nil
 }
 
+// onServeError handles the error returned by the Serve loop,
+// according to the configured policy
+func (server *HTTPServer) onServeError(err error) {
+	// the Serve error while stopping the server is a valid error.
+	// Note that with ReusePort enabled, the address in use is not detected
+	// when another process listens on the same port with ReusePort.
+	if !netutil.IsAddrInUse(err) && err == http.ErrServerClosed {
+		logger.KV(xlog.WARNING, "api", "StartHTTP", "service", server.Name(), "status", "stopped", "reason", err)
+		return
+	}
+
+	switch server.serveErrPolicy {
+	case ServeErrorLog:
+		logger.KV(xlog.ERROR, "api", "StartHTTP", "service", server.Name(), "err", err)
+	case ServeErrorCallback:
+		logger.KV(xlog.ERROR, "api", "StartHTTP", "service", server.Name(), "err", err)
+		if server.serveErrHandler != nil {
+			server.serveErrHandler(err)
+		}
+	default:
+		logger.Panicf("api=StartHTTP, service=%s, err=[%v]", server.Name(), errors.Trace(err))
+	}
+}
+
 func hearbeatMetricsTask(server *HTTPServer) {
-	metricsutil.PublishHeartbeat(server.httpConfig.GetServiceName())
+	var role string
+	if server.clusterRole != nil {
+		role = server.clusterRole()
+	}
+	metricsutil.PublishHeartbeatStatus(server.httpConfig.GetServiceName(), server.IsReady(), role)
 	metricsutil.PublishUptime(server.httpConfig.GetServiceName(), server.Uptime())
 }
 
+func hearbeatAuditTask(server *HTTPServer) {
+	server.Audit(
+		EvtSourceStatus,
+		EvtHeartbeat,
+		server.AuditIdentity(),
+		server.LocalIP(),
+		0,
+		fmt.Sprintf("uptime=%s, ready=%t",
+			server.Uptime()/time.Second*time.Second, server.IsReady()),
+	)
+}
+
+func profilerRetentionTask(server *HTTPServer) {
+	dir := server.httpConfig.GetProfilerDir()
+	if err := xhttp.PruneProfiles(dir, server.profileRetention()); err != nil {
+		logger.KV(xlog.ERROR, "api", "profilerRetentionTask", "dir", dir, "err", err.Error())
+	}
+}
+
+// profileRetention returns the retention of the profile files
+func (server *HTTPServer) profileRetention() xhttp.ProfileRetention {
+	return xhttp.ProfileRetention{
+		MaxFiles: server.httpConfig.GetProfilerMaxFiles(),
+		MaxSize:  server.httpConfig.GetProfilerMaxSize(),
+		MaxAge:   server.httpConfig.GetProfilerMaxAge(),
+	}
+}
+
+// Drain stops accepting new connections, and waits for the in-flight requests
+// to complete within the timeout, the responses of the in-flight requests
+// are sent with Connection: close header.
+// Unlike StopHTTP, the services are not closed and the stopped event is not sent,
+// the readiness probe reports "server is draining", see StartDraining.
+//
+// The zero-downtime restart on the same port is performed by:
+//		1) the old process is started with ReusePort enabled,
+//				or passes its listener to the new process as a file descriptor
+//		2) the new process binds the same port with StartHTTP and ReusePort enabled,
+//				or with StartHTTPWithListener for the inherited listener
+//		3) once the new process is ready, the old process calls Drain
+//				so the new connections are accepted only by the new process
+//		4) the old process calls StopHTTP and exits
+func (server *HTTPServer) Drain(timeout time.Duration) error {
+	if server.httpServer == nil {
+		return errors.NotValidf("server is not started")
+	}
+	server.StartDraining()
+	atomic.StoreInt32(&server.serving, 0)
+
+	logger.KV(xlog.INFO, "api", "Drain", "service", server.Name(), "timeout", timeout)
+
+	if err := server.shutdown(timeout); err != nil {
+		return errors.Annotatef(err, "api=Drain, reason=Shutdown, service=%s", server.Name())
+	}
+	return nil
+}
+
 // StopHTTP will perform a graceful shutdown of the serivce by
 //		1) signally to the Load Balancer to remove this instance from the pool
 //				by changing to response to /availability
@@ -421,77 +1479,376 @@ func hearbeatMetricsTask(server *HTTPServer) {
 //		5) step 4 is capped by a overrall timeout where we'll give up waiting
 //			 for the requests to complete and will exit.
 //
+// The server's Context is canceled before the services are closed,
+// so the services can abort any in-progress background work in Close.
+// The workers registered with AddWorker are awaited before the services are closed.
+//
 // it is expected that you don't try and use the server instance again
 // after this. [i.e. if you want to start it again, create another server instance]
 func (server *HTTPServer) StopHTTP() {
+	// cancel the lifecycle context first,
+	// so the services can abort the outbound calls before Close
+	server.cancel()
+
+	// the workers may use the services, so they are stopped first
+	if err := server.waitWorkers(server.shutdownTimeout); err != nil {
+		logger.KV(xlog.ERROR, "api", "StopHTTP", "reason", "waitWorkers", "err", err.Error())
+	}
+
+	server.lock.Lock()
+	if server.rebuildTimer != nil {
+		server.rebuildTimer.Stop()
+	}
+	server.lock.Unlock()
+
 	// close services
-	for _, f := range server.services {
-		logger.Tracef("api=StopHTTP, service=%q", f.Name())
+	for _, f := range server.servicesList() {
+		logger.KV(xlog.TRACE, "api", "StopHTTP", "service", f.Name())
 		f.Close()
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), server.shutdownTimeout)
-	defer cancel()
-	err := server.httpServer.Shutdown(ctx)
+	err := server.shutdown(server.shutdownTimeout)
 	if err != nil {
-		logger.Errorf("api=StopHTTP, reason=Shutdown, err=[%v]", errors.ErrorStack(err))
+		logger.KV(xlog.ERROR, "api", "StopHTTP", "reason", "Shutdown", "err", errors.ErrorStack(err))
 	}
 
 	for _, handler := range server.evtHandlers[ServerStoppedEvent] {
 		handler(ServerStoppedEvent)
 	}
 
+	server.closeAccessLog()
+
 	ut := server.Uptime() / time.Second * time.Second
 	server.Audit(
 		EvtSourceStatus,
 		EvtServiceStopped,
-		server.HostName(),
+		server.AuditIdentity(),
 		server.LocalIP(),
 		0,
 		fmt.Sprintf("uptime=%s", ut),
 	)
+	// the stopped event must be persisted before the process exits
+	server.flushAudit()
+}
+
+// shutdown gracefully shuts down the http server within the timeout,
+// and reports the number of the in-flight requests while draining
+func (server *HTTPServer) shutdown(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	progress := func() {
+		inFlight := server.InFlight()
+		metricsutil.PublishShutdownInFlight(server.httpConfig.GetServiceName(), inFlight)
+		if server.shutdownNotify != nil {
+			server.shutdownNotify(inFlight)
+		}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(server.shutdownTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				progress()
+			}
+		}
+	}()
+
+	err := server.httpServer.Shutdown(ctx)
+	close(done)
+	<-stopped
+	progress()
+
+	logger.KV(xlog.INFO, "api", "shutdown", "service", server.Name(), "in_flight", server.InFlight())
+	return err
 }
 
-// NewMux creates a new http handler for the http server, typically you only
+// MustNewMux creates a new http handler for the http server,
+// and panics if the handler can not be created, typically you only
 // need to call this directly for tests.
-func (server *HTTPServer) NewMux() http.Handler {
+func (server *HTTPServer) MustNewMux() http.Handler {
+	handler, err := server.NewMux()
+	if err != nil {
+		panic(errors.ErrorStack(err))
+	}
+	return handler
+}
+
+// NewMux creates a new http handler for the http server,
+// or returns an error if the Authz handler can not be created.
+//
+// The handlers are composed by muxLayers from the outermost to the router:
+// the missing Host policy, the identity context, the panic recovery,
+// server and security headers, the request limits, the path normalization,
+// the route matching, Content-Length and decompression, the probes,
+// the drain rejection, the readiness, the concurrency limit, metrics, logging,
+// the route audit, authorization, the timeout, the response cache,
+// the single-flight, and the route validation.
+// The recovery is installed inside the identity context,
+// so a panic in any inner handler is replied with 500 and audited
+// with the correlation ID of the request.
+func (server *HTTPServer) NewMux() (http.Handler, error) {
 	var router Router
 	if server.cors != nil {
-		router = NewRouterWithCORS(notFoundHandler, server.cors)
+		router = NewRouterWithCORS(server.notFound.ServeHTTP, server.cors)
 	} else {
-		router = NewRouter(notFoundHandler)
+		router = NewRouter(server.notFound.ServeHTTP)
 	}
+	router.(*proxy).setMethodNotAllowed(server.notAllowed)
 
-	for _, f := range server.services {
-		f.Register(router)
+	services := server.servicesList()
+	for _, f := range services {
+		f.Register(serviceRouter(router, f))
 	}
-	logger.Debugf("api=NewMux, service=%s, service_count=%d",
-		server.Name(), len(server.services))
+	server.routes.Store(router.Routes())
+	logger.KV(xlog.DEBUG, "api", "NewMux", "service", server.Name(), "service_count", len(services))
 
-	var err error
-	httpHandler := router.Handler()
+	routes := router.Routes()
+	layers := &muxLayers{}
 
-	logger.Infof("api=NewMux, service=%s, ClientAuth=%s", server.Name(), server.clientAuth)
+	// the requests without Host are handled before the tenant is resolved from the host
+	if server.hostPolicy != xhttp.MissingHostAllow {
+		host := server.defaultHost
+		if host == "" {
+			host = server.httpConfig.GetVIPName()
+		}
+		if host == "" {
+			host = server.HostName()
+		}
+		layers.missingHost = func(h http.Handler) http.Handler {
+			missingHost := xhttp.NewMissingHost(h, server.hostPolicy, host)
+			if server.rejectAuditor != nil {
+				missingHost.WithAuditor(server.rejectAuditor)
+			}
+			return missingHost
+		}
+	}
 
-	if server.authz != nil {
-		httpHandler, err = server.authz.NewHandler(httpHandler)
-		if err != nil {
-			panic(errors.ErrorStack(err))
+	// role/contextID wrapper, the client IP is resolved via the trusted proxies
+	layers.context = func(h http.Handler) http.Handler {
+		return identity.NewContextHandlerWithTrustedProxies(h, server.trustedProxies)
+	}
+
+	// the panics are recovered inside the context,
+	// so the response and the audit event are tagged with the correlation ID
+	layers.recovery = func(h http.Handler) http.Handler {
+		return xhttp.NewRecovery(h).WithAuditor(server)
+	}
+
+	// Server header is applied to all responses
+	layers.headers = append(layers.headers, func(h http.Handler) http.Handler {
+		return xhttp.NewServerHeader(h, server.serverHeader)
+	})
+	// the security headers are applied to all responses, including the rejected
+	if server.securityHeaders != nil {
+		cfg := *server.securityHeaders
+		layers.headers = append(layers.headers, func(h http.Handler) http.Handler {
+			return xhttp.NewSecurityHeaders(h, cfg)
+		})
+	}
+
+	// the requests with too many header fields are rejected before the request is routed
+	maxHeaderCount := server.httpConfig.GetMaxHeaderCount()
+	if maxHeaderCount <= 0 {
+		maxHeaderCount = DefaultMaxHeaderCount
+	}
+	layers.limits = append(layers.limits, func(h http.Handler) http.Handler {
+		headerLimiter := xhttp.NewMaxHeaderCount(h, maxHeaderCount)
+		if server.rejectAuditor != nil {
+			headerLimiter.WithAuditor(server.rejectAuditor)
 		}
+		return headerLimiter
+	})
+
+	// the long URIs are rejected before the request is routed
+	maxURILength := server.httpConfig.GetMaxURILength()
+	if maxURILength <= 0 {
+		maxURILength = DefaultMaxURILength
+	}
+	layers.limits = append(layers.limits, func(h http.Handler) http.Handler {
+		return xhttp.NewMaxURILength(h, maxURILength)
+	})
+
+	// the large bodies are rejected, or limited when streamed
+	if maxBodyBytes := server.httpConfig.GetMaxBodyBytes(); maxBodyBytes > 0 {
+		layers.limits = append(layers.limits, func(h http.Handler) http.Handler {
+			return xhttp.NewMaxBodySize(h, maxBodyBytes)
+		})
 	}
 
-	// logging wrapper
-	httpHandler = xhttp.NewRequestLogger(httpHandler, server.Name(), serverExtraLogger, time.Millisecond, server.httpConfig.GetPackageLogger())
+	// the path is normalized before the routes, policies and probes are matched
+	if server.slashPolicy != xhttp.TrailingSlashKeep {
+		layers.routing = append(layers.routing, func(h http.Handler) http.Handler {
+			return xhttp.NewTrailingSlash(h, server.slashPolicy).
+				WithExclude(server.slashExclude...)
+		})
+	}
 
-	// metrics wrapper
-	httpHandler = xhttp.NewRequestMetrics(httpHandler)
+	// the route is matched once for the per-route handlers
+	layers.routing = append(layers.routing, func(h http.Handler) http.Handler {
+		return newRouteMatcher(routes, h)
+	})
+
+	// the routes requiring Content-Length are validated before the decompression,
+	// which removes the header
+	layers.routing = append(layers.routing, func(h http.Handler) http.Handler {
+		return newContentLengthHandler(routeContentLengths(routes, services), h)
+	})
+
+	// the compressed bodies are decoded with the limit on the decompressed size
+	if maxDecompressed := server.httpConfig.GetMaxDecompressedBodyBytes(); maxDecompressed > 0 {
+		layers.routing = append(layers.routing, func(h http.Handler) http.Handler {
+			return xhttp.NewRequestDecompressor(h, maxDecompressed)
+		})
+	}
+
+	// the responses are encoded with the server default of the empty fields
+	if server.emptyFields != marshal.OmitEmptyFields {
+		mode := server.emptyFields
+		layers.routing = append(layers.routing, func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				h.ServeHTTP(w, r.WithContext(marshal.WithEmptyFields(r.Context(), mode)))
+			})
+		})
+	}
+
+	// the readiness and startup end-points are served regardless of the readiness
+	probes := map[string]http.Handler{
+		ready.URIReadyz:   ready.NewStatusHandler(server),
+		ready.URIStartupz: ready.NewStartupHandler(server),
+	}
+	layers.availability = append(layers.availability, func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if probe, ok := probes[r.URL.Path]; ok && r.Method == http.MethodGet {
+				probe.ServeHTTP(w, r)
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	})
+
+	notReady := server.notReady
+	if notReady == nil {
+		notReady = ready.DefaultNotReadyResponse()
+	}
+	// while draining, the new requests are rejected, except the always available paths
+	if server.drainReject {
+		layers.availability = append(layers.availability, func(h http.Handler) http.Handler {
+			return server.newDrainHandler(notReady, h)
+		})
+	}
 
 	// service ready
-	httpHandler = ready.NewServiceStatusVerifier(server, httpHandler)
+	layers.availability = append(layers.availability, func(h http.Handler) http.Handler {
+		return ready.NewServiceStatusVerifierWithResponse(server, h, notReady)
+	})
+
+	// the limiter is applied before logging and metrics,
+	// to reject the requests with minimal overhead
+	if server.maxInFlight > 0 {
+		layers.availability = append(layers.availability, func(h http.Handler) http.Handler {
+			limiter := xhttp.NewConcurrencyLimiter(h, server.maxInFlight).
+				WithStreamingExempt(server.exemptStreaming)
+			if server.rejectAuditor != nil {
+				limiter.WithAuditor(server.rejectAuditor)
+			}
+			return limiter
+		})
+	}
 
-	// role/contextID wrapper
-	httpHandler = identity.NewContextHandler(httpHandler)
-	return httpHandler
+	// metrics wrapper
+	layers.logging = append(layers.logging, xhttp.NewRequestMetrics)
+
+	// logging wrapper
+	extraLogger := serverExtraLogger
+	if server.headerLogger != nil {
+		extraLogger = server.headerLogger.Extractor(serverExtraLogger)
+	}
+	if server.logConnID {
+		extraLogger = xhttp.ConnectionLogExtractor(extraLogger)
+	}
+	layers.logging = append(layers.logging, func(h http.Handler) http.Handler {
+		if server.accessLogger != nil {
+			return xhttp.NewRequestLoggerWithLogger(h, server.Name(), extraLogger, time.Millisecond, server.accessLogger)
+		}
+		return xhttp.NewRequestLogger(h, server.Name(), extraLogger, time.Millisecond, server.httpConfig.GetPackageLogger())
+	})
+
+	// the audited routes are recorded on completion, including the denied requests
+	layers.authz = append(layers.authz, func(h http.Handler) http.Handler {
+		return server.newRouteAuditHandler(routeAudits(routes, services), h)
+	})
+
+	logger.KV(xlog.INFO, "api", "NewMux", "service", server.Name(), "ClientAuth", server.clientAuth)
+
+	// the routes with a policy are authorized by the policy,
+	// other routes by the Authz provider
+	var authzErr error
+	layers.authz = append(layers.authz, func(h http.Handler) http.Handler {
+		authzHandler := h
+		if server.authz != nil {
+			if az, ok := server.authz.(AuditableAuthz); ok {
+				az.SetAuditor(server)
+			}
+			authzHandler, authzErr = server.authz.NewHandler(h)
+			if authzErr != nil {
+				return h
+			}
+		}
+		return server.newRoutePolicyHandler(routePolicies(routes, services), h, authzHandler)
+	})
+
+	if server.requestTimeout > 0 || len(server.routeTimeouts) > 0 || server.clientTimeout > 0 {
+		layers.routes = append(layers.routes, func(h http.Handler) http.Handler {
+			timeout := xhttp.NewTimeout(h, server.requestTimeout).
+				WithClientTimeout(server.clientTimeout)
+			for prefix, d := range server.routeTimeouts {
+				timeout.WithRoute(prefix, d)
+			}
+			return timeout
+		})
+	}
+
+	layers.routes = append(layers.routes,
+		// the cached responses are replied before the identical requests are coalesced
+		func(h http.Handler) http.Handler {
+			return newResponseCacheHandler(routeCaches(routes, services), server.cacheStore, h)
+		},
+		// the identical requests are coalesced after they are authorized
+		func(h http.Handler) http.Handler {
+			return newSingleFlightHandler(routeSingleFlights(routes, services), h)
+		},
+		// the requests missing the required headers are rejected before the body is validated
+		func(h http.Handler) http.Handler {
+			return newRequiredHeadersHandler(routeRequiredHeaders(routes, services), h)
+		},
+		// the requests with unsupported content type are rejected before the handler
+		func(h http.Handler) http.Handler {
+			return newContentTypeHandler(routeContentTypes(routes, services), h)
+		},
+		// the requests not matching the schema are rejected before the handler,
+		// but after the content type is validated
+		func(h http.Handler) http.Handler {
+			return newSchemaHandler(routeSchemas(routes, services), server.validateResp, h)
+		},
+		// the services with a concurrency limit are isolated from each other
+		func(h http.Handler) http.Handler {
+			return server.newBulkheadHandler(services, h)
+		},
+	)
+
+	httpHandler := layers.compose(router.Handler())
+	if authzErr != nil {
+		return nil, errors.Annotate(authzErr, "unable to create Authz handler")
+	}
+	return httpHandler, nil
 }
 
 // ServeHTTP should write reply headers and data to the ResponseWriter
@@ -507,8 +1864,17 @@ func notFoundHandler(w http.ResponseWriter, r *http.Request) {
 	marshal.WriteJSON(w, r, httperror.WithNotFound(r.URL.Path))
 }
 
+func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	marshal.WriteJSON(w, r, httperror.WithMethodNotAllowed("%s %s", r.Method, r.URL.Path))
+}
+
 func serverExtraLogger(resp *xhttp.ResponseCapture, req *http.Request) []string {
-	return []string{identity.ForRequest(req).CorrelationID()}
+	ctx := identity.ForRequest(req)
+	fields := []string{ctx.CorrelationID()}
+	if tenant := ctx.Tenant(); tenant != "" {
+		fields = append(fields, "tenant="+tenant)
+	}
+	return fields
 }
 
 // GetServerURL returns complete server URL for given relative end-point
@@ -1,28 +1,45 @@
 package rest_test
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/go-phorce/dolly/audit"
+	"github.com/go-phorce/dolly/clock"
 	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/netutil"
 	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/ready"
 	"github.com/go-phorce/dolly/rest/tlsconfig"
+	"github.com/go-phorce/dolly/tasks"
+	"github.com/go-phorce/dolly/testify"
 	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/go-phorce/dolly/xhttp"
 	"github.com/go-phorce/dolly/xhttp/authz"
 	"github.com/go-phorce/dolly/xhttp/header"
 	"github.com/go-phorce/dolly/xhttp/identity"
 	"github.com/go-phorce/dolly/xhttp/marshal"
+	"github.com/go-phorce/dolly/xlog"
 	"github.com/juju/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -245,6 +262,813 @@ func Test_NewServerWithGracefulShutdownSet(t *testing.T) {
 	require.NotNil(t, e)
 }
 
+func Test_ServerContext(t *testing.T) {
+	cfg := &serverConfig{
+		BindAddr: ":8083",
+	}
+
+	server, err := rest.New("v1.0.123", "", cfg, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory())
+
+	ctx := server.Context()
+	require.NotNil(t, ctx)
+	assert.NoError(t, ctx.Err())
+
+	svc := &ctxService{server: server}
+	server.AddService(svc)
+
+	err = server.StartHTTP()
+	require.NoError(t, err)
+
+	server.StopHTTP()
+	assert.Equal(t, context.Canceled, ctx.Err())
+	assert.Equal(t, context.Canceled, svc.errOnClose, "the context must be canceled before Close")
+}
+
+type ctxService struct {
+	server     rest.Server
+	errOnClose error
+}
+
+func (s *ctxService) Name() string           { return "ctxService" }
+func (s *ctxService) IsReady() bool          { return true }
+func (s *ctxService) Register(r rest.Router) {}
+func (s *ctxService) Close() {
+	s.errOnClose = s.server.Context().Err()
+}
+
+func Test_ServerRebuild(t *testing.T) {
+	cfg := &serverConfig{
+		BindAddr: ":8084",
+	}
+
+	server, err := rest.New("v1.0.123", "", cfg, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory()).
+		WithRebuildDelay(50 * time.Millisecond)
+
+	factory := &countingMuxer{server: server}
+	server.WithMuxFactory(factory)
+
+	err = server.StartHTTP()
+	require.NoError(t, err)
+	defer server.StopHTTP()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&factory.count))
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, testURL, nil)
+		require.NoError(t, err)
+		server.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	for i := 0; i < 10 && !server.IsReady(); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, http.StatusNotFound, serve())
+
+	// multiple changes are applied by a single rebuild
+	server.AddService(NewService(server))
+	server.AddService(&ctxService{server: server})
+	server.RemoveService("ctxService")
+	assert.Equal(t, http.StatusNotFound, serve())
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&factory.count))
+	assert.Equal(t, http.StatusOK, serve())
+
+	server.RemoveService("testService")
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&factory.count))
+	assert.Equal(t, http.StatusNotFound, serve())
+
+	// not registered service does not trigger rebuild
+	server.RemoveService("testService")
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&factory.count))
+}
+
+func Test_ServerReload(t *testing.T) {
+	pemCert, pemKey, err := testify.MakeSelfCertRSAPem(1)
+	require.NoError(t, err)
+
+	tmpDir := filepath.Join(os.TempDir(), "tests", "rest")
+	require.NoError(t, os.MkdirAll(tmpDir, os.ModePerm))
+	pemFile := filepath.Join(tmpDir, "Reload.pem")
+	keyFile := filepath.Join(tmpDir, "Reload-key.pem")
+	require.NoError(t, ioutil.WriteFile(pemFile, pemCert, os.ModePerm))
+	require.NoError(t, ioutil.WriteFile(keyFile, pemKey, os.ModePerm))
+
+	reloader, err := tlsconfig.NewKeypairReloader(pemFile, keyFile, time.Hour)
+	require.NoError(t, err)
+	defer reloader.Close()
+
+	au := auditor.NewInMemory()
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8098"}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(au).WithKeypairReloader(reloader)
+
+	factory := &countingMuxer{server: server}
+	server.WithMuxFactory(factory)
+
+	var reloading int32
+	server.OnEvent(rest.ServerReloadingEvent, func(evt rest.ServerEvent) {
+		atomic.AddInt32(&reloading, 1)
+	})
+
+	// the handler is not rebuilt before the start
+	require.NoError(t, server.Reload())
+	assert.Equal(t, int32(0), atomic.LoadInt32(&factory.count))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reloading))
+	assert.Equal(t, uint32(2), reloader.LoadedCount())
+
+	require.NoError(t, server.StartHTTP())
+	defer server.StopHTTP()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&factory.count))
+
+	require.NoError(t, server.Reload())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&factory.count))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&reloading))
+	assert.Equal(t, uint32(3), reloader.LoadedCount())
+
+	// the handler is rebuilt with the previous key pair
+	require.NoError(t, os.Remove(keyFile))
+	err = server.Reload()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to reload TLS key pair")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&factory.count))
+	assert.NotNil(t, reloader.Keypair())
+
+	e := au.Find(rest.EvtSourceStatus, rest.EvtServiceReloaded)
+	require.NotNil(t, e)
+	assert.Equal(t, server.AuditIdentity(), e.Identity)
+}
+
+type countingMuxer struct {
+	server *rest.HTTPServer
+	count  int32
+}
+
+func (m *countingMuxer) NewMux() (http.Handler, error) {
+	atomic.AddInt32(&m.count, 1)
+	return m.server.NewMux()
+}
+
+func Test_ServerProxyProtocol(t *testing.T) {
+	cfg := &serverConfig{
+		BindAddr:            ":8087",
+		ProxyProtocol:       true,
+		ProxyProtocolStrict: true,
+	}
+
+	server, err := rest.New("v1.0.123", "", cfg, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory())
+	server.WithMuxFactory(muxer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.RemoteAddr))
+	})))
+
+	err = server.StartHTTP()
+	require.NoError(t, err)
+	defer server.StopHTTP()
+
+	c, err := net.Dial("tcp", "localhost:8087")
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Write([]byte("PROXY TCP4 192.168.1.10 10.0.0.1 56324 8087\r\n" +
+		"GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"))
+	require.NoError(t, err)
+
+	res, err := ioutil.ReadAll(c)
+	require.NoError(t, err)
+	assert.Contains(t, string(res), "\r\n\r\n192.168.1.10:56324")
+}
+
+type orderedService struct {
+	name  string
+	order *[]string
+}
+
+func (s *orderedService) Name() string  { return s.name }
+func (s *orderedService) IsReady() bool { return true }
+func (s *orderedService) Close()        {}
+func (s *orderedService) Register(r rest.Router) {
+	*s.order = append(*s.order, s.name)
+	r.GET("/v1/"+s.name, func(http.ResponseWriter, *http.Request, rest.Params) {})
+}
+
+func Test_ServerServicesOrder(t *testing.T) {
+	server, err := rest.New("v1.0.123", "", &serverConfig{}, nil)
+	require.NoError(t, err)
+
+	var order []string
+	for _, name := range []string{"static", "api2", "api1", "proxy"} {
+		server.AddService(&orderedService{name: name, order: &order})
+	}
+	// static files are the fallback, registers last
+	server.AddOrReplaceService(&orderedService{name: "static", order: &order}, -10)
+	server.AddService(&orderedService{name: "health", order: &order}, 10)
+
+	for i := 0; i < 3; i++ {
+		order = nil
+		server.MustNewMux()
+		assert.Equal(t, []string{"health", "api1", "api2", "proxy", "static"}, order)
+	}
+
+	assert.Equal(t, []rest.Route{
+		{Method: http.MethodGet, Path: "/v1/api1"},
+		{Method: http.MethodGet, Path: "/v1/api2"},
+		{Method: http.MethodGet, Path: "/v1/health"},
+		{Method: http.MethodGet, Path: "/v1/proxy"},
+		{Method: http.MethodGet, Path: "/v1/static"},
+	}, server.Routes())
+}
+
+func Test_ServerDuplicateServices(t *testing.T) {
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8092"}, nil)
+	require.NoError(t, err)
+
+	var order []string
+	svc := &orderedService{name: "api1", order: &order}
+	require.NoError(t, server.AddService(svc))
+	err = server.AddService(&orderedService{name: "api1", order: &order})
+	require.Error(t, err)
+	assert.True(t, errors.IsAlreadyExists(err))
+	assert.Equal(t, svc, server.Service("api1"))
+
+	replaced := &orderedService{name: "api1", order: &order}
+	server.AddOrReplaceService(replaced)
+	assert.Equal(t, replaced, server.Service("api1"))
+
+	// the conflict service registers the same route as api1
+	require.NoError(t, server.AddService(&conflictingService{orderedService{name: "conflict", order: &order}}))
+	err = server.StartHTTP()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `service "conflict" has conflicting route`)
+}
+
+type conflictingService struct {
+	orderedService
+}
+
+func (s *conflictingService) Register(r rest.Router) {
+	r.GET("/v1/api1", func(http.ResponseWriter, *http.Request, rest.Params) {})
+}
+
+func Test_ServerUptimeWithClock(t *testing.T) {
+	started := time.Date(2020, time.May, 1, 10, 0, 0, 0, time.UTC)
+	mock := clock.NewMock(started)
+
+	server, err := rest.New("v1.0.123", "", &serverConfig{}, nil)
+	require.NoError(t, err)
+	server.WithClock(mock)
+
+	assert.Equal(t, started, server.StartedAt())
+	assert.Equal(t, time.Duration(0), server.Uptime())
+
+	mock.Add(90 * time.Minute)
+	assert.Equal(t, started, server.StartedAt())
+	assert.Equal(t, 90*time.Minute, server.Uptime())
+}
+
+func Test_ServerAuditHeartbeat(t *testing.T) {
+	mock := clock.NewMock(time.Now())
+	tasks.SetGlobalClock(mock)
+	defer tasks.SetGlobalClock(clock.New())
+
+	cfg := &serverConfig{
+		BindAddr:           ":8089",
+		AuditHeartbeatSecs: 60,
+	}
+	scheduler := tasks.NewScheduler()
+	au := auditor.NewInMemory()
+	server, err := rest.New("v1.0.123", "", cfg, nil)
+	require.NoError(t, err)
+	server.WithAuditor(au).WithScheduler(scheduler)
+
+	require.NoError(t, server.StartHTTP())
+	defer server.StopHTTP()
+	require.NoError(t, scheduler.Start())
+	defer scheduler.Stop()
+
+	require.Len(t, scheduler.Tasks(), 1)
+	assert.Nil(t, au.Find(rest.EvtSourceStatus, rest.EvtHeartbeat))
+
+	for mock.Waiters() == 0 {
+		runtime.Gosched()
+	}
+	mock.Add(61 * time.Second)
+
+	var e *auditor.Event
+	for i := 0; i < 100 && e == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+		e = au.Find(rest.EvtSourceStatus, rest.EvtHeartbeat)
+	}
+	require.NotNil(t, e)
+	assert.Contains(t, e.Message, "uptime=")
+	assert.Contains(t, e.Message, "ready=")
+}
+
+func Test_ServerAuditIdentityHasher(t *testing.T) {
+	au := auditor.NewInMemory()
+	server, err := rest.New("v1.0.123", "", &serverConfig{}, nil)
+	require.NoError(t, err)
+	hasher := audit.NewIdentityHasher([]byte("secret"), "http")
+	server.WithAuditor(au).WithAuditIdentityHasher(hasher)
+
+	server.Audit("http", "request", "client/bob", "ctx1", 0, "msg")
+	server.Audit("authz", "denied", "client/bob", "ctx2", 0, "msg")
+
+	e := au.Find("http", "request")
+	require.NotNil(t, e)
+	assert.Equal(t, hasher.Identity("http", "client/bob"), e.Identity)
+	assert.NotEqual(t, "client/bob", e.Identity)
+
+	e = au.Find("authz", "denied")
+	require.NotNil(t, e)
+	assert.Equal(t, "client/bob", e.Identity)
+}
+
+// flushAuditor records the number of the events at every Flush
+type flushAuditor struct {
+	*auditor.InMemory
+	flushed []int
+}
+
+func (a *flushAuditor) Flush() error {
+	a.flushed = append(a.flushed, a.Len())
+	return nil
+}
+
+func Test_ServerAuditFlush(t *testing.T) {
+	au := &flushAuditor{InMemory: auditor.NewInMemory()}
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8095"}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(au)
+
+	err = server.StartHTTP()
+	require.NoError(t, err)
+	require.Len(t, au.flushed, 1)
+	assert.Equal(t, au.Len(), au.flushed[0])
+
+	server.StopHTTP()
+	require.Len(t, au.flushed, 2)
+	assert.Equal(t, au.Len(), au.flushed[1])
+	assert.NotNil(t, au.Find(rest.EvtSourceStatus, rest.EvtServiceStopped))
+}
+
+func Test_ServerAuditIdentity(t *testing.T) {
+	au := auditor.NewInMemory()
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8097"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, server.HostName(), server.AuditIdentity())
+
+	server.WithAuditor(au).WithAuditIdentity("node1/svc-account")
+	assert.Equal(t, "node1/svc-account", server.AuditIdentity())
+
+	require.NoError(t, server.StartHTTP())
+	server.StopHTTP()
+
+	e := au.Find(rest.EvtSourceStatus, rest.EvtServiceStarted)
+	require.NotNil(t, e)
+	assert.Equal(t, "node1/svc-account", e.Identity)
+	e = au.Find(rest.EvtSourceStatus, rest.EvtServiceStopped)
+	require.NotNil(t, e)
+	assert.Equal(t, "node1/svc-account", e.Identity)
+}
+
+func Test_ServerAdvertiseIP(t *testing.T) {
+	cfg := &serverConfig{
+		AdvertiseIP: "10.1.2.3",
+	}
+
+	server, err := rest.New("v1.0.123", "", cfg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "10.1.2.3", server.LocalIP())
+
+	server, err = rest.New("v1.0.123", "10.3.2.1", cfg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "10.3.2.1", server.LocalIP())
+}
+
+func Test_ServerReadyz(t *testing.T) {
+	checked := time.Date(2020, time.May, 1, 10, 0, 0, 0, time.UTC)
+	server, err := rest.New("v1.0.123", "", &serverConfig{}, nil)
+	require.NoError(t, err)
+	server.WithReadyCache(time.Minute, 0).WithClock(clock.NewMock(checked))
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest(http.MethodGet, ready.URIReadyz, nil)
+	require.NoError(t, err)
+	server.MustNewMux().ServeHTTP(w, r)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, `{"checked_at":"2020-05-01T10:00:00Z","ready":false,"reason":"server is not serving"}`, w.Body.String())
+	assert.False(t, server.IsReady())
+	assert.Equal(t, checked, server.ReadyStatus().CheckedAt)
+}
+
+func Test_ServerNotReadyResponse(t *testing.T) {
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8094"}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory()).
+		WithNotReadyResponse(&ready.NotReadyResponse{
+			Header: http.Header{header.RetryAfter: []string{"10"}},
+			Body: func(_ *http.Request, status ready.Status) interface{} {
+				return map[string]string{"reason": status.Reason}
+			},
+		})
+	server.AddService(newService(t, server, "notready", false))
+	require.NoError(t, server.StartHTTP())
+	defer server.StopHTTP()
+	for i := 0; i < 100 && server.ReadyStatus().Reason == "server is not serving"; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest(http.MethodGet, "/v1/allowany", nil)
+	require.NoError(t, err)
+	server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "10", w.Header().Get(header.RetryAfter))
+	assert.Equal(t, `{"reason":"service \"notready\" is not ready"}`, w.Body.String())
+}
+
+type dependencyService struct {
+	rest.Service
+	deps []ready.Dependency
+}
+
+func (s *dependencyService) Dependencies() []ready.Dependency {
+	return s.deps
+}
+
+func Test_ServerDependencies(t *testing.T) {
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8096"}, nil)
+	require.NoError(t, err)
+
+	var dbUp int32
+	server.WithAuditor(auditor.NewInMemory()).
+		WithDependency(ready.Dependency{
+			Name: "cache",
+			Check: func(context.Context) error {
+				return errors.New("no route to host")
+			},
+		})
+	server.AddService(&dependencyService{
+		Service: newService(t, server, "deps", true),
+		deps: []ready.Dependency{
+			{
+				Name:     "db",
+				Critical: true,
+				CacheTTL: time.Millisecond,
+				Check: func(context.Context) error {
+					if atomic.LoadInt32(&dbUp) == 0 {
+						return errors.New("connection refused")
+					}
+					return nil
+				},
+			},
+		},
+	})
+	require.NoError(t, server.StartHTTP())
+	defer server.StopHTTP()
+	for i := 0; i < 100 && server.ReadyStatus().Reason == "server is not serving"; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	readyz := func() (int, ready.Status) {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, ready.URIReadyz, nil)
+		require.NoError(t, err)
+		server.ServeHTTP(w, r)
+
+		var status ready.Status
+		require.NoError(t, marshal.DecodeBytes(w.Body.Bytes(), &status))
+		return w.Code, status
+	}
+
+	code, status := readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, status.Ready)
+	assert.Equal(t, `dependency "db" is not healthy`, status.Reason)
+	require.Len(t, status.Dependencies, 2)
+	assert.Equal(t, "cache", status.Dependencies[0].Name)
+	assert.False(t, status.Dependencies[0].Critical)
+	assert.Equal(t, "no route to host", status.Dependencies[0].Error)
+	assert.Equal(t, "db", status.Dependencies[1].Name)
+	assert.True(t, status.Dependencies[1].Critical)
+	assert.Equal(t, "connection refused", status.Dependencies[1].Error)
+
+	// the failing non-critical dependency does not fail the readiness
+	atomic.StoreInt32(&dbUp, 1)
+	time.Sleep(10 * time.Millisecond)
+	code, status = readyz()
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, status.Ready)
+	require.Len(t, status.Dependencies, 2)
+	assert.False(t, status.Dependencies[0].Healthy)
+	assert.True(t, status.Dependencies[1].Healthy)
+}
+
+func Test_ServerHasStarted(t *testing.T) {
+	cfg := &serverConfig{
+		BindAddr: ":8091",
+	}
+	server, err := rest.New("v1.0.123", "", cfg, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory())
+
+	svc := newService(t, server, "started", false)
+	server.AddService(svc)
+	require.NoError(t, server.StartHTTP())
+	defer server.StopHTTP()
+
+	probe := func(path string) int {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		server.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.False(t, server.HasStarted())
+	assert.Equal(t, http.StatusServiceUnavailable, probe(ready.URIStartupz))
+
+	svc.setReady()
+	for i := 0; i < 10 && !server.IsReady(); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.True(t, server.HasStarted())
+	assert.Equal(t, http.StatusOK, probe(ready.URIStartupz))
+	assert.Equal(t, http.StatusOK, probe(ready.URIReadyz))
+
+	// transient not ready does not fail the startup probe
+	svc.ready = false
+	assert.False(t, server.IsReady())
+	assert.True(t, server.HasStarted())
+	assert.Equal(t, http.StatusOK, probe(ready.URIStartupz))
+	assert.Equal(t, http.StatusServiceUnavailable, probe(ready.URIReadyz))
+	assert.Equal(t, http.StatusServiceUnavailable, probe("/v1/allow"))
+}
+
+func Test_ServerMaxHeaderBytes(t *testing.T) {
+	cfg := &serverConfig{
+		BindAddr:       ":8093",
+		MaxHeaderBytes: 1024,
+	}
+	server, err := rest.New("v1.0.123", "", cfg, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory())
+	server.AddService(newService(t, server, "headers", true))
+	require.NoError(t, server.StartHTTP())
+	defer server.StopHTTP()
+
+	// http.Server allows additional 4096 bytes over the limit
+	r, err := http.NewRequest(http.MethodGet, "http://localhost:8093/v1/allow", nil)
+	require.NoError(t, err)
+	r.Header.Set("X-Large", strings.Repeat("a", 8*1024))
+	resp, err := http.DefaultClient.Do(r)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+}
+
+func Test_ServerMaxURILength(t *testing.T) {
+	server, err := rest.New("v1.0.123", "", &serverConfig{MaxURILength: 64}, nil)
+	require.NoError(t, err)
+	handler := server.MustNewMux()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/"+strings.Repeat("a", 64), nil)
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestURITooLong, w.Code)
+	// the limits are applied inside the context
+	assert.NotEmpty(t, w.Header().Get(header.XCorrelationID))
+
+	// the request within the limit is passed to the readiness check
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/v1/"+strings.Repeat("a", 32), nil)
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func Test_ServerMaxHeaderCount(t *testing.T) {
+	server, err := rest.New("v1.0.123", "", &serverConfig{MaxHeaderCount: 4}, nil)
+	require.NoError(t, err)
+	handler := server.MustNewMux()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/status", nil)
+	for i := 0; i < 5; i++ {
+		r.Header.Add("X-Test", "value")
+	}
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, w.Code)
+
+	// the request within the limit is passed to the readiness check
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/v1/status", nil)
+	r.Header.Add("X-Test", "value")
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func Test_ServerMissingHost(t *testing.T) {
+	server, err := rest.New("v1.0.123", "", &serverConfig{}, nil)
+	require.NoError(t, err)
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/v1/status", nil)
+		r.Proto, r.ProtoMajor, r.ProtoMinor = "HTTP/1.0", 1, 0
+		r.Host = ""
+		return r
+	}
+
+	// by default the request is passed to the readiness check
+	w := httptest.NewRecorder()
+	server.MustNewMux().ServeHTTP(w, newRequest())
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	server.WithMissingHostPolicy(xhttp.MissingHostReject, "")
+	w = httptest.NewRecorder()
+	server.MustNewMux().ServeHTTP(w, newRequest())
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	server.WithMissingHostPolicy(xhttp.MissingHostDefault, "")
+	w = httptest.NewRecorder()
+	server.MustNewMux().ServeHTTP(w, newRequest())
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func Test_ServerSecurityHeaders(t *testing.T) {
+	server, err := rest.New("v1.0.123", "", &serverConfig{MaxURILength: 64}, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/status", nil)
+	server.MustNewMux().ServeHTTP(w, r)
+	assert.Empty(t, w.Header().Get(header.XContentTypeOptions))
+
+	server.WithSecurityHeaders(xhttp.DefaultSecurityHeadersConfig())
+	handler := server.MustNewMux()
+
+	// the rejected requests have the headers
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/v1/"+strings.Repeat("a", 64), nil)
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestURITooLong, w.Code)
+	assert.Equal(t, "nosniff", w.Header().Get(header.XContentTypeOptions))
+	assert.Equal(t, "DENY", w.Header().Get(header.XFrameOptions))
+	assert.Empty(t, w.Header().Get(header.StrictTransportSecurity))
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "https://localhost/v1/status", nil)
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, "max-age=31536000", w.Header().Get(header.StrictTransportSecurity))
+}
+
+func Test_ServerTrailingSlashPolicy(t *testing.T) {
+	server, err := rest.New("v1.0.123", "", &serverConfig{}, nil)
+	require.NoError(t, err)
+
+	// the path is kept by default, and not matched to the probe
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, ready.URIReadyz+"/", nil)
+	server.MustNewMux().ServeHTTP(w, r)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotContains(t, w.Body.String(), "checked_at")
+
+	server.WithTrailingSlashPolicy(xhttp.TrailingSlashStrip)
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, ready.URIReadyz+"/", nil)
+	server.MustNewMux().ServeHTTP(w, r)
+	assert.Contains(t, w.Body.String(), "checked_at")
+
+	server.WithTrailingSlashPolicy(xhttp.TrailingSlashRedirect, ready.URIReadyz+"/")
+	handler := server.MustNewMux()
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/v1/items/?q=1", nil)
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "/v1/items?q=1", w.Header().Get(header.Location))
+
+	// the excluded path is not redirected
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, ready.URIReadyz+"/", nil)
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func Test_ServerStartAddrInUse(t *testing.T) {
+	cfg := &serverConfig{
+		BindAddr: ":8090",
+	}
+
+	server1, err := rest.New("v1.0.123", "", cfg, nil)
+	require.NoError(t, err)
+	server1.WithAuditor(auditor.NewInMemory())
+	require.NoError(t, server1.StartHTTP())
+	defer server1.StopHTTP()
+
+	server2, err := rest.New("v1.0.123", "", cfg, nil)
+	require.NoError(t, err)
+	server2.WithAuditor(auditor.NewInMemory())
+	err = server2.StartHTTP()
+	require.Error(t, err)
+	assert.True(t, netutil.IsAddrInUse(errors.Cause(err)), "unexpected error: %v", err)
+}
+
+func Test_ServerStartWithListener(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	var order []string
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8443"}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory())
+	server.AddService(&orderedService{name: "api1", order: &order})
+	require.NoError(t, server.StartHTTPWithListener(listener))
+	assert.Equal(t, port, server.Port())
+	assert.Equal(t, "127.0.0.1", server.HostName())
+
+	resp, err := http.Get("http://127.0.0.1:" + port + "/v1/api1")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	server.StopHTTP()
+	_, err = http.Get("http://127.0.0.1:" + port + "/v1/api1")
+	assert.Error(t, err)
+}
+
+func Test_ServerNotFoundHandlers(t *testing.T) {
+	cfg := &serverConfig{
+		ServiceName: "dolly-test",
+		BindAddr:    ":8088",
+	}
+
+	serve := func(server *rest.HTTPServer, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(method, path, nil)
+		require.NoError(t, err)
+		server.ServeHTTP(w, r)
+		return w
+	}
+
+	start := func(server *rest.HTTPServer) {
+		server.WithAuditor(auditor.NewInMemory())
+		server.AddService(NewService(server))
+		require.NoError(t, server.StartHTTP())
+		for i := 0; i < 10 && !server.IsReady(); i++ {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		server, err := rest.New("v1.0.123", "", cfg, nil)
+		require.NoError(t, err)
+		start(server)
+		defer server.StopHTTP()
+
+		w := serve(server, http.MethodGet, "/notfound")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, `{"code":"not_found","message":"/notfound"}`, w.Body.String())
+
+		w = serve(server, http.MethodDelete, testURL)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, `{"code":"method_not_allowed","message":"DELETE /v1/test"}`, w.Body.String())
+		assert.Equal(t, "dolly-test", w.Header().Get(header.Server))
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		server, err := rest.New("v1.0.123", "", cfg, nil)
+		require.NoError(t, err)
+		server.WithNotFoundHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(header.ContentType, "text/html")
+			w.Write([]byte("<html>index</html>"))
+		})).WithMethodNotAllowedHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			w.Write([]byte("not allowed"))
+		})).WithServerHeader("")
+		start(server)
+		defer server.StopHTTP()
+
+		w := serve(server, http.MethodGet, "/app/page")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/html", w.Header().Get(header.ContentType))
+		assert.Equal(t, "<html>index</html>", w.Body.String())
+
+		w = serve(server, http.MethodDelete, testURL)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "not allowed", w.Body.String())
+		assert.Empty(t, w.Header()[header.Server])
+	})
+}
+
 func Test_NewServerWithCustomHandler(t *testing.T) {
 	cfg := &serverConfig{
 		BindAddr: ":8082",
@@ -257,7 +1081,7 @@ func Test_NewServerWithCustomHandler(t *testing.T) {
 	svc := NewService(server)
 	server.AddService(svc)
 
-	defaultHandler := server.NewMux()
+	defaultHandler := server.MustNewMux()
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := context.WithValue(r.Context(), "test", "value in context")
 		r = r.WithContext(ctx)
@@ -314,6 +1138,227 @@ func Test_TLSConfig(t *testing.T) {
 	assert.Equal(t, tlsConfig, server.TLSConfig())
 }
 
+func Test_ServerDrain(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8443"}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory())
+	assert.Error(t, server.Drain(time.Second))
+
+	var progressLock sync.Mutex
+	var progress []int
+	server.WithShutdownProgress(10*time.Millisecond, func(inFlight int) {
+		progressLock.Lock()
+		defer progressLock.Unlock()
+		progress = append(progress, inFlight)
+	})
+
+	server.AddService(rest.HandlerService("slow", "/v1/slow", h))
+	require.NoError(t, server.StartHTTPWithListener(listener))
+	defer server.StopHTTP()
+
+	resc := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/v1/slow")
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+		resc <- resp
+	}()
+	<-started
+
+	drained := make(chan error, 1)
+	go func() {
+		drained <- server.Drain(5 * time.Second)
+	}()
+
+	// new connections are not accepted while draining
+	assert.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return true
+		}
+		conn.Close()
+		return false
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// the in-flight request is reported while draining
+	assert.Eventually(t, func() bool {
+		progressLock.Lock()
+		defer progressLock.Unlock()
+		return len(progress) > 0 && progress[0] == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	close(release)
+	require.NoError(t, <-drained)
+
+	progressLock.Lock()
+	assert.Equal(t, 0, progress[len(progress)-1], "the completed drain must be reported")
+	progressLock.Unlock()
+
+	resp := <-resc
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, resp.Close, "in-flight response must close the connection")
+}
+
+func Test_ServerStartDraining(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8443"}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory())
+	server.AddService(rest.HandlerService("ok", "/v1/ok", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	require.NoError(t, server.StartHTTPWithListener(listener))
+	defer server.StopHTTP()
+
+	assert.Eventually(t, server.IsReady, time.Second, 10*time.Millisecond)
+	assert.False(t, server.IsDraining())
+
+	server.StartDraining()
+	assert.True(t, server.IsDraining())
+	status := server.ReadyStatus()
+	assert.False(t, status.Ready)
+	assert.Equal(t, "server is draining", status.Reason)
+	assert.Equal(t, 0, server.InFlight())
+
+	// the server is still serving, but closes the connections
+	resp, err := http.Get("http://" + addr + "/v1/ok")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, resp.Close, "the response must close the connection")
+}
+
+func Test_ServerConnectionIDLogging(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8443"}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory()).WithConnectionIDLogging(true)
+	server.AddService(rest.HandlerService("conn", "/v1/conn", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(xhttp.ConnectionIDFromRequest(r)))
+	})))
+	require.NoError(t, server.StartHTTPWithListener(listener))
+	defer server.StopHTTP()
+	assert.Eventually(t, server.IsReady, time.Second, 10*time.Millisecond)
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	xlog.SetFormatter(xlog.NewPrettyFormatter(writer, false))
+	defer xlog.SetFormatter(xlog.NewPrettyFormatter(os.Stderr, true))
+
+	get := func() string {
+		resp, err := http.Get("http://" + addr + "/v1/conn")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	id := get()
+	require.NotEmpty(t, id)
+	assert.Equal(t, id, get())
+
+	writer.Flush()
+	assert.Equal(t, 2, strings.Count(buf.String(), ":conn="+id+"\n"), buf.String())
+}
+
+func Test_ServerRequestCapture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capture")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8443", ProfilerDir: dir}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory()).
+		WithHeaderLogger(xhttp.NewHeaderLogger(nil, nil).WithRedacted("X-Custom-Secret")).
+		WithRequestCapture("", 0)
+	server.AddService(rest.HandlerService("ok", "/v1/ok", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})))
+	require.NoError(t, server.StartHTTPWithListener(listener))
+	defer server.StopHTTP()
+	assert.Eventually(t, server.IsReady, time.Second, 10*time.Millisecond)
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+addr+"/v1/ok", nil)
+	require.NoError(t, err)
+	req.Header.Set(header.XCorrelationID, "capture1")
+	req.Header.Set(header.XCaptureRequest, "1")
+	req.Header.Set("X-Custom-Secret", "custom")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	ex, err := xhttp.LoadCapturedExchange(filepath.Join(dir, "capture_capture1.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/ok", ex.URI)
+	assert.Equal(t, "ok", string(ex.ResponseBody))
+	assert.Equal(t, xhttp.RedactedValue, ex.RequestHeader.Get("X-Custom-Secret"))
+}
+
+type failingAuthz struct{}
+
+func (a failingAuthz) SetRoleMapper(func(*http.Request) string) {}
+func (a failingAuthz) NewHandler(delegate http.Handler) (http.Handler, error) {
+	return nil, errors.New("invalid configuration")
+}
+
+func Test_ServerAuthzHandlerError(t *testing.T) {
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: "127.0.0.1:0"}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory()).WithAuthz(failingAuthz{})
+
+	_, err = server.NewMux()
+	require.Error(t, err)
+	assert.Equal(t, "unable to create Authz handler: invalid configuration", err.Error())
+	assert.Panics(t, func() { server.MustNewMux() })
+
+	err = server.StartHTTP()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid configuration")
+}
+
+func Test_TLSConfigHook(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	tlsConfig := &tls.Config{}
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8443"}, tlsConfig)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory()).
+		WithTLSConfigHook(func(cfg *tls.Config) {
+			cfg.NextProtos = []string{"h2", "http/1.1"}
+		})
+
+	require.NoError(t, server.StartHTTPWithListener(listener))
+	defer server.StopHTTP()
+
+	assert.Equal(t, []string{"h2", "http/1.1"}, server.TLSConfig().NextProtos)
+}
+
 func Test_ResolveTCPAddr(t *testing.T) {
 	cfg := &serverConfig{
 		ServiceName: "invalid",
@@ -377,8 +1422,8 @@ type testMuxer struct {
 	handler http.Handler
 }
 
-func (tm *testMuxer) NewMux() http.Handler {
-	return tm.handler
+func (tm *testMuxer) NewMux() (http.Handler, error) {
+	return tm.handler, nil
 }
 
 func muxer(handler http.Handler) *testMuxer {
@@ -468,7 +1513,8 @@ func Test_Authz(t *testing.T) {
 		assert.NotEmpty(t, w.Header().Get(header.XHostname))
 		assert.NotEmpty(t, w.Header().Get(header.XCorrelationID))
 		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
-		assert.Equal(t, `{"code":"not_ready","message":"the service is not ready yet"}`, string(w.Body.Bytes()))
+		assert.Equal(t, ready.DefaultRetryAfter, w.Header().Get(header.RetryAfter))
+		assert.Equal(t, `{"code":"not_ready","message":"the service is not ready yet","reason":"service \"authztest\" is not ready"}`, string(w.Body.Bytes()))
 	})
 
 	service.setReady()
@@ -651,3 +1697,88 @@ func (s *serviceX) handle() rest.Handle {
 		marshal.WriteJSON(w, r, res)
 	}
 }
+
+func Test_ServerDrainRejection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8443"}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory()).
+		WithDrainRejection()
+	server.AddService(rest.HandlerService("ok", "/v1/ok", ok))
+	server.AddService(rest.HandlerService("healthz", "/healthz", ok))
+	server.AddService(rest.HandlerService("version", "/version", ok))
+	require.NoError(t, server.StartHTTPWithListener(listener))
+	defer server.StopHTTP()
+
+	assert.Eventually(t, server.IsReady, time.Second, 10*time.Millisecond)
+
+	get := func(path string) int {
+		resp, err := http.Get("http://" + addr + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, get("/v1/ok"))
+	assert.Equal(t, http.StatusOK, get(ready.URIReadyz))
+
+	server.StartDraining()
+
+	// the application routes are rejected, while the probes are served
+	assert.Equal(t, http.StatusServiceUnavailable, get("/v1/ok"))
+	assert.Equal(t, http.StatusOK, get("/healthz"))
+	assert.Equal(t, http.StatusOK, get("/version"))
+	assert.Equal(t, http.StatusOK, get(ready.URIStartupz))
+	assert.Equal(t, http.StatusServiceUnavailable, get(ready.URIReadyz), "readiness must report draining")
+}
+
+func Test_ServerAccessLogFile(t *testing.T) {
+	// the file is opened when the server is started
+	server, err := rest.New("v1.0.123", "", &serverConfig{AccessLogFile: "/notfound/access.log"}, nil)
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	err = server.StartHTTPWithListener(listener)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to open access log file")
+
+	dir, err := ioutil.TempDir("", "access")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "access.log")
+	listener, err = net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+
+	// the file is not created, when the server is not started
+	_, err = rest.New("v1.0.123", "", &serverConfig{AccessLogFile: file}, nil)
+	require.NoError(t, err)
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+
+	server, err = rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8443", AccessLogFile: file}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(auditor.NewInMemory())
+	server.AddService(rest.HandlerService("ok", "/v1/ok", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	require.NoError(t, server.StartHTTPWithListener(listener))
+
+	assert.Eventually(t, server.IsReady, time.Second, 10*time.Millisecond)
+	resp, err := http.Get("http://" + addr + "/v1/ok")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	server.StopHTTP()
+
+	logs, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(logs), ":GET:/v1/ok:")
+}
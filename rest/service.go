@@ -1,5 +1,10 @@
 package rest
 
+import (
+	"net/http"
+	"strings"
+)
+
 // Service provides a way for subservices to be registered so they get added to the http API.
 type Service interface {
 	Name() string
@@ -8,3 +13,100 @@ type Service interface {
 	// IsReady indicates that service is ready to serve its end-points
 	IsReady() bool
 }
+
+// BasePathProvider is an optional interface for the Service,
+// that declares the base path of its routes, such as /v2.
+// The base path is prepended to the paths registered by the service,
+// and to the paths of the service route declarations, such as RoutePolicy,
+// so the same service can be mounted under different prefixes.
+type BasePathProvider interface {
+	// BasePath returns the prefix of the service routes,
+	// empty value specifies the absolute paths
+	BasePath() string
+}
+
+// serviceBasePath returns the normalized base path of the service,
+// or empty string if the service does not declare the base path
+func serviceBasePath(s Service) string {
+	p, ok := s.(BasePathProvider)
+	if !ok {
+		return ""
+	}
+	base := strings.Trim(p.BasePath(), "/")
+	if base == "" {
+		return ""
+	}
+	return "/" + base
+}
+
+// servicePath returns the path prefixed with the base path of the service
+func servicePath(s Service, path string) string {
+	return serviceBasePath(s) + path
+}
+
+// serviceRouter returns the router to register the service routes,
+// that prepends the base path of the service
+func serviceRouter(router Router, s Service) Router {
+	base := serviceBasePath(s)
+	if base == "" {
+		return router
+	}
+	return &funcRouter{
+		Router: router,
+		handle: func(method, path string, handle Handle) {
+			router.Handle(method, base+path, handle)
+		},
+	}
+}
+
+// funcRouter registers the routes with the handle func,
+// the handler and the routes are provided by the wrapped Router
+type funcRouter struct {
+	Router
+	handle func(method, path string, handle Handle)
+}
+
+// Handle registers the handle for the method and path
+func (p *funcRouter) Handle(method, path string, handle Handle) {
+	p.handle(method, path, handle)
+}
+
+// GET is a shortcut for router.Handle("GET", path, handle)
+func (p *funcRouter) GET(path string, handle Handle) {
+	p.handle(http.MethodGet, path, handle)
+}
+
+// HEAD is a shortcut for router.Handle("HEAD", path, handle)
+func (p *funcRouter) HEAD(path string, handle Handle) {
+	p.handle(http.MethodHead, path, handle)
+}
+
+// OPTIONS is a shortcut for router.Handle("OPTIONS", path, handle)
+func (p *funcRouter) OPTIONS(path string, handle Handle) {
+	p.handle(http.MethodOptions, path, handle)
+}
+
+// POST is a shortcut for router.Handle("POST", path, handle)
+func (p *funcRouter) POST(path string, handle Handle) {
+	p.handle(http.MethodPost, path, handle)
+}
+
+// PUT is a shortcut for router.Handle("PUT", path, handle)
+func (p *funcRouter) PUT(path string, handle Handle) {
+	p.handle(http.MethodPut, path, handle)
+}
+
+// PATCH is a shortcut for router.Handle("PATCH", path, handle)
+func (p *funcRouter) PATCH(path string, handle Handle) {
+	p.handle(http.MethodPatch, path, handle)
+}
+
+// DELETE is a shortcut for router.Handle("DELETE", path, handle)
+func (p *funcRouter) DELETE(path string, handle Handle) {
+	p.handle(http.MethodDelete, path, handle)
+}
+
+// CONNECT is a shortcut for router.Handle("CONNECT", path, handle)
+func (p *funcRouter) CONNECT(path string, handle Handle) {
+	p.handle(http.MethodConnect, path, handle)
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
 	"github.com/go-phorce/dolly/rest/tlsconfig"
 	"github.com/go-phorce/dolly/testify/auditor"
 	"github.com/go-phorce/dolly/xhttp/header"
@@ -417,3 +418,95 @@ func getAvailableBinding() string {
 	defer ln.Close()
 	return ":" + strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
 }
+
+func Test_HandlerService(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/vars", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("vars"))
+	})
+	mux.HandleFunc("/debug/vars/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	})
+
+	svc := rest.HandlerService("expvar", "/debug/vars/", mux)
+	assert.Equal(t, "expvar", svc.Name())
+	assert.True(t, svc.IsReady())
+	svc.Close()
+
+	router := rest.NewRouter(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	svc.Register(router)
+	assert.Contains(t, router.Routes(), rest.Route{Method: http.MethodGet, Path: "/debug/vars"})
+	assert.Contains(t, router.Routes(), rest.Route{Method: http.MethodPost, Path: "/debug/vars/*path"})
+
+	tcases := []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{http.MethodGet, "/debug/vars", http.StatusOK, "vars"},
+		{http.MethodGet, "/debug/vars/memstats", http.StatusOK, "GET /debug/vars/memstats"},
+		{http.MethodPost, "/debug/vars/a/b", http.StatusOK, "POST /debug/vars/a/b"},
+		{http.MethodGet, "/debug/other", http.StatusNotFound, ""},
+	}
+	for _, tc := range tcases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		router.Handler().ServeHTTP(w, r)
+		assert.Equal(t, tc.code, w.Code, tc.path)
+		assert.Equal(t, tc.body, w.Body.String(), tc.path)
+	}
+}
+
+// basePathService is mounted under the base path
+type basePathService struct {
+	name string
+	base string
+}
+
+func (s *basePathService) Name() string     { return s.name }
+func (s *basePathService) IsReady() bool    { return true }
+func (s *basePathService) Close()           {}
+func (s *basePathService) BasePath() string { return s.base }
+func (s *basePathService) Register(r rest.Router) {
+	r.GET("/items/:id", func(w http.ResponseWriter, _ *http.Request, p rest.Params) {
+		w.Write([]byte(s.name + ":" + p.ByName("id")))
+	})
+}
+
+func (s *basePathService) RoutePolicies() []rest.RoutePolicy {
+	return []rest.RoutePolicy{
+		{Path: "/items/:id", Public: true},
+	}
+}
+
+func Test_ServiceBasePath(t *testing.T) {
+	server, url, cleanup := resttest.Start(t, resttest.Options{
+		Services: []resttest.ServiceFactory{
+			func(rest.Server) rest.Service { return &basePathService{name: "v1", base: "/v1/"} },
+			func(rest.Server) rest.Service { return &basePathService{name: "v2", base: "v2"} },
+			func(rest.Server) rest.Service { return &basePathService{name: "root"} },
+		},
+	})
+	defer cleanup()
+
+	assert.Equal(t, []rest.Route{
+		{Method: http.MethodGet, Path: "/items/:id"},
+		{Method: http.MethodGet, Path: "/v1/items/:id"},
+		{Method: http.MethodGet, Path: "/v2/items/:id"},
+	}, server.Routes())
+
+	for path, exp := range map[string]string{
+		"/v1/items/1": "v1:1",
+		"/v2/items/2": "v2:2",
+		"/items/3":    "root:3",
+	} {
+		resp, err := http.Get(url + path)
+		require.NoError(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Equal(t, exp, string(body), path)
+	}
+}
@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/juju/errors"
+)
+
+// Reloader is an optional interface for the Server,
+// that reloads the configuration without the restart
+type Reloader interface {
+	// Reload reloads the configuration of the running server
+	Reload() error
+}
+
+// RunUntilSignal starts the server, and blocks until one of the signals is received,
+// then stops the server gracefully, within the configured shutdown timeout.
+// If signals are not specified, then SIGINT and SIGTERM are handled,
+// and SIGHUP if the server implements Reloader.
+//
+// SIGHUP calls Reload of the server, if it implements Reloader,
+// the server continues to run if the reload fails.
+//
+// The second signal received while the server is stopping forces to return,
+// without waiting for the graceful stop to complete.
+func RunUntilSignal(server Server, signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+		if _, ok := server.(Reloader); ok {
+			signals = append(signals, syscall.SIGHUP)
+		}
+	}
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, signals...)
+	defer signal.Stop(sigs)
+
+	return runUntilSignal(server, sigs)
+}
+
+func runUntilSignal(server Server, sigs <-chan os.Signal) error {
+	if err := server.StartHTTP(); err != nil {
+		return errors.Trace(err)
+	}
+
+	sig := <-sigs
+	reloader, canReload := server.(Reloader)
+	for canReload && sig == syscall.SIGHUP {
+		logger.Infof("api=RunUntilSignal, service=%s, signal=%v, status=reloading", server.Name(), sig)
+		if err := reloader.Reload(); err != nil {
+			logger.Errorf("api=RunUntilSignal, service=%s, reason=reload, err=[%v]", server.Name(), err)
+		}
+		sig = <-sigs
+	}
+	logger.Infof("api=RunUntilSignal, service=%s, signal=%v, status=stopping", server.Name(), sig)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		server.StopHTTP()
+	}()
+
+	select {
+	case <-stopped:
+		logger.Infof("api=RunUntilSignal, service=%s, status=stopped", server.Name())
+		return nil
+	case sig = <-sigs:
+		logger.Warningf("api=RunUntilSignal, service=%s, signal=%v, status=force_quit", server.Name(), sig)
+		return errors.Errorf("forced to quit on %v signal", sig)
+	}
+}
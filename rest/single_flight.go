@@ -0,0 +1,59 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/xhttp"
+)
+
+// RouteSingleFlight specifies the GET route, where the concurrent identical requests
+// are coalesced, and share the response of the request in flight
+type RouteSingleFlight struct {
+	// Path specifies the path template, as registered with the router,
+	// such as /v1/reports/:id
+	Path string
+	// MaxBodySize specifies the max size of the response body to share,
+	// if not set, xhttp.DefaultSingleFlightMaxBodySize is used
+	MaxBodySize int
+}
+
+// RouteSingleFlightProvider is an optional interface for the Service,
+// that declares the expensive GET routes to coalesce.
+// The route must reply with the same response to the requests
+// with the same path, query, caller's identity and tenant, see xhttp.SingleFlight.
+type RouteSingleFlightProvider interface {
+	// RouteSingleFlights returns the service routes to coalesce
+	RouteSingleFlights() []RouteSingleFlight
+}
+
+// routeSingleFlights returns the registered GET routes to coalesce
+func routeSingleFlights(routes []Route, services []Service) map[Route]RouteSingleFlight {
+	res := map[Route]RouteSingleFlight{}
+	for _, s := range services {
+		p, ok := s.(RouteSingleFlightProvider)
+		if !ok {
+			continue
+		}
+		for _, sf := range p.RouteSingleFlights() {
+			for _, route := range registeredRoutes("routeSingleFlights", routes, s, http.MethodGet, sf.Path) {
+				res[route] = sf
+			}
+		}
+	}
+	return res
+}
+
+// newSingleFlightHandler returns a http.Handler that coalesces the identical requests
+// for the specified routes, and passes other requests to the delegate handler
+func newSingleFlightHandler(routes map[Route]RouteSingleFlight, delegate http.Handler) http.Handler {
+	handlers := map[Route]http.Handler{}
+	for route, sf := range routes {
+		logger.Infof("api=newSingleFlightHandler, method=%s, path=%s", route.Method, route.Path)
+		flight := xhttp.NewSingleFlight(delegate)
+		if sf.MaxBodySize > 0 {
+			flight.WithMaxBodySize(sf.MaxBodySize)
+		}
+		handlers[route] = flight
+	}
+	return newRouteHandler(handlers, delegate)
+}
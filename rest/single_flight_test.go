@@ -0,0 +1,72 @@
+package rest_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type singleFlightService struct {
+	calls   int32
+	release chan struct{}
+}
+
+func (s *singleFlightService) Name() string  { return "singleflighttest" }
+func (s *singleFlightService) IsReady() bool { return true }
+func (s *singleFlightService) Close()        {}
+func (s *singleFlightService) Register(r rest.Router) {
+	r.GET("/v1/reports/:id", func(w http.ResponseWriter, r *http.Request, p rest.Params) {
+		atomic.AddInt32(&s.calls, 1)
+		<-s.release
+		w.Write([]byte("report " + p.ByName("id")))
+	})
+}
+
+func (s *singleFlightService) RouteSingleFlights() []rest.RouteSingleFlight {
+	return []rest.RouteSingleFlight{
+		{Path: "/v1/reports/:id"},
+		{Path: "/v1/notregistered"},
+	}
+}
+
+func Test_RouteSingleFlights(t *testing.T) {
+	svc := &singleFlightService{release: make(chan struct{})}
+	_, url, cleanup := resttest.Start(t, resttest.Options{
+		Services: []resttest.ServiceFactory{
+			func(rest.Server) rest.Service { return svc },
+		},
+	})
+	defer cleanup()
+
+	var wg sync.WaitGroup
+	get := func(path string) {
+		defer wg.Done()
+		resp, err := http.Get(url + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, "report 1", string(b))
+	}
+
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go get("/v1/reports/1")
+	}
+	for i := 0; i < 100 && atomic.LoadInt32(&svc.calls) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// let the other requests to join the flight
+	time.Sleep(50 * time.Millisecond)
+	close(svc.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&svc.calls))
+}
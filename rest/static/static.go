@@ -0,0 +1,192 @@
+// Package static provides a service to serve static files,
+// such as bundled admin UI, from a directory or embedded file system.
+package static
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+	"github.com/go-phorce/dolly/xlog"
+)
+
+var logger = xlog.NewPackageLogger("github.com/go-phorce/dolly", "rest/static")
+
+const (
+	// DefaultCacheControl is the default Cache-Control header value,
+	// the clients must revalidate the files with ETag or Last-Modified
+	DefaultCacheControl = "no-cache"
+
+	indexFile = "index.html"
+)
+
+// Service defines the static files service
+type Service struct {
+	name         string
+	prefix       string
+	fs           http.FileSystem
+	cacheControl string
+	fallback     string
+	listing      bool
+	gzipped      bool
+}
+
+// NewService returns an instance of the service to serve the files
+// from the file system at the route prefix, for example "/ui".
+// Use http.Dir for a directory, or http.FS for embed.FS.
+func NewService(name, prefix string, fs http.FileSystem) *Service {
+	return &Service{
+		name:         name,
+		prefix:       strings.TrimSuffix(prefix, "/"),
+		fs:           fs,
+		cacheControl: DefaultCacheControl,
+	}
+}
+
+// WithCacheControl sets the Cache-Control header value for the served files
+func (s *Service) WithCacheControl(value string) *Service {
+	s.cacheControl = value
+	return s
+}
+
+// WithIndexFallback specifies the file to serve for not found paths,
+// to support SPA routing, for example "/index.html".
+// The fallback is not applied to the paths with a file extension,
+// so the missing assets are still replied with 404.
+func (s *Service) WithIndexFallback(file string) *Service {
+	s.fallback = file
+	return s
+}
+
+// WithDirectoryListing enables the directory listing,
+// it is disabled by default
+func (s *Service) WithDirectoryListing(enabled bool) *Service {
+	s.listing = enabled
+	return s
+}
+
+// WithPrecompressed enables serving of the precompressed files,
+// if the client accepts gzip and the sibling file with .gz extension exists,
+// for example app.js.gz for app.js, then it's served with gzip Content-Encoding.
+// Content-Type is set by the original file extension,
+// and ETag is derived from the original file with -gzip suffix.
+// Otherwise the original file is served.
+func (s *Service) WithPrecompressed(enabled bool) *Service {
+	s.gzipped = enabled
+	return s
+}
+
+// Name returns the service name
+func (s *Service) Name() string {
+	return s.name
+}
+
+// IsReady indicates that the service is ready to serve its end-points
+func (s *Service) IsReady() bool {
+	return true
+}
+
+// Close the subservices and it's resources
+func (s *Service) Close() {
+}
+
+// Register adds the endpoints to the overall URL router
+func (s *Service) Register(r rest.Router) {
+	route := s.prefix + "/*filepath"
+	r.GET(route, s.serveFile)
+	r.HEAD(route, s.serveFile)
+}
+
+func (s *Service) serveFile(w http.ResponseWriter, r *http.Request, p rest.Params) {
+	name := path.Clean("/" + p.ByName("filepath"))
+
+	f, stat, err := s.open(name)
+	if err == nil && stat.IsDir() {
+		f.Close()
+		if s.listing {
+			w.Header().Set(header.CacheControl, s.cacheControl)
+			http.StripPrefix(s.prefix, http.FileServer(s.fs)).ServeHTTP(w, r)
+			return
+		}
+		name = path.Join(name, indexFile)
+		f, stat, err = s.open(name)
+	}
+	if err != nil && s.fallback != "" && path.Ext(name) == "" {
+		name = s.fallback
+		f, stat, err = s.open(name)
+	}
+	if err != nil || stat.IsDir() {
+		if err != nil && !os.IsNotExist(err) {
+			logger.Errorf("api=static.serveFile, service=%s, path=%q, err=[%v]", s.name, name, err)
+		}
+		marshal.WriteJSON(w, r, httperror.WithNotFound(r.URL.Path))
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set(header.CacheControl, s.cacheControl)
+	w.Header().Set(header.ETag, etag(stat))
+
+	if s.gzipped {
+		w.Header().Add(header.Vary, header.AcceptEncoding)
+		if gz, ok := s.openPrecompressed(w, r, name, stat); ok {
+			defer gz.Close()
+			f = gz
+		}
+	}
+	// ServeContent sets Content-Type by the file extension,
+	// and handles the conditional and range requests
+	http.ServeContent(w, r, stat.Name(), stat.ModTime(), f)
+}
+
+// openPrecompressed returns the gzip sibling of the file, if the client accepts gzip,
+// and sets the headers of the original resource
+func (s *Service) openPrecompressed(w http.ResponseWriter, r *http.Request, name string, stat os.FileInfo) (http.File, bool) {
+	if !strings.Contains(r.Header.Get(header.AcceptEncoding), "gzip") {
+		return nil, false
+	}
+	// without the type of the original, ServeContent would sniff the compressed content
+	contentType := mime.TypeByExtension(path.Ext(stat.Name()))
+	if contentType == "" {
+		return nil, false
+	}
+	gz, gzStat, err := s.open(name + ".gz")
+	if err != nil {
+		return nil, false
+	}
+	if gzStat.IsDir() {
+		gz.Close()
+		return nil, false
+	}
+
+	h := w.Header()
+	h.Set(header.ContentType, contentType)
+	h.Set(header.ContentEncoding, "gzip")
+	h.Set(header.ETag, strings.TrimSuffix(etag(stat), `"`)+`-gzip"`)
+	return gz, true
+}
+
+func (s *Service) open(name string) (http.File, os.FileInfo, error) {
+	f, err := s.fs.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, stat, nil
+}
+
+// etag returns a weak ETag from the file size and modification time
+func etag(stat os.FileInfo) string {
+	return fmt.Sprintf(`W/"%x-%x"`, stat.Size(), stat.ModTime().UnixNano())
+}
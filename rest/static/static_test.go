@@ -0,0 +1,139 @@
+package static_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/static"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Static(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "assets"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("<html>index</html>"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "assets", "app.js"), []byte("var a;"), 0644))
+
+	serve := func(svc *static.Service, method, path string, hdrs ...string) *httptest.ResponseRecorder {
+		router := rest.NewRouter(nil)
+		svc.Register(router)
+
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(method, path, nil)
+		require.NoError(t, err)
+		for i := 0; i+1 < len(hdrs); i += 2 {
+			r.Header.Set(hdrs[i], hdrs[i+1])
+		}
+		router.Handler().ServeHTTP(w, r)
+		return w
+	}
+
+	svc := static.NewService("ui", "/ui/", http.Dir(dir))
+	assert.Equal(t, "ui", svc.Name())
+	assert.True(t, svc.IsReady())
+	defer svc.Close()
+
+	t.Run("file", func(t *testing.T) {
+		w := serve(svc, http.MethodGet, "/ui/assets/app.js")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get(header.ContentType), "javascript")
+		assert.Equal(t, static.DefaultCacheControl, w.Header().Get(header.CacheControl))
+		assert.NotEmpty(t, w.Header().Get("Last-Modified"))
+		assert.Equal(t, "var a;", w.Body.String())
+
+		etag := w.Header().Get(header.ETag)
+		require.NotEmpty(t, etag)
+		w = serve(svc, http.MethodGet, "/ui/assets/app.js", header.IfNoneMatch, etag)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("index", func(t *testing.T) {
+		w := serve(svc, http.MethodGet, "/ui/")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<html>index</html>", w.Body.String())
+
+		w = serve(svc, http.MethodHead, "/ui/")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("no listing", func(t *testing.T) {
+		w := serve(svc, http.MethodGet, "/ui/assets/")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = serve(svc, http.MethodGet, "/ui/app/page")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("listing", func(t *testing.T) {
+		svc := static.NewService("ui", "/ui", http.Dir(dir)).WithDirectoryListing(true)
+		w := serve(svc, http.MethodGet, "/ui/assets/")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "app.js")
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		svc := static.NewService("ui", "/ui", http.Dir(dir)).
+			WithIndexFallback("/index.html").
+			WithCacheControl("public, max-age=60")
+		w := serve(svc, http.MethodGet, "/ui/app/page")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "public, max-age=60", w.Header().Get(header.CacheControl))
+		assert.Equal(t, "<html>index</html>", w.Body.String())
+
+		// missing assets are not found
+		w = serve(svc, http.MethodGet, "/ui/assets/missing.js")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("precompressed", func(t *testing.T) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write([]byte("var a;"))
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "assets", "app.js.gz"), buf.Bytes(), 0644))
+
+		plain := serve(svc, http.MethodGet, "/ui/assets/app.js", header.AcceptEncoding, "gzip")
+		require.Equal(t, http.StatusOK, plain.Code)
+		assert.Empty(t, plain.Header().Get(header.ContentEncoding))
+
+		svc := static.NewService("ui", "/ui", http.Dir(dir)).WithPrecompressed(true)
+		w := serve(svc, http.MethodGet, "/ui/assets/app.js", header.AcceptEncoding, "gzip, deflate")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get(header.ContentEncoding))
+		assert.Equal(t, header.AcceptEncoding, w.Header().Get(header.Vary))
+		assert.Contains(t, w.Header().Get(header.ContentType), "javascript")
+		assert.Equal(t, buf.Bytes(), w.Body.Bytes())
+
+		etag := w.Header().Get(header.ETag)
+		assert.Equal(t, strings.TrimSuffix(plain.Header().Get(header.ETag), `"`)+`-gzip"`, etag)
+		w = serve(svc, http.MethodGet, "/ui/assets/app.js", header.AcceptEncoding, "gzip", header.IfNoneMatch, etag)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+
+		// the client does not accept gzip
+		w = serve(svc, http.MethodGet, "/ui/assets/app.js")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get(header.ContentEncoding))
+		assert.Equal(t, header.AcceptEncoding, w.Header().Get(header.Vary))
+		assert.Equal(t, "var a;", w.Body.String())
+
+		// no precompressed sibling
+		w = serve(svc, http.MethodGet, "/ui/", header.AcceptEncoding, "gzip")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get(header.ContentEncoding))
+		assert.Equal(t, "<html>index</html>", w.Body.String())
+	})
+}
@@ -0,0 +1,148 @@
+package rest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+
+	metricsutil "github.com/go-phorce/dolly/metrics/util"
+	"github.com/go-phorce/dolly/xhttp"
+)
+
+const (
+	// EvtSourceTLS specifies source for the TLS handshake events
+	EvtSourceTLS = "tls"
+	// EvtClientAuthFailed specifies the event of the client certificate,
+	// rejected at the TLS handshake
+	EvtClientAuthFailed = "client auth failed"
+)
+
+// handshakeErrorPrefix is the prefix of the message,
+// logged by http.Server on the failed TLS handshake
+var handshakeErrorPrefix = []byte("http: TLS handshake error from ")
+
+// handshakeMetrics publishes the metrics of the successful handshakes,
+// the failed handshakes are published by the ErrorLog of http.Server
+type handshakeMetrics struct {
+	conns sync.Map
+	// hellos stores SNI of the handshakes in progress, by the remote address
+	hellos sync.Map
+	// auditor records the rejected client certificates, if set
+	auditor xhttp.Auditor
+}
+
+// ConnState is called by http.Server on the connection state change,
+// the handshake is published once per connection, when it becomes active
+func (m *handshakeMetrics) ConnState(c net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateActive:
+		m.hellos.Delete(c.RemoteAddr().String())
+		tc, ok := c.(*tls.Conn)
+		if !ok {
+			return
+		}
+		if _, loaded := m.conns.LoadOrStore(c, true); !loaded {
+			cs := tc.ConnectionState()
+			metricsutil.PublishTLSHandshake(&cs)
+		}
+	case http.StateClosed, http.StateHijacked:
+		m.conns.Delete(c)
+		m.hellos.Delete(c.RemoteAddr().String())
+	}
+}
+
+// trackClientHello returns the GetConfigForClient callback,
+// that records SNI of the client to report it with the handshake failure,
+// and calls the next callback, if set
+func (m *handshakeMetrics) trackClientHello(
+	next func(*tls.ClientHelloInfo) (*tls.Config, error),
+) func(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		if hello.Conn != nil {
+			m.hellos.Store(hello.Conn.RemoteAddr().String(), hello.ServerName)
+		}
+		if next != nil {
+			return next(hello)
+		}
+		return nil, nil
+	}
+}
+
+// errorLog returns the logger for http.Server,
+// that publishes the metrics of the failed handshakes,
+// as the handshake errors are reported by http.Server only to ErrorLog
+func (m *handshakeMetrics) errorLog(l *log.Logger) *log.Logger {
+	return log.New(&handshakeErrorWriter{w: l.Writer(), m: m}, l.Prefix(), l.Flags())
+}
+
+// handshakeFailed publishes the failed handshake,
+// the rejected client certificates are logged and audited
+// with the client IP and SNI
+func (m *handshakeMetrics) handshakeFailed(msg []byte) {
+	reason := handshakeFailureReason(msg)
+	metricsutil.PublishTLSHandshakeFailed(reason)
+	if reason == metricsutil.TLSFailedHandshake {
+		return
+	}
+
+	// the message is: <remote address>: <error>
+	remote := string(msg)
+	if i := bytes.Index(msg, []byte(": ")); i >= 0 {
+		remote = string(msg[:i])
+	}
+	var sni string
+	if v, ok := m.hellos.Load(remote); ok {
+		sni = v.(string)
+	}
+	ip := remote
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		ip = host
+	}
+
+	info := fmt.Sprintf("reason=%s, ip=%s, sni=%q", reason, ip, sni)
+	logger.Warningf("api=ClientAuth, %s", info)
+	if m.auditor != nil {
+		m.auditor.Audit(
+			EvtSourceTLS,
+			EvtClientAuthFailed,
+			"",
+			"",
+			0,
+			info,
+		)
+	}
+}
+
+type handshakeErrorWriter struct {
+	w io.Writer
+	m *handshakeMetrics
+}
+
+func (w *handshakeErrorWriter) Write(p []byte) (int, error) {
+	if i := bytes.Index(p, handshakeErrorPrefix); i >= 0 {
+		w.m.handshakeFailed(bytes.TrimSpace(p[i+len(handshakeErrorPrefix):]))
+	}
+	return w.w.Write(p)
+}
+
+// handshakeFailureReason returns one of TLSFailed*ClientCert reasons,
+// if the client certificate was rejected by the server
+func handshakeFailureReason(msg []byte) string {
+	// the server verifies only the client certificates
+	switch {
+	case bytes.Contains(msg, []byte("client didn't provide a certificate")):
+		return metricsutil.TLSFailedNoClientCert
+	case !bytes.Contains(msg, []byte("failed to verify")):
+		return metricsutil.TLSFailedHandshake
+	case bytes.Contains(msg, []byte("certificate has expired or is not yet valid")):
+		return metricsutil.TLSFailedExpiredClientCert
+	case bytes.Contains(msg, []byte("certificate signed by unknown authority")):
+		return metricsutil.TLSFailedUntrustedClientCert
+	}
+	return metricsutil.TLSFailedClientCert
+}
@@ -0,0 +1,175 @@
+package rest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/metrics"
+	metricsutil "github.com/go-phorce/dolly/metrics/util"
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/go-phorce/dolly/testify/testca"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_handshakeFailureReason(t *testing.T) {
+	tcases := []struct {
+		msg    string
+		reason string
+	}{
+		{"http: TLS handshake error from 127.0.0.1:1234: remote error: tls: bad certificate", metricsutil.TLSFailedHandshake},
+		{"http: TLS handshake error from 127.0.0.1:1234: tls: client didn't provide a certificate", metricsutil.TLSFailedNoClientCert},
+		{"http: TLS handshake error from 127.0.0.1:1234: tls: failed to verify certificate: x509: certificate signed by unknown authority", metricsutil.TLSFailedUntrustedClientCert},
+		{"http: TLS handshake error from 127.0.0.1:1234: tls: failed to verify certificate: x509: certificate has expired or is not yet valid: current time", metricsutil.TLSFailedExpiredClientCert},
+		{"http: TLS handshake error from 127.0.0.1:1234: tls: failed to verify certificate: x509: certificate specifies an incompatible key usage", metricsutil.TLSFailedClientCert},
+	}
+	for _, tc := range tcases {
+		assert.Equal(t, tc.reason, handshakeFailureReason([]byte(tc.msg)), tc.msg)
+	}
+}
+
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func Test_HandshakeMetrics(t *testing.T) {
+	im := metrics.NewInmemSink(time.Minute, time.Minute*5)
+	_, err := metrics.NewGlobal(metrics.DefaultConfig("tlstest"), im)
+	require.NoError(t, err)
+
+	logged := &syncBuffer{}
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	hm := new(handshakeMetrics)
+	ts.Config.ConnState = hm.ConnState
+	ts.Config.ErrorLog = hm.errorLog(log.New(logged, "", 0))
+	ts.StartTLS()
+	defer ts.Close()
+
+	// trusted client
+	resp, err := ts.Client().Get(ts.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// untrusted client rejects the server certificate
+	_, err = http.Get(ts.URL)
+	require.Error(t, err)
+
+	// wait for the server to log the handshake error
+	for i := 0; i < 20 && logged.String() == ""; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.Contains(t, logged.String(), "TLS handshake error")
+
+	var ok, failed int
+	for k, c := range im.Data()[0].Counters {
+		if strings.HasPrefix(k, "tlstest.tls.handshake;status=ok;") {
+			ok += c.Count
+		}
+		if k == "tlstest.tls.handshake;status=failed;reason=handshake" {
+			failed += c.Count
+		}
+	}
+	assert.Equal(t, 1, ok)
+	assert.Equal(t, 1, failed)
+}
+
+func Test_ClientAuthFailures(t *testing.T) {
+	im := metrics.NewInmemSink(time.Minute, time.Minute*5)
+	_, err := metrics.NewGlobal(metrics.DefaultConfig("clientauth"), im)
+	require.NoError(t, err)
+
+	ca := testca.NewEntity(testca.Authority)
+	other := testca.NewEntity(testca.Authority)
+	clientUsage := testca.ExtKeyUsage(x509.ExtKeyUsageClientAuth)
+
+	audit := auditor.NewInMemory()
+	hm := &handshakeMetrics{auditor: audit}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  ca.ChainPool(),
+	}
+	ts.TLS.GetConfigForClient = hm.trackClientHello(nil)
+	ts.Config.ConnState = hm.ConnState
+	ts.Config.ErrorLog = hm.errorLog(log.New(ioutil.Discard, "", 0))
+	ts.StartTLS()
+	defer ts.Close()
+
+	tcases := []struct {
+		name   string
+		client *testca.Entity
+		reason string
+	}{
+		{"valid", ca.Issue(clientUsage), ""},
+		{"no_cert", nil, metricsutil.TLSFailedNoClientCert},
+		{"expired", ca.Issue(clientUsage, testca.NotAfter(time.Now().Add(-time.Hour))), metricsutil.TLSFailedExpiredClientCert},
+		{"untrusted", other.Issue(clientUsage), metricsutil.TLSFailedUntrustedClientCert},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			audit.Reset()
+
+			tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+			tlsConfig.ServerName = "example.com"
+			if tc.client != nil {
+				// the certificate is sent even if not issued by the CA requested by the server
+				cert := &tls.Certificate{
+					Certificate: [][]byte{tc.client.Certificate.Raw},
+					PrivateKey:  tc.client.PrivateKey,
+				}
+				tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					return cert, nil
+				}
+			}
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+			defer client.CloseIdleConnections()
+
+			resp, err := client.Get(ts.URL)
+			if tc.reason == "" {
+				require.NoError(t, err)
+				resp.Body.Close()
+				assert.Nil(t, audit.Find(EvtSourceTLS, EvtClientAuthFailed))
+				return
+			}
+			require.Error(t, err)
+
+			evt := audit.WaitFor(EvtSourceTLS, EvtClientAuthFailed, 2*time.Second)
+			require.NotNil(t, evt)
+			assert.Equal(t, `reason=`+tc.reason+`, ip=127.0.0.1, sni="example.com"`, evt.Message)
+		})
+	}
+
+	for _, reason := range []string{
+		metricsutil.TLSFailedNoClientCert,
+		metricsutil.TLSFailedExpiredClientCert,
+		metricsutil.TLSFailedUntrustedClientCert,
+	} {
+		c, ok := im.Data()[0].Counters["clientauth.tls.handshake;status=failed;reason="+reason]
+		if assert.True(t, ok, reason) {
+			assert.Equal(t, 1, c.Count, reason)
+		}
+	}
+}
@@ -9,9 +9,19 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/go-phorce/dolly/metrics"
 	"github.com/juju/errors"
 )
 
+// DefaultReloadFailureThreshold specifies the default number of consecutive reload failures,
+// after which the failure handlers are called
+const DefaultReloadFailureThreshold = 3
+
+var (
+	keyForReloadFailures = []string{"tls", "reload", "failures"}
+	keyForReloadStale    = []string{"tls", "reload", "stale"}
+)
+
 // Wrap time.Tick so we can override it in tests.
 var makeTicker = func(interval time.Duration) (func(), <-chan time.Time) {
 	t := time.NewTicker(interval)
@@ -21,6 +31,10 @@ var makeTicker = func(interval time.Duration) (func(), <-chan time.Time) {
 // OnReloadFunc is a callback to handle cert reload
 type OnReloadFunc func(pair *tls.Certificate)
 
+// OnReloadFailureFunc is a callback to handle consecutive reload failures,
+// such as to audit the event
+type OnReloadFailureFunc func(label string, failures uint32, err error)
+
 // KeypairReloader keeps necessary info to provide reloaded certificate
 type KeypairReloader struct {
 	label          string
@@ -36,6 +50,14 @@ type KeypairReloader struct {
 	stopChan       chan<- struct{}
 	closed         bool
 	handlers       []OnReloadFunc
+
+	failures         uint32
+	lastErr          error
+	failureThreshold uint32
+	failureHandlers  []OnReloadFailureFunc
+
+	rotationWindow time.Duration
+	stale          bool
 }
 
 // NewKeypairReloader return an instance of the TLS cert loader
@@ -50,10 +72,11 @@ func NewKeypairReloaderWithLabel(label, certPath, keyPath string, checkInterval
 	}
 
 	result := &KeypairReloader{
-		label:    label,
-		certPath: certPath,
-		keyPath:  keyPath,
-		stopChan: make(chan struct{}),
+		label:            label,
+		certPath:         certPath,
+		keyPath:          keyPath,
+		stopChan:         make(chan struct{}),
+		failureThreshold: DefaultReloadFailureThreshold,
 	}
 
 	logger.Infof("api=NewKeypairReloader, label=%s, status=started", label)
@@ -95,6 +118,7 @@ func NewKeypairReloaderWithLabel(label, certPath, keyPath string, checkInterval
 						logger.Errorf("api=NewKeypairReloader, label=%s, err=[%v]", result.label, errors.ErrorStack(err))
 					}
 				}
+				result.checkStale()
 			}
 		}
 	}()
@@ -113,7 +137,44 @@ func (k *KeypairReloader) OnReload(f OnReloadFunc) *KeypairReloader {
 	return k
 }
 
-// Reload will explicitly load TLS certs from the disk
+// OnReloadFailure allows to add OnReloadFailureFunc handler,
+// called when the number of consecutive reload failures reaches the threshold
+func (k *KeypairReloader) OnReloadFailure(f OnReloadFailureFunc) *KeypairReloader {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if f != nil {
+		k.failureHandlers = append(k.failureHandlers, f)
+	}
+	return k
+}
+
+// WithFailureThreshold specifies the number of consecutive reload failures,
+// after which the failure is logged at Warning and the failure handlers are called
+func (k *KeypairReloader) WithFailureThreshold(n uint32) *KeypairReloader {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if n > 0 {
+		k.failureThreshold = n
+	}
+	return k
+}
+
+// WithRotationWindow specifies the expected interval of the key pair rotation.
+// If the files are not modified within the window, then the key pair is stale,
+// the reloader logs the warning and publishes tls.reload.stale metric,
+// which allows to detect the broken rotation before the certificate expires.
+func (k *KeypairReloader) WithRotationWindow(window time.Duration) *KeypairReloader {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	k.rotationWindow = window
+	return k
+}
+
+// Reload will explicitly load TLS certs from the disk.
+// On failure, the last loaded pair is kept.
 func (k *KeypairReloader) Reload() error {
 	k.lock.Lock()
 	if k.inProgress {
@@ -145,9 +206,16 @@ func (k *KeypairReloader) Reload() error {
 		logger.Warningf("api=Reload, reason=LoadX509KeyPair, label=%s, file=%q, err=[%v]", k.label, k.certPath, err)
 	}
 	if err != nil {
+		k.onFailure(err)
 		return errors.Annotatef(err, "count: %d", k.count)
 	}
 
+	if k.failures > 0 {
+		logger.Noticef("api=Reload, label=%s, status=recovered, failures=%d", k.label, k.failures)
+		k.failures = 0
+		k.lastErr = nil
+		metrics.SetGauge(keyForReloadFailures, 0, metrics.Tag{Name: "label", Value: k.label})
+	}
 	atomic.AddUint32(&k.count, 1)
 	k.loadedAt = time.Now().UTC()
 
@@ -183,6 +251,27 @@ func (k *KeypairReloader) Reload() error {
 	return nil
 }
 
+// onFailure tracks the consecutive failures, must be called under the lock
+func (k *KeypairReloader) onFailure(err error) {
+	k.failures++
+	k.lastErr = err
+	metrics.SetGauge(keyForReloadFailures, float32(k.failures), metrics.Tag{Name: "label", Value: k.label})
+
+	if k.failures < k.failureThreshold {
+		return
+	}
+
+	logger.Warningf("api=Reload, reason=consecutive_failures, label=%s, failures=%d, loadedAt=%q, err=[%v]",
+		k.label, k.failures, k.loadedAt.Format(time.RFC3339), err)
+
+	// notify once the threshold is reached
+	if k.failures == k.failureThreshold {
+		for _, h := range k.failureHandlers {
+			go h(k.label, k.failures, err)
+		}
+	}
+}
+
 func (k *KeypairReloader) tlsCert() *tls.Certificate {
 	var err error
 	kp := k.keypair
@@ -241,7 +330,7 @@ func (k *KeypairReloader) CertAndKeyFiles() (string, string) {
 	return k.certPath, k.keyPath
 }
 
-// LoadedAt return the last time when the pair was loaded
+// LoadedAt return the last time when the pair was successfully loaded
 func (k *KeypairReloader) LoadedAt() time.Time {
 	k.lock.RLock()
 	defer k.lock.RUnlock()
@@ -249,6 +338,77 @@ func (k *KeypairReloader) LoadedAt() time.Time {
 	return k.loadedAt
 }
 
+// LastModified returns the last modification time of the cert or key file,
+// as of the last successful reload
+func (k *KeypairReloader) LastModified() time.Time {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+
+	return k.lastModified()
+}
+
+// StaleSince returns the time when the key pair became stale,
+// that is the end of the rotation window after the last modification,
+// or zero time if the files were modified within the window,
+// or the rotation window is not specified
+func (k *KeypairReloader) StaleSince() time.Time {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+
+	return k.staleSince(time.Now())
+}
+
+func (k *KeypairReloader) lastModified() time.Time {
+	if k.keyModifiedAt.After(k.certModifiedAt) {
+		return k.keyModifiedAt
+	}
+	return k.certModifiedAt
+}
+
+func (k *KeypairReloader) staleSince(now time.Time) time.Time {
+	if k.rotationWindow <= 0 {
+		return time.Time{}
+	}
+	since := k.lastModified().Add(k.rotationWindow)
+	if since.After(now) {
+		return time.Time{}
+	}
+	return since
+}
+
+// checkStale logs and publishes the change of the stale state of the key pair
+func (k *KeypairReloader) checkStale() {
+	k.lock.Lock()
+	since := k.staleSince(time.Now())
+	stale := !since.IsZero()
+	changed := stale != k.stale
+	k.stale = stale
+	modifiedAt := k.lastModified()
+	k.lock.Unlock()
+
+	if !changed {
+		return
+	}
+	if stale {
+		logger.Warningf("api=KeypairReloader, reason=stale, label=%s, window=%v, modifiedAt=%q, staleSince=%q",
+			k.label, k.rotationWindow, modifiedAt.Format(time.RFC3339), since.Format(time.RFC3339))
+		metrics.SetGauge(keyForReloadStale, 1, metrics.Tag{Name: "label", Value: k.label})
+	} else {
+		logger.Noticef("api=KeypairReloader, label=%s, status=rotated, modifiedAt=%q",
+			k.label, modifiedAt.Format(time.RFC3339))
+		metrics.SetGauge(keyForReloadStale, 0, metrics.Tag{Name: "label", Value: k.label})
+	}
+}
+
+// ConsecutiveFailures returns the number of consecutive reload failures,
+// and the last error, since the last successful reload
+func (k *KeypairReloader) ConsecutiveFailures() (uint32, error) {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+
+	return k.failures, k.lastErr
+}
+
 // LoadedCount returns the number of times the pair was loaded from disk
 func (k *KeypairReloader) LoadedCount() uint32 {
 	return atomic.LoadUint32(&k.count)
@@ -260,16 +420,18 @@ func (k *KeypairReloader) Close() error {
 		return nil
 	}
 
-	k.lock.RLock()
-	defer k.lock.RUnlock()
-
+	k.lock.Lock()
 	if k.closed {
+		k.lock.Unlock()
 		return errors.New("already closed")
 	}
 
 	logger.Infof("api=Close, label=%s, count=%d, cert=%q, key=%q", k.label, k.count, k.certPath, k.keyPath)
 
 	k.closed = true
+	k.lock.Unlock()
+
+	// the lock is not held, as the reloader's goroutine acquires it on tick
 	k.stopChan <- struct{}{}
 
 	return nil
@@ -126,3 +126,99 @@ func Test_KeypairReloader_Reload(t *testing.T) {
 	wg.Wait()
 	assert.Equal(t, 0, reloadedCount)
 }
+
+func Test_KeypairReloader_ReloadFailures(t *testing.T) {
+	pemCert, pemKey, err := testify.MakeSelfCertRSAPem(1)
+	require.NoError(t, err)
+
+	pemFile := filepath.Join(os.TempDir(), "test-KeypairReloader3.pem")
+	keyFile := filepath.Join(os.TempDir(), "test-KeypairReloader3-key.pem")
+
+	err = ioutil.WriteFile(pemFile, pemCert, os.ModePerm)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(keyFile, pemKey, os.ModePerm)
+	require.NoError(t, err)
+
+	k, err := tlsconfig.NewKeypairReloader(pemFile, keyFile, time.Hour)
+	require.NoError(t, err)
+	defer k.Close()
+
+	notified := make(chan uint32, 10)
+	k.WithFailureThreshold(2).OnReloadFailure(func(label string, failures uint32, err error) {
+		assert.Equal(t, "test-KeypairReloader3.pem", label)
+		assert.Error(t, err)
+		notified <- failures
+	})
+
+	kpair := k.Keypair()
+	loadedAt := k.LoadedAt()
+
+	// simulate the broken rotation
+	err = ioutil.WriteFile(pemFile, []byte("broken"), os.ModePerm)
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		require.Error(t, k.Reload())
+		failures, lastErr := k.ConsecutiveFailures()
+		assert.Equal(t, uint32(i), failures)
+		assert.Error(t, lastErr)
+	}
+
+	select {
+	case failures := <-notified:
+		assert.Equal(t, uint32(2), failures)
+	case <-time.After(time.Second):
+		t.Fatal("failure handler was not called")
+	}
+	assert.Empty(t, notified, "the handler must be called once")
+
+	// the last good pair is served
+	assert.Equal(t, kpair, k.Keypair())
+	assert.Equal(t, loadedAt, k.LoadedAt())
+
+	err = ioutil.WriteFile(pemFile, pemCert, os.ModePerm)
+	require.NoError(t, err)
+	require.NoError(t, k.Reload())
+	failures, lastErr := k.ConsecutiveFailures()
+	assert.Equal(t, uint32(0), failures)
+	assert.NoError(t, lastErr)
+	assert.True(t, k.LoadedAt().After(loadedAt))
+}
+
+func Test_KeypairReloader_Stale(t *testing.T) {
+	pemCert, pemKey, err := testify.MakeSelfCertRSAPem(1)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "reloader-stale")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	pemFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, ioutil.WriteFile(pemFile, pemCert, os.ModePerm))
+	require.NoError(t, ioutil.WriteFile(keyFile, pemKey, os.ModePerm))
+
+	k, err := tlsconfig.NewKeypairReloader(pemFile, keyFile, 50*time.Millisecond)
+	require.NoError(t, err)
+	defer k.Close()
+
+	fi, err := os.Stat(keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, fi.ModTime(), k.LastModified())
+	assert.True(t, k.StaleSince().IsZero(), "the rotation window is not specified")
+
+	k.WithRotationWindow(500 * time.Millisecond)
+	assert.True(t, k.StaleSince().IsZero(), "must not be stale within the window")
+
+	time.Sleep(600 * time.Millisecond)
+	since := k.StaleSince()
+	assert.False(t, since.IsZero(), "must be stale after the window")
+	assert.Equal(t, k.LastModified().Add(500*time.Millisecond), since)
+
+	// rotated
+	require.NoError(t, ioutil.WriteFile(pemFile, pemCert, os.ModePerm))
+	require.NoError(t, ioutil.WriteFile(keyFile, pemKey, os.ModePerm))
+	time.Sleep(300 * time.Millisecond)
+	assert.True(t, k.StaleSince().IsZero(), "must not be stale after the rotation")
+	assert.True(t, k.LastModified().After(fi.ModTime()))
+}
@@ -0,0 +1,110 @@
+package tlsconfig
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"io"
+	"sync"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/tasks"
+	"github.com/juju/errors"
+)
+
+// DefaultSessionTicketKeys specifies the default number of the session ticket keys,
+// including the current one, so the sessions issued with the previous keys can be resumed
+const DefaultSessionTicketKeys = 3
+
+// SessionTicketKeyRotationTaskName specifies the name of the key rotation task
+const SessionTicketKeyRotationTaskName = "tls.session_ticket_keys"
+
+var keyForSessionTicketKeyRotations = []string{"tls", "session_ticket_keys", "rotated"}
+
+// SessionPolicy specifies the TLS session resumption and renegotiation policy.
+// The zero value keeps the defaults of crypto/tls:
+// the session tickets are enabled, and the renegotiation is not supported.
+type SessionPolicy struct {
+	// SessionTicketsDisabled specifies to disable the session resumption with tickets
+	SessionTicketsDisabled bool
+	// Renegotiation specifies the renegotiation policy,
+	// it only applies to the client configuration, as the server never renegotiates
+	Renegotiation tls.RenegotiationSupport
+}
+
+// ApplySessionPolicy sets the session policy to the configuration
+func ApplySessionPolicy(cfg *tls.Config, policy *SessionPolicy) {
+	if policy == nil {
+		return
+	}
+	cfg.SessionTicketsDisabled = policy.SessionTicketsDisabled
+	cfg.Renegotiation = policy.Renegotiation
+}
+
+// SessionTicketKeyRotator rotates the session ticket keys of the server configuration.
+// Without the rotator, crypto/tls uses the keys generated on the start,
+// and rotates them daily.
+type SessionTicketKeyRotator struct {
+	cfg  *tls.Config
+	keep int
+	keys [][32]byte
+	rand io.Reader
+	lock sync.Mutex
+}
+
+// NewSessionTicketKeyRotator returns the rotator of the session ticket keys,
+// that keeps up to keep keys, including the current one.
+// If keep is not positive, then DefaultSessionTicketKeys is used.
+//
+// The rotator changes the keys of the supplied configuration,
+// it must be the same instance that is used by the listener,
+// not a clone of it.
+func NewSessionTicketKeyRotator(cfg *tls.Config, keep int) *SessionTicketKeyRotator {
+	if keep <= 0 {
+		keep = DefaultSessionTicketKeys
+	}
+	return &SessionTicketKeyRotator{
+		cfg:  cfg,
+		keep: keep,
+		rand: rand.Reader,
+	}
+}
+
+// Rotate generates the new key from the cryptographically random source,
+// the new key is used to issue the tickets,
+// and the previous keys are kept to resume the sessions.
+func (r *SessionTicketKeyRotator) Rotate() error {
+	var key [32]byte
+	if _, err := io.ReadFull(r.rand, key[:]); err != nil {
+		logger.Errorf("api=SessionTicketKeyRotator, reason=rand, err=[%v]", errors.ErrorStack(err))
+		return errors.Annotate(err, "failed to generate session ticket key")
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	keys := append([][32]byte{key}, r.keys...)
+	if len(keys) > r.keep {
+		keys = keys[:r.keep]
+	}
+	r.keys = keys
+	r.cfg.SetSessionTicketKeys(keys)
+
+	metrics.IncrCounter(keyForSessionTicketKeyRotations, 1)
+	logger.Infof("api=SessionTicketKeyRotator, status=rotated, keys=%d", len(keys))
+	return nil
+}
+
+// Keys returns the number of the session ticket keys in the rotation
+func (r *SessionTicketKeyRotator) Keys() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.keys)
+}
+
+// Task returns the task to rotate the keys at the interval,
+// the task should be added to the server's scheduler.
+// The caller should call Rotate before the server is started,
+// so the keys are set before the first ticket is issued.
+func (r *SessionTicketKeyRotator) Task(interval uint64, unit tasks.TimeUnit) tasks.Task {
+	return tasks.NewTaskAtIntervals(interval, unit).Do(SessionTicketKeyRotationTaskName, r.Rotate)
+}
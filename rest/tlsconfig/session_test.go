@@ -0,0 +1,99 @@
+package tlsconfig_test
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+
+	"github.com/go-phorce/dolly/rest/tlsconfig"
+	"github.com/go-phorce/dolly/tasks"
+	"github.com/go-phorce/dolly/testify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ApplySessionPolicy(t *testing.T) {
+	cfg := &tls.Config{}
+	tlsconfig.ApplySessionPolicy(cfg, nil)
+	assert.False(t, cfg.SessionTicketsDisabled)
+	assert.Equal(t, tls.RenegotiateNever, cfg.Renegotiation)
+
+	tlsconfig.ApplySessionPolicy(cfg, &tlsconfig.SessionPolicy{
+		SessionTicketsDisabled: true,
+		Renegotiation:          tls.RenegotiateOnceAsClient,
+	})
+	assert.True(t, cfg.SessionTicketsDisabled)
+	assert.Equal(t, tls.RenegotiateOnceAsClient, cfg.Renegotiation)
+}
+
+func Test_SessionTicketKeyRotator(t *testing.T) {
+	pemCert, pemKey, err := testify.MakeSelfCertRSAPem(1)
+	require.NoError(t, err)
+	pair, err := tls.X509KeyPair(pemCert, pemKey)
+	require.NoError(t, err)
+
+	serverCfg := &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		// TLS 1.2 issues the ticket during the handshake
+		MaxVersion: tls.VersionTLS12,
+	}
+
+	rotator := tlsconfig.NewSessionTicketKeyRotator(serverCfg, 2)
+	require.NoError(t, rotator.Rotate())
+	assert.Equal(t, 1, rotator.Keys())
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+
+	go func() {
+		for {
+			c, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			tc := tls.Server(c, serverCfg)
+			tc.Handshake()
+			tc.Close()
+		}
+	}()
+
+	clientCfg := &tls.Config{
+		InsecureSkipVerify: true,
+		ClientSessionCache: tls.NewLRUClientSessionCache(1),
+	}
+	resumed := func() bool {
+		c, err := tls.Dial("tcp", lis.Addr().String(), clientCfg)
+		require.NoError(t, err)
+		defer c.Close()
+		return c.ConnectionState().DidResume
+	}
+
+	assert.False(t, resumed())
+	assert.True(t, resumed())
+
+	// the previous key is kept for resumption
+	require.NoError(t, rotator.Rotate())
+	assert.Equal(t, 2, rotator.Keys())
+	assert.True(t, resumed())
+
+	// the ticket is re-issued with the current key on resumption,
+	// so rotate twice to drop the key of the cached ticket
+	require.NoError(t, rotator.Rotate())
+	require.NoError(t, rotator.Rotate())
+	assert.Equal(t, 2, rotator.Keys())
+	assert.False(t, resumed())
+
+	t.Run("disabled", func(t *testing.T) {
+		tlsconfig.ApplySessionPolicy(serverCfg, &tlsconfig.SessionPolicy{SessionTicketsDisabled: true})
+		defer tlsconfig.ApplySessionPolicy(serverCfg, &tlsconfig.SessionPolicy{})
+
+		assert.False(t, resumed())
+		assert.False(t, resumed())
+	})
+
+	t.Run("task", func(t *testing.T) {
+		task := rotator.Task(1, tasks.Hours)
+		assert.Contains(t, task.Name(), tlsconfig.SessionTicketKeyRotationTaskName)
+	})
+}
@@ -0,0 +1,182 @@
+package rest
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+// APIVersion specifies the version of the API served by VersionedService
+type APIVersion struct {
+	// Version specifies the name of the version, such as v2
+	Version string
+	// Service specifies the service, that registers the routes of the version,
+	// relatively to the version prefix, for example /items/:id
+	Service Service
+	// Deprecated specifies to reply with Deprecation header
+	Deprecated bool
+	// Sunset specifies the time, after which the version is removed,
+	// if not zero, then the responses have Sunset header
+	Sunset time.Time
+}
+
+// VersionedService serves multiple versions of the API side by side.
+//
+// The routes of each version are registered under the version prefix,
+// for example /v2/items/:id, and without the prefix, for example /items/:id.
+// The requests without the prefix are routed by the version in Accept header,
+// such as application/vnd.<vendor>.v2+json, or to the default version,
+// if Accept header does not specify the version.
+// The requests for an unknown version are replied with 406 Not Acceptable.
+//
+// The optional interfaces of the version services, such as RoutePolicyProvider,
+// are not used, the routes must not conflict with the version prefixes.
+type VersionedService struct {
+	name     string
+	vendor   string
+	def      string
+	versions []APIVersion
+}
+
+// NewVersionedService returns the service, that serves the versions of the API,
+// where vendor specifies the vendor of the media type in Accept header,
+// such as foo for application/vnd.foo.v2+json.
+// The first version is the default.
+func NewVersionedService(name, vendor string, versions ...APIVersion) *VersionedService {
+	s := &VersionedService{
+		name:     name,
+		vendor:   strings.ToLower(vendor),
+		versions: versions,
+	}
+	if len(versions) > 0 {
+		s.def = versions[0].Version
+	}
+	return s
+}
+
+// WithDefaultVersion specifies the version to serve the requests without the version prefix,
+// when Accept header does not specify the version
+func (s *VersionedService) WithDefaultVersion(version string) *VersionedService {
+	s.def = version
+	return s
+}
+
+// Name returns the service name
+func (s *VersionedService) Name() string {
+	return s.name
+}
+
+// IsReady indicates that all versions are ready to serve their end-points
+func (s *VersionedService) IsReady() bool {
+	for _, v := range s.versions {
+		if !v.Service.IsReady() {
+			return false
+		}
+	}
+	return true
+}
+
+// Close the services of all versions
+func (s *VersionedService) Close() {
+	for _, v := range s.versions {
+		v.Service.Close()
+	}
+}
+
+// Register adds the endpoints of all versions to the overall URL router
+func (s *VersionedService) Register(r Router) {
+	var unversioned []Route
+	handles := map[Route]map[string]Handle{}
+
+	for i := range s.versions {
+		v := &s.versions[i]
+		prefix := "/" + v.Version
+		v.Service.Register(&funcRouter{
+			Router: r,
+			handle: func(method, path string, handle Handle) {
+				handle = v.withHeaders(handle)
+				r.Handle(method, prefix+path, handle)
+
+				route := Route{Method: method, Path: path}
+				if handles[route] == nil {
+					handles[route] = map[string]Handle{}
+					unversioned = append(unversioned, route)
+				}
+				handles[route][v.Version] = handle
+			},
+		})
+	}
+
+	for _, route := range unversioned {
+		route := route
+		r.Handle(route.Method, route.Path, func(w http.ResponseWriter, req *http.Request, p Params) {
+			w.Header().Add(header.Vary, header.Accept)
+
+			version := s.acceptVersion(req)
+			if version == "" {
+				version = s.def
+			}
+			if !s.hasVersion(version) {
+				marshal.WriteJSON(w, req, httperror.WithNotAcceptable("unsupported API version: %q", version))
+				return
+			}
+			handle, ok := handles[route][version]
+			if !ok {
+				marshal.WriteJSON(w, req, httperror.WithNotFound("%s is not supported in API version %q", route.Path, version))
+				return
+			}
+			handle(w, req, p)
+		})
+	}
+}
+
+// hasVersion returns true if the version is served
+func (s *VersionedService) hasVersion(version string) bool {
+	for _, v := range s.versions {
+		if v.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptVersion returns the version from the vendor media type in Accept header,
+// or empty string if the version is not specified
+func (s *VersionedService) acceptVersion(r *http.Request) string {
+	prefix := "application/vnd." + s.vendor + "."
+	for _, accept := range r.Header.Values(header.Accept) {
+		for _, value := range strings.Split(accept, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(value))
+			if err != nil || !strings.HasPrefix(mediaType, prefix) {
+				continue
+			}
+			version := strings.TrimPrefix(mediaType, prefix)
+			if i := strings.IndexByte(version, '+'); i >= 0 {
+				version = version[:i]
+			}
+			return version
+		}
+	}
+	return ""
+}
+
+// withHeaders returns the handle, that sets the deprecation headers of the version
+func (v *APIVersion) withHeaders(handle Handle) Handle {
+	if !v.Deprecated && v.Sunset.IsZero() {
+		return handle
+	}
+	return func(w http.ResponseWriter, r *http.Request, p Params) {
+		if v.Deprecated {
+			w.Header().Set(header.Deprecation, "true")
+		}
+		if !v.Sunset.IsZero() {
+			w.Header().Set(header.Sunset, v.Sunset.UTC().Format(http.TimeFormat))
+		}
+		handle(w, r, p)
+	}
+}
@@ -0,0 +1,100 @@
+package rest_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/rest/resttest"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type versionService struct {
+	version string
+	extra   bool
+}
+
+func (s *versionService) Name() string  { return "items" + s.version }
+func (s *versionService) IsReady() bool { return true }
+func (s *versionService) Close()        {}
+func (s *versionService) Register(r rest.Router) {
+	r.GET("/items/:id", func(w http.ResponseWriter, _ *http.Request, p rest.Params) {
+		w.Write([]byte(s.version + ":" + p.ByName("id")))
+	})
+	if s.extra {
+		r.GET("/extra", func(w http.ResponseWriter, _ *http.Request, _ rest.Params) {
+			w.Write([]byte(s.version + ":extra"))
+		})
+	}
+}
+
+func Test_VersionedService(t *testing.T) {
+	sunset := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	vs := rest.NewVersionedService("items", "foo",
+		rest.APIVersion{Version: "v1", Service: &versionService{version: "v1"}, Deprecated: true, Sunset: sunset},
+		rest.APIVersion{Version: "v2", Service: &versionService{version: "v2", extra: true}},
+	)
+	assert.Equal(t, "items", vs.Name())
+	assert.True(t, vs.IsReady())
+
+	server, url, cleanup := resttest.Start(t, resttest.Options{
+		Services: []resttest.ServiceFactory{
+			func(rest.Server) rest.Service { return vs },
+		},
+	})
+	defer cleanup()
+
+	assert.Equal(t, []rest.Route{
+		{Method: http.MethodGet, Path: "/extra"},
+		{Method: http.MethodGet, Path: "/items/:id"},
+		{Method: http.MethodGet, Path: "/v1/items/:id"},
+		{Method: http.MethodGet, Path: "/v2/extra"},
+		{Method: http.MethodGet, Path: "/v2/items/:id"},
+	}, server.Routes())
+
+	tcases := []struct {
+		path       string
+		accept     string
+		status     int
+		body       string
+		deprecated bool
+	}{
+		{"/v1/items/1", "", http.StatusOK, "v1:1", true},
+		{"/v2/items/1", "", http.StatusOK, "v2:1", false},
+		{"/v2/extra", "", http.StatusOK, "v2:extra", false},
+		{"/items/2", "", http.StatusOK, "v1:2", true},
+		{"/items/2", "application/json", http.StatusOK, "v1:2", true},
+		{"/items/2", "application/json, application/vnd.foo.v2+json", http.StatusOK, "v2:2", false},
+		{"/items/2", "application/vnd.foo.v3+json", http.StatusNotAcceptable, "", false},
+		{"/extra", "application/vnd.foo.v2+json", http.StatusOK, "v2:extra", false},
+		{"/extra", "", http.StatusNotFound, "", false},
+		{"/v3/items/1", "", http.StatusNotFound, "", false},
+	}
+
+	for _, tc := range tcases {
+		req, err := http.NewRequest(http.MethodGet, url+tc.path, nil)
+		require.NoError(t, err)
+		if tc.accept != "" {
+			req.Header.Set(header.Accept, tc.accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, tc.status, resp.StatusCode, "%s %q: %s", tc.path, tc.accept, string(body))
+		if tc.body != "" {
+			assert.Equal(t, tc.body, string(body), "%s %q", tc.path, tc.accept)
+		}
+		if tc.deprecated {
+			assert.Equal(t, "true", resp.Header.Get(header.Deprecation), tc.path)
+			assert.Equal(t, "Tue, 01 Jan 2030 00:00:00 GMT", resp.Header.Get(header.Sunset), tc.path)
+		} else {
+			assert.Empty(t, resp.Header.Get(header.Deprecation), tc.path)
+		}
+	}
+}
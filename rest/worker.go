@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-phorce/dolly/xlog"
+	"github.com/juju/errors"
+)
+
+// WorkerFunc is a long-running background loop, such as a queue consumer,
+// it must return when ctx is canceled
+type WorkerFunc func(ctx context.Context) error
+
+// worker is a background loop registered with AddWorker
+type worker struct {
+	name string
+	run  WorkerFunc
+}
+
+// AddWorker registers the background worker, tied to the server lifecycle.
+// The worker is started in a goroutine by StartHTTP, or immediately,
+// if the server is already started.
+// The worker's context is the server's Context, which is canceled by StopHTTP,
+// and StopHTTP waits for the workers to return within the shutdown timeout,
+// before the services are closed.
+// The panics and errors of the worker are logged and audited,
+// the worker is not restarted.
+func (server *HTTPServer) AddWorker(name string, run WorkerFunc) {
+	w := &worker{name: name, run: run}
+
+	server.lock.Lock()
+	server.workers = append(server.workers, w)
+	started := server.workersStarted
+	server.lock.Unlock()
+
+	if started {
+		server.startWorker(w)
+	}
+}
+
+// startWorkers starts the registered workers once
+func (server *HTTPServer) startWorkers() {
+	server.lock.Lock()
+	if server.workersStarted {
+		server.lock.Unlock()
+		return
+	}
+	server.workersStarted = true
+	workers := append([]*worker(nil), server.workers...)
+	server.lock.Unlock()
+
+	for _, w := range workers {
+		server.startWorker(w)
+	}
+}
+
+// startWorker runs the worker in a goroutine
+func (server *HTTPServer) startWorker(w *worker) {
+	server.workersWG.Add(1)
+	go func() {
+		defer server.workersWG.Done()
+		logger.KV(xlog.INFO, "api", "startWorker", "service", server.Name(), "worker", w.name, "status", "started")
+
+		err := server.runWorker(w)
+		if err != nil && server.ctx.Err() != nil && errors.Cause(err) == context.Canceled {
+			// the worker was stopped by StopHTTP
+			err = nil
+		}
+		if err == nil {
+			logger.KV(xlog.INFO, "api", "startWorker", "service", server.Name(), "worker", w.name, "status", "stopped")
+			return
+		}
+
+		logger.KV(xlog.ERROR, "api", "startWorker", "service", server.Name(), "worker", w.name, "err", err.Error())
+		server.Audit(
+			EvtSourceStatus,
+			EvtWorkerFailed,
+			server.AuditIdentity(),
+			server.LocalIP(),
+			0,
+			fmt.Sprintf("worker=%s, err=%q", w.name, err.Error()),
+		)
+	}()
+}
+
+// runWorker runs the worker, and returns the error, or the recovered panic
+func (server *HTTPServer) runWorker(w *worker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.KV(xlog.ERROR, "api", "runWorker", "worker", w.name, "panic", r, "stack", string(debug.Stack()))
+			err = errors.Errorf("panic: %v", r)
+		}
+	}()
+	return w.run(server.ctx)
+}
+
+// waitWorkers waits for the workers to return within the timeout,
+// and returns an error if the timeout expired
+func (server *HTTPServer) waitWorkers(timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		server.workersWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return errors.Timeoutf("workers did not stop in %v", timeout)
+	}
+}
@@ -0,0 +1,62 @@
+package rest_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/rest"
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ServerWorkers(t *testing.T) {
+	au := auditor.NewInMemory()
+	server, err := rest.New("v1.0.123", "", &serverConfig{BindAddr: ":8094"}, nil)
+	require.NoError(t, err)
+	server.WithAuditor(au)
+
+	var started, stopped int32
+	consumer := func(ctx context.Context) error {
+		atomic.AddInt32(&started, 1)
+		<-ctx.Done()
+		atomic.AddInt32(&stopped, 1)
+		return ctx.Err()
+	}
+	server.AddWorker("consumer", consumer)
+	server.AddWorker("failing", func(ctx context.Context) error {
+		return errors.New("queue is not available")
+	})
+
+	// the workers are not started before StartHTTP
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&started))
+
+	require.NoError(t, server.StartHTTP())
+
+	evt := au.WaitFor(rest.EvtSourceStatus, rest.EvtWorkerFailed, time.Second)
+	require.NotNil(t, evt)
+	assert.Equal(t, `worker=failing, err="queue is not available"`, evt.Message)
+
+	// added after the start
+	au.Reset()
+	server.AddWorker("consumer2", consumer)
+	server.AddWorker("panicking", func(ctx context.Context) error {
+		panic("oops")
+	})
+	evt = au.WaitFor(rest.EvtSourceStatus, rest.EvtWorkerFailed, time.Second)
+	require.NotNil(t, evt)
+	assert.Equal(t, `worker=panicking, err="panic: oops"`, evt.Message)
+
+	for i := 0; i < 100 && atomic.LoadInt32(&started) < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&started))
+
+	server.StopHTTP()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&stopped), "StopHTTP must wait for the workers")
+	assert.Len(t, au.FindAll(rest.EvtSourceStatus, rest.EvtWorkerFailed), 1, "the canceled workers are not failed")
+}
@@ -0,0 +1,72 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EvtSourceTask specifies source for the audited task runs
+const EvtSourceTask = "task"
+
+// Auditor is an interface to record the runs of the audited tasks
+type Auditor interface {
+	// Audit records an auditable event.
+	Audit(
+		source string,
+		eventType string,
+		identity string,
+		contextID string,
+		raftIndex uint64,
+		message string)
+}
+
+// auditedTask records an audit event for each run of the task
+type auditedTask struct {
+	Task
+	auditor Auditor
+}
+
+// Run will try to run the task, if it's not already running,
+// and records the audit event, if the task was run
+func (t *auditedTask) Run() bool {
+	if !t.Task.Run() {
+		return false
+	}
+	t.audit(t.Task.LastError())
+	return true
+}
+
+// runNow executes the task out of band, and records the audit event
+func (t *auditedTask) runNow() error {
+	err := runNow(t.Task)
+	t.audit(err)
+	return err
+}
+
+// audit records the event of the last run of the task
+func (t *auditedTask) audit(err error) {
+	name := t.Task.Name()
+	status := "completed"
+	if err != nil {
+		status = "failed"
+	}
+	msg := fmt.Sprintf("task=%q, status=%s, started_at='%s', duration=%v",
+		name,
+		status,
+		t.Task.LastRunTime().UTC().Format(time.RFC3339),
+		t.Task.LastDuration())
+	if err != nil {
+		msg += fmt.Sprintf(", err=[%v]", err)
+	}
+	t.auditor.Audit(EvtSourceTask, AuditEventType(name), "", "", 0, msg)
+}
+
+// AuditEventType returns the type of the audit event of the task,
+// which is the name given to Do, without the function name
+func AuditEventType(taskName string) string {
+	if i := strings.LastIndexByte(taskName, '@'); i > 0 {
+		return taskName[:i]
+	}
+	return taskName
+}
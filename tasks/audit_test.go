@@ -0,0 +1,42 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AddAudited(t *testing.T) {
+	audit := auditor.NewInMemory()
+	scheduler := NewScheduler()
+
+	rotate := NewTaskAtIntervals(1, Hours).Do("cert_rotation", func() error {
+		return errors.New("rotation failed")
+	})
+	silent := NewTaskAtIntervals(1, Hours).Do("silent", testTask)
+	scheduler.AddAudited(rotate, audit).Add(silent)
+	assert.Equal(t, "cert_rotation", AuditEventType(rotate.Name()))
+
+	audited := scheduler.Task(rotate.Name())
+	require.NotNil(t, audited)
+	assert.True(t, audited.Run())
+	evt := audit.Find(EvtSourceTask, "cert_rotation")
+	require.NotNil(t, evt)
+	assert.Contains(t, evt.Message, `task="`+rotate.Name()+`", status=failed, started_at=`)
+	assert.Contains(t, evt.Message, "duration=")
+	assert.Contains(t, evt.Message, "err=[rotation failed]")
+
+	audit.Reset()
+	assert.EqualError(t, scheduler.RunNow(rotate.Name()), "rotation failed")
+	assert.Len(t, audit.FindAll(EvtSourceTask, "cert_rotation"), 1)
+	assert.Equal(t, uint32(2), rotate.RunCount())
+
+	// not audited tasks are silent
+	audit.Reset()
+	assert.True(t, scheduler.Task(silent.Name()).Run())
+	assert.NoError(t, scheduler.RunNow(silent.Name()))
+	assert.Equal(t, 0, audit.Len())
+}
@@ -28,6 +28,9 @@
 
 	scheduler.Add(j)
 
+	// Record an audit event for each run of the task
+	scheduler.AddAudited(j, auditor)
+
 	// Start the scheduler
 	scheduler.Start()
 
@@ -7,8 +7,10 @@ package tasks
 import (
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-phorce/dolly/clock"
 	"github.com/go-phorce/dolly/xlog"
 	"github.com/juju/errors"
 )
@@ -18,21 +20,61 @@ var logger = xlog.NewPackageLogger("github.com/go-phorce/dolly", "tasks")
 // Time location, default set by the time.Local (*time.Location)
 var loc = time.Local
 
+// globalClock holds the time source for the scheduler and tasks
+var globalClock atomic.Value
+
+type clockHolder struct {
+	clock.Clock
+}
+
+func init() {
+	globalClock.Store(clockHolder{clock.New()})
+}
+
+// clk returns the time source for the package
+func clk() clock.Clock {
+	return globalClock.Load().(clockHolder).Clock
+}
+
 // SetGlobalLocation the time location for the package
 func SetGlobalLocation(newLocation *time.Location) {
 	loc = newLocation
 }
 
+// SetGlobalClock sets the time source for the package,
+// a fake clock allows to advance the time in unit tests
+func SetGlobalClock(c clock.Clock) {
+	if c == nil {
+		logger.Panic("Clock must not be nil")
+	}
+	globalClock.Store(clockHolder{c})
+}
+
 // Scheduler defines the scheduler interface
 type Scheduler interface {
 	// Add adds a task to a pool of scheduled tasks
 	Add(Task) Scheduler
+	// AddAudited adds a task to a pool of scheduled tasks,
+	// and records an audit event with the status, duration and error
+	// of each run of the task
+	AddAudited(Task, Auditor) Scheduler
 	// Clear will delete all scheduled tasks
 	Clear()
 	// Count returns the number of registered tasks
 	Count() int
+	// Tasks returns the list of registered tasks
+	Tasks() []Task
+	// Task returns the registered task by name, or nil if not found
+	Task(name string) Task
+	// RunNow executes the named task immediately, without changing its schedule,
+	// and returns the error returned by the task
+	RunNow(name string) error
 	// IsRunning return the status
 	IsRunning() bool
+	// LastTick returns the time of the last tick of the scheduler,
+	// or zero time if the scheduler was not started.
+	// The running scheduler ticks every second.
+	LastTick() time.Time
 	// Start all the pending tasks
 	Start() error
 	// Stop the scheduler
@@ -45,6 +87,8 @@ type scheduler struct {
 	running bool
 	quit    chan bool
 	lock    sync.RWMutex
+	// lastTick is the time of the last tick in UnixNano
+	lastTick int64
 }
 
 // Scheduler implements the sort.Interface{} for sorting tasks, by the time nextRun
@@ -96,6 +140,56 @@ func (s *scheduler) getRunnableTasks() []Task {
 	return runnable
 }
 
+// Tasks returns the list of registered tasks
+func (s *scheduler) Tasks() []Task {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	list := make([]Task, len(s.tasks))
+	copy(list, s.tasks)
+	return list
+}
+
+// Task returns the registered task by name, or nil if not found
+func (s *scheduler) Task(name string) Task {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, j := range s.tasks {
+		if j.Name() == name {
+			return j
+		}
+	}
+	return nil
+}
+
+// RunNow executes the named task immediately, without changing its schedule,
+// and returns the error returned by the task.
+// If the task is already running, then it waits for the run to complete.
+func (s *scheduler) RunNow(name string) error {
+	j := s.Task(name)
+	if j == nil {
+		return errors.NotFoundf("task %q", name)
+	}
+
+	logger.Infof("api=Scheduler.RunNow, task=%q", name)
+	if t, ok := j.(*auditedTask); ok {
+		return t.runNow()
+	}
+	return runNow(j)
+}
+
+// runNow executes the task immediately, without changing its schedule
+func runNow(j Task) error {
+	if t, ok := j.(*task); ok {
+		return t.runNow()
+	}
+	if !j.Run() {
+		return errors.Errorf("task %q is already running", j.Name())
+	}
+	return j.LastError()
+}
+
 // Get the current runnable tasks, which shouldRun is True
 func (s *scheduler) getAllTasks() []Task {
 	s.lock.Lock()
@@ -113,6 +207,15 @@ func (s *scheduler) Add(j Task) Scheduler {
 	return s
 }
 
+// AddAudited adds a task to a pool of scheduled tasks,
+// and records an audit event with the status, duration and error
+// of each run of the task.
+// The event source is EvtSourceTask, and the event type is AuditEventType
+// of the task name.
+func (s *scheduler) AddAudited(j Task, auditor Auditor) Scheduler {
+	return s.Add(&auditedTask{Task: j, auditor: auditor})
+}
+
 // runPending will run all the tasks that are scheduled to run.
 func (s *scheduler) runPending() {
 	for _, task := range s.getRunnableTasks() {
@@ -135,6 +238,16 @@ func (s *scheduler) IsRunning() bool {
 	return s.running
 }
 
+// LastTick returns the time of the last tick of the scheduler,
+// or zero time if the scheduler was not started
+func (s *scheduler) LastTick() time.Time {
+	tick := atomic.LoadInt64(&s.lastTick)
+	if tick == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, tick)
+}
+
 // Start all the pending tasks,
 // and create a second ticker
 func (s *scheduler) Start() error {
@@ -146,15 +259,23 @@ func (s *scheduler) Start() error {
 		return errors.Errorf("api=Scheduler.Start, reasoen=already_running")
 	}
 	s.running = true
+	atomic.StoreInt64(&s.lastTick, clk().Now().UnixNano())
 
-	ticker := time.NewTicker(1 * time.Second)
 	go func() {
+		// tick every second, aligned to the start time as time.Ticker does
+		next := clk().Now().Add(time.Second)
 		for {
 			select {
-			case <-ticker.C:
+			case <-clk().After(next.Sub(clk().Now())):
+				atomic.StoreInt64(&s.lastTick, clk().Now().UnixNano())
 				s.runPending()
+
+				next = next.Add(time.Second)
+				if now := clk().Now(); !next.After(now) {
+					// skip the missed ticks
+					next = now.Add(time.Second - now.Sub(next)%time.Second)
+				}
 			case <-s.quit:
-				ticker.Stop()
 				return
 			}
 		}
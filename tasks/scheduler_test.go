@@ -1,9 +1,12 @@
 package tasks
 
 import (
+	"runtime"
 	"testing"
 	"time"
 
+	"github.com/go-phorce/dolly/clock"
+	"github.com/juju/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -57,3 +60,97 @@ func Test_AddAndClear(t *testing.T) {
 	scheduler.Clear()
 	assert.Equal(t, 0, scheduler.Count())
 }
+
+func Test_TasksAndStats(t *testing.T) {
+	scheduler := NewScheduler()
+	require.NotNil(t, scheduler)
+
+	failing := NewTaskAtIntervals(1, Hours).Do("failing", func() error {
+		return errors.New("task failed")
+	})
+	scheduler.Add(NewTaskAtIntervals(1, Hours).Do("test", testTask))
+	scheduler.Add(failing)
+
+	list := scheduler.Tasks()
+	require.Len(t, list, 2)
+	assert.Nil(t, scheduler.Task("notfound"))
+	assert.Equal(t, failing, scheduler.Task(failing.Name()))
+
+	assert.True(t, failing.Run())
+	assert.EqualError(t, failing.LastError(), "task failed")
+	assert.NotZero(t, failing.LastDuration())
+
+	ok := list[0]
+	assert.True(t, ok.Run())
+	assert.NoError(t, ok.LastError())
+}
+
+func Test_RunNow(t *testing.T) {
+	scheduler := NewScheduler()
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	blocking := NewTaskAtIntervals(1, Hours).Do("blocking", func() {
+		started <- struct{}{}
+		<-release
+	})
+	failing := NewTaskAtIntervals(1, Hours).Do("failing", func() error {
+		return errors.New("task failed")
+	})
+	scheduler.Add(blocking).Add(failing)
+
+	next := failing.NextScheduledTime()
+	assert.EqualError(t, scheduler.RunNow(failing.Name()), "task failed")
+	assert.Equal(t, uint32(1), failing.RunCount())
+	assert.Equal(t, next, failing.NextScheduledTime(), "the schedule must not change")
+
+	assert.True(t, errors.IsNotFound(scheduler.RunNow("notfound")))
+
+	// RunNow waits for the regular run to complete
+	go blocking.Run()
+	<-started
+	done := make(chan error)
+	go func() {
+		done <- scheduler.RunNow(blocking.Name())
+	}()
+	close(release)
+	<-started
+	assert.NoError(t, <-done)
+	assert.Equal(t, uint32(2), blocking.RunCount())
+}
+
+func Test_SchedulerWithMockClock(t *testing.T) {
+	mock := clock.NewMock(time.Date(2020, time.May, 1, 10, 0, 0, 0, time.UTC))
+	SetGlobalClock(mock)
+	defer SetGlobalClock(clock.New())
+
+	fired := make(chan time.Time, 1)
+	j := NewTaskAtIntervals(10, Seconds).Do("mock", func() {
+		fired <- mock.Now()
+	})
+
+	scheduler := NewScheduler()
+	scheduler.Add(j)
+	assert.True(t, scheduler.LastTick().IsZero())
+	require.NoError(t, scheduler.Start())
+	defer scheduler.Stop()
+	assert.Equal(t, mock.Now().UnixNano(), scheduler.LastTick().UnixNano())
+
+	waitForTick := func() {
+		for mock.Waiters() == 0 {
+			runtime.Gosched()
+		}
+	}
+
+	// not yet due
+	waitForTick()
+	mock.Add(5 * time.Second)
+	waitForTick()
+	assert.Len(t, fired, 0)
+	assert.Equal(t, uint32(0), j.RunCount())
+
+	mock.Add(6 * time.Second)
+	at := <-fired
+	assert.Equal(t, time.Date(2020, time.May, 1, 10, 0, 11, 0, time.UTC), at)
+	assert.Equal(t, at.UnixNano(), scheduler.LastTick().UnixNano())
+}
@@ -7,6 +7,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -43,6 +44,11 @@ type Task interface {
 	LastRunTime() time.Time
 	// Duration returns interval between runs
 	Duration() time.Duration
+	// LastDuration returns the execution time of the last run
+	LastDuration() time.Duration
+	// LastError returns the error returned by the last run,
+	// if the task function returns error as the last value
+	LastError() error
 
 	// ShouldRun returns true if the task should be run now
 	ShouldRun() bool
@@ -81,8 +87,15 @@ type task struct {
 
 	runLock chan struct{}
 	running bool
+
+	// statsLock protects the stats of the last run
+	statsLock    sync.RWMutex
+	lastDuration time.Duration
+	lastErr      error
 }
 
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
 // NewTaskAtIntervals creates a new task with the time interval.
 func NewTaskAtIntervals(interval uint64, unit TimeUnit) Task {
 	return &task{
@@ -154,7 +167,7 @@ func (j *task) RunCount() uint32 {
 
 // ShouldRun returns true if the task should be run now
 func (j *task) ShouldRun() bool {
-	return !j.running && time.Now().After(j.nextRunAt)
+	return !j.running && clk().Now().After(j.nextRunAt)
 }
 
 // NextScheduledTime returns the time of when this task is to run next
@@ -170,6 +183,20 @@ func (j *task) LastRunTime() time.Time {
 	return time.Unix(0, 0)
 }
 
+// LastDuration returns the execution time of the last run
+func (j *task) LastDuration() time.Duration {
+	j.statsLock.RLock()
+	defer j.statsLock.RUnlock()
+	return j.lastDuration
+}
+
+// LastError returns the error returned by the last run
+func (j *task) LastError() error {
+	j.statsLock.RLock()
+	defer j.statsLock.RUnlock()
+	return j.lastErr
+}
+
 // // Duration returns interval between runs
 func (j *task) Duration() time.Duration {
 	if j.period == 0 {
@@ -218,18 +245,19 @@ func (j *task) Do(taskName string, taskFunc interface{}, params ...interface{})
 }
 
 func (j *task) at(hour, min int) *task {
-	y, m, d := time.Now().Date()
+	now := clk().Now()
+	y, m, d := now.Date()
 
 	// time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
 	mock := time.Date(y, m, d, hour, min, 0, 0, loc)
 
 	if j.unit == Days {
-		if !time.Now().After(mock) {
+		if !now.After(mock) {
 			// remove 1 day
 			mock = mock.UTC().AddDate(0, 0, -1).Local()
 		}
 	} else if j.unit == Weeks {
-		if j.startDay != time.Now().Weekday() || (time.Now().After(mock) && j.startDay == time.Now().Weekday()) {
+		if j.startDay != now.Weekday() || (now.After(mock) && j.startDay == now.Weekday()) {
 			i := int(mock.Weekday() - j.startDay)
 			if i < 0 {
 				i = 7 + i
@@ -246,7 +274,7 @@ func (j *task) at(hour, min int) *task {
 
 // scheduleNextRun computes the instant when this task should run next
 func (j *task) scheduleNextRun() time.Time {
-	now := time.Now()
+	now := clk().Now()
 	if j.lastRunAt == nil {
 		if j.unit == Weeks {
 			i := now.Weekday() - j.startDay
@@ -282,18 +310,7 @@ func (j *task) Run() bool {
 	select {
 	case j.runLock <- struct{}{}:
 		timer.Stop()
-		now := time.Now()
-		j.lastRunAt = &now
-		j.running = true
-		count := atomic.AddUint32(&j.count, 1)
-
-		logger.Infof("api=task.Run, status=running, count=%d, started_at='%v', task=%q",
-			count,
-			j.lastRunAt.Format(time.RFC3339),
-			j.Name())
-
-		j.callback.Call(j.params)
-		j.running = false
+		j.execute()
 		j.scheduleNextRun()
 		<-j.runLock
 		return true
@@ -307,6 +324,43 @@ func (j *task) Run() bool {
 	return false
 }
 
+// runNow executes the task out of band, without changing its schedule,
+// if the task is already running, then it waits for the run to complete
+func (j *task) runNow() error {
+	j.runLock <- struct{}{}
+	defer func() { <-j.runLock }()
+	return j.execute()
+}
+
+// execute calls the task function and records the stats of the run,
+// the caller must hold runLock
+func (j *task) execute() error {
+	now := clk().Now()
+	j.lastRunAt = &now
+	j.running = true
+	count := atomic.AddUint32(&j.count, 1)
+
+	logger.Infof("api=task.Run, status=running, count=%d, started_at='%v', task=%q",
+		count,
+		j.lastRunAt.Format(time.RFC3339),
+		j.Name())
+
+	res := j.callback.Call(j.params)
+	var err error
+	if n := len(res); n > 0 && res[n-1].Type().Implements(errorType) && !res[n-1].IsNil() {
+		err = res[n-1].Interface().(error)
+		logger.Errorf("api=task.Run, task=%q, err=[%v]", j.Name(), err)
+	}
+
+	j.statsLock.Lock()
+	j.lastDuration = clk().Now().Sub(now)
+	j.lastErr = err
+	j.statsLock.Unlock()
+
+	j.running = false
+	return err
+}
+
 func parseTimeFormat(t string) (hour, min int, err error) {
 	var errTimeFormat = errors.NotValidf("%q time format", t)
 	ts := strings.Split(t, ":")
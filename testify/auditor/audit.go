@@ -2,6 +2,7 @@ package auditor
 
 import (
 	"sync"
+	"time"
 )
 
 // Event provides a default impl of Event
@@ -19,6 +20,8 @@ type InMemory struct {
 	events []*Event
 	sync.Mutex
 	closed bool
+	// changed is closed when a new event is recorded
+	changed chan struct{}
 }
 
 // NewInMemory creates new Auditor that captures events in memory
@@ -50,6 +53,49 @@ func (a *InMemory) Find(source, eventType string) *Event {
 	return nil
 }
 
+// FindAll returns all events with the source and event type,
+// the empty eventType matches any event of the source
+func (a *InMemory) FindAll(source, eventType string) []*Event {
+	a.Lock()
+	defer a.Unlock()
+	var result []*Event
+	for _, e := range a.events {
+		if e.Source == source && (eventType == "" || e.EventType == eventType) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// WaitFor returns first event satisfying the filter,
+// waiting for the event to be recorded up to the timeout,
+// or nil if the event is not recorded within the timeout.
+// It's safe to call while the events are recorded by other go-routines.
+func (a *InMemory) WaitFor(source, eventType string, timeout time.Duration) *Event {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		a.Lock()
+		for _, e := range a.events {
+			if e.Source == source && e.EventType == eventType {
+				a.Unlock()
+				return e
+			}
+		}
+		if a.changed == nil {
+			a.changed = make(chan struct{})
+		}
+		changed := a.changed
+		a.Unlock()
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return nil
+		}
+	}
+}
+
 // GetAll returns a cloned copy of all the events
 // Ordering in audit events may not work as expected
 // when multiple go-routines are appending events
@@ -72,6 +118,10 @@ func (a *InMemory) Event(e *Event) {
 			a.events = make([]*Event, 0, 10)
 		}
 		a.events = append(a.events, e)
+		if a.changed != nil {
+			close(a.changed)
+			a.changed = nil
+		}
 	}
 }
 
@@ -2,6 +2,7 @@ package auditor_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/go-phorce/dolly/testify/auditor"
 	"github.com/stretchr/testify/assert"
@@ -34,3 +35,32 @@ func Test_Audit(t *testing.T) {
 
 	assert.NoError(t, a.Close())
 }
+
+func Test_AuditFindAndWait(t *testing.T) {
+	a := auditor.NewInMemory()
+	defer a.Close()
+
+	a.Audit("source1", "evt1", "bob/bob1-1", "1234", 0, "message1")
+	a.Audit("source1", "evt2", "bob/bob1-1", "1235", 0, "message2")
+	a.Audit("source2", "evt1", "bob/bob1-1", "1236", 0, "message3")
+
+	assert.Len(t, a.FindAll("source1", ""), 2)
+	assert.Len(t, a.FindAll("source1", "evt2"), 1)
+	assert.Empty(t, a.FindAll("source3", ""))
+
+	evt := a.WaitFor("source2", "evt1", time.Millisecond)
+	if assert.NotNil(t, evt) {
+		assert.Equal(t, "message3", evt.Message)
+	}
+	assert.Nil(t, a.WaitFor("source3", "evt1", 10*time.Millisecond))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		a.Audit("source1", "evt1", "bob/bob1-1", "1237", 0, "noise")
+		a.Audit("source3", "evt3", "bob/bob1-1", "1238", 0, "message4")
+	}()
+	evt = a.WaitFor("source3", "evt3", 5*time.Second)
+	if assert.NotNil(t, evt) {
+		assert.Equal(t, "message4", evt.Message)
+	}
+}
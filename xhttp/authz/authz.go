@@ -25,6 +25,9 @@
 // Once you've built your Provider you can call NewHandler to get a http.Handler
 // that implements those rules.
 //
+// If an Auditor is set, the handler emits an audit event for each denied request,
+// and for allowed requests to the paths configured with AuditAllowed.
+//
 package authz
 
 import (
@@ -53,6 +56,27 @@ var (
 	ErrNoPathsConfigured = errors.New("you must have at least one path before being able to create a http.Handler")
 )
 
+const (
+	// EvtSourceAuthz specifies source for authorization events
+	EvtSourceAuthz = "authz"
+	// EvtDenied specifies Access Denied event
+	EvtDenied = "denied"
+	// EvtAllowed specifies Access Allowed event
+	EvtAllowed = "allowed"
+)
+
+// Auditor is an interface to record the authorization decisions
+type Auditor interface {
+	// Audit records an auditable event.
+	Audit(
+		source string,
+		eventType string,
+		identity string,
+		contextID string,
+		raftIndex uint64,
+		message string)
+}
+
 // Config contains configuration for the authorization module
 type Config struct {
 	// Allow will allow the specified roles access to this path and its children, in format: ${path}:${role},${role}
@@ -72,6 +96,10 @@ type Config struct {
 
 	// LogDenied specifies to log denied access
 	LogDenied bool
+
+	// AuditAllowed specifies the paths, where allowed access to the path and its children
+	// is audited, in addition to the denied access
+	AuditAllowed []string
 }
 
 // Provider represents an Authorization provider,
@@ -83,6 +111,9 @@ type Provider struct {
 	roleMapper func(r *http.Request) string
 	pathRoot   *pathNode
 	cfg        *Config
+	auditor    Auditor
+	// auditPaths specifies paths where allowed access is audited
+	auditPaths []string
 }
 
 type allowTypes int8
@@ -144,6 +175,11 @@ func New(cfg *Config) (*Provider, error) {
 		az.Allow(parts[0], roles...)
 	}
 
+	for _, s := range cfg.AuditAllowed {
+		az.AuditAllowed(s)
+		logger.Noticef("api=authz.New, AuditAllowed=%s", s)
+	}
+
 	return az, nil
 }
 
@@ -247,6 +283,8 @@ func (c *Provider) Clone() *Provider {
 		roleMapper: c.roleMapper,
 		pathRoot:   c.pathRoot.clone(),
 		cfg:        &Config{},
+		auditor:    c.auditor,
+		auditPaths: append([]string{}, c.auditPaths...),
 	}
 
 	copier.Copy(p.cfg, c.cfg)
@@ -259,6 +297,30 @@ func (c *Provider) SetRoleMapper(m func(r *http.Request) string) {
 	c.roleMapper = m
 }
 
+// Role returns the role of the request, as provided by the role mapper,
+// or the guest role if the mapper returns an empty role
+func (c *Provider) Role(r *http.Request) string {
+	role := c.roleMapper(r)
+	if role == "" {
+		role = identity.GuestRoleName
+	}
+	return role
+}
+
+// SetAuditor configures the auditor to record the authorization decisions
+func (c *Provider) SetAuditor(auditor Auditor) {
+	c.auditor = auditor
+}
+
+// AuditAllowed will audit allowed access to this path and its children,
+// denied access is always audited when the Auditor is set
+func (c *Provider) AuditAllowed(path string) {
+	if len(path) == 0 || path[0] != '/' {
+		panic(fmt.Sprintf("Invalid path supplied to AuditAllowed %v", path))
+	}
+	c.auditPaths = append(c.auditPaths, strings.TrimSuffix(path, "/"))
+}
+
 // AllowAny will allow any authenticated request access to this path and its children
 // [unless a specific Allow/AllowAny is called for a child path]
 func (c *Provider) AllowAny(path string) {
@@ -346,22 +408,64 @@ func (c *Provider) isAllowed(path, role string) bool {
 	return res
 }
 
+// isAudited returns true if allowed access to 'path' has to be audited
+func (c *Provider) isAudited(path string) bool {
+	for _, p := range c.auditPaths {
+		if strings.HasPrefix(path, p) && (len(path) == len(p) || path[len(p)] == '/') {
+			return true
+		}
+	}
+	return false
+}
+
 // checkAccess ensures that access to the supplied http.request is allowed
 func (c *Provider) checkAccess(r *http.Request) error {
+	_, err := c.authorize(r)
+	return err
+}
+
+// authorize returns the role of the request,
+// and an error if access to the supplied http.request is not allowed.
+// The role is empty for OPTIONS requests, which are always allowed.
+func (c *Provider) authorize(r *http.Request) (string, error) {
 	if r.Method == http.MethodOptions {
 		// always allow OPTIONS
-		return nil
+		return "", nil
 	}
 
-	role := c.roleMapper(r)
-	if role == "" {
-		role = identity.GuestRoleName
-	}
+	role := c.Role(r)
 	if !c.isAllowed(r.URL.Path, role) {
-		return errors.Errorf("the %q role is not allowed", role)
+		return role, errors.Errorf("the %q role is not allowed", role)
+	}
+
+	return role, nil
+}
+
+// audit records the authorization decision
+func (c *Provider) audit(eventType string, r *http.Request, role string) {
+	ctx := identity.ForRequest(r)
+	var id string
+	if ctx.Identity() != nil {
+		id = ctx.Identity().String()
 	}
+	c.auditor.Audit(
+		EvtSourceAuthz,
+		eventType,
+		id,
+		ctx.CorrelationID(),
+		0,
+		AuditMessage(r, role),
+	)
+}
 
-	return nil
+// AuditMessage returns the message of the authorization audit event for the request,
+// with the method, path, role and the tenant of the request
+func AuditMessage(r *http.Request, role string) string {
+	msg := fmt.Sprintf("method=%s, path=%s, role=%s", r.Method, r.URL.Path, role)
+	if tenant := identity.ForRequest(r).Tenant(); tenant != "" {
+		msg += ", tenant=" + tenant
+	}
+	return msg
 }
 
 // NewHandler returns a http.Handler that enforces the current authorization configuration
@@ -391,10 +495,16 @@ type authHandler struct {
 }
 
 func (a *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	err := a.config.checkAccess(r)
+	role, err := a.config.authorize(r)
 	if err == nil {
+		if a.config.auditor != nil && role != "" && a.config.isAudited(r.URL.Path) {
+			a.config.audit(EvtAllowed, r, role)
+		}
 		a.delegate.ServeHTTP(w, r)
 	} else {
+		if a.config.auditor != nil {
+			a.config.audit(EvtDenied, r, role)
+		}
 		marshal.WriteJSON(w, r, httperror.WithUnauthorized(err.Error()))
 	}
 }
@@ -7,6 +7,8 @@ import (
 	"sort"
 	"testing"
 
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/go-phorce/dolly/xhttp/identity"
 	"github.com/go-phorce/dolly/xlog"
 
 	"github.com/go-phorce/dolly/xhttp/header"
@@ -292,6 +294,18 @@ func TestConfig_Clone(t *testing.T) {
 	assert.True(t, clone.isAllowed("/foo", "bob"), "Config.Clone() return a clone that's missing an Allow() from the source")
 }
 
+func TestConfig_Role(t *testing.T) {
+	c, err := New(&Config{})
+	require.NoError(t, err)
+
+	r, _ := http.NewRequest(http.MethodGet, "/foo", nil)
+	c.SetRoleMapper(roleMapper("bob"))
+	assert.Equal(t, "bob", c.Role(r))
+
+	c.SetRoleMapper(roleMapper(""))
+	assert.Equal(t, identity.GuestRoleName, c.Role(r))
+}
+
 func TestConfig_checkAccess_noTLS(t *testing.T) {
 	c, err := New(&Config{})
 	require.NoError(t, err)
@@ -366,6 +380,50 @@ func TestConfig_Handler(t *testing.T) {
 	testHandler("/", false)
 }
 
+func TestConfig_HandlerAudit(t *testing.T) {
+	delegate := http.HandlerFunc(testHTTPHandler)
+	c, err := New(&Config{
+		Allow:        []string{"/bob:bob"},
+		AuditAllowed: []string{"/bob/admin"},
+	})
+	require.NoError(t, err)
+
+	au := auditor.NewInMemory()
+	c.SetRoleMapper(roleMapper("bob"))
+	c.SetAuditor(au)
+	h, err := c.NewHandler(delegate)
+	require.NoError(t, err)
+
+	testHandler := func(method, path string, expectedStatus int) {
+		r, err := http.NewRequest(method, path, nil)
+		require.NoError(t, err)
+		r.Header.Set(header.XCorrelationID, "corr1234")
+		r = identity.WithTestIdentity(r, identity.NewIdentity("bob", "bob1", ""))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, expectedStatus, w.Code, "%s %s", method, path)
+	}
+
+	testHandler(http.MethodGet, "/bob", http.StatusOK)
+	testHandler(http.MethodGet, "/bob/administrator", http.StatusOK)
+	testHandler(http.MethodOptions, "/alice", http.StatusOK)
+	assert.Equal(t, 0, au.Len())
+
+	testHandler(http.MethodGet, "/alice", http.StatusUnauthorized)
+	require.Equal(t, 1, au.Len())
+	e := au.Find(EvtSourceAuthz, EvtDenied)
+	require.NotNil(t, e)
+	assert.Equal(t, "bob/bob1", e.Identity)
+	assert.Equal(t, "corr1234", e.ContextID)
+	assert.Equal(t, "method=GET, path=/alice, role=bob", e.Message)
+
+	testHandler(http.MethodPost, "/bob/admin/users", http.StatusOK)
+	require.Equal(t, 2, au.Len())
+	e = au.Find(EvtSourceAuthz, EvtAllowed)
+	require.NotNil(t, e)
+	assert.Equal(t, "method=POST, path=/bob/admin/users, role=bob", e.Message)
+}
+
 func testHTTPHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Hello"))
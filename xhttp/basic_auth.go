@@ -0,0 +1,139 @@
+package xhttp
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	"github.com/go-phorce/dolly/xhttp/authz"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+// BasicAuthVerifier verifies the user credentials,
+// and returns the role of the user
+type BasicAuthVerifier func(user, password string) (role string, ok bool)
+
+// BasicAuthUser specifies the credentials of the user
+type BasicAuthUser struct {
+	Password string
+	Role     string
+}
+
+// NewBasicAuthVerifier returns a verifier for the static list of users,
+// the credentials are compared in constant time
+func NewBasicAuthVerifier(users map[string]BasicAuthUser) BasicAuthVerifier {
+	type hashed struct {
+		user     [sha256.Size]byte
+		password [sha256.Size]byte
+		role     string
+	}
+	list := make([]hashed, 0, len(users))
+	for u, c := range users {
+		list = append(list, hashed{
+			user:     sha256.Sum256([]byte(u)),
+			password: sha256.Sum256([]byte(c.Password)),
+			role:     c.Role,
+		})
+	}
+
+	return func(user, password string) (string, bool) {
+		u := sha256.Sum256([]byte(user))
+		p := sha256.Sum256([]byte(password))
+
+		role := ""
+		found := 0
+		// check all entries to not reveal the existing users by timing
+		for i := range list {
+			match := subtle.ConstantTimeCompare(u[:], list[i].user[:]) &
+				subtle.ConstantTimeCompare(p[:], list[i].password[:])
+			if match == 1 {
+				role = list[i].role
+			}
+			found |= match
+		}
+		return role, found == 1
+	}
+}
+
+// BasicAuth is a http.Handler that authenticates the requests
+// with HTTP basic authentication, and sets the caller's Identity
+// in the request context.
+type BasicAuth struct {
+	delegate http.Handler
+	verify   BasicAuthVerifier
+	realm    string
+	auditor  authz.Auditor
+}
+
+// NewBasicAuth returns a handler that serves the requests with valid credentials,
+// and replies with 401 Unauthorized and WWW-Authenticate header otherwise.
+// The delegate can be nil, if the handler is used per route with Authenticate.
+func NewBasicAuth(delegate http.Handler, verify BasicAuthVerifier) *BasicAuth {
+	return &BasicAuth{
+		delegate: delegate,
+		verify:   verify,
+		realm:    "restricted",
+	}
+}
+
+// WithRealm sets the realm returned in WWW-Authenticate header
+func (a *BasicAuth) WithRealm(realm string) *BasicAuth {
+	a.realm = realm
+	return a
+}
+
+// WithAuditor specifies the auditor to record the denied requests
+func (a *BasicAuth) WithAuditor(auditor authz.Auditor) *BasicAuth {
+	a.auditor = auditor
+	return a
+}
+
+// SetAuditor configures the auditor to record the denied requests
+func (a *BasicAuth) SetAuditor(auditor authz.Auditor) {
+	a.auditor = auditor
+}
+
+// ServeHTTP implements the http.Handler interface
+func (a *BasicAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r, ok := a.Authenticate(w, r); ok {
+		a.delegate.ServeHTTP(w, r)
+	}
+}
+
+// Authenticate verifies the credentials of the request,
+// and returns the request with the caller's Identity.
+// If the credentials are not valid, then the error response is written,
+// and false is returned.
+func (a *BasicAuth) Authenticate(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	user, password, ok := r.BasicAuth()
+	if ok {
+		var role string
+		if role, ok = a.verify(user, password); ok {
+			return identity.WithIdentity(r, identity.NewIdentity(role, user, "")), true
+		}
+	}
+
+	if a.auditor != nil {
+		ctx := identity.ForRequest(r)
+		var id string
+		if ctx.Identity() != nil {
+			id = ctx.Identity().String()
+		}
+		a.auditor.Audit(
+			authz.EvtSourceAuthz,
+			authz.EvtDenied,
+			id,
+			ctx.CorrelationID(),
+			0,
+			fmt.Sprintf("method=%s, path=%s, user=%s", r.Method, r.URL.Path, user),
+		)
+	}
+
+	w.Header().Set(header.WWWAuthenticate, fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", a.realm))
+	marshal.WriteJSON(w, r, httperror.WithUnauthorized("invalid credentials"))
+	return r, false
+}
@@ -0,0 +1,85 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/go-phorce/dolly/xhttp/authz"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BasicAuthVerifier(t *testing.T) {
+	verify := NewBasicAuthVerifier(map[string]BasicAuthUser{
+		"admin": {Password: "secret", Role: "admin"},
+		"ops":   {Password: "ops123", Role: "operator"},
+	})
+
+	role, ok := verify("admin", "secret")
+	assert.True(t, ok)
+	assert.Equal(t, "admin", role)
+
+	role, ok = verify("ops", "ops123")
+	assert.True(t, ok)
+	assert.Equal(t, "operator", role)
+
+	_, ok = verify("admin", "ops123")
+	assert.False(t, ok)
+	_, ok = verify("bob", "secret")
+	assert.False(t, ok)
+	_, ok = verify("", "")
+	assert.False(t, ok)
+}
+
+func Test_BasicAuth(t *testing.T) {
+	var caller identity.Identity
+	h := func(w http.ResponseWriter, r *http.Request) {
+		caller = identity.ForRequest(r).Identity()
+		w.WriteHeader(http.StatusOK)
+	}
+
+	au := auditor.NewInMemory()
+	auth := NewBasicAuth(http.HandlerFunc(h), NewBasicAuthVerifier(map[string]BasicAuthUser{
+		"admin": {Password: "secret", Role: "admin"},
+	})).
+		WithRealm("dolly").
+		WithAuditor(au)
+
+	serve := func(user, password string) *httptest.ResponseRecorder {
+		r, err := http.NewRequest(http.MethodGet, "/v1/admin", nil)
+		require.NoError(t, err)
+		r.Header.Set(header.XCorrelationID, "corr1234")
+		if user != "" {
+			r.SetBasicAuth(user, password)
+		}
+		w := httptest.NewRecorder()
+		identity.NewContextHandler(auth).ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("admin", "secret")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, caller)
+	assert.Equal(t, "admin", caller.Role())
+	assert.Equal(t, "admin", caller.Name())
+	assert.Equal(t, "corr1234", w.Header().Get(header.XCorrelationID))
+	assert.Equal(t, 0, au.Len())
+
+	w = serve("admin", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Basic realm="dolly", charset="UTF-8"`, w.Header().Get(header.WWWAuthenticate))
+	assert.Equal(t, `{"code":"unauthorized","message":"invalid credentials"}`, w.Body.String())
+	require.Equal(t, 1, au.Len())
+	e := au.Find(authz.EvtSourceAuthz, authz.EvtDenied)
+	require.NotNil(t, e)
+	assert.Equal(t, "corr1234", e.ContextID)
+	assert.Equal(t, "method=GET, path=/v1/admin, user=admin", e.Message)
+
+	w = serve("", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, 2, au.Len())
+}
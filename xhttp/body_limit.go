@@ -0,0 +1,45 @@
+package xhttp
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+var keyForHTTPReqBodyTooLarge = []string{"http", "request", "body", "too_large"}
+
+// NewMaxBodySize returns a handler that limits the size of the request body to max bytes.
+//
+// The request with Content-Length larger than max is rejected with 413 Request Entity Too Large,
+// before the delegate handler is called, and without reading the body.
+// Otherwise the body is limited by http.MaxBytesReader,
+// so the body is never read beyond the limit,
+// and the delegate handler receives the error on read after max bytes.
+// marshal.DecodeBody and marshal.DecodeRequest return 413 for such error,
+// the handlers reading the body directly should check httperror.IsRequestBodyTooLarge.
+//
+// In both cases the connection is closed after the response,
+// as the rest of the body is not read.
+func NewMaxBodySize(delegate http.Handler, max int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > max {
+			metrics.IncrCounter(keyForHTTPReqBodyTooLarge, 1,
+				metrics.Tag{Name: tags.Method, Value: r.Method},
+			)
+			logger.Warningf("api=MaxBodySize, reason=too_large, method=%s, path=%s, length=%d, limit=%d",
+				r.Method, r.URL.Path, r.ContentLength, max)
+
+			w.Header().Set(header.Connection, "close")
+			marshal.WriteJSON(w, r, httperror.WithRequestEntityTooLarge("the request body is too large"))
+			return
+		}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = http.MaxBytesReader(w, r.Body, max)
+		}
+		delegate.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,116 @@
+package xhttp
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// infiniteReader generates the body up to size bytes,
+// and counts the bytes read
+type infiniteReader struct {
+	size int64
+	read int64
+}
+
+func (r *infiniteReader) Read(p []byte) (int, error) {
+	left := r.size - atomic.LoadInt64(&r.read)
+	if left <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > left {
+		p = p[:left]
+	}
+	for i := range p {
+		p[i] = 'a'
+	}
+	atomic.AddInt64(&r.read, int64(len(p)))
+	return len(p), nil
+}
+
+func Test_MaxBodySize(t *testing.T) {
+	const max = 1 << 20
+
+	var received int64
+	h := NewMaxBodySize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json" {
+			var v map[string]string
+			if marshal.DecodeBody(w, r, &v) == nil {
+				marshal.WriteJSON(w, r, v)
+			}
+			return
+		}
+		n, err := io.Copy(ioutil.Discard, r.Body)
+		atomic.StoreInt64(&received, n)
+		if httperror.IsRequestBodyTooLarge(err) {
+			marshal.WriteJSON(w, r, httperror.WithRequestEntityTooLarge("the request body is too large"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}), max)
+
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	post := func(path string, body io.Reader, length int64) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, s.URL+path, body)
+		require.NoError(t, err)
+		req.ContentLength = length
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(b)
+	}
+
+	t.Run("small", func(t *testing.T) {
+		code, body := post("/", strings.NewReader("hello"), 5)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok", body)
+	})
+
+	t.Run("content_length", func(t *testing.T) {
+		r := &infiniteReader{size: 1 << 30}
+		code, body := post("/", r, r.size)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+		assert.Contains(t, body, `"code":"request_too_large"`)
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		// 1GB chunked body, the transfer is aborted after the limit
+		r := &infiniteReader{size: 1 << 30}
+		code, body := post("/", r, -1)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+		assert.Contains(t, body, `"code":"request_too_large"`)
+		assert.Equal(t, int64(max), atomic.LoadInt64(&received))
+		assert.True(t, atomic.LoadInt64(&r.read) < 1<<28, "read %d", atomic.LoadInt64(&r.read))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		r := io.MultiReader(strings.NewReader(`{"a":"`), &infiniteReader{size: 1 << 30})
+		code, body := post("/json", r, -1)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+		assert.Contains(t, body, `"code":"request_too_large"`)
+	})
+
+	t.Run("keep_alive", func(t *testing.T) {
+		// the next request succeeds after the connection was closed
+		code, body := post("/", strings.NewReader("hello"), 5)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok", body)
+
+		code, body = post("/json", strings.NewReader(`{"a":"b"}`), -1)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Contains(t, body, `"a":"b"`)
+	})
+}
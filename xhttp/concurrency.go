@@ -0,0 +1,160 @@
+package xhttp
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+var (
+	keyForHTTPReqInFlight     = []string{"http", "request", "inflight"}
+	keyForHTTPReqInFlightPeak = []string{"http", "request", "inflight", "peak"}
+	keyForHTTPReqRejected     = []string{"http", "request", "rejected"}
+	keyForHTTPServiceInFlight = []string{"http", "service", "inflight"}
+	keyForHTTPServiceRejected = []string{"http", "service", "rejected"}
+)
+
+// ConcurrencyLimiter is a http.Handler that limits the number of
+// concurrent in-flight requests served by the delegate handler.
+type ConcurrencyLimiter struct {
+	delegate        http.Handler
+	sem             chan struct{}
+	retryAfter      time.Duration
+	exemptStreaming bool
+	auditor         Auditor
+	service         string
+
+	current int32
+	peak    int32
+}
+
+// NewConcurrencyLimiter returns a handler that serves up to max concurrent requests,
+// and replies with 503 Service Unavailable and Retry-After header,
+// when the limit is exceeded.
+func NewConcurrencyLimiter(delegate http.Handler, max int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		delegate:   delegate,
+		sem:        make(chan struct{}, max),
+		retryAfter: time.Second,
+	}
+}
+
+// WithRetryAfter sets the duration returned in Retry-After header
+func (l *ConcurrencyLimiter) WithRetryAfter(d time.Duration) *ConcurrencyLimiter {
+	l.retryAfter = d
+	return l
+}
+
+// WithStreamingExempt specifies to not limit streaming requests,
+// such as SSE (Accept: text/event-stream) or WebSocket upgrade,
+// as they hold the slot for the connection lifetime
+func (l *ConcurrencyLimiter) WithStreamingExempt(exempt bool) *ConcurrencyLimiter {
+	l.exemptStreaming = exempt
+	return l
+}
+
+// WithAuditor sets the auditor to record the rejected requests,
+// use audit.Sampler to not flood the audit log under the load
+func (l *ConcurrencyLimiter) WithAuditor(auditor Auditor) *ConcurrencyLimiter {
+	l.auditor = auditor
+	return l
+}
+
+// WithService scopes the limiter to the service routes,
+// the in-flight and rejected metrics are published per service
+func (l *ConcurrencyLimiter) WithService(service string) *ConcurrencyLimiter {
+	l.service = service
+	return l
+}
+
+// Current returns the number of in-flight requests
+func (l *ConcurrencyLimiter) Current() int {
+	return int(atomic.LoadInt32(&l.current))
+}
+
+// Peak returns the max number of in-flight requests observed
+func (l *ConcurrencyLimiter) Peak() int {
+	return int(atomic.LoadInt32(&l.peak))
+}
+
+// ServeHTTP implements the http.Handler interface
+func (l *ConcurrencyLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if l.exemptStreaming && isStreamingRequest(r) {
+		l.delegate.ServeHTTP(w, r)
+		return
+	}
+
+	select {
+	case l.sem <- struct{}{}:
+	default:
+		l.reject(w, r)
+		return
+	}
+
+	current := atomic.AddInt32(&l.current, 1)
+	for {
+		peak := atomic.LoadInt32(&l.peak)
+		if current <= peak || atomic.CompareAndSwapInt32(&l.peak, peak, current) {
+			break
+		}
+	}
+	l.setInFlight(current)
+	if l.service == "" {
+		metrics.SetGauge(keyForHTTPReqInFlightPeak, float32(l.Peak()))
+	}
+
+	defer func() {
+		l.setInFlight(atomic.AddInt32(&l.current, -1))
+		<-l.sem
+	}()
+
+	l.delegate.ServeHTTP(w, r)
+}
+
+// setInFlight publishes the number of in-flight requests
+func (l *ConcurrencyLimiter) setInFlight(current int32) {
+	if l.service != "" {
+		metrics.SetGauge(keyForHTTPServiceInFlight, float32(current),
+			metrics.Tag{Name: tags.Service, Value: l.service},
+		)
+		return
+	}
+	metrics.SetGauge(keyForHTTPReqInFlight, float32(current))
+}
+
+// reject replies with 503 and Retry-After header
+func (l *ConcurrencyLimiter) reject(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(header.RetryAfter, strconv.Itoa(int(l.retryAfter.Seconds()+0.5)))
+
+	if l.service != "" {
+		metrics.IncrCounter(keyForHTTPServiceRejected, 1,
+			metrics.Tag{Name: tags.Service, Value: l.service},
+			metrics.Tag{Name: tags.Method, Value: r.Method},
+		)
+		logger.Warningf("api=ConcurrencyLimiter, reason=service_limit_exceeded, service=%s, method=%s, path=%s, limit=%d",
+			l.service, r.Method, r.URL.Path, cap(l.sem))
+		if l.auditor != nil {
+			auditRejected(l.auditor, r, "service_concurrency_limit")
+		}
+		marshal.WriteJSON(w, r, httperror.WithServerBusy("the service is busy, try again later"))
+		return
+	}
+
+	metrics.IncrCounter(keyForHTTPReqRejected, 1,
+		metrics.Tag{Name: tags.Method, Value: r.Method},
+		metrics.Tag{Name: tags.URI, Value: r.URL.Path},
+	)
+	logger.Warningf("api=ConcurrencyLimiter, reason=limit_exceeded, method=%s, path=%s, limit=%d",
+		r.Method, r.URL.Path, cap(l.sem))
+	if l.auditor != nil {
+		auditRejected(l.auditor, r, "concurrency_limit")
+	}
+	marshal.WriteJSON(w, r, httperror.WithServerBusy("the server is busy, try again later"))
+}
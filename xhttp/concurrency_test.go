@@ -0,0 +1,145 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConcurrencyLimiter(t *testing.T) {
+	im := metrics.NewInmemSink(time.Minute, time.Minute*5)
+	_, err := metrics.NewGlobal(metrics.DefaultConfig("test"), im)
+	require.NoError(t, err)
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	h := func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}
+
+	auditor := &rejectAuditor{}
+	limiter := NewConcurrencyLimiter(http.HandlerFunc(h), 1).
+		WithRetryAfter(2 * time.Second).
+		WithStreamingExempt(true).
+		WithAuditor(auditor)
+
+	serve := func(accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, "/v1/test", nil)
+		require.NoError(t, err)
+		if accept != "" {
+			r.Header.Set(header.Accept, accept)
+		}
+		limiter.ServeHTTP(w, r)
+		return w
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w := serve("")
+		assert.Equal(t, http.StatusOK, w.Code)
+	}()
+	<-started
+	assert.Equal(t, 1, limiter.Current())
+
+	w := serve("")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "2", w.Header().Get(header.RetryAfter))
+	assert.Equal(t, `{"code":"server_busy","message":"the server is busy, try again later"}`, w.Body.String())
+	require.Len(t, auditor.events, 1)
+	assert.Equal(t, "throttle:rejected:reason=concurrency_limit, method=GET, path=/v1/test, ip=192.0.2.2", auditor.events[0])
+
+	// streaming is exempt
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w := serve(header.TextEventStream)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}()
+	<-started
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, 0, limiter.Current())
+	assert.Equal(t, 1, limiter.Peak())
+
+	data := im.Data()
+	require.NotEmpty(t, data)
+	s, exists := data[0].Counters["test.http.request.rejected;method=GET;uri=/v1/test"]
+	require.True(t, exists)
+	assert.Equal(t, 1, s.Count)
+	// gauges are prefixed with the host name
+	peak := false
+	for k, g := range data[0].Gauges {
+		if strings.HasSuffix(k, ".http.request.inflight.peak") {
+			peak = true
+			assert.Equal(t, float32(1), g.Value)
+		}
+	}
+	assert.True(t, peak, "peak gauge must be published")
+}
+
+type rejectAuditor struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (a *rejectAuditor) Audit(source string, eventType string, identity string, contextID string, raftIndex uint64, message string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.events = append(a.events, source+":"+eventType+":"+message)
+}
+
+func Test_ConcurrencyLimiterWithService(t *testing.T) {
+	im := metrics.NewInmemSink(time.Minute, time.Minute*5)
+	_, err := metrics.NewGlobal(metrics.DefaultConfig("test"), im)
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	h := func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}
+	limiter := NewConcurrencyLimiter(http.HandlerFunc(h), 1).WithService("heavy")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		limiter.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/heavy", nil))
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	limiter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/heavy", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, `{"code":"server_busy","message":"the service is busy, try again later"}`, w.Body.String())
+
+	close(release)
+	<-done
+
+	data := im.Data()
+	require.NotEmpty(t, data)
+	s, exists := data[0].Counters["test.http.service.rejected;service=heavy;method=GET"]
+	require.True(t, exists)
+	assert.Equal(t, 1, s.Count)
+	inFlight := false
+	for k := range data[0].Gauges {
+		if strings.HasSuffix(k, ".http.service.inflight;service=heavy") {
+			inFlight = true
+		}
+	}
+	assert.True(t, inFlight, "service in-flight gauge must be published")
+}
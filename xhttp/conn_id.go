@@ -0,0 +1,40 @@
+package xhttp
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// lastConnectionID is the ID of the last accepted connection
+var lastConnectionID uint64
+
+func nextConnectionID() string {
+	return strconv.FormatUint(atomic.AddUint64(&lastConnectionID, 1), 10)
+}
+
+// ConnectionIDFromRequest returns the ID of the connection the request was received on,
+// the requests sent over the same keep-alive connection have the same ID.
+// Empty value is returned if the server was not started with ConnContext.
+func ConnectionIDFromRequest(r *http.Request) string {
+	if c := connFromRequest(r); c != nil {
+		return c.id
+	}
+	return ""
+}
+
+// ConnectionLogExtractor returns AdditionalLogExtractor that appends conn=<id> field
+// to the fields returned by the next extractor, if provided,
+// to find the requests that shared the connection in the logs.
+func ConnectionLogExtractor(next AdditionalLogExtractor) AdditionalLogExtractor {
+	return func(resp *ResponseCapture, req *http.Request) []string {
+		var fields []string
+		if next != nil {
+			fields = next(resp, req)
+		}
+		if id := ConnectionIDFromRequest(req); id != "" {
+			fields = append(fields, "conn="+id)
+		}
+		return fields
+	}
+}
@@ -0,0 +1,49 @@
+package xhttp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConnectionID(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Empty(t, ConnectionIDFromRequest(r))
+	assert.Empty(t, ConnectionLogExtractor(nil)(nil, r))
+
+	extractor := ConnectionLogExtractor(func(resp *ResponseCapture, req *http.Request) []string {
+		return []string{"corr1234"}
+	})
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields := extractor(nil, r)
+		require.Len(t, fields, 2)
+		assert.Equal(t, "corr1234", fields[0])
+		assert.Equal(t, "conn="+ConnectionIDFromRequest(r), fields[1])
+		w.Write([]byte(ConnectionIDFromRequest(r)))
+	}))
+	s.Config.ConnContext = ConnContext
+	s.Start()
+	defer s.Close()
+
+	get := func(client *http.Client) string {
+		resp, err := client.Get(s.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	client := s.Client()
+	id1 := get(client)
+	require.NotEmpty(t, id1)
+	// keep-alive connection is reused
+	assert.Equal(t, id1, get(client))
+
+	client.Transport.(*http.Transport).CloseIdleConnections()
+	assert.NotEqual(t, id1, get(client))
+}
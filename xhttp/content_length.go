@@ -0,0 +1,54 @@
+package xhttp
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+var keyForHTTPReqLengthRequired = []string{"http", "request", "length_required"}
+
+// NewContentLengthRequired returns a handler that rejects the requests
+// without Content-Length header, or with Transfer-Encoding: chunked,
+// with 411 Length Required, before the delegate handler is called,
+// so the size of the body is declared before it's read.
+// The requests with GET, HEAD, OPTIONS methods are not validated.
+//
+// The connection is closed after the response, as the body is not read.
+//
+// Note that NewRequestDecompressor removes Content-Length header,
+// as it does not apply to the decompressed body,
+// so this handler must be applied before the decompressor.
+func NewContentLengthRequired(delegate http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			delegate.ServeHTTP(w, r)
+			return
+		}
+
+		var reason string
+		if len(r.TransferEncoding) > 0 {
+			reason = "chunked"
+		} else if r.ContentLength < 0 {
+			reason = "missing"
+		}
+		if reason != "" {
+			metrics.IncrCounter(keyForHTTPReqLengthRequired, 1,
+				metrics.Tag{Name: tags.Method, Value: r.Method},
+			)
+			logger.Warningf("api=ContentLengthRequired, reason=%s, method=%s, path=%s",
+				reason, r.Method, r.URL.Path)
+
+			w.Header().Set(header.Connection, "close")
+			marshal.WriteJSON(w, r, httperror.New(http.StatusLengthRequired, httperror.ContentLengthRequired,
+				"Content-Length header is required, chunked transfer encoding is not allowed"))
+			return
+		}
+		delegate.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,47 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ContentLengthRequired(t *testing.T) {
+	h := NewContentLengthRequired(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	tcases := []struct {
+		method  string
+		length  int64
+		chunked bool
+		status  int
+	}{
+		{http.MethodPost, 2, false, http.StatusOK},
+		{http.MethodPut, 0, false, http.StatusOK},
+		{http.MethodPost, -1, true, http.StatusLengthRequired},
+		{http.MethodPut, -1, false, http.StatusLengthRequired},
+		{http.MethodGet, -1, true, http.StatusOK},
+		{http.MethodHead, -1, false, http.StatusOK},
+	}
+
+	for _, tc := range tcases {
+		req, err := http.NewRequest(tc.method, "/v1/upload", strings.NewReader("{}"))
+		require.NoError(t, err)
+		req.ContentLength = tc.length
+		if tc.chunked {
+			req.TransferEncoding = []string{"chunked"}
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, tc.status, w.Code, "%s length=%d chunked=%t", tc.method, tc.length, tc.chunked)
+		if tc.status == http.StatusLengthRequired {
+			assert.Contains(t, w.Body.String(), `"code":"content_length_required"`)
+			assert.Equal(t, "close", w.Header().Get("Connection"))
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package xhttp
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+var keyForHTTPReqUnsupportedMediaType = []string{"http", "request", "unsupported_media_type"}
+
+// NewContentTypeValidator returns a handler that rejects the requests,
+// with Content-Type not matching one of the types, with 415 Unsupported Media Type,
+// before the delegate handler is called.
+// The media types are matched regardless of the case and the parameters, such as charset.
+// The requests with GET, HEAD, OPTIONS methods, and the requests without body are not validated.
+func NewContentTypeValidator(delegate http.Handler, types ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hasBody(r) {
+			contentType := r.Header.Get(header.ContentType)
+			if !header.MatchContentType(contentType, types...) {
+				metrics.IncrCounter(keyForHTTPReqUnsupportedMediaType, 1,
+					metrics.Tag{Name: tags.Method, Value: r.Method},
+				)
+				logger.Warningf("api=ContentTypeValidator, reason=unsupported, method=%s, path=%s, content_type=%q",
+					r.Method, r.URL.Path, contentType)
+
+				marshal.WriteJSON(w, r, httperror.WithUnsupportedMediaType("unsupported content type: %q", contentType))
+				return
+			}
+		}
+		delegate.ServeHTTP(w, r)
+	})
+}
+
+// hasBody returns false for the bodyless methods and requests
+func hasBody(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return r.ContentLength != 0 && r.Body != nil && r.Body != http.NoBody
+}
@@ -0,0 +1,51 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ContentTypeValidator(t *testing.T) {
+	h := NewContentTypeValidator(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}), "application/json", "application/jose+json")
+
+	tcases := []struct {
+		method      string
+		contentType string
+		body        string
+		status      int
+	}{
+		{http.MethodPost, "application/json", "{}", http.StatusOK},
+		{http.MethodPost, "Application/JSON; charset=UTF-8", "{}", http.StatusOK},
+		{http.MethodPut, "application/jose+json", "{}", http.StatusOK},
+		{http.MethodPost, "text/plain", "{}", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "", "{}", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "", "", http.StatusOK},
+		{http.MethodGet, "text/plain", "{}", http.StatusOK},
+		{http.MethodHead, "text/plain", "{}", http.StatusOK},
+	}
+
+	for _, tc := range tcases {
+		req, err := http.NewRequest(tc.method, "/v1/test", strings.NewReader(tc.body))
+		require.NoError(t, err)
+		if tc.body == "" {
+			req.Body = http.NoBody
+			req.ContentLength = 0
+		}
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, tc.status, w.Code, "%s %q", tc.method, tc.contentType)
+		if tc.status == http.StatusUnsupportedMediaType {
+			assert.Contains(t, w.Body.String(), `"code":"invalid_content_type"`)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package xhttp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/identity"
+)
+
+// CorrelationRoundTripper is a http.RoundTripper that propagates
+// the correlation ID of the request context to the outbound requests
+// in X-Correlation-ID header, which is extracted by identity.NewContextHandler
+// on the inbound requests.
+type CorrelationRoundTripper struct {
+	delegate http.RoundTripper
+	ctx      context.Context
+}
+
+// NewCorrelationRoundTripper returns a RoundTripper that sets
+// X-Correlation-ID header from the context of the outbound request,
+// if delegate is nil, then http.DefaultTransport is used.
+func NewCorrelationRoundTripper(delegate http.RoundTripper) *CorrelationRoundTripper {
+	if delegate == nil {
+		delegate = http.DefaultTransport
+	}
+	return &CorrelationRoundTripper{
+		delegate: delegate,
+	}
+}
+
+// WithContext specifies the fallback context, such as a handler's request context,
+// to use when the outbound request context has no correlation ID
+func (t *CorrelationRoundTripper) WithContext(ctx context.Context) *CorrelationRoundTripper {
+	t.ctx = ctx
+	return t
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+// The header already set on the request is not changed.
+func (t *CorrelationRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Header.Get(header.XCorrelationID) == "" {
+		rctx := identity.FromContext(r.Context())
+		if rctx == nil && t.ctx != nil {
+			rctx = identity.FromContext(t.ctx)
+		}
+		if rctx != nil && rctx.CorrelationID() != "" {
+			// RoundTripper must not modify the request
+			r = r.Clone(r.Context())
+			r.Header.Set(header.XCorrelationID, rctx.CorrelationID())
+		}
+	}
+	return t.delegate.RoundTrip(r)
+}
+
+// NewClientForContext returns a copy of the client, which propagates
+// the correlation ID from ctx, for example the handler's request context,
+// to the outbound requests.
+// If client is nil, then http.DefaultClient is used.
+func NewClientForContext(ctx context.Context, client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := *client
+	c.Transport = NewCorrelationRoundTripper(client.Transport).WithContext(ctx)
+	return &c
+}
@@ -0,0 +1,72 @@
+package xhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CorrelationRoundTripper(t *testing.T) {
+	var received string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(header.XCorrelationID)
+	}))
+	defer downstream.Close()
+
+	// the handler calls the downstream service
+	var outbound *http.Request
+	handler := identity.NewContextHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := NewClientForContext(r.Context(), nil)
+		req, err := http.NewRequest(http.MethodGet, downstream.URL, nil)
+		require.NoError(t, err)
+		outbound = req
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}))
+
+	r, err := http.NewRequest(http.MethodGet, "/v1/test", nil)
+	require.NoError(t, err)
+	r.Header.Set(header.XCorrelationID, "corr1234")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "corr1234", received)
+	assert.Empty(t, outbound.Header.Get(header.XCorrelationID), "the request must not be modified")
+
+	client := &http.Client{Transport: NewCorrelationRoundTripper(nil)}
+
+	t.Run("request context", func(t *testing.T) {
+		in := identity.WithTestIdentity(r, identity.NewIdentity("guest", "test", ""))
+		req, err := http.NewRequest(http.MethodGet, downstream.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req.WithContext(in.Context()))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, "corr1234", received)
+	})
+
+	t.Run("explicit header", func(t *testing.T) {
+		in := identity.WithTestIdentity(r, identity.NewIdentity("guest", "test", ""))
+		req, err := http.NewRequest(http.MethodGet, downstream.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set(header.XCorrelationID, "explicit")
+		resp, err := client.Do(req.WithContext(in.Context()))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, "explicit", received)
+	})
+
+	t.Run("no context", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, downstream.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req.WithContext(context.Background()))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Empty(t, received)
+	})
+}
@@ -0,0 +1,114 @@
+package xhttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDeadlineNotSupported is returned when the connection deadline
+// can not be changed for the request
+var ErrDeadlineNotSupported = errors.New("connection deadline is not supported")
+
+type contextValue int
+
+const (
+	contextValueForConn contextValue = iota
+)
+
+// conn keeps the accepted connection in the context,
+// and tracks if its deadline was changed by the handler
+type conn struct {
+	net.Conn
+	id       string
+	extended int32
+}
+
+// ConnContext stores the accepted connection in the context,
+// and assigns the connection ID.
+// Use it as http.Server.ConnContext to enable ExtendDeadline
+// and ConnectionIDFromRequest for the requests.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, contextValueForConn, &conn{Conn: c, id: nextConnectionID()})
+}
+
+func connFromRequest(r *http.Request) *conn {
+	c, _ := r.Context().Value(contextValueForConn).(*conn)
+	return c
+}
+
+// ExtendDeadline sets the read and write deadline of the request connection,
+// to allow long-poll and streaming handlers to run longer than the server timeouts.
+// If d is not positive, then the deadline is cleared.
+//
+// The server IdleTimeout only applies to the keep-alive connection between the requests,
+// it is restored by http.Server when the response is completed,
+// so the extended deadline does not prolong the idle connection.
+//
+// ExtendDeadline returns ErrDeadlineNotSupported for HTTP/2 requests,
+// where the connection is shared by the streams,
+// or if the server was not started with ConnContext.
+func ExtendDeadline(r *http.Request, d time.Duration) error {
+	c := connFromRequest(r)
+	if c == nil || r.ProtoMajor != 1 {
+		return ErrDeadlineNotSupported
+	}
+
+	var deadline time.Time
+	if d > 0 {
+		deadline = time.Now().Add(d)
+	}
+	atomic.StoreInt32(&c.extended, 1)
+	return c.SetDeadline(deadline)
+}
+
+// RestoreDeadline clears the deadline of the request connection,
+// if it was changed by ExtendDeadline.
+// The server calls it when the handler returns,
+// so the deadline of the request does not apply to the next request on the connection.
+func RestoreDeadline(r *http.Request) {
+	c := connFromRequest(r)
+	if c != nil && atomic.CompareAndSwapInt32(&c.extended, 1, 0) {
+		c.SetDeadline(time.Time{})
+	}
+}
+
+// writeDeadliner is implemented by http.ResponseWriter
+// that allows to control the write deadline of the response
+type writeDeadliner interface {
+	SetWriteDeadline(deadline time.Time) error
+}
+
+// unwrapper is implemented by http.ResponseWriter wrappers
+type unwrapper interface {
+	Unwrap() http.ResponseWriter
+}
+
+// ResetWriteDeadline sets the write deadline of the response,
+// to allow the streaming handlers to write the response longer than the server timeouts.
+// If d is not positive, then the deadline is cleared.
+//
+// The writer, or one of the wrapped writers, must implement SetWriteDeadline,
+// as net/http response writer does starting from Go 1.20,
+// otherwise ErrDeadlineNotSupported is returned,
+// and ExtendDeadline can be used instead.
+func ResetWriteDeadline(w http.ResponseWriter, d time.Duration) error {
+	var deadline time.Time
+	if d > 0 {
+		deadline = time.Now().Add(d)
+	}
+	for w != nil {
+		if wd, ok := w.(writeDeadliner); ok {
+			return wd.SetWriteDeadline(deadline)
+		}
+		u, ok := w.(unwrapper)
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+	return ErrDeadlineNotSupported
+}
@@ -0,0 +1,78 @@
+package xhttp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ExtendDeadline(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/extend":
+			if err := ExtendDeadline(r, time.Second); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		case "/reset":
+			if err := ResetWriteDeadline(NewResponseCapture(w), time.Second); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte("ok"))
+	})
+
+	s := httptest.NewUnstartedServer(handler)
+	s.Config.WriteTimeout = 100 * time.Millisecond
+	s.Config.ConnContext = ConnContext
+	s.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer RestoreDeadline(r)
+		handler.ServeHTTP(w, r)
+	})
+	s.Start()
+	defer s.Close()
+
+	get := func(path string) (int, string, error) {
+		resp, err := http.Get(s.URL + path)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), err
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		_, _, err := get("/timeout")
+		assert.Error(t, err)
+	})
+
+	t.Run("extend", func(t *testing.T) {
+		code, body, err := get("/extend")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok", body)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		code, body, err := get("/reset")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok", body)
+	})
+
+	t.Run("not_supported", func(t *testing.T) {
+		r, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		assert.Equal(t, ErrDeadlineNotSupported, ExtendDeadline(r, time.Second))
+		assert.Equal(t, ErrDeadlineNotSupported, ResetWriteDeadline(httptest.NewRecorder(), time.Second))
+		RestoreDeadline(r)
+	})
+}
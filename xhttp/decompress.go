@@ -0,0 +1,125 @@
+package xhttp
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+// errDecompressedBodyTooLarge has the same text as the error of http.MaxBytesReader,
+// so httperror.IsRequestBodyTooLarge detects it
+var errDecompressedBodyTooLarge = errors.New("http: request body too large")
+
+var (
+	keyForHTTPReqBodyMalformed      = []string{"http", "request", "body", "malformed"}
+	keyForHTTPReqBodyDecompTooLarge = []string{"http", "request", "body", "decompressed", "too_large"}
+)
+
+// NewRequestDecompressor returns a handler that decompresses the request body,
+// encoded with gzip or deflate, as specified by Content-Encoding header.
+// The decompressed body is limited to max bytes, to prevent the compression bombs.
+//
+// The request with malformed compression header is rejected with 400 Bad Request,
+// and with unsupported encoding with 415 Unsupported Media Type.
+// The delegate handler receives the error on read of the corrupted stream,
+// or after max decompressed bytes,
+// marshal.DecodeBody and marshal.DecodeRequest return 413 for the latter,
+// the handlers reading the body directly should check httperror.IsRequestBodyTooLarge.
+//
+// Content-Encoding and Content-Length headers are removed from the request,
+// as they do not apply to the decompressed body.
+func NewRequestDecompressor(delegate http.Handler, max int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := strings.ToLower(strings.TrimSpace(r.Header.Get(header.ContentEncoding)))
+		if encoding == "" || encoding == "identity" || r.Body == nil || r.Body == http.NoBody {
+			delegate.ServeHTTP(w, r)
+			return
+		}
+
+		var body io.ReadCloser
+		var err error
+		switch encoding {
+		case "gzip", "x-gzip":
+			body, err = gzip.NewReader(r.Body)
+		case "deflate":
+			body, err = zlib.NewReader(r.Body)
+		default:
+			marshal.WriteJSON(w, r, httperror.WithUnsupportedMediaType("unsupported content encoding: %q", encoding))
+			return
+		}
+		if err != nil {
+			metrics.IncrCounter(keyForHTTPReqBodyMalformed, 1,
+				metrics.Tag{Name: tags.Method, Value: r.Method},
+			)
+			logger.Warningf("api=RequestDecompressor, reason=malformed, method=%s, path=%s, encoding=%s, err=[%v]",
+				r.Method, r.URL.Path, encoding, err)
+			w.Header().Set(header.Connection, "close")
+			marshal.WriteJSON(w, r, httperror.WithInvalidRequest("malformed %s body", encoding).WithCause(err))
+			return
+		}
+
+		r.Body = &decompressedBody{
+			ReadCloser: body,
+			orig:       r.Body,
+			remaining:  max,
+			method:     r.Method,
+			path:       r.URL.Path,
+			max:        max,
+		}
+		r.ContentLength = -1
+		r.Header.Del(header.ContentEncoding)
+		r.Header.Del(header.ContentLength)
+
+		delegate.ServeHTTP(w, r)
+	})
+}
+
+// decompressedBody limits the size of the decompressed stream
+type decompressedBody struct {
+	io.ReadCloser
+	orig      io.ReadCloser
+	remaining int64
+	max       int64
+	method    string
+	path      string
+	exceeded  bool
+}
+
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, errDecompressedBodyTooLarge
+	}
+	// read one byte over the limit to detect the large body
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+		b.exceeded = true
+
+		metrics.IncrCounter(keyForHTTPReqBodyDecompTooLarge, 1,
+			metrics.Tag{Name: tags.Method, Value: b.method},
+		)
+		logger.Warningf("api=RequestDecompressor, reason=too_large, method=%s, path=%s, limit=%d",
+			b.method, b.path, b.max)
+		return n, errDecompressedBodyTooLarge
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *decompressedBody) Close() error {
+	b.ReadCloser.Close()
+	return b.orig.Close()
+}
@@ -0,0 +1,115 @@
+package xhttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	}
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func Test_RequestDecompressor(t *testing.T) {
+	const max = 1 << 10
+
+	h := NewRequestDecompressor(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(header.ContentEncoding))
+		if r.URL.Path == "/json" {
+			var v map[string]string
+			if marshal.DecodeBody(w, r, &v) == nil {
+				marshal.WriteJSON(w, r, v)
+			}
+			return
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if httperror.IsRequestBodyTooLarge(err) {
+			marshal.WriteJSON(w, r, httperror.WithRequestEntityTooLarge("the request body is too large"))
+			return
+		}
+		if err != nil {
+			marshal.WriteJSON(w, r, httperror.WithInvalidRequest(err.Error()))
+			return
+		}
+		w.Write(b)
+	}), max)
+
+	serve := func(path, encoding string, body []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		if encoding != "" {
+			r.Header.Set(header.ContentEncoding, encoding)
+		}
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("identity", func(t *testing.T) {
+		w := serve("/", "", []byte("plain"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "plain", w.Body.String())
+	})
+
+	for _, encoding := range []string{"gzip", "deflate"} {
+		t.Run(encoding, func(t *testing.T) {
+			w := serve("/json", encoding, compress(t, encoding, []byte(`{"a":"b"}`)))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, `{"a":"b"}`, w.Body.String())
+
+			// exactly at the limit
+			data := []byte(strings.Repeat("a", max))
+			w = serve("/", encoding, compress(t, encoding, data))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, max, w.Body.Len())
+
+			// the small compressed body exceeds the limit when decompressed
+			data = []byte(strings.Repeat("a", 100*max))
+			body := compress(t, encoding, data)
+			assert.Less(t, len(body), max)
+
+			w = serve("/", encoding, body)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+			w = serve("/json", encoding, compress(t, encoding, []byte(`{"a":"`+strings.Repeat("a", 100*max)+`"}`)))
+			assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+			w = serve("/", encoding, []byte("not compressed"))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, "close", w.Header().Get(header.Connection))
+		})
+	}
+
+	t.Run("corrupted", func(t *testing.T) {
+		body := compress(t, "gzip", []byte(strings.Repeat("abc", 100)))
+		w := serve("/", "gzip", body[:len(body)-4])
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		w := serve("/", "br", []byte("data"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+}
@@ -3,6 +3,12 @@ package header
 const (
 	// Accept is HTTP header for "Accept"
 	Accept = "Accept"
+	// AcceptEncoding is HTTP header for "Accept-Encoding"
+	AcceptEncoding = "Accept-Encoding"
+	// Age is HTTP header for "Age"
+	Age = "Age"
+	// Allow is HTTP header for "Allow"
+	Allow = "Allow"
 	// ApplicationJSON is HTTP header value for "application/json"
 	ApplicationJSON = "application/json"
 	// ApplicationJoseJSON is HTTP header value for "application/jose+json"
@@ -19,32 +25,86 @@ const (
 	Bearer = "Bearer"
 	// CacheControl is HTTP header for "Cache-Control"
 	CacheControl = "Cache-Control"
+	// Connection is HTTP header for "Connection"
+	Connection = "Connection"
 	// ContentDisposition is HTTP header for "Content-Disposition"
 	ContentDisposition = "Content-Disposition"
+	// ContentEncoding is HTTP header for "Content-Encoding"
+	ContentEncoding = "Content-Encoding"
 	// ContentLength is HTTP header for "Content-Length"
 	ContentLength = "Content-Length"
+	// ContentSecurityPolicy is HTTP header for "Content-Security-Policy"
+	ContentSecurityPolicy = "Content-Security-Policy"
 	// ContentType is HTTP header for "Content-Type"
 	ContentType = "Content-Type"
+	// Cookie is HTTP header for "Cookie"
+	Cookie = "Cookie"
+	// Deprecation is HTTP header for "Deprecation"
+	Deprecation = "Deprecation"
+	// ETag is HTTP header for "ETag"
+	ETag = "ETag"
 	// IfMatch is HTTP header for "If-Match"
 	IfMatch = "If-Match"
+	// IfModifiedSince is HTTP header for "If-Modified-Since"
+	IfModifiedSince = "If-Modified-Since"
+	// IfNoneMatch is HTTP header for "If-None-Match"
+	IfNoneMatch = "If-None-Match"
+	// LastModified is HTTP header for "Last-Modified"
+	LastModified = "Last-Modified"
 	// Link is HTTP header for "Link"
 	Link = "Link"
 	// Location is HTTP header for "Location"
 	Location = "Location"
+	// ReferrerPolicy is HTTP header for "Referrer-Policy"
+	ReferrerPolicy = "Referrer-Policy"
 	// ReplayNonce is HTTP header for "Replay-Nonce"
 	ReplayNonce = "Replay-Nonce"
+	// RetryAfter indicates how long the client should wait before making a follow-up request
+	RetryAfter = "Retry-After"
+	// Server is HTTP header for "Server"
+	Server = "Server"
+	// SetCookie is HTTP header for "Set-Cookie"
+	SetCookie = "Set-Cookie"
+	// StrictTransportSecurity is HTTP header for "Strict-Transport-Security"
+	StrictTransportSecurity = "Strict-Transport-Security"
+	// Sunset is HTTP header for "Sunset"
+	Sunset = "Sunset"
+	// TextEventStream is HTTP header value for "text/event-stream"
+	TextEventStream = "text/event-stream"
 	// TextPlain is HTTP header value for "application/json"
 	TextPlain = "text/plain"
+	// Upgrade is HTTP header for "Upgrade"
+	Upgrade = "Upgrade"
 	// UserAgent is HTTP header value for "User-Agent"
 	UserAgent = "User-Agent"
+	// Vary is HTTP header for "Vary"
+	Vary = "Vary"
+	// WWWAuthenticate is HTTP header for "WWW-Authenticate"
+	WWWAuthenticate = "WWW-Authenticate"
+	// XAPIKey is HTTP header for "X-Api-Key"
+	XAPIKey = "X-Api-Key"
+	// XFrameOptions is HTTP header for "X-Frame-Options"
+	XFrameOptions = "X-Frame-Options"
 	// XHostname contains the name of the HTTP header to indicate which host requested the signature
 	XHostname = "X-HostName"
+	// XContentTypeOptions is HTTP header for "X-Content-Type-Options"
+	XContentTypeOptions = "X-Content-Type-Options"
 	// XCorrelationID is HTTP header for "X-Correlation-ID"
 	XCorrelationID = "X-Correlation-ID"
+	// XCache indicates if the response is served from the cache
+	XCache = "X-Cache"
+	// XCaptureRequest requests to capture the request and response for debugging
+	XCaptureRequest = "X-Capture-Request"
 	// XDeviceID is HTTP header for "X-Device-ID"
 	XDeviceID = "X-Device-ID"
 	// XFilename contains the name of the artifact to sign
 	XFilename = "X-Filename"
+	// XForwardedFor contains the client and proxies addresses
+	XForwardedFor = "X-Forwarded-For"
 	// XForwardedProto contains the protocol
 	XForwardedProto = "X-Forwarded-Proto"
+	// XRealIP contains the client's address
+	XRealIP = "X-Real-Ip"
+	// XRequestTimeout contains the client's timeout of the request, such as 2s
+	XRequestTimeout = "X-Request-Timeout"
 )
@@ -21,6 +21,8 @@ func Test_Headers(t *testing.T) {
 	assert.Equal(t, "Content-Disposition", header.ContentDisposition)
 	assert.Equal(t, "If-Match", header.IfMatch)
 	assert.Equal(t, "Replay-Nonce", header.ReplayNonce)
+	assert.Equal(t, "Deprecation", header.Deprecation)
+	assert.Equal(t, "Sunset", header.Sunset)
 	assert.Equal(t, "text/plain", header.TextPlain)
 	assert.Equal(t, "User-Agent", header.UserAgent)
 	assert.Equal(t, "X-HostName", header.XHostname)
@@ -28,4 +30,35 @@ func Test_Headers(t *testing.T) {
 	assert.Equal(t, "X-Device-ID", header.XDeviceID)
 	assert.Equal(t, "X-Filename", header.XFilename)
 	assert.Equal(t, "X-Forwarded-Proto", header.XForwardedProto)
+	assert.Equal(t, "Allow", header.Allow)
+	assert.Equal(t, "X-Api-Key", header.XAPIKey)
+	assert.Equal(t, "Set-Cookie", header.SetCookie)
+	assert.Equal(t, "Cookie", header.Cookie)
+	assert.Equal(t, "Connection", header.Connection)
+	assert.Equal(t, "Server", header.Server)
+	assert.Equal(t, "WWW-Authenticate", header.WWWAuthenticate)
+	assert.Equal(t, "If-None-Match", header.IfNoneMatch)
+	assert.Equal(t, "ETag", header.ETag)
+	assert.Equal(t, "Retry-After", header.RetryAfter)
+	assert.Equal(t, "X-Real-Ip", header.XRealIP)
+	assert.Equal(t, "X-Forwarded-For", header.XForwardedFor)
+	assert.Equal(t, "Upgrade", header.Upgrade)
+	assert.Equal(t, "text/event-stream", header.TextEventStream)
+	assert.Equal(t, "Accept-Encoding", header.AcceptEncoding)
+	assert.Equal(t, "If-Modified-Since", header.IfModifiedSince)
+	assert.Equal(t, "Last-Modified", header.LastModified)
+	assert.Equal(t, "Vary", header.Vary)
+	assert.Equal(t, "X-Request-Timeout", header.XRequestTimeout)
+	assert.Equal(t, "X-Capture-Request", header.XCaptureRequest)
+	assert.Equal(t, "X-Cache", header.XCache)
+	assert.Equal(t, "Age", header.Age)
+}
+
+func Test_MatchContentType(t *testing.T) {
+	assert.True(t, header.MatchContentType("application/json", header.ApplicationJSON))
+	assert.True(t, header.MatchContentType("Application/JSON; charset=UTF-8", header.ApplicationJSON))
+	assert.True(t, header.MatchContentType("application/jose+json", header.ApplicationJSON, header.ApplicationJoseJSON))
+	assert.False(t, header.MatchContentType("text/plain", header.ApplicationJSON))
+	assert.False(t, header.MatchContentType("", header.ApplicationJSON))
+	assert.False(t, header.MatchContentType("application/json;;", header.ApplicationJSON))
 }
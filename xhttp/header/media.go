@@ -0,0 +1,21 @@
+package header
+
+import (
+	"mime"
+	"strings"
+)
+
+// MatchContentType returns true if the media type of the Content-Type value,
+// matches one of the types, regardless of the case and the parameters, such as charset
+func MatchContentType(value string, types ...string) bool {
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return false
+	}
+	for _, t := range types {
+		if strings.EqualFold(mediaType, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package xhttp
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+var keyForHTTPReqTooManyHeaders = []string{"http", "request", "headers", "too_many"}
+
+// MaxHeaderCount is a http.Handler that rejects the requests
+// with the number of the header fields over the limit,
+// with 431 Request Header Fields Too Large,
+// before the delegate handler is called.
+type MaxHeaderCount struct {
+	delegate http.Handler
+	max      int
+	auditor  Auditor
+}
+
+// NewMaxHeaderCount returns a handler that limits the number of the request header fields to max,
+// the repeated header fields are counted per value
+func NewMaxHeaderCount(delegate http.Handler, max int) *MaxHeaderCount {
+	return &MaxHeaderCount{
+		delegate: delegate,
+		max:      max,
+	}
+}
+
+// WithAuditor sets the auditor to record the rejected requests,
+// use audit.Sampler to not flood the audit log under the load
+func (l *MaxHeaderCount) WithAuditor(auditor Auditor) *MaxHeaderCount {
+	l.auditor = auditor
+	return l
+}
+
+// ServeHTTP implements http.Handler
+func (l *MaxHeaderCount) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	count := 0
+	for _, values := range r.Header {
+		count += len(values)
+	}
+	if count > l.max {
+		metrics.IncrCounter(keyForHTTPReqTooManyHeaders, 1,
+			metrics.Tag{Name: tags.Method, Value: r.Method},
+		)
+		logger.Warningf("api=MaxHeaderCount, reason=too_many, method=%s, path=%s, count=%d, limit=%d",
+			r.Method, r.URL.Path, count, l.max)
+		if l.auditor != nil {
+			auditRejected(l.auditor, r, "too_many_headers")
+		}
+
+		marshal.WriteJSON(w, r, httperror.WithRequestHeaderFieldsTooLarge("the request has too many header fields"))
+		return
+	}
+	l.delegate.ServeHTTP(w, r)
+}
@@ -0,0 +1,47 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MaxHeaderCount(t *testing.T) {
+	audit := auditor.NewInMemory()
+	h := NewMaxHeaderCount(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}), 3).WithAuditor(audit)
+
+	tcases := []struct {
+		headers int
+		status  int
+	}{
+		{0, http.StatusOK},
+		{3, http.StatusOK},
+		{4, http.StatusRequestHeaderFieldsTooLarge},
+	}
+
+	for _, tc := range tcases {
+		req, err := http.NewRequest(http.MethodGet, "/v1/test", nil)
+		require.NoError(t, err)
+		for i := 0; i < tc.headers; i++ {
+			// the repeated fields are counted per value
+			req.Header.Add("X-Test-"+strconv.Itoa(i%2), "value")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, tc.status, w.Code, "headers=%d", tc.headers)
+		if tc.status == http.StatusRequestHeaderFieldsTooLarge {
+			assert.Contains(t, w.Body.String(), `"code":"request_header_too_large"`)
+		}
+	}
+
+	evt := audit.Find(EvtSourceThrottle, EvtRejected)
+	require.NotNil(t, evt)
+	assert.Contains(t, evt.Message, "reason=too_many_headers")
+}
@@ -0,0 +1,104 @@
+package xhttp
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+)
+
+// RedactedValue is logged instead of the value of the sensitive header
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactedHeaders specifies the sensitive headers,
+// which values are not logged
+var DefaultRedactedHeaders = []string{
+	header.Authorization,
+	header.Cookie,
+	header.SetCookie,
+	header.XAPIKey,
+}
+
+// HeaderLogger provides AdditionalLogExtractor to log
+// the allowed request and response headers
+type HeaderLogger struct {
+	requestHeaders  []string
+	responseHeaders []string
+	redacted        map[string]bool
+}
+
+// NewHeaderLogger returns HeaderLogger for the allowed request and response headers,
+// the values of DefaultRedactedHeaders are redacted.
+func NewHeaderLogger(requestHeaders, responseHeaders []string) *HeaderLogger {
+	l := &HeaderLogger{
+		requestHeaders:  canonicalHeaders(requestHeaders),
+		responseHeaders: canonicalHeaders(responseHeaders),
+		redacted:        map[string]bool{},
+	}
+	return l.WithRedacted(DefaultRedactedHeaders...)
+}
+
+// WithRedacted adds the headers, which values must be redacted
+func (l *HeaderLogger) WithRedacted(headers ...string) *HeaderLogger {
+	for _, h := range headers {
+		l.redacted[http.CanonicalHeaderKey(h)] = true
+	}
+	return l
+}
+
+// RedactedHeaders returns the headers, which values are redacted
+func (l *HeaderLogger) RedactedHeaders() []string {
+	list := make([]string, 0, len(l.redacted))
+	for h := range l.redacted {
+		list = append(list, h)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Extractor returns AdditionalLogExtractor that appends the headers
+// to the fields returned by the next extractor, if provided.
+// Each header is logged as req.<Name>="<value>" or resp.<Name>="<value>",
+// the headers not present in the request or response are omitted.
+func (l *HeaderLogger) Extractor(next AdditionalLogExtractor) AdditionalLogExtractor {
+	return func(resp *ResponseCapture, req *http.Request) []string {
+		var fields []string
+		if next != nil {
+			fields = next(resp, req)
+		}
+		fields = l.appendFields(fields, "req", req.Header)
+		if resp != nil {
+			fields = l.appendFields(fields, "resp", resp.Header())
+		}
+		return fields
+	}
+}
+
+func (l *HeaderLogger) appendFields(fields []string, prefix string, hdrs http.Header) []string {
+	list := l.requestHeaders
+	if prefix == "resp" {
+		list = l.responseHeaders
+	}
+	for _, name := range list {
+		values, ok := hdrs[name]
+		if !ok {
+			continue
+		}
+		value := strings.Join(values, ",")
+		if l.redacted[name] {
+			value = RedactedValue
+		}
+		fields = append(fields, fmt.Sprintf("%s.%s=%q", prefix, name, value))
+	}
+	return fields
+}
+
+func canonicalHeaders(headers []string) []string {
+	list := make([]string, len(headers))
+	for i, h := range headers {
+		list[i] = http.CanonicalHeaderKey(h)
+	}
+	return list
+}
@@ -0,0 +1,51 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HeaderLogger(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "/foo", nil)
+	r.Header.Set(header.Authorization, "Bearer secret")
+	r.Header.Set(header.XAPIKey, "key")
+	r.Header.Set("X-Custom-Secret", "custom")
+	r.Header.Add(header.Accept, "text/plain")
+	r.Header.Add(header.Accept, "application/json")
+
+	w := NewResponseCapture(httptest.NewRecorder())
+	w.Header().Set(header.SetCookie, "session=123")
+	w.Header().Set(header.ContentType, header.TextPlain)
+
+	next := func(resp *ResponseCapture, req *http.Request) []string {
+		return []string{"corr1234"}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		l := NewHeaderLogger(
+			[]string{"authorization", header.Accept, header.UserAgent, header.XAPIKey, "X-Custom-Secret"},
+			[]string{header.SetCookie, header.ContentType},
+		)
+		fields := l.Extractor(next)(w, r)
+		assert.Equal(t, []string{
+			"corr1234",
+			`req.Authorization="[REDACTED]"`,
+			`req.Accept="text/plain,application/json"`,
+			`req.X-Api-Key="[REDACTED]"`,
+			`req.X-Custom-Secret="custom"`,
+			`resp.Set-Cookie="[REDACTED]"`,
+			`resp.Content-Type="text/plain"`,
+		}, fields)
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		l := NewHeaderLogger([]string{"X-Custom-Secret"}, nil).WithRedacted("x-custom-secret")
+		fields := l.Extractor(nil)(w, r)
+		assert.Equal(t, []string{`req.X-Custom-Secret="[REDACTED]"`}, fields)
+		assert.Equal(t, []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Custom-Secret"}, l.RedactedHeaders())
+	})
+}
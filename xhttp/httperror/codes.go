@@ -3,6 +3,8 @@ package httperror
 const (
 	// AccountNotFound when requested account not found
 	AccountNotFound = "account_not_found"
+	// BadGateway is returned when the upstream server replied with invalid response.
+	BadGateway = "bad_gateway"
 	// BadNonce is returned for bad nonce.
 	BadNonce = "bad_nonce"
 	// Conflict is returned whith 409 CONFLICT response code.
@@ -15,6 +17,8 @@ const (
 	FailedToReadRequestBody = "request_body"
 	// Forbidden is returned when the client is not authorized to access the resource indicated.
 	Forbidden = "forbidden"
+	// GatewayTimeout is returned when the upstream server did not reply within allowed time.
+	GatewayTimeout = "gateway_timeout"
 	// InvalidContentType is returned when request specifies invalid Content-Type.
 	InvalidContentType = "invalid_content_type"
 	// InvalidJSON is returned when we were unable to parse a client supplied JSON Payload.
@@ -25,6 +29,12 @@ const (
 	InvalidRequest = "invalid_request"
 	// Malformed is returned when the request was malformed.
 	Malformed = "malformed"
+	// MethodNotAllowed is returned when the method is not supported by the resource.
+	MethodNotAllowed = "method_not_allowed"
+	// NotAcceptable is returned when the requested representation, such as API version, is not supported.
+	NotAcceptable = "not_acceptable"
+	// NotImplemented is returned when the requested functionality is not implemented or not supported.
+	NotImplemented = "not_implemented"
 	// NotFound is returned when the requested URL doesn't exist.
 	NotFound = "not_found"
 	// NotReady is returned when the service is not ready to serve
@@ -33,8 +43,16 @@ const (
 	RateLimitExceeded = "rate_limit_exceeded"
 	// RequestFailed is returned when an outbound request failed.
 	RequestFailed = "request_failed"
+	// RequestURITooLong is returned when the request URI is longer than allowed.
+	RequestURITooLong = "request_uri_too_long"
+	// RequestHeaderTooLarge is returned when the request has too many or too large header fields.
+	RequestHeaderTooLarge = "request_header_too_large"
 	// RequestTooLarge is returned when the client provided payload is larger than allowed for the particular resource.
 	RequestTooLarge = "request_too_large"
+	// ServerBusy is returned when the server has reached the limit of concurrent requests.
+	ServerBusy = "server_busy"
+	// Timeout is returned when the request was not processed within allowed time.
+	Timeout = "timeout"
 	// Unauthorized is for unauthorized access.
 	Unauthorized = "unauthorized"
 	// Unexpected is returned when something went wrong.
@@ -10,21 +10,29 @@ import (
 
 func Test_ErrorCodes(t *testing.T) {
 	assert.Equal(t, "account_not_found", httperror.AccountNotFound)
+	assert.Equal(t, "bad_gateway", httperror.BadGateway)
 	assert.Equal(t, "bad_nonce", httperror.BadNonce)
 	assert.Equal(t, "conflict", httperror.Conflict)
 	assert.Equal(t, "connection", httperror.Connection)
 	assert.Equal(t, "content_length_required", httperror.ContentLengthRequired)
 	assert.Equal(t, "forbidden", httperror.Forbidden)
+	assert.Equal(t, "gateway_timeout", httperror.GatewayTimeout)
 	assert.Equal(t, "invalid_content_type", httperror.InvalidContentType)
 	assert.Equal(t, "invalid_json", httperror.InvalidJSON)
 	assert.Equal(t, "invalid_parameter", httperror.InvalidParam)
 	assert.Equal(t, "invalid_request", httperror.InvalidRequest)
 	assert.Equal(t, "malformed", httperror.Malformed)
+	assert.Equal(t, "method_not_allowed", httperror.MethodNotAllowed)
+	assert.Equal(t, "not_acceptable", httperror.NotAcceptable)
 	assert.Equal(t, "not_found", httperror.NotFound)
 	assert.Equal(t, "not_ready", httperror.NotReady)
 	assert.Equal(t, "rate_limit_exceeded", httperror.RateLimitExceeded)
 	assert.Equal(t, "request_body", httperror.FailedToReadRequestBody)
+	assert.Equal(t, "request_header_too_large", httperror.RequestHeaderTooLarge)
 	assert.Equal(t, "request_too_large", httperror.RequestTooLarge)
+	assert.Equal(t, "request_uri_too_long", httperror.RequestURITooLong)
+	assert.Equal(t, "server_busy", httperror.ServerBusy)
+	assert.Equal(t, "timeout", httperror.Timeout)
 	assert.Equal(t, "unauthorized", httperror.Unauthorized)
 	assert.Equal(t, "unexpected", httperror.Unexpected)
 }
@@ -41,9 +49,14 @@ func Test_StatusCodes(t *testing.T) {
 		{httperror.WithInvalidRequest("1"), http.StatusBadRequest, "invalid_request: 1"},
 		{httperror.WithMalformed("1"), http.StatusBadRequest, "malformed: 1"},
 		{httperror.WithInvalidContentType("1"), http.StatusBadRequest, "invalid_content_type: 1"},
+		{httperror.WithUnsupportedMediaType("1"), http.StatusUnsupportedMediaType, "invalid_content_type: 1"},
 		{httperror.WithContentLengthRequired(), http.StatusBadRequest, "content_length_required: Content-Length header not provided"},
+		{httperror.WithNotAcceptable("1"), http.StatusNotAcceptable, "not_acceptable: 1"},
 		{httperror.WithNotFound("1"), http.StatusNotFound, "not_found: 1"},
 		{httperror.WithRequestTooLarge("1"), http.StatusBadRequest, "request_too_large: 1"},
+		{httperror.WithRequestURITooLong("1"), http.StatusRequestURITooLong, "request_uri_too_long: 1"},
+		{httperror.WithRequestHeaderFieldsTooLarge("1"), http.StatusRequestHeaderFieldsTooLarge, "request_header_too_large: 1"},
+		{httperror.WithRequestEntityTooLarge("1"), http.StatusRequestEntityTooLarge, "request_too_large: 1"},
 		{httperror.WithFailedToReadRequestBody("1"), http.StatusInternalServerError, "request_body: 1"},
 		{httperror.WithRateLimitExceeded("1"), http.StatusTooManyRequests, "rate_limit_exceeded: 1"},
 		{httperror.WithUnexpected("1"), http.StatusInternalServerError, "unexpected: 1"},
@@ -52,6 +65,11 @@ func Test_StatusCodes(t *testing.T) {
 		{httperror.WithAccountNotFound("1"), http.StatusForbidden, "account_not_found: 1"},
 		{httperror.WithNotReady("1"), http.StatusForbidden, "not_ready: 1"},
 		{httperror.WithConflict("1"), http.StatusConflict, "conflict: 1"},
+		{httperror.WithTimeout("1"), http.StatusServiceUnavailable, "timeout: 1"},
+		{httperror.WithGatewayTimeout("1"), http.StatusGatewayTimeout, "gateway_timeout: 1"},
+		{httperror.WithBadGateway("1"), http.StatusBadGateway, "bad_gateway: 1"},
+		{httperror.WithMethodNotAllowed("1"), http.StatusMethodNotAllowed, "method_not_allowed: 1"},
+		{httperror.WithServerBusy("1"), http.StatusServiceUnavailable, "server_busy: 1"},
 	}
 	for _, tc := range tcases {
 		t.Run(tc.httpErr.Code, func(t *testing.T) {
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/juju/errors"
 	"github.com/ugorji/go/codec"
 )
 
@@ -63,11 +64,22 @@ func WithInvalidContentType(msgFormat string, vals ...interface{}) *Error {
 	return New(http.StatusBadRequest, InvalidContentType, msgFormat, vals...)
 }
 
+// WithUnsupportedMediaType for builds a new Error instance with InvalidContentType code,
+// and 415 Unsupported Media Type status
+func WithUnsupportedMediaType(msgFormat string, vals ...interface{}) *Error {
+	return New(http.StatusUnsupportedMediaType, InvalidContentType, msgFormat, vals...)
+}
+
 // WithContentLengthRequired for builds a new Error instance with ContentLengthRequired code
 func WithContentLengthRequired() *Error {
 	return New(http.StatusBadRequest, ContentLengthRequired, "Content-Length header not provided")
 }
 
+// WithNotAcceptable for builds a new Error instance with NotAcceptable code
+func WithNotAcceptable(msgFormat string, vals ...interface{}) *Error {
+	return New(http.StatusNotAcceptable, NotAcceptable, msgFormat, vals...)
+}
+
 // WithNotFound for builds a new Error instance with NotFound code
 func WithNotFound(msgFormat string, vals ...interface{}) *Error {
 	return New(http.StatusNotFound, NotFound, msgFormat, vals...)
@@ -78,6 +90,30 @@ func WithRequestTooLarge(msgFormat string, vals ...interface{}) *Error {
 	return New(http.StatusBadRequest, RequestTooLarge, msgFormat, vals...)
 }
 
+// WithRequestHeaderFieldsTooLarge for builds a new Error instance with RequestHeaderTooLarge code,
+// and 431 Request Header Fields Too Large status
+func WithRequestHeaderFieldsTooLarge(msgFormat string, vals ...interface{}) *Error {
+	return New(http.StatusRequestHeaderFieldsTooLarge, RequestHeaderTooLarge, msgFormat, vals...)
+}
+
+// WithRequestEntityTooLarge for builds a new Error instance with RequestTooLarge code,
+// and 413 Request Entity Too Large status
+func WithRequestEntityTooLarge(msgFormat string, vals ...interface{}) *Error {
+	return New(http.StatusRequestEntityTooLarge, RequestTooLarge, msgFormat, vals...)
+}
+
+// IsRequestBodyTooLarge returns true if the error is returned by the request body reader,
+// limited by http.MaxBytesReader, or if the error is caused by it
+func IsRequestBodyTooLarge(err error) bool {
+	// http.MaxBytesReader returns unexported error
+	return err != nil && strings.Contains(err.Error(), "http: request body too large")
+}
+
+// WithRequestURITooLong for builds a new Error instance with RequestURITooLong code
+func WithRequestURITooLong(msgFormat string, vals ...interface{}) *Error {
+	return New(http.StatusRequestURITooLong, RequestURITooLong, msgFormat, vals...)
+}
+
 // WithFailedToReadRequestBody for builds a new Error instance with FailedToReadRequestBody code
 func WithFailedToReadRequestBody(msgFormat string, vals ...interface{}) *Error {
 	return New(http.StatusInternalServerError, FailedToReadRequestBody, msgFormat, vals...)
@@ -118,6 +154,63 @@ func WithConflict(msgFormat string, vals ...interface{}) *Error {
 	return New(http.StatusConflict, Conflict, msgFormat, vals...)
 }
 
+// WithTimeout for builds a new Error instance with Timeout code
+func WithTimeout(msgFormat string, vals ...interface{}) *Error {
+	return New(http.StatusServiceUnavailable, Timeout, msgFormat, vals...)
+}
+
+// WithServerBusy for builds a new Error instance with ServerBusy code
+func WithServerBusy(msgFormat string, vals ...interface{}) *Error {
+	return New(http.StatusServiceUnavailable, ServerBusy, msgFormat, vals...)
+}
+
+// WithMethodNotAllowed for builds a new Error instance with MethodNotAllowed code
+func WithMethodNotAllowed(msgFormat string, vals ...interface{}) *Error {
+	return New(http.StatusMethodNotAllowed, MethodNotAllowed, msgFormat, vals...)
+}
+
+// WithBadGateway for builds a new Error instance with BadGateway code
+func WithBadGateway(msgFormat string, vals ...interface{}) *Error {
+	return New(http.StatusBadGateway, BadGateway, msgFormat, vals...)
+}
+
+// WithGatewayTimeout for builds a new Error instance with GatewayTimeout code
+func WithGatewayTimeout(msgFormat string, vals ...interface{}) *Error {
+	return New(http.StatusGatewayTimeout, GatewayTimeout, msgFormat, vals...)
+}
+
+// WithNotImplemented for builds a new Error instance with NotImplemented code
+func WithNotImplemented(msgFormat string, vals ...interface{}) *Error {
+	return New(http.StatusNotImplemented, NotImplemented, msgFormat, vals...)
+}
+
+// FromErrorKind returns the Error with the standard code
+// matching the juju/errors type of err, such as NotFound for errors.NotFound,
+// and InvalidRequest for errors.NotValid,
+// or nil if err is not one of the mapped types
+func FromErrorKind(err error) *Error {
+	msg := err.Error()
+	switch {
+	case errors.IsNotFound(err), errors.IsUserNotFound(err):
+		return WithNotFound("%s", msg)
+	case errors.IsNotValid(err), errors.IsBadRequest(err):
+		return WithInvalidRequest("%s", msg)
+	case errors.IsAlreadyExists(err):
+		return WithConflict("%s", msg)
+	case errors.IsUnauthorized(err):
+		return WithUnauthorized("%s", msg)
+	case errors.IsForbidden(err):
+		return WithForbidden("%s", msg)
+	case errors.IsMethodNotAllowed(err):
+		return WithMethodNotAllowed("%s", msg)
+	case errors.IsTimeout(err):
+		return WithTimeout("%s", msg)
+	case errors.IsNotImplemented(err), errors.IsNotSupported(err):
+		return WithNotImplemented("%s", msg)
+	}
+	return nil
+}
+
 // WithCause adds the cause error
 func (e *Error) WithCause(err error) *Error {
 	e.Cause = err
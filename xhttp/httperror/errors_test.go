@@ -2,8 +2,10 @@ package httperror_test
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-phorce/dolly/xhttp/httperror"
@@ -145,3 +147,42 @@ func TestError_WriteHTTPResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestError_IsRequestBodyTooLarge(t *testing.T) {
+	body := http.MaxBytesReader(httptest.NewRecorder(), ioutil.NopCloser(strings.NewReader("1234567890")), 5)
+	_, err := ioutil.ReadAll(body)
+	require.Error(t, err)
+	assert.True(t, httperror.IsRequestBodyTooLarge(err))
+	assert.True(t, httperror.IsRequestBodyTooLarge(errors.Annotate(err, "decode")))
+	assert.False(t, httperror.IsRequestBodyTooLarge(errors.New("unexpected EOF")))
+	assert.False(t, httperror.IsRequestBodyTooLarge(nil))
+}
+
+func TestFromErrorKind(t *testing.T) {
+	tcases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{errors.NotFoundf("item"), http.StatusNotFound, httperror.NotFound},
+		{errors.UserNotFoundf("bob"), http.StatusNotFound, httperror.NotFound},
+		{errors.Annotate(errors.NotValidf("id"), "request"), http.StatusBadRequest, httperror.InvalidRequest},
+		{errors.BadRequestf("id"), http.StatusBadRequest, httperror.InvalidRequest},
+		{errors.AlreadyExistsf("item"), http.StatusConflict, httperror.Conflict},
+		{errors.Unauthorizedf("token"), http.StatusUnauthorized, httperror.Unauthorized},
+		{errors.Forbiddenf("item"), http.StatusForbidden, httperror.Forbidden},
+		{errors.MethodNotAllowedf("DELETE"), http.StatusMethodNotAllowed, httperror.MethodNotAllowed},
+		{errors.Timeoutf("query"), http.StatusServiceUnavailable, httperror.Timeout},
+		{errors.NotImplementedf("export"), http.StatusNotImplemented, httperror.NotImplemented},
+		{errors.NotSupportedf("export"), http.StatusNotImplemented, httperror.NotImplemented},
+	}
+	for _, tc := range tcases {
+		e := httperror.FromErrorKind(tc.err)
+		require.NotNil(t, e, tc.err.Error())
+		assert.Equal(t, tc.status, e.HTTPStatus, tc.err.Error())
+		assert.Equal(t, tc.code, e.Code, tc.err.Error())
+		assert.Equal(t, tc.err.Error(), e.Message)
+	}
+
+	assert.Nil(t, httperror.FromErrorKind(errors.New("failed")))
+}
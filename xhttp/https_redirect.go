@@ -0,0 +1,55 @@
+package xhttp
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ACMEChallengePrefix specifies the path prefix of ACME HTTP-01 challenge requests
+const ACMEChallengePrefix = "/.well-known/acme-challenge/"
+
+// HTTPSRedirect is a http.Handler for the plaintext listener,
+// that redirects the requests to HTTPS,
+// except ACME HTTP-01 challenge requests, that must be served on the plaintext port
+type HTTPSRedirect struct {
+	challenge http.Handler
+	port      string
+}
+
+// NewHTTPSRedirect returns a handler that serves ACME challenge requests with challenge handler,
+// such as autocert.Manager.HTTPHandler(nil), and redirects all other requests to HTTPS port.
+// If challenge is nil, then all requests are redirected.
+// If port is empty or 443, then the port is omitted in the redirect URL.
+func NewHTTPSRedirect(challenge http.Handler, port string) *HTTPSRedirect {
+	return &HTTPSRedirect{
+		challenge: challenge,
+		port:      port,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *HTTPSRedirect) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the challenge must be checked first, otherwise the renewal fails on redirect
+	if h.challenge != nil && strings.HasPrefix(r.URL.Path, ACMEChallengePrefix) {
+		h.challenge.ServeHTTP(w, r)
+		return
+	}
+
+	host := r.Host
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	if h.port != "" && h.port != "443" {
+		host = net.JoinHostPort(host, h.port)
+	}
+
+	target := "https://" + host + r.URL.RequestURI()
+
+	// 308 preserves the method and body of non-GET requests
+	code := http.StatusPermanentRedirect
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+	http.Redirect(w, r, target, code)
+}
@@ -0,0 +1,44 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_HTTPSRedirect(t *testing.T) {
+	challenge := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("token"))
+	})
+
+	tcases := []struct {
+		handler  http.Handler
+		method   string
+		url      string
+		status   int
+		location string
+	}{
+		{NewHTTPSRedirect(challenge, "443"), http.MethodGet, "http://dolly.com/.well-known/acme-challenge/abc", http.StatusOK, ""},
+		{NewHTTPSRedirect(challenge, "443"), http.MethodGet, "http://dolly.com/v1/status?pp", http.StatusMovedPermanently, "https://dolly.com/v1/status?pp"},
+		{NewHTTPSRedirect(challenge, ""), http.MethodGet, "http://dolly.com:8080/", http.StatusMovedPermanently, "https://dolly.com/"},
+		{NewHTTPSRedirect(challenge, "8443"), http.MethodPost, "http://dolly.com:8080/v1/sign", http.StatusPermanentRedirect, "https://dolly.com:8443/v1/sign"},
+		{NewHTTPSRedirect(nil, "443"), http.MethodGet, "http://dolly.com/.well-known/acme-challenge/abc", http.StatusMovedPermanently, "https://dolly.com/.well-known/acme-challenge/abc"},
+	}
+
+	for _, tc := range tcases {
+		r, err := http.NewRequest(tc.method, tc.url, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		tc.handler.ServeHTTP(w, r)
+		assert.Equal(t, tc.status, w.Code, tc.url)
+		if tc.status == http.StatusOK {
+			assert.Equal(t, "token", w.Body.String())
+		} else {
+			assert.Equal(t, tc.location, w.Header().Get(header.Location), tc.url)
+		}
+	}
+}
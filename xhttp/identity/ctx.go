@@ -22,14 +22,23 @@ type contextKey int
 const (
 	keyContext contextKey = iota
 	keyIdentity
+	keyTrustedProxies
 )
 
 // NodeInfoFactory returns NodeInfo
 type NodeInfoFactory func() netutil.NodeInfo
 
+// TenantResolver returns the tenant of the request,
+// resolved from the host, header or the client certificate
+type TenantResolver func(r *http.Request, id Identity) (string, error)
+
 var (
 	nodeInfoFactory        = newNodeInfoFactory()
 	identityMapper  Mapper = GuestIdentityMapper
+	tenantResolver  TenantResolver
+	// tenantRejectStatus specifies the status code of the response,
+	// when the tenant resolver returns an error
+	tenantRejectStatus = http.StatusForbidden
 )
 
 // RequestContext represents user contextual information about a request being processed by the server,
@@ -38,6 +47,7 @@ type RequestContext struct {
 	identity      Identity
 	correlationID string
 	clientIP      string
+	tenant        string
 }
 
 // NewRequestContext creates a request context with a specific identity.
@@ -53,6 +63,7 @@ type Context interface {
 	Identity() Identity
 	CorrelationID() string
 	ClientIP() string
+	Tenant() string
 }
 
 type defaultNodeInfoFactory struct {
@@ -97,6 +108,26 @@ func SetGlobalIdentityMapper(e Mapper) {
 	identityMapper = e
 }
 
+// SetGlobalTenantResolver applies global TenantResolver for the application,
+// the rejectStatus specifies the status code of the response,
+// when the resolver returns an error: http.StatusBadRequest or http.StatusForbidden
+func SetGlobalTenantResolver(r TenantResolver, rejectStatus int) {
+	if rejectStatus != http.StatusBadRequest && rejectStatus != http.StatusForbidden {
+		logger.Panicf("unsupported reject status: %d", rejectStatus)
+	}
+	tenantResolver = r
+	tenantRejectStatus = rejectStatus
+}
+
+// resolveTenant returns the tenant of the request,
+// or empty string if the resolver is not set
+func resolveTenant(r *http.Request, id Identity) (string, error) {
+	if tenantResolver == nil {
+		return "", nil
+	}
+	return tenantResolver(r, id)
+}
+
 //FromContext extracts the RequestContext stored inside a go context. Returns null if no such value exists.
 func FromContext(ctx context.Context) *RequestContext {
 	ret, _ := ctx.Value(keyContext).(*RequestContext)
@@ -108,6 +139,20 @@ func AddToContext(ctx context.Context, rq *RequestContext) context.Context {
 	return context.WithValue(ctx, keyContext, rq)
 }
 
+// WithIdentity returns a shallow copy of the request with the identity
+// replaced in the request context, the correlation ID and client IP are preserved.
+// It is used by authentication middleware, such as basic auth.
+func WithIdentity(r *http.Request, id Identity) *http.Request {
+	rctx := ForRequest(r)
+	c := &RequestContext{
+		identity:      id,
+		correlationID: rctx.correlationID,
+		clientIP:      rctx.clientIP,
+		tenant:        rctx.tenant,
+	}
+	return r.WithContext(context.WithValue(r.Context(), keyContext, c))
+}
+
 // ForRequest returns the full context ascocicated with this http request.
 func ForRequest(r *http.Request) *RequestContext {
 	v := r.Context().Value(keyContext)
@@ -118,21 +163,43 @@ func ForRequest(r *http.Request) *RequestContext {
 			logger.Errorf("api=ForRequest, reason=identityMapper, ip=%q, err=[%v]", clientIP, err.Error())
 			identity = NewIdentity(GuestRoleName, clientIP, "")
 		}
+		tenant, err := resolveTenant(r, identity)
+		if err != nil {
+			logger.Errorf("api=ForRequest, reason=tenantResolver, ip=%q, err=[%v]", clientIP, err.Error())
+		}
 
 		return &RequestContext{
 			identity:      identity,
 			correlationID: extractCorrelationID(r),
 			clientIP:      clientIP,
+			tenant:        tenant,
 		}
 	}
 	return v.(*RequestContext)
 }
 
+// TenantFromRequest returns the tenant of the request,
+// or empty string if the tenant is not resolved
+func TenantFromRequest(r *http.Request) string {
+	return ForRequest(r).Tenant()
+}
+
 // NewContextHandler returns a handler that will extact the role & contextID from the request
 // and stash them away in the request context for later handlers to use.
 // Also adds header to indicate which host is currently servicing the request
 func NewContextHandler(delegate http.Handler) http.Handler {
+	return NewContextHandlerWithTrustedProxies(delegate, nil)
+}
+
+// NewContextHandlerWithTrustedProxies returns NewContextHandler,
+// that resolves the client's IP via the trusted proxies,
+// which are also stored in the request context for later handlers to use.
+func NewContextHandlerWithTrustedProxies(delegate http.Handler, proxies TrustedProxies) http.Handler {
 	h := func(w http.ResponseWriter, r *http.Request) {
+		if len(proxies) > 0 {
+			r = WithTrustedProxies(r, proxies)
+		}
+
 		// Set XHostname on the response
 		w.Header().Set(header.XHostname, nodeInfoFactory().HostName())
 
@@ -146,11 +213,22 @@ func NewContextHandler(delegate http.Handler) http.Handler {
 				marshal.WriteJSON(w, r, httperror.WithUnauthorized(err.Error()))
 				return
 			}
+			tenant, err := resolveTenant(r, identity)
+			if err != nil {
+				logger.Errorf("api=ForRequest, reason=tenantResolver, ip=%q, err=[%v]", clientIP, err.Error())
+				if tenantRejectStatus == http.StatusBadRequest {
+					marshal.WriteJSON(w, r, httperror.WithInvalidRequest(err.Error()))
+				} else {
+					marshal.WriteJSON(w, r, httperror.WithForbidden(err.Error()))
+				}
+				return
+			}
 
 			rctx = &RequestContext{
 				identity:      identity,
 				correlationID: extractCorrelationID(r),
 				clientIP:      clientIP,
+				tenant:        tenant,
 			}
 			r = r.WithContext(context.WithValue(r.Context(), keyContext, rctx))
 		} else {
@@ -180,6 +258,11 @@ func (c *RequestContext) ClientIP() string {
 	return c.clientIP
 }
 
+// Tenant returns request's tenant, resolved by the TenantResolver
+func (c *RequestContext) Tenant() string {
+	return c.tenant
+}
+
 // extractCorrelationID will find or create a requestID for this http request.
 func extractCorrelationID(req *http.Request) string {
 	corID := req.Header.Get(header.XCorrelationID)
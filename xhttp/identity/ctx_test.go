@@ -257,3 +257,47 @@ func identityMapperFromCNMust(r *http.Request) (Identity, error) {
 	}
 	return identity{name: r.TLS.PeerCertificates[0].Subject.CommonName, role: r.TLS.PeerCertificates[0].Subject.CommonName}, nil
 }
+
+func Test_TenantResolver(t *testing.T) {
+	assert.Panics(t, func() { SetGlobalTenantResolver(nil, http.StatusUnauthorized) })
+	defer SetGlobalTenantResolver(nil, http.StatusForbidden)
+
+	SetGlobalTenantResolver(func(r *http.Request, id Identity) (string, error) {
+		tenant := r.Header.Get("X-Tenant")
+		if tenant == "bad" {
+			return "", errors.New("unknown tenant")
+		}
+		return tenant, nil
+	}, http.StatusBadRequest)
+
+	var tenant string
+	d := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = TenantFromRequest(r)
+	})
+	handler := NewContextHandler(d)
+
+	r, err := http.NewRequest(http.MethodGet, "/test", nil)
+	require.NoError(t, err)
+	r.Header.Set("X-Tenant", "acme")
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, r)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "acme", tenant)
+	assert.Equal(t, "acme", TenantFromRequest(r), "resolved without the context handler")
+
+	r.Header.Set("X-Tenant", "bad")
+	rw = httptest.NewRecorder()
+	handler.ServeHTTP(rw, r)
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	assert.Equal(t, `{"code":"invalid_request","message":"unknown tenant"}`, rw.Body.String())
+
+	SetGlobalTenantResolver(tenantResolver, http.StatusForbidden)
+	rw = httptest.NewRecorder()
+	handler.ServeHTTP(rw, r)
+	assert.Equal(t, http.StatusForbidden, rw.Code)
+
+	// the tenant is preserved when the identity is replaced
+	r = r.WithContext(AddToContext(r.Context(), &RequestContext{tenant: "acme"}))
+	r = WithIdentity(r, NewIdentity("admin", "bob", ""))
+	assert.Equal(t, "acme", TenantFromRequest(r))
+}
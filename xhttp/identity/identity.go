@@ -83,10 +83,10 @@ func (c identity) String() string {
 // GuestIdentityMapper always returns "guest" for the role
 func GuestIdentityMapper(r *http.Request) (Identity, error) {
 	var name string
-	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
-		name = ClientIPFromRequest(r)
+	if cert := PeerCertificate(r); cert != nil {
+		name = cert.Subject.CommonName
 	} else {
-		name = r.TLS.PeerCertificates[0].Subject.CommonName
+		name = ClientIPFromRequest(r)
 	}
 	return NewIdentity(GuestRoleName, name, ""), nil
 }
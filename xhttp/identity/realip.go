@@ -1,46 +1,155 @@
 package identity
 
 import (
+	"context"
 	"net"
 	"net/http"
 	"strings"
 
 	"github.com/go-phorce/dolly/netutil"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/juju/errors"
 )
 
+// TrustedProxies specifies the networks of the trusted proxies.
+// X-Forwarded-For and X-Real-Ip headers are used
+// only when the request is received from a trusted proxy, and
+// X-Forwarded-For is walked from right to left skipping the trusted proxies.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies returns the trusted proxies from the list of CIDRs.
+// A single IP address is treated as /32 or /128 network.
+func ParseTrustedProxies(cidrs []string) (TrustedProxies, error) {
+	var list TrustedProxies
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil {
+				bits := 8 * net.IPv6len
+				if ip.To4() != nil {
+					ip = ip.To4()
+					bits = 8 * net.IPv4len
+				}
+				list = append(list, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+				continue
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.NotValidf("trusted proxy %q", cidr)
+		}
+		list = append(list, network)
+	}
+	return list, nil
+}
+
+// contains returns true if the IP belongs to a trusted proxy
+func (p TrustedProxies) contains(ip net.IP) bool {
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithTrustedProxies returns a shallow copy of the request
+// with the trusted proxies in the context,
+// used by ClientIPFromRequest and ClientAddr to resolve the client's IP.
+func WithTrustedProxies(r *http.Request, proxies TrustedProxies) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), keyTrustedProxies, proxies))
+}
+
+// trustedProxiesForRequest returns the trusted proxies from the request context
+func trustedProxiesForRequest(r *http.Request) TrustedProxies {
+	proxies, _ := r.Context().Value(keyTrustedProxies).(TrustedProxies)
+	return proxies
+}
+
+// ClientIP returns client's IP address for the request,
+// from the request context if it's set, or resolved from the request.
+func ClientIP(r *http.Request) string {
+	if v, ok := r.Context().Value(keyContext).(*RequestContext); ok && v.clientIP != "" {
+		return v.clientIP
+	}
+	return ClientIPFromRequest(r)
+}
+
+// ClientAddr returns client's address in host:port format for logging.
+// If the request is received from a trusted proxy, then the host is the client's IP
+// resolved via the trusted proxies, otherwise RemoteAddr is returned as is.
+func ClientAddr(r *http.Request) string {
+	if len(trustedProxiesForRequest(r)) == 0 {
+		return r.RemoteAddr
+	}
+	host, port, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	if ip := ClientIP(r); ip != "" && ip != host {
+		return net.JoinHostPort(ip, port)
+	}
+	return r.RemoteAddr
+}
+
 // ClientIPFromRequest return client's real public IP address from http request headers.
+// The headers are used only if the request is received from a trusted proxy,
+// set by WithTrustedProxies in the request context, otherwise the IP of RemoteAddr is returned.
+// To trust the headers from any client, for example behind a load balancer
+// with unknown addresses, configure 0.0.0.0/0 and ::/0 as the trusted proxies.
 func ClientIPFromRequest(r *http.Request) string {
-	// Fetch header value
-	xRealIP := r.Header.Get("X-Real-Ip")
-	xForwardedFor := r.Header.Get("X-Forwarded-For")
-
-	// If both empty, return IP from remote address
-	if xRealIP == "" && xForwardedFor == "" {
-		var remoteIP string
-
-		// If there are colon in remote address, remove the port number
-		// otherwise, return remote address as is
-		if strings.ContainsRune(r.RemoteAddr, ':') {
-			remoteIP, _, _ = net.SplitHostPort(r.RemoteAddr)
-		} else {
-			remoteIP = r.RemoteAddr
-		}
+	client := remoteIP(r)
+	if client == "" {
+		client, _ = netutil.GetLocalIP()
+		return client
+	}
+	proxies := trustedProxiesForRequest(r)
+	if len(proxies) == 0 {
+		return client
+	}
+	return proxies.clientIP(r, client)
+}
 
-		if remoteIP == "" {
-			remoteIP, _ = netutil.GetLocalIP()
+// remoteIP returns IP from the remote address of the request
+func remoteIP(r *http.Request) string {
+	if strings.ContainsRune(r.RemoteAddr, ':') {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err == nil {
+			return host
 		}
-		return remoteIP
+	}
+	return r.RemoteAddr
+}
+
+// clientIP resolves the client's IP address,
+// trusting the headers only from the trusted proxies
+func (p TrustedProxies) clientIP(r *http.Request, client string) string {
+	ip := net.ParseIP(client)
+	if ip == nil || !p.contains(ip) {
+		return client
 	}
 
-	// Check list of IP in X-Forwarded-For and return the first global address
-	for _, address := range strings.Split(xForwardedFor, ",") {
-		address = strings.TrimSpace(address)
-		isPrivate, err := netutil.IsPrivateAddress(address)
-		if !isPrivate && err == nil {
-			return address
+	var hops []string
+	for _, h := range r.Header.Values(header.XForwardedFor) {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	if len(hops) == 0 {
+		if xRealIP := strings.TrimSpace(r.Header.Get(header.XRealIP)); net.ParseIP(xRealIP) != nil {
+			return xRealIP
 		}
+		return client
 	}
 
-	// If nothing succeed, return X-Real-IP
-	return xRealIP
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		ip = net.ParseIP(hop)
+		if ip == nil {
+			// the header is malformed, use the last valid hop
+			break
+		}
+		client = hop
+		if !p.contains(ip) {
+			break
+		}
+	}
+	return client
 }
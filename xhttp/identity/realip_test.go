@@ -2,9 +2,12 @@ package identity
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/go-phorce/dolly/xhttp/header"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRealIP(t *testing.T) {
@@ -39,16 +42,20 @@ func TestRealIP(t *testing.T) {
 			request:  newRequest(publicAddr1, ""),
 			expected: publicAddr1,
 		}, {
-			name:     "Has X-Forwarded-For",
-			request:  newRequest("", "", publicAddr1),
+			name:     "With port",
+			request:  newRequest(publicAddr1+":1234", ""),
 			expected: publicAddr1,
 		}, {
-			name:     "Has multiple X-Forwarded-For",
-			request:  newRequest("", "", localAddr, publicAddr1, publicAddr2),
-			expected: publicAddr2,
+			name:     "Untrusted X-Forwarded-For",
+			request:  newRequest(publicAddr1+":1234", "", publicAddr2),
+			expected: publicAddr1,
+		}, {
+			name:     "Untrusted multiple X-Forwarded-For",
+			request:  newRequest(localAddr+":1234", "", localAddr, publicAddr1, publicAddr2),
+			expected: localAddr,
 		}, {
-			name:     "Has X-Real-IP",
-			request:  newRequest("", publicAddr1),
+			name:     "Untrusted X-Real-IP",
+			request:  newRequest(publicAddr1+":1234", publicAddr2),
 			expected: publicAddr1,
 		},
 	}
@@ -61,3 +68,82 @@ func TestRealIP(t *testing.T) {
 		})
 	}
 }
+
+func TestTrustedClientIP(t *testing.T) {
+	_, err := ParseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "::1"})
+	require.NoError(t, err)
+
+	newRequest := func(remoteAddr string, xForwardedFor ...string) *http.Request {
+		h := http.Header{}
+		for _, address := range xForwardedFor {
+			h.Add(header.XForwardedFor, address)
+		}
+		r := &http.Request{
+			RemoteAddr: remoteAddr,
+			Header:     h,
+		}
+		return WithTrustedProxies(r, proxies)
+	}
+
+	tcs := []struct {
+		name     string
+		request  *http.Request
+		expected string
+	}{
+		{"untrusted remote", newRequest("144.12.54.87:1234", "119.14.55.11"), "144.12.54.87"},
+		{"trusted remote without XFF", newRequest("10.0.0.1:1234"), "10.0.0.1"},
+		{"trusted remote", newRequest("10.0.0.1:1234", "119.14.55.11"), "119.14.55.11"},
+		{"spoofed XFF", newRequest("10.0.0.1:1234", "1.1.1.1, 119.14.55.11, 10.1.1.1"), "119.14.55.11"},
+		{"multiple headers", newRequest("[::1]:1234", "119.14.55.11", "192.168.1.1"), "119.14.55.11"},
+		{"all trusted", newRequest("10.0.0.1:1234", "10.1.1.1, 10.2.2.2"), "10.1.1.1"},
+		{"malformed XFF", newRequest("10.0.0.1:1234", "garbage, 10.2.2.2"), "10.2.2.2"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ClientIPFromRequest(tc.request))
+			assert.Equal(t, tc.expected, ClientIP(tc.request))
+		})
+	}
+
+	r := newRequest("10.0.0.1:1234", "119.14.55.11")
+	assert.Equal(t, "119.14.55.11:1234", ClientAddr(r))
+	r = newRequest("144.12.54.87:1234", "119.14.55.11")
+	assert.Equal(t, "144.12.54.87:1234", ClientAddr(r))
+
+	// the proxies are not shared with the requests without them
+	r = WithTrustedProxies(newRequest("10.0.0.1:1234", "119.14.55.11"), nil)
+	assert.Equal(t, "10.0.0.1", ClientIPFromRequest(r))
+	assert.Equal(t, "10.0.0.1:1234", ClientAddr(r))
+}
+
+func TestContextHandlerWithTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	var clientIP, clientAddr string
+	d := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientIP = ClientIP(r)
+		clientAddr = ClientAddr(r)
+	})
+	trusted := NewContextHandlerWithTrustedProxies(d, proxies)
+	untrusted := NewContextHandler(d)
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set(header.XForwardedFor, "119.14.55.11")
+		return r
+	}
+
+	trusted.ServeHTTP(httptest.NewRecorder(), newRequest())
+	assert.Equal(t, "119.14.55.11", clientIP)
+	assert.Equal(t, "119.14.55.11:1234", clientAddr)
+
+	// the proxies of another handler are not trusted
+	untrusted.ServeHTTP(httptest.NewRecorder(), newRequest())
+	assert.Equal(t, "10.0.0.1", clientIP)
+	assert.Equal(t, "10.0.0.1:1234", clientAddr)
+}
@@ -0,0 +1,37 @@
+package identity
+
+import (
+	"crypto/x509"
+	"net/http"
+)
+
+// PeerCertificate returns the leaf certificate presented by the client,
+// or nil if the request is not received over TLS,
+// or the client did not present a certificate
+func PeerCertificate(r *http.Request) *x509.Certificate {
+	if r == nil || r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return r.TLS.PeerCertificates[0]
+}
+
+// TLSVersion returns the negotiated TLS version, such as tls.VersionTLS12,
+// or 0 if the request is not received over TLS
+func TLSVersion(r *http.Request) uint16 {
+	if r == nil || r.TLS == nil {
+		return 0
+	}
+	return r.TLS.Version
+}
+
+// VerifiedChains returns the certificate chains of the client,
+// verified by the server against its trusted roots,
+// where the first element of each chain is the leaf certificate.
+// It returns nil if the request is not received over TLS,
+// or the client certificate was not verified
+func VerifiedChains(r *http.Request) [][]*x509.Certificate {
+	if r == nil || r.TLS == nil {
+		return nil
+	}
+	return r.TLS.VerifiedChains
+}
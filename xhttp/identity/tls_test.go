@@ -0,0 +1,38 @@
+package identity
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TLSHelpers(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, PeerCertificate(r))
+	assert.Equal(t, uint16(0), TLSVersion(r))
+	assert.Nil(t, VerifiedChains(r))
+
+	r.TLS = &tls.ConnectionState{Version: tls.VersionTLS13}
+	assert.Nil(t, PeerCertificate(r))
+	assert.Equal(t, uint16(tls.VersionTLS13), TLSVersion(r))
+	assert.Nil(t, VerifiedChains(r))
+
+	leaf := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}}
+	ca := &x509.Certificate{Subject: pkix.Name{CommonName: "ca"}}
+	r.TLS = &tls.ConnectionState{
+		Version:          tls.VersionTLS12,
+		PeerCertificates: []*x509.Certificate{leaf, ca},
+		VerifiedChains:   [][]*x509.Certificate{{leaf, ca}},
+	}
+	assert.Equal(t, leaf, PeerCertificate(r))
+	assert.Equal(t, uint16(tls.VersionTLS12), TLSVersion(r))
+	assert.Equal(t, [][]*x509.Certificate{{leaf, ca}}, VerifiedChains(r))
+
+	assert.Nil(t, PeerCertificate(nil))
+	assert.Equal(t, uint16(0), TLSVersion(nil))
+	assert.Nil(t, VerifiedChains(nil))
+}
@@ -0,0 +1,98 @@
+package xhttp
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+var keyForHTTPReqIPForbidden = []string{"http", "request", "ip", "forbidden"}
+
+// IPFilter is a http.Handler that allows the requests
+// only from the permitted networks of the client IP
+type IPFilter struct {
+	delegate http.Handler
+	allow    []net.IPNet
+	deny     []net.IPNet
+	auditor  Auditor
+}
+
+// NewIPFilter returns a handler that rejects the requests with 403 Forbidden,
+// when the client IP is not permitted.
+// The client IP is the peer address of the request, X-Forwarded-For and X-Real-Ip headers
+// are used only when the peer is a trusted proxy, see identity.WithTrustedProxies.
+// The deny networks take precedence over the allow networks,
+// the empty allow list permits all IPs, that are not denied.
+// Both IPv4 and IPv6 networks are supported.
+// The delegate can be nil, if the filter is used only to Check the requests,
+// for example by rest.WithIPFilter for specific routes.
+func NewIPFilter(delegate http.Handler, allow []net.IPNet, deny []net.IPNet) *IPFilter {
+	return &IPFilter{
+		delegate: delegate,
+		allow:    allow,
+		deny:     deny,
+	}
+}
+
+// WithAuditor sets the auditor to record the rejected requests,
+// use audit.Sampler to not flood the audit log
+func (f *IPFilter) WithAuditor(auditor Auditor) *IPFilter {
+	f.auditor = auditor
+	return f
+}
+
+// IsAllowed returns true if the IP is permitted by the filter
+func (f *IPFilter) IsAllowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for i := range f.deny {
+		if f.deny[i].Contains(ip) {
+			return false
+		}
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	for i := range f.allow {
+		if f.allow[i].Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns true if the client IP of the request is permitted,
+// otherwise the request is replied with 403 status
+func (f *IPFilter) Check(w http.ResponseWriter, r *http.Request) bool {
+	// the IP is resolved from the request, not from the context,
+	// so the spoofed headers of untrusted peers are never used
+	clientIP := identity.ClientIPFromRequest(r)
+	if !f.IsAllowed(net.ParseIP(clientIP)) {
+		metrics.IncrCounter(keyForHTTPReqIPForbidden, 1,
+			metrics.Tag{Name: tags.Method, Value: r.Method},
+			metrics.Tag{Name: tags.URI, Value: r.URL.Path},
+		)
+		logger.Warningf("api=IPFilter, reason=forbidden, method=%s, path=%s, ip=%q",
+			r.Method, r.URL.Path, clientIP)
+		if f.auditor != nil {
+			auditRejected(f.auditor, r, "ip_filter")
+		}
+
+		marshal.WriteJSON(w, r, httperror.WithForbidden("access denied"))
+		return false
+	}
+	return true
+}
+
+// ServeHTTP implements http.Handler
+func (f *IPFilter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.Check(w, r) {
+		f.delegate.ServeHTTP(w, r)
+	}
+}
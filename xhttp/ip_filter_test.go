@@ -0,0 +1,100 @@
+package xhttp
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IPFilter(t *testing.T) {
+	cidrs := func(list ...string) []net.IPNet {
+		var res []net.IPNet
+		for _, s := range list {
+			_, n, err := net.ParseCIDR(s)
+			require.NoError(t, err)
+			res = append(res, *n)
+		}
+		return res
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	allowInternal := NewIPFilter(ok, cidrs("10.0.0.0/8", "fd00::/8"), cidrs("10.0.0.0/24"))
+	denyOnly := NewIPFilter(ok, nil, cidrs("192.168.0.0/16", "2001:db8::/32"))
+
+	tcases := []struct {
+		filter *IPFilter
+		remote string
+		status int
+	}{
+		{allowInternal, "10.1.2.3:1234", http.StatusOK},
+		{allowInternal, "[fd12::1]:1234", http.StatusOK},
+		// deny takes precedence
+		{allowInternal, "10.0.0.5:1234", http.StatusForbidden},
+		{allowInternal, "192.168.1.1:1234", http.StatusForbidden},
+		{allowInternal, "[2001:db8::1]:1234", http.StatusForbidden},
+		{denyOnly, "10.1.2.3:1234", http.StatusOK},
+		{denyOnly, "192.168.1.1:1234", http.StatusForbidden},
+		{denyOnly, "[2001:db8::1]:1234", http.StatusForbidden},
+		{denyOnly, "[fd12::1]:1234", http.StatusOK},
+	}
+
+	for _, tc := range tcases {
+		r, err := http.NewRequest(http.MethodGet, "/v1/admin", nil)
+		require.NoError(t, err)
+		r.RemoteAddr = tc.remote
+		w := httptest.NewRecorder()
+		tc.filter.ServeHTTP(w, r)
+		assert.Equal(t, tc.status, w.Code, tc.remote)
+	}
+
+	auditor := &rejectAuditor{}
+	allowInternal.WithAuditor(auditor)
+	r, err := http.NewRequest(http.MethodGet, "/v1/admin", nil)
+	require.NoError(t, err)
+	r.RemoteAddr = "192.168.1.1:1234"
+	w := httptest.NewRecorder()
+	allowInternal.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, `{"code":"forbidden","message":"access denied"}`, w.Body.String())
+	require.Len(t, auditor.events, 1)
+	assert.Equal(t, "throttle:rejected:reason=ip_filter, method=GET, path=/v1/admin, ip=192.168.1.1", auditor.events[0])
+}
+
+func Test_IPFilterForwardedHeaders(t *testing.T) {
+	_, allow, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	filter := NewIPFilter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}), []net.IPNet{*allow}, nil)
+
+	proxies, err := identity.ParseTrustedProxies([]string{"192.168.1.1"})
+	require.NoError(t, err)
+
+	serve := func(remote string, proxies identity.TrustedProxies) int {
+		r, err := http.NewRequest(http.MethodGet, "/v1/admin", nil)
+		require.NoError(t, err)
+		r = identity.WithTrustedProxies(r, proxies)
+		r.RemoteAddr = remote
+		r.Header.Set(header.XRealIP, "10.1.2.3")
+		r.Header.Set(header.XForwardedFor, "10.1.2.3")
+		w := httptest.NewRecorder()
+		filter.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// the spoofed headers from an untrusted peer are ignored
+	assert.Equal(t, http.StatusForbidden, serve("144.12.54.87:1234", nil))
+	assert.Equal(t, http.StatusForbidden, serve("192.168.1.1:1234", nil))
+
+	assert.Equal(t, http.StatusForbidden, serve("144.12.54.87:1234", proxies))
+	// the headers are used only from the trusted proxy
+	assert.Equal(t, http.StatusOK, serve("192.168.1.1:1234", proxies))
+}
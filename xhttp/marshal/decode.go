@@ -0,0 +1,109 @@
+package marshal
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/ugorji/go/codec"
+)
+
+// jsonDecLenientHandle is used to decode json, ignoring the fields
+// with no matching field in the go type
+var jsonDecLenientHandle codec.JsonHandle
+
+func init() {
+	jsonDecLenientHandle.MapType = reflect.TypeOf(map[string]interface{}{})
+}
+
+// DecodeOptions specifies the options of DecodeRequest
+type DecodeOptions struct {
+	// MaxBytes specifies the maximum size of the request body,
+	// if not positive, then the size is limited only by the server middleware
+	MaxBytes int64
+	// ContentTypes specifies the accepted media types of the request,
+	// if empty, then application/json is accepted
+	ContentTypes []string
+	// RequireContentType specifies to reject the request without Content-Type header,
+	// otherwise the body is decoded as JSON
+	RequireContentType bool
+	// AllowUnknownFields specifies to ignore the fields in the json,
+	// with no matching field in the result
+	AllowUnknownFields bool
+}
+
+// DecodeRequest validates the Content-Type of the request,
+// and decodes the json body into the supplied result instance.
+// The returned error is *httperror.Error with the status code:
+//
+//	415 if the Content-Type is not accepted,
+//	413 if the body is larger than MaxBytes,
+//	or limited by http.MaxBytesReader of the server middleware,
+//	400 if the body is not valid json for the result.
+//
+// The caller should write the error to the response:
+//
+//	if err := marshal.DecodeRequest(r, &req, nil); err != nil {
+//		marshal.WriteJSON(w, r, err)
+//		return
+//	}
+func DecodeRequest(r *http.Request, result interface{}, opts *DecodeOptions) error {
+	if opts == nil {
+		opts = &DecodeOptions{}
+	}
+
+	contentType := r.Header.Get(header.ContentType)
+	if contentType != "" || opts.RequireContentType {
+		accepted := opts.ContentTypes
+		if len(accepted) == 0 {
+			accepted = []string{header.ApplicationJSON}
+		}
+		if !header.MatchContentType(contentType, accepted...) {
+			return httperror.WithUnsupportedMediaType("unsupported content type: %q", contentType)
+		}
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return httperror.WithInvalidJSON("missing request body")
+	}
+
+	if opts.MaxBytes > 0 && r.ContentLength > opts.MaxBytes {
+		return httperror.WithRequestEntityTooLarge("the request body is too large")
+	}
+
+	body := &countingReader{r: r.Body}
+	var reader io.Reader = body
+	if opts.MaxBytes > 0 {
+		// read one byte over the limit to detect the large body
+		reader = io.LimitReader(body, opts.MaxBytes+1)
+	}
+
+	h := &jsonDecHandle
+	if opts.AllowUnknownFields {
+		h = &jsonDecLenientHandle
+	}
+
+	err := codec.NewDecoder(bufio.NewReader(reader), h).Decode(result)
+	if httperror.IsRequestBodyTooLarge(err) || (opts.MaxBytes > 0 && body.n > opts.MaxBytes) {
+		return httperror.WithRequestEntityTooLarge("the request body is too large").WithCause(err)
+	}
+	if err != nil {
+		return httperror.WithInvalidJSON("failed to decode '%T': %v", result, err.Error()).WithCause(err)
+	}
+	return nil
+}
+
+// countingReader counts the bytes read
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
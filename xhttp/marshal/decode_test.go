@@ -0,0 +1,91 @@
+package marshal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DecodeRequest(t *testing.T) {
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	newRequest := func(contentType, body string) *http.Request {
+		r, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		require.NoError(t, err)
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		return r
+	}
+
+	tcases := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        *DecodeOptions
+		status      int
+		exp         string
+	}{
+		{"json", "application/json", `{"name":"dolly"}`, nil, 0, "dolly"},
+		{"charset", "Application/JSON; charset=utf-8", `{"name":"dolly"}`, nil, 0, "dolly"},
+		{"no_content_type", "", `{"name":"dolly"}`, nil, 0, "dolly"},
+		{"required_content_type", "", `{"name":"dolly"}`, &DecodeOptions{RequireContentType: true}, http.StatusUnsupportedMediaType, ""},
+		{"unsupported", "text/plain", `{"name":"dolly"}`, nil, http.StatusUnsupportedMediaType, ""},
+		{"accepted", "application/jose+json", `{"name":"dolly"}`, &DecodeOptions{ContentTypes: []string{"application/jose+json"}}, 0, "dolly"},
+		{"invalid", "application/json", `{"name":`, nil, http.StatusBadRequest, ""},
+		{"unknown_field", "application/json", `{"name":"dolly","age":1}`, nil, http.StatusBadRequest, ""},
+		{"allow_unknown_field", "application/json", `{"name":"dolly","age":1}`, &DecodeOptions{AllowUnknownFields: true}, 0, "dolly"},
+		{"max_bytes", "application/json", `{"name":"dolly"}`, &DecodeOptions{MaxBytes: 16}, 0, "dolly"},
+		{"too_large", "application/json", `{"name":"dolly1"}`, &DecodeOptions{MaxBytes: 16}, http.StatusRequestEntityTooLarge, ""},
+	}
+
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var v request
+			err := DecodeRequest(newRequest(tc.contentType, tc.body), &v, tc.opts)
+			if tc.status == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, tc.exp, v.Name)
+				return
+			}
+			require.Error(t, err)
+			herr, ok := err.(*httperror.Error)
+			require.True(t, ok)
+			assert.Equal(t, tc.status, herr.HTTPStatus)
+		})
+	}
+
+	t.Run("streaming_too_large", func(t *testing.T) {
+		r := newRequest("application/json", `{"name":"`+strings.Repeat("a", 1024)+`"}`)
+		r.ContentLength = -1
+		var v request
+		err := DecodeRequest(r, &v, &DecodeOptions{MaxBytes: 100})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, err.(*httperror.Error).HTTPStatus)
+	})
+
+	t.Run("max_bytes_reader", func(t *testing.T) {
+		r := newRequest("application/json", `{"name":"`+strings.Repeat("a", 1024)+`"}`)
+		r.Body = http.MaxBytesReader(httptest.NewRecorder(), r.Body, 100)
+		var v request
+		err := DecodeRequest(r, &v, nil)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, err.(*httperror.Error).HTTPStatus)
+	})
+
+	t.Run("no_body", func(t *testing.T) {
+		r, err := http.NewRequest(http.MethodPost, "/", nil)
+		require.NoError(t, err)
+		var v request
+		err = DecodeRequest(r, &v, nil)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(*httperror.Error).HTTPStatus)
+	})
+}
@@ -0,0 +1,299 @@
+package marshal
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+)
+
+// EmptyFields specifies the encoding of the struct fields,
+// tagged with omitempty, when the value is empty
+type EmptyFields int
+
+const (
+	// OmitEmptyFields omits the empty fields tagged with omitempty, that is the default
+	OmitEmptyFields EmptyFields = iota
+	// IncludeEmptyFields includes all fields regardless of omitempty tag,
+	// the nil pointers, slices, maps and interfaces are encoded as null
+	IncludeEmptyFields
+)
+
+type emptyFieldsContextKey struct{}
+
+// WithEmptyFields returns the context, that specifies the encoding of the empty fields
+// of the responses written by WriteJSON, WriteResult and WriteJSONWithETag,
+// the server uses it to set the default for all requests
+func WithEmptyFields(ctx context.Context, mode EmptyFields) context.Context {
+	return context.WithValue(ctx, emptyFieldsContextKey{}, mode)
+}
+
+// EmptyFieldsFromContext returns the encoding of the empty fields,
+// or OmitEmptyFields if not set
+func EmptyFieldsFromContext(ctx context.Context) EmptyFields {
+	if mode, ok := ctx.Value(emptyFieldsContextKey{}).(EmptyFields); ok {
+		return mode
+	}
+	return OmitEmptyFields
+}
+
+// emptyFieldsValue overrides the encoding of the empty fields of the response
+type emptyFieldsValue struct {
+	v    interface{}
+	mode EmptyFields
+}
+
+// Explicit returns the response value, that is encoded with all fields,
+// including the empty fields tagged with omitempty,
+// regardless of the server default
+func Explicit(v interface{}) interface{} {
+	return &emptyFieldsValue{v: v, mode: IncludeEmptyFields}
+}
+
+// Compact returns the response value, that is encoded without the empty fields
+// tagged with omitempty, regardless of the server default
+func Compact(v interface{}) interface{} {
+	return &emptyFieldsValue{v: v, mode: OmitEmptyFields}
+}
+
+// encodeBody encodes the response body in the format requested by r,
+// and with the encoding of the empty fields specified for the value or the request
+func encodeBody(w io.Writer, r *http.Request, body interface{}) error {
+	mode := OmitEmptyFields
+	if r != nil {
+		mode = EmptyFieldsFromContext(r.Context())
+	}
+	if ev, ok := body.(*emptyFieldsValue); ok {
+		body = ev.v
+		mode = ev.mode
+	}
+
+	pp := DontPrettyPrint
+	if r != nil {
+		pp = shouldPrettyPrint(r)
+	}
+	if mode != IncludeEmptyFields {
+		return codec.NewEncoder(w, encoderHandle(pp)).Encode(body)
+	}
+
+	var buf bytes.Buffer
+	if err := encodeExplicit(&buf, reflect.ValueOf(body)); err != nil {
+		return err
+	}
+	if pp == PrettyPrint {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, buf.Bytes(), "", "\t"); err != nil {
+			return err
+		}
+		buf = indented
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	selferType        = reflect.TypeOf((*codec.Selfer)(nil)).Elem()
+)
+
+// isCustomEncoded returns true if the type provides its own encoding,
+// such as time.Time, so it's encoded as is
+func isCustomEncoded(t reflect.Type) bool {
+	for _, it := range []reflect.Type{jsonMarshalerType, textMarshalerType, selferType} {
+		if t.Implements(it) || reflect.PtrTo(t).Implements(it) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeExplicit encodes the value with all struct fields,
+// the values of other types are encoded as is
+func encodeExplicit(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteString("null")
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if !isCustomEncoded(v.Type()) || v.Kind() == reflect.Interface {
+			return encodeExplicit(buf, v.Elem())
+		}
+	case reflect.Struct:
+		if !isCustomEncoded(v.Type()) {
+			return encodeExplicitStruct(buf, v)
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if !isCustomEncoded(v.Type()) {
+			return encodeExplicitMap(buf, v)
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if v.Type().Elem().Kind() != reflect.Uint8 && !isCustomEncoded(v.Type()) {
+			return encodeExplicitList(buf, v)
+		}
+	case reflect.Array:
+		if !isCustomEncoded(v.Type()) {
+			return encodeExplicitList(buf, v)
+		}
+	}
+
+	b, err := EncodeBytes(DontPrettyPrint, v.Interface())
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
+// encodeExplicitStruct encodes the exported fields of the struct,
+// the names are taken from codec or json tags, as by the default encoder
+func encodeExplicitStruct(buf *bytes.Buffer, v reflect.Value) error {
+	buf.WriteByte('{')
+	seen := map[string]bool{}
+	if err := encodeExplicitFields(buf, v, seen); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func encodeExplicitFields(buf *bytes.Buffer, v reflect.Value, seen map[string]bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("codec")
+		if tag == "" {
+			tag = f.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		fv := v.Field(i)
+		if f.Anonymous && name == "" {
+			// the fields of the embedded struct are promoted
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !isCustomEncoded(ft) {
+				if err := encodeExplicitFields(buf, fv, seen); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if len(seen) > 1 {
+			buf.WriteByte(',')
+		}
+		if err := encodeExplicitKey(buf, name); err != nil {
+			return err
+		}
+		if err := encodeExplicit(buf, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeExplicitMap encodes the map with the keys sorted
+func encodeExplicitMap(buf *bytes.Buffer, v reflect.Value) error {
+	keys := make([]string, 0, v.Len())
+	values := make(map[string]reflect.Value, v.Len())
+	for _, k := range v.MapKeys() {
+		var key string
+		switch k.Kind() {
+		case reflect.String:
+			key = k.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			key = strconv.FormatInt(k.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			key = strconv.FormatUint(k.Uint(), 10)
+		default:
+			key = fmt.Sprint(k.Interface())
+		}
+		keys = append(keys, key)
+		values[key] = v.MapIndex(k)
+	}
+	sort.Strings(keys)
+
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeExplicitKey(buf, key); err != nil {
+			return err
+		}
+		if err := encodeExplicit(buf, values[key]); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// encodeExplicitList encodes the elements of the slice or array
+func encodeExplicitList(buf *bytes.Buffer, v reflect.Value) error {
+	buf.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeExplicit(buf, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+func encodeExplicitKey(buf *bytes.Buffer, key string) error {
+	b, err := EncodeBytes(DontPrettyPrint, key)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	buf.WriteByte(':')
+	return nil
+}
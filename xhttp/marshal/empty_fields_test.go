@@ -0,0 +1,77 @@
+package marshal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type emptyInner struct {
+	Name string `json:"name,omitempty"`
+}
+
+type emptyBase struct {
+	ID string `json:"id,omitempty"`
+}
+
+type emptyResponse struct {
+	emptyBase
+	Count    int               `json:"count,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Inner    *emptyInner       `json:"inner,omitempty"`
+	Value    emptyInner        `json:"value"`
+	Created  time.Time         `json:"created,omitempty"`
+	Skipped  string            `json:"-"`
+	Untagged bool
+	internal string
+}
+
+func Test_EmptyFields(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	v := &emptyResponse{
+		Labels:   map[string]string{"b": "2", "a": "1"},
+		Created:  created,
+		Skipped:  "skipped",
+		internal: "internal",
+	}
+
+	write := func(r *http.Request, body interface{}) string {
+		w := httptest.NewRecorder()
+		WriteJSON(w, r, body)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/test", nil)
+	compact := write(r, v)
+	assert.NotContains(t, compact, `"count"`)
+	assert.NotContains(t, compact, `"id"`)
+
+	explicit := `{"id":"","count":0,"tags":null,"labels":{"a":"1","b":"2"},"inner":null,"value":{"name":""},"created":"2020-01-02T03:04:05Z","Untagged":false}`
+	assert.Equal(t, explicit, write(r, Explicit(v)))
+
+	// the server default
+	rx := r.WithContext(WithEmptyFields(r.Context(), IncludeEmptyFields))
+	assert.Equal(t, IncludeEmptyFields, EmptyFieldsFromContext(rx.Context()))
+	assert.Equal(t, explicit, write(rx, v))
+	// the codec encodes the maps in random order
+	assert.JSONEq(t, compact, write(rx, Compact(v)))
+
+	assert.Equal(t, `null`, write(r, Explicit(nil)))
+	assert.Equal(t, `[{"name":""},null]`, write(r, Explicit([]*emptyInner{{}, nil})))
+
+	pp := httptest.NewRequest(http.MethodGet, "/v1/test?pp", nil)
+	assert.Equal(t, "{\n\t\"name\": \"\"\n}", write(pp, Explicit(&emptyInner{})))
+
+	t.Run("ETag", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		WriteJSONWithETag(w, rx, v)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, explicit, w.Body.String())
+	})
+}
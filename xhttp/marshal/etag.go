@@ -0,0 +1,119 @@
+package marshal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+)
+
+// gzipETagSuffix is appended to the ETag of the compressed response,
+// as the strong ETag must be different for each representation
+const gzipETagSuffix = "-gzip"
+
+// WriteJSONWithETag serializes the supplied value as JSON response,
+// with a strong ETag computed as the hash of the serialized body.
+// If the value is an error, or implements the WriteHTTPResponse interface,
+// then it's written by WriteJSON.
+//
+// For GET and HEAD requests, 304 Not Modified is returned without body,
+// when If-None-Match matches the ETag, or when If-None-Match is not present,
+// and the Last-Modified header set by the handler is not after If-Modified-Since.
+//
+// The ETag of the gzip compressed response has -gzip suffix,
+// both forms are matched by If-None-Match.
+func WriteJSONWithETag(w http.ResponseWriter, r *http.Request, v interface{}) {
+	switch v.(type) {
+	case WriteHTTPResponse, error:
+		WriteJSON(w, r, v)
+		return
+	}
+	if clientGone(r) {
+		logger.Debugf("api=WriteJSONWithETag, reason=client_gone, path=%s", r.URL.Path)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := encodeBody(&buf, r, v); err != nil {
+		logger.Warningf("api=WriteJSONWithETag, reason=encode, type=%T, err=[%v]", v, err.Error())
+		WriteJSON(w, r, httperror.WithUnexpected("unable to encode the response"))
+		return
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	tag := hex.EncodeToString(sum[:16])
+	compress := r != nil && strings.Contains(r.Header.Get(header.AcceptEncoding), "gzip")
+
+	h := w.Header()
+	if compress {
+		h.Set(header.ETag, `"`+tag+gzipETagSuffix+`"`)
+	} else {
+		h.Set(header.ETag, `"`+tag+`"`)
+	}
+	h.Add(header.Vary, header.AcceptEncoding)
+
+	if r != nil && isNotModified(r, tag, h.Get(header.LastModified)) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.Set(header.ContentType, header.ApplicationJSON)
+	var out io.Writer = w
+	if compress {
+		h.Set(header.ContentEncoding, "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		out = gz
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := out.Write(buf.Bytes()); err != nil {
+		if clientGone(r) {
+			logger.Debugf("api=WriteJSONWithETag, reason=client_gone, path=%s", r.URL.Path)
+			return
+		}
+		logger.Warningf("api=WriteJSONWithETag, reason=write, err=[%v]", err.Error())
+	}
+}
+
+// isNotModified returns true if the conditional GET or HEAD request
+// matches the ETag or the last modified time
+func isNotModified(r *http.Request, tag, lastModified string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get(header.IfNoneMatch); inm != "" {
+		for _, s := range strings.Split(inm, ",") {
+			s = strings.TrimSpace(s)
+			if s == "*" {
+				return true
+			}
+			s = strings.Trim(strings.TrimPrefix(s, "W/"), `"`)
+			if strings.TrimSuffix(s, gzipETagSuffix) == tag {
+				return true
+			}
+		}
+		// If-Modified-Since is ignored when If-None-Match is present
+		return false
+	}
+
+	ims := r.Header.Get(header.IfModifiedSince)
+	if ims == "" || lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
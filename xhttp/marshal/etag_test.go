@@ -0,0 +1,90 @@
+package marshal
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WriteJSONWithETag(t *testing.T) {
+	v := &AStruct{A: "a", B: "b"}
+
+	serve := func(method string, hdrs ...string) *httptest.ResponseRecorder {
+		r, err := http.NewRequest(method, "/v1/items", nil)
+		require.NoError(t, err)
+		for i := 0; i < len(hdrs); i += 2 {
+			r.Header.Set(hdrs[i], hdrs[i+1])
+		}
+		w := httptest.NewRecorder()
+		WriteJSONWithETag(w, r, v)
+		return w
+	}
+
+	w := serve(http.MethodGet)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"A":"a","B":"b"}`, w.Body.String())
+	etag := w.Header().Get(header.ETag)
+	require.NotEmpty(t, etag)
+	assert.Equal(t, header.AcceptEncoding, w.Header().Get(header.Vary))
+
+	w = serve(http.MethodGet, header.IfNoneMatch, etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get(header.ETag))
+
+	w = serve(http.MethodGet, header.IfNoneMatch, `"other", W/`+etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = serve(http.MethodGet, header.IfNoneMatch, `"other"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// not a conditional method
+	w = serve(http.MethodPost, header.IfNoneMatch, etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// compressed
+	w = serve(http.MethodGet, header.AcceptEncoding, "gzip")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get(header.ContentEncoding))
+	gzEtag := w.Header().Get(header.ETag)
+	assert.NotEqual(t, etag, gzEtag)
+	gz, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, `{"A":"a","B":"b"}`, string(body))
+
+	w = serve(http.MethodGet, header.AcceptEncoding, "gzip", header.IfNoneMatch, gzEtag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	w = serve(http.MethodGet, header.AcceptEncoding, "gzip", header.IfNoneMatch, etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
+
+func Test_WriteJSONWithETagLastModified(t *testing.T) {
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	serve := func(ims string) *httptest.ResponseRecorder {
+		r, err := http.NewRequest(http.MethodGet, "/v1/items", nil)
+		require.NoError(t, err)
+		if ims != "" {
+			r.Header.Set(header.IfModifiedSince, ims)
+		}
+		w := httptest.NewRecorder()
+		w.Header().Set(header.LastModified, modified.Format(http.TimeFormat))
+		WriteJSONWithETag(w, r, &AStruct{A: "a"})
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("").Code)
+	assert.Equal(t, http.StatusNotModified, serve(modified.Format(http.TimeFormat)).Code)
+	assert.Equal(t, http.StatusNotModified, serve(modified.Add(time.Hour).Format(http.TimeFormat)).Code)
+	assert.Equal(t, http.StatusOK, serve(modified.Add(-time.Hour).Format(http.TimeFormat)).Code)
+	assert.Equal(t, http.StatusOK, serve("invalid").Code)
+}
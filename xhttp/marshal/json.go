@@ -109,6 +109,10 @@ func Decode(r io.Reader, result interface{}) error {
 func DecodeBody(w http.ResponseWriter, r *http.Request, result interface{}) error {
 	err := Decode(r.Body, result)
 	if err != nil {
+		if httperror.IsRequestBodyTooLarge(err) {
+			WriteJSON(w, r, httperror.WithRequestEntityTooLarge("the request body is too large").WithCause(err))
+			return err
+		}
 		WriteJSON(
 			w, r,
 			httperror.New(
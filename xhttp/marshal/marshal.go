@@ -3,6 +3,7 @@ package marshal
 import (
 	"bufio"
 	"compress/gzip"
+	"context"
 	goErrors "errors"
 	"io"
 	"net/http"
@@ -26,7 +27,8 @@ type WriteHTTPResponse interface {
 // WriteJSON will serialize the supplied body parameter as a http response.
 // If the body value implements the WriteHTTPResponse interface,
 // then that will be called to have it do the response generation
-// if body implements error, then that's returned as a server error
+// if body implements error, then that's returned as a server error,
+// or with the matching status for the juju/errors types, such as 404 for errors.NotFound,
 // use the Error type to fully specify your error response
 // otherwise body is assumed to be a succesful response, and its serialized
 // and written as a json response with a 200 status code.
@@ -37,7 +39,15 @@ type WriteHTTPResponse interface {
 //		WriteJSON(logger,w,r,err,x)
 //	and if there was an error, that's what'll get returned
 //
+// The response is not written, if the client has disconnected,
+// and the encoding of the body is aborted, when the client disconnects
+// while the response is written.
 func WriteJSON(w http.ResponseWriter, r *http.Request, bodies ...interface{}) {
+	if clientGone(r) {
+		logger.Debugf("api=WriteJSON, reason=client_gone, path=%s", r.URL.Path)
+		return
+	}
+
 	var body interface{}
 	for i := range bodies {
 		if bodies[i] != nil {
@@ -62,28 +72,74 @@ func WriteJSON(w http.ResponseWriter, r *http.Request, bodies ...interface{}) {
 			return
 		}
 
+		// the juju/errors types are replied with the matching status
+		if e := httperror.FromErrorKind(bv); e != nil {
+			WriteJSON(w, r, e)
+			return
+		}
+
 		// you should really be using Error to get a good error response returned
 		logger.Debugf("api=WriteJSON, reason=generic_error, type=%T, err=[%v]", bv, bv)
 		WriteJSON(w, r, httperror.WithUnexpected(bv.Error()))
 		return
 
 	default:
-		w.Header().Set(header.ContentType, header.ApplicationJSON)
-		var out io.Writer = w
-		if r != nil && strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
-			w.Header().Set("Content-Encoding", "gzip")
-			gz := gzip.NewWriter(out)
-			out = gz
-			defer gz.Close()
-		}
-		bw := bufio.NewWriter(out)
-		if err := NewEncoder(bw, r).Encode(body); err != nil {
+		// the status is implicit, to keep the status set by the handler
+		writeBody(w, r, 0, body)
+	}
+}
+
+// writeBody serializes the body as JSON response with the status code,
+// if the status code is zero, then the status is not written,
+// and the response is replied with the status set by the handler, or 200
+func writeBody(w http.ResponseWriter, r *http.Request, statusCode int, body interface{}) {
+	w.Header().Set(header.ContentType, header.ApplicationJSON)
+	var out io.Writer = w
+	if r != nil {
+		out = &contextWriter{Writer: w, r: r}
+	}
+	if r != nil && strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(out)
+		out = gz
+		defer gz.Close()
+	}
+	if statusCode != 0 {
+		w.WriteHeader(statusCode)
+	}
+	bw := bufio.NewWriter(out)
+	err := encodeBody(bw, r, body)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		if clientGone(r) {
+			logger.Debugf("api=WriteJSON, reason=client_gone, path=%s, type=%T", r.URL.Path, body)
+		} else {
 			logger.Warningf("api=WriteJSON, reason=encode, type=%T, err=[%v]", body, err.Error())
 		}
-		bw.Flush()
 	}
 }
 
+// clientGone returns true if the client of the request has disconnected
+func clientGone(r *http.Request) bool {
+	return r != nil && goErrors.Is(r.Context().Err(), context.Canceled)
+}
+
+// contextWriter stops writing the response,
+// when the client of the request has disconnected
+type contextWriter struct {
+	io.Writer
+	r *http.Request
+}
+
+func (w *contextWriter) Write(p []byte) (int, error) {
+	if err := w.r.Context().Err(); goErrors.Is(err, context.Canceled) {
+		return 0, err
+	}
+	return w.Writer.Write(p)
+}
+
 func tryLogHTTPError(bv interface{}, r *http.Request) {
 	if e, ok := bv.(*httperror.Error); ok {
 		if e.HTTPStatus >= 500 {
@@ -1,11 +1,14 @@
 package marshal
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/juju/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -35,3 +38,84 @@ func Test_WritePlainJSON(t *testing.T) {
 		assert.Equal(t, header.ApplicationJSON, w.Header().Get(header.ContentType))
 	})
 }
+
+// cancelingWriter cancels the request context after the first write
+type cancelingWriter struct {
+	*httptest.ResponseRecorder
+	cancel context.CancelFunc
+	writes int
+}
+
+func (w *cancelingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	w.cancel()
+	return w.ResponseRecorder.Write(p)
+}
+
+func Test_WriteJSONClientGone(t *testing.T) {
+	t.Run("completed", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		WriteJSON(w, r, &AStruct{A: "a"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"A":"a","B":""}`, strings.TrimSpace(w.Body.String()))
+	})
+
+	t.Run("before", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+		w := httptest.NewRecorder()
+		WriteJSON(w, r, &AStruct{A: "a"})
+		assert.False(t, w.Flushed)
+		assert.Empty(t, w.Body.String())
+		assert.Empty(t, w.Header().Get(header.ContentType))
+
+		w = httptest.NewRecorder()
+		WriteJSONWithETag(w, r, &AStruct{A: "a"})
+		assert.Empty(t, w.Body.String())
+		assert.Empty(t, w.Header().Get(header.ETag))
+	})
+
+	t.Run("during", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+		list := make([]AStruct, 10000)
+		for i := range list {
+			list[i].A = strings.Repeat("a", 100)
+		}
+		w := &cancelingWriter{ResponseRecorder: httptest.NewRecorder(), cancel: cancel}
+		WriteJSON(w, r, list)
+		assert.Equal(t, 1, w.writes, "the writing must be aborted after the client is gone")
+		assert.Less(t, w.Body.Len(), 100*len(list))
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 0)
+		defer cancel()
+		<-ctx.Done()
+		r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+		// the server timeout is reported to the client
+		w := httptest.NewRecorder()
+		WriteJSON(w, r, &AStruct{A: "a"})
+		assert.Equal(t, `{"A":"a","B":""}`, strings.TrimSpace(w.Body.String()))
+	})
+}
+
+func Test_WriteJSONErrorKind(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/items/1", nil)
+
+	w := httptest.NewRecorder()
+	WriteJSON(w, r, errors.NotFoundf("item %q", "1"))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, `{"code":"not_found","message":"item \"1\" not found"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	WriteJSON(w, r, errors.New("failed"))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, `{"code":"unexpected","message":"failed"}`, w.Body.String())
+}
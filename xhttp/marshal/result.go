@@ -0,0 +1,58 @@
+package marshal
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/juju/errors"
+)
+
+// WriteResult serializes the supplied value as JSON response with the status code.
+// If the value is an error, or implements the WriteHTTPResponse interface,
+// then it's written by WriteJSON, and the status code is ignored.
+func WriteResult(w http.ResponseWriter, r *http.Request, statusCode int, v interface{}) {
+	switch bv := v.(type) {
+	case WriteHTTPResponse:
+		WriteJSON(w, r, bv)
+	case error:
+		Error(w, r, bv)
+	default:
+		writeBody(w, r, statusCode, v)
+	}
+}
+
+// Created writes the supplied value as JSON response with 201 status,
+// and the Location header of the created resource, if the location is not empty.
+func Created(w http.ResponseWriter, r *http.Request, location string, v interface{}) {
+	if location != "" {
+		w.Header().Set(header.Location, location)
+	}
+	WriteResult(w, r, http.StatusCreated, v)
+}
+
+// NoContent writes the response with 204 status and without body
+func NoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Error writes the error response.
+// The httperror.Error and httperror.ManyError are written as is,
+// the juju/errors types are replied with the matching status,
+// such as 404 for errors.NotFound, and 400 for errors.NotValid,
+// all other errors are replied with 500 status.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	WriteJSON(w, r, toHTTPError(err))
+}
+
+// toHTTPError returns the error with the status matching the juju/errors type
+func toHTTPError(err error) error {
+	if _, ok := errors.Cause(err).(WriteHTTPResponse); ok {
+		return errors.Cause(err)
+	}
+
+	if e := httperror.FromErrorKind(err); e != nil {
+		return e
+	}
+	return err
+}
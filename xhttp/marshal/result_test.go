@@ -0,0 +1,69 @@
+package marshal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WriteResult(t *testing.T) {
+	r, err := http.NewRequest(http.MethodPost, "/v1/items", nil)
+	require.NoError(t, err)
+
+	v := &AStruct{A: "a", B: "b"}
+
+	w := httptest.NewRecorder()
+	WriteResult(w, r, http.StatusAccepted, v)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, header.ApplicationJSON, w.Header().Get(header.ContentType))
+	assert.Equal(t, `{"A":"a","B":"b"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	Created(w, r, "/v1/items/1", v)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/v1/items/1", w.Header().Get(header.Location))
+	assert.Equal(t, `{"A":"a","B":"b"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	NoContent(w)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = httptest.NewRecorder()
+	WriteResult(w, r, http.StatusOK, httperror.WithConflict("exists"))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, `{"code":"conflict","message":"exists"}`, w.Body.String())
+}
+
+func Test_Error(t *testing.T) {
+	r, err := http.NewRequest(http.MethodGet, "/v1/items/1", nil)
+	require.NoError(t, err)
+
+	tcases := []struct {
+		err    error
+		status int
+		exp    string
+	}{
+		{errors.NotFoundf("item %q", "1"), http.StatusNotFound, `{"code":"not_found","message":"item \"1\" not found"}`},
+		{errors.Annotate(errors.NotValidf("id"), "request"), http.StatusBadRequest, `{"code":"invalid_request","message":"request: id not valid"}`},
+		{errors.AlreadyExistsf("item"), http.StatusConflict, `{"code":"conflict","message":"item already exists"}`},
+		{errors.Unauthorizedf("token"), http.StatusUnauthorized, `{"code":"unauthorized","message":"token"}`},
+		{errors.Forbiddenf("item"), http.StatusForbidden, `{"code":"forbidden","message":"item"}`},
+		{errors.NotSupportedf("export"), http.StatusNotImplemented, `{"code":"not_implemented","message":"export not supported"}`},
+		{errors.Trace(httperror.WithServerBusy("busy")), http.StatusServiceUnavailable, `{"code":"server_busy","message":"busy"}`},
+		{errors.New("failed"), http.StatusInternalServerError, `{"code":"unexpected","message":"failed"}`},
+	}
+
+	for _, tc := range tcases {
+		w := httptest.NewRecorder()
+		Error(w, r, tc.err)
+		assert.Equal(t, tc.status, w.Code, tc.err.Error())
+		assert.Equal(t, tc.exp, w.Body.String(), tc.err.Error())
+	}
+}
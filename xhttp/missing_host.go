@@ -0,0 +1,86 @@
+package xhttp
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+var keyForHTTPReqHostMissing = []string{"http", "request", "host", "missing"}
+
+// MissingHostPolicy specifies how the requests without Host header are handled.
+// The HTTP/1.1 requests without Host are rejected by net/http,
+// the policy applies to the legacy HTTP/1.0 clients, that may not send Host.
+type MissingHostPolicy int
+
+const (
+	// MissingHostAllow passes the requests as is, with empty Host
+	MissingHostAllow MissingHostPolicy = iota
+	// MissingHostReject rejects the requests with 400 Bad Request
+	MissingHostReject
+	// MissingHostDefault assigns the default host to the requests
+	MissingHostDefault
+)
+
+// MissingHost is a http.Handler that applies the policy to the requests without Host header,
+// before the delegate handler is called.
+//
+// It must be installed before the handlers that depend on the host,
+// such as HTTPSRedirect, that builds the redirect URL from the Host.
+// TrailingSlash redirects to the relative URL, and SecurityHeaders
+// do not depend on the host, so both are not affected by the missing Host.
+type MissingHost struct {
+	delegate http.Handler
+	policy   MissingHostPolicy
+	host     string
+	auditor  Auditor
+}
+
+// NewMissingHost returns a handler that applies the policy to the requests without Host header,
+// the host is assigned to the requests with MissingHostDefault policy
+func NewMissingHost(delegate http.Handler, policy MissingHostPolicy, host string) *MissingHost {
+	return &MissingHost{
+		delegate: delegate,
+		policy:   policy,
+		host:     host,
+	}
+}
+
+// WithAuditor sets the auditor to record the rejected requests,
+// use audit.Sampler to not flood the audit log under the load
+func (h *MissingHost) WithAuditor(auditor Auditor) *MissingHost {
+	h.auditor = auditor
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *MissingHost) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Host != "" || h.policy == MissingHostAllow {
+		h.delegate.ServeHTTP(w, r)
+		return
+	}
+
+	metrics.IncrCounter(keyForHTTPReqHostMissing, 1,
+		metrics.Tag{Name: tags.Method, Value: r.Method},
+	)
+
+	if h.policy == MissingHostDefault && h.host != "" {
+		logger.Debugf("api=MissingHost, reason=default_host, method=%s, path=%s, proto=%s, host=%s",
+			r.Method, r.URL.Path, r.Proto, h.host)
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.Host = h.host
+		h.delegate.ServeHTTP(w, r2)
+		return
+	}
+
+	logger.Warningf("api=MissingHost, reason=missing_host, method=%s, path=%s, proto=%s",
+		r.Method, r.URL.Path, r.Proto)
+	if h.auditor != nil {
+		auditRejected(h.auditor, r, "missing_host")
+	}
+	marshal.WriteJSON(w, r, httperror.WithInvalidRequest("missing required Host header"))
+}
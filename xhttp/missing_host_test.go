@@ -0,0 +1,58 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MissingHost(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("host=" + r.Host))
+	})
+
+	newRequest := func(host string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/v1/test", nil)
+		r.Proto, r.ProtoMajor, r.ProtoMinor = "HTTP/1.0", 1, 0
+		r.Host = host
+		return r
+	}
+
+	audit := auditor.NewInMemory()
+	tcases := []struct {
+		name   string
+		policy MissingHostPolicy
+		host   string
+		status int
+		exp    string
+	}{
+		{"allow", MissingHostAllow, "", http.StatusOK, "host="},
+		{"default", MissingHostDefault, "api.example.com", http.StatusOK, "host=api.example.com"},
+		{"default_empty", MissingHostDefault, "", http.StatusBadRequest, `{"code":"invalid_request","message":"missing required Host header"}`},
+		{"reject", MissingHostReject, "", http.StatusBadRequest, `{"code":"invalid_request","message":"missing required Host header"}`},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewMissingHost(echo, tc.policy, tc.host).WithAuditor(audit)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, newRequest(""))
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.exp, w.Body.String())
+
+			// the requests with Host are not changed
+			w = httptest.NewRecorder()
+			h.ServeHTTP(w, newRequest("localhost"))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "host=localhost", w.Body.String())
+		})
+	}
+
+	evt := audit.Find(EvtSourceThrottle, EvtRejected)
+	require.NotNil(t, evt)
+	assert.Contains(t, evt.Message, "reason=missing_host")
+}
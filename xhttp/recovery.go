@@ -0,0 +1,129 @@
+package xhttp
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+const (
+	// EvtSourceRecovery specifies source for the recovered panics of the request handlers
+	EvtSourceRecovery = "recovery"
+	// EvtPanic specifies the event of the recovered panic
+	EvtPanic = "panic"
+)
+
+var keyForHTTPReqPanic = []string{"http", "request", "panic"}
+
+// Recovery is a http.Handler that recovers from the panic of the delegate handler,
+// and replies with 500 Internal Server Error with the correlation ID of the request.
+// If the response was already started, then the connection is aborted
+// with http.ErrAbortHandler, as the response can not be replaced.
+//
+// Recovery must be installed inside identity.NewContextHandler,
+// so the response and the audit event are tagged with the correlation ID.
+type Recovery struct {
+	delegate http.Handler
+	auditor  Auditor
+}
+
+// NewRecovery returns a handler that recovers from the panics of the delegate
+func NewRecovery(delegate http.Handler) *Recovery {
+	return &Recovery{
+		delegate: delegate,
+	}
+}
+
+// WithAuditor sets the auditor to record the recovered panics
+func (h *Recovery) WithAuditor(auditor Auditor) *Recovery {
+	h.auditor = auditor
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *Recovery) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rw := &recoveryWriter{ResponseWriter: w}
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+		if p == http.ErrAbortHandler {
+			// the handler aborts the response on purpose
+			panic(p)
+		}
+
+		ctx := identity.ForRequest(r)
+		metrics.IncrCounter(keyForHTTPReqPanic, 1,
+			metrics.Tag{Name: tags.Method, Value: r.Method},
+		)
+		logger.Errorf("api=Recovery, reason=panic, method=%s, path=%s, ctx=%q, err=[%v], stack=[%s]",
+			r.Method, r.URL.Path, ctx.CorrelationID(), p, debug.Stack())
+		if h.auditor != nil {
+			h.audit(ctx, r, p)
+		}
+
+		if rw.wroteHeader {
+			panic(http.ErrAbortHandler)
+		}
+		marshal.WriteJSON(w, r, httperror.WithUnexpected("the request failed, correlation ID: %s", ctx.CorrelationID()))
+	}()
+	h.delegate.ServeHTTP(rw, r)
+}
+
+// audit records the recovered panic
+func (h *Recovery) audit(ctx *identity.RequestContext, r *http.Request, p interface{}) {
+	var id string
+	if ctx.Identity() != nil {
+		id = ctx.Identity().String()
+	}
+	msg := fmt.Sprintf("method=%s, path=%s, ip=%s, err=[%v]", r.Method, r.URL.Path, ctx.ClientIP(), p)
+	if tenant := ctx.Tenant(); tenant != "" {
+		msg += ", tenant=" + tenant
+	}
+	h.auditor.Audit(
+		EvtSourceRecovery,
+		EvtPanic,
+		id,
+		ctx.CorrelationID(),
+		0,
+		msg,
+	)
+}
+
+// recoveryWriter tracks if the response was started
+type recoveryWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+// Write the supplied data to the response
+func (w *recoveryWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteHeader sets the HTTP status code of the response
+func (w *recoveryWriter) WriteHeader(sc int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(sc)
+}
+
+// Flush sends any buffered data to the client
+func (w *recoveryWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer
+func (w *recoveryWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
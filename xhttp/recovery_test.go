@@ -0,0 +1,63 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-phorce/dolly/testify/auditor"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Recovery(t *testing.T) {
+	audit := auditor.NewInMemory()
+	h := identity.NewContextHandler(NewRecovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/panic":
+			panic("handler failed")
+		case "/started":
+			w.WriteHeader(http.StatusOK)
+			panic("handler failed after response")
+		case "/abort":
+			panic(http.ErrAbortHandler)
+		}
+		w.Write([]byte("ok"))
+	})).WithAuditor(audit))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		req.Header.Set(header.XCorrelationID, "recovery-1234")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/ok")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+	assert.Equal(t, 0, audit.Len())
+
+	w = serve("/panic")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "recovery-1234", w.Header().Get(header.XCorrelationID))
+	assert.Contains(t, w.Body.String(), "correlation ID: recovery-1234")
+
+	evt := audit.Find(EvtSourceRecovery, EvtPanic)
+	require.NotNil(t, evt)
+	assert.Equal(t, "recovery-1234", evt.ContextID)
+	assert.Contains(t, evt.Message, "method=GET, path=/panic")
+	assert.Contains(t, evt.Message, "err=[handler failed]")
+
+	// the started response can not be replaced
+	audit.Reset()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { serve("/started") })
+	assert.NotNil(t, audit.Find(EvtSourceRecovery, EvtPanic))
+
+	audit.Reset()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { serve("/abort") })
+	assert.Equal(t, 0, audit.Len(), "the aborted response is not audited")
+}
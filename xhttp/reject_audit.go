@@ -0,0 +1,48 @@
+package xhttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-phorce/dolly/xhttp/identity"
+)
+
+const (
+	// EvtSourceThrottle specifies source for the requests rejected by the traffic shaping
+	EvtSourceThrottle = "throttle"
+	// EvtRejected specifies the event of the rejected request
+	EvtRejected = "rejected"
+)
+
+// Auditor is an interface to record the rejected requests
+type Auditor interface {
+	// Audit records an auditable event.
+	Audit(
+		source string,
+		eventType string,
+		identity string,
+		contextID string,
+		raftIndex uint64,
+		message string)
+}
+
+// auditRejected records the rejected request with the reason
+func auditRejected(auditor Auditor, r *http.Request, reason string) {
+	ctx := identity.ForRequest(r)
+	var id string
+	if ctx.Identity() != nil {
+		id = ctx.Identity().String()
+	}
+	msg := fmt.Sprintf("reason=%s, method=%s, path=%s, ip=%s", reason, r.Method, r.URL.Path, ctx.ClientIP())
+	if tenant := ctx.Tenant(); tenant != "" {
+		msg += ", tenant=" + tenant
+	}
+	auditor.Audit(
+		EvtSourceThrottle,
+		EvtRejected,
+		id,
+		ctx.CorrelationID(),
+		0,
+		msg,
+	)
+}
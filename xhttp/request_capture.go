@@ -0,0 +1,245 @@
+package xhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-phorce/dolly/algorithms/guid"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/juju/errors"
+)
+
+// DefaultCaptureMaxBodySize specifies the default max size of the captured body
+const DefaultCaptureMaxBodySize = 64 * 1024
+
+// CapturedExchange provides the captured request and response
+type CapturedExchange struct {
+	CorrelationID     string        `json:"correlation_id"`
+	Time              time.Time     `json:"time"`
+	Duration          time.Duration `json:"duration"`
+	Method            string        `json:"method"`
+	Host              string        `json:"host"`
+	URI               string        `json:"uri"`
+	RequestHeader     http.Header   `json:"request_header"`
+	RequestBody       []byte        `json:"request_body,omitempty"`
+	RequestTruncated  bool          `json:"request_truncated,omitempty"`
+	StatusCode        int           `json:"status_code"`
+	ResponseHeader    http.Header   `json:"response_header"`
+	ResponseBody      []byte        `json:"response_body,omitempty"`
+	ResponseTruncated bool          `json:"response_truncated,omitempty"`
+}
+
+// RequestCapture is a http.Handler that captures the sampled requests and responses,
+// including headers and size-capped bodies, to the files for offline replay.
+// The file for each exchange is named by the correlation ID, such as capture_<id>.json
+type RequestCapture struct {
+	delegate    http.Handler
+	dir         string
+	rate        uint64
+	trigger     string
+	maxBodySize int
+	redacted    map[string]bool
+
+	count uint64
+}
+
+// NewRequestCapture returns a handler that captures the requests with the trigger header,
+// see WithSampleRate to capture the sampled requests.
+// If dir is "" then a temp dir is created to contain the captured exchanges,
+// otherwise the indicated directory is used.
+// The values of DefaultRedactedHeaders are redacted.
+func NewRequestCapture(delegate http.Handler, dir string) (*RequestCapture, error) {
+	var err error
+	if dir == "" {
+		dir, err = ioutil.TempDir("", "request_capture")
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	} else {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	c := &RequestCapture{
+		delegate:    delegate,
+		dir:         dir,
+		trigger:     header.XCaptureRequest,
+		maxBodySize: DefaultCaptureMaxBodySize,
+		redacted:    map[string]bool{},
+	}
+	return c.WithRedacted(DefaultRedactedHeaders...), nil
+}
+
+// WithSampleRate specifies to capture 1 in rate requests,
+// zero value captures only the requests with the trigger header
+func (c *RequestCapture) WithSampleRate(rate int) *RequestCapture {
+	c.rate = 0
+	if rate > 0 {
+		c.rate = uint64(rate)
+	}
+	return c
+}
+
+// WithTrigger specifies the header that triggers the capture of the request,
+// regardless of the sample rate, by default X-Capture-Request
+func (c *RequestCapture) WithTrigger(name string) *RequestCapture {
+	c.trigger = name
+	return c
+}
+
+// WithMaxBodySize specifies the max size of the captured request and response bodies,
+// the larger bodies are truncated
+func (c *RequestCapture) WithMaxBodySize(size int) *RequestCapture {
+	c.maxBodySize = size
+	return c
+}
+
+// WithRedacted adds the headers, which values must be redacted
+func (c *RequestCapture) WithRedacted(headers ...string) *RequestCapture {
+	for _, h := range headers {
+		c.redacted[http.CanonicalHeaderKey(h)] = true
+	}
+	return c
+}
+
+// Dir returns the directory of the captured exchanges
+func (c *RequestCapture) Dir() string {
+	return c.dir
+}
+
+// ServeHTTP implements the http.Handler interface
+func (c *RequestCapture) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !c.sampled(r) {
+		c.delegate.ServeHTTP(w, r)
+		return
+	}
+
+	started := time.Now().UTC()
+	ex := &CapturedExchange{
+		Time:          started,
+		Method:        r.Method,
+		Host:          r.Host,
+		URI:           r.URL.RequestURI(),
+		RequestHeader: c.redact(r.Header),
+	}
+
+	var reqBody *capturedBody
+	if r.Body != nil && r.Body != http.NoBody {
+		reqBody = &capturedBody{max: c.maxBodySize}
+		r.Body = &captureReader{ReadCloser: r.Body, body: reqBody}
+	}
+	rc := &captureWriter{
+		ResponseCapture: NewResponseCapture(w),
+		body:            &capturedBody{max: c.maxBodySize},
+	}
+
+	c.delegate.ServeHTTP(rc, r)
+
+	ex.Duration = time.Since(started)
+	ex.StatusCode = rc.StatusCode()
+	ex.ResponseHeader = c.redact(rc.Header())
+	ex.ResponseBody = rc.body.Bytes()
+	ex.ResponseTruncated = rc.body.truncated
+	if reqBody != nil {
+		ex.RequestBody = reqBody.Bytes()
+		ex.RequestTruncated = reqBody.truncated
+	}
+
+	// the correlation ID is set on the response by identity.NewContextHandler
+	ex.CorrelationID = rc.Header().Get(header.XCorrelationID)
+	if ex.CorrelationID == "" {
+		ex.CorrelationID = r.Header.Get(header.XCorrelationID)
+	}
+	if ex.CorrelationID == "" {
+		ex.CorrelationID = guid.MustCreate()
+	}
+
+	if err := c.save(ex); err != nil {
+		logger.Errorf("api=RequestCapture, reason=save, correlation=%s, err=[%v]", ex.CorrelationID, err)
+	}
+}
+
+// sampled returns true if the request must be captured
+func (c *RequestCapture) sampled(r *http.Request) bool {
+	if c.trigger != "" && r.Header.Get(c.trigger) != "" {
+		return true
+	}
+	return c.rate > 0 && atomic.AddUint64(&c.count, 1)%c.rate == 1%c.rate
+}
+
+// redact returns the copy of the headers with the redacted values
+func (c *RequestCapture) redact(hdrs http.Header) http.Header {
+	res := hdrs.Clone()
+	for name := range res {
+		if c.redacted[name] {
+			res[name] = []string{RedactedValue}
+		}
+	}
+	return res
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// save writes the exchange to the file named by the correlation ID
+func (c *RequestCapture) save(ex *CapturedExchange) error {
+	js, err := json.MarshalIndent(ex, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	file := filepath.Join(c.dir, "capture_"+unsafeFileChars.ReplaceAllString(ex.CorrelationID, "_")+".json")
+	if err = ioutil.WriteFile(file, js, 0600); err != nil {
+		return errors.Trace(err)
+	}
+	logger.Infof("api=RequestCapture, status=captured, method=%s, path=%s, location=%s", ex.Method, ex.URI, file)
+	return nil
+}
+
+// capturedBody keeps up to max bytes of the body
+type capturedBody struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *capturedBody) capture(data []byte) {
+	if n := b.max - b.Len(); n < len(data) {
+		b.truncated = true
+		if n <= 0 {
+			return
+		}
+		data = data[:n]
+	}
+	b.Write(data)
+}
+
+// captureReader captures the request body as it's read by the handler
+type captureReader struct {
+	io.ReadCloser
+	body *capturedBody
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.body.capture(p[:n])
+	return n, err
+}
+
+// captureWriter captures the response body in addition to the status code
+type captureWriter struct {
+	*ResponseCapture
+	body *capturedBody
+}
+
+// Write the supplied data to the response and the captured body
+func (w *captureWriter) Write(data []byte) (int, error) {
+	w.body.capture(data)
+	return w.ResponseCapture.Write(data)
+}
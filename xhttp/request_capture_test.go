@@ -0,0 +1,92 @@
+package xhttp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RequestCapture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capture")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set(header.SetCookie, "session=123")
+		w.Header().Set(header.XCorrelationID, r.Header.Get(header.XCorrelationID))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("echo:"))
+		w.Write(body)
+	}
+
+	capture, err := NewRequestCapture(http.HandlerFunc(h), filepath.Join(dir, "sub"))
+	require.NoError(t, err)
+	capture.WithMaxBodySize(8).WithRedacted("X-Custom-Secret")
+	assert.Equal(t, filepath.Join(dir, "sub"), capture.Dir())
+
+	serve := func(corID, body string, hdrs ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/v1/items?q=1", strings.NewReader(body))
+		r.Header.Set(header.XCorrelationID, corID)
+		r.Header.Set(header.Authorization, "Bearer secret")
+		r.Header.Set("X-Custom-Secret", "custom")
+		for i := 0; i+1 < len(hdrs); i += 2 {
+			r.Header.Set(hdrs[i], hdrs[i+1])
+		}
+		w := httptest.NewRecorder()
+		capture.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("not sampled", func(t *testing.T) {
+		w := serve("notsampled", "{}")
+		assert.Equal(t, "echo:{}", w.Body.String())
+		_, err := os.Stat(filepath.Join(capture.Dir(), "capture_notsampled.json"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("trigger", func(t *testing.T) {
+		w := serve("corr/1", "{\"id\":1234}", header.XCaptureRequest, "1")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		// the response is not affected by the size cap
+		assert.Equal(t, "echo:{\"id\":1234}", w.Body.String())
+
+		ex, err := LoadCapturedExchange(filepath.Join(capture.Dir(), "capture_corr_1.json"))
+		require.NoError(t, err)
+		assert.Equal(t, "corr/1", ex.CorrelationID)
+		assert.Equal(t, http.MethodPost, ex.Method)
+		assert.Equal(t, "/v1/items?q=1", ex.URI)
+		assert.Equal(t, RedactedValue, ex.RequestHeader.Get(header.Authorization))
+		assert.Equal(t, RedactedValue, ex.RequestHeader.Get("X-Custom-Secret"))
+		assert.Equal(t, "{\"id\":12", string(ex.RequestBody))
+		assert.True(t, ex.RequestTruncated)
+		assert.Equal(t, http.StatusCreated, ex.StatusCode)
+		assert.Equal(t, RedactedValue, ex.ResponseHeader.Get(header.SetCookie))
+		assert.Equal(t, "echo:{\"i", string(ex.ResponseBody))
+		assert.True(t, ex.ResponseTruncated)
+	})
+
+	t.Run("sampled", func(t *testing.T) {
+		capture.WithSampleRate(2).WithTrigger("")
+		serve("sampled1", "{}")
+		serve("sampled2", "{}")
+		serve("sampled3", "{}")
+
+		ex, err := LoadCapturedExchange(filepath.Join(capture.Dir(), "capture_sampled1.json"))
+		require.NoError(t, err)
+		assert.Equal(t, "{}", string(ex.RequestBody))
+		assert.False(t, ex.RequestTruncated)
+		_, err = os.Stat(filepath.Join(capture.Dir(), "capture_sampled2.json"))
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(filepath.Join(capture.Dir(), "capture_sampled3.json"))
+		assert.NoError(t, err)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/identity"
 	"github.com/go-phorce/dolly/xlog"
 )
 
@@ -37,7 +38,17 @@ func NewRequestLogger(handler http.Handler, prefix string, additionalEntries Add
 	if packageLogger != "" {
 		l = xlog.NewPackageLogger(packageLogger, "xhttp")
 	}
+	return NewRequestLoggerWithLogger(handler, prefix, additionalEntries, granularity, l)
+}
 
+// NewRequestLoggerWithLogger create a new RequestLogger handler, that writes the log lines to the supplied logger,
+// for example created by xlog.NewFormatterLogger, to write the access logs separately from the application logs.
+// The log lines are in the same format as NewRequestLogger.
+// If the logger is nil, then the requests are not logged.
+func NewRequestLoggerWithLogger(handler http.Handler, prefix string, additionalEntries AdditionalLogExtractor, granularity time.Duration, l xlog.Logger) http.Handler {
+	if handler == nil {
+		panic(errNoHandler)
+	}
 	if l == nil {
 		return handler
 	}
@@ -65,26 +76,23 @@ func (l *RequestLogger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			extra = ":" + strings.Join(fields, ":")
 		}
 	}
+	remoteAddr := identity.ClientAddr(r)
 	agent := r.Header.Get(header.UserAgent)
 	if agent == "" {
 		agent = "no-agent"
 	}
 	if rw.statusCode < 400 {
 		l.logger.Infof("%s:%s:%s:%s:%s:%d:%d.%d:%d:%v:%q%s",
-			l.prefix, clientCertUser, r.Method, r.URL.Path, r.RemoteAddr, rw.statusCode, r.ProtoMajor, r.ProtoMinor, rw.bodySize, dur.Nanoseconds()/l.granularity, agent, extra)
+			l.prefix, clientCertUser, r.Method, r.URL.Path, remoteAddr, rw.statusCode, r.ProtoMajor, r.ProtoMinor, rw.bodySize, dur.Nanoseconds()/l.granularity, agent, extra)
 	} else {
 		l.logger.Errorf("%s:%s:%s:%s:%s:%d:%d.%d:%d:%v:%q%s",
-			l.prefix, clientCertUser, r.Method, r.URL.Path, r.RemoteAddr, rw.statusCode, r.ProtoMajor, r.ProtoMinor, rw.bodySize, dur.Nanoseconds()/l.granularity, agent, extra)
+			l.prefix, clientCertUser, r.Method, r.URL.Path, remoteAddr, rw.statusCode, r.ProtoMajor, r.ProtoMinor, rw.bodySize, dur.Nanoseconds()/l.granularity, agent, extra)
 	}
 }
 
 func (l *RequestLogger) client(r *http.Request) string {
-	if r.TLS == nil {
-		return ""
-	}
-	pc := r.TLS.PeerCertificates
-	if len(pc) == 0 {
-		return ""
+	if cert := identity.PeerCertificate(r); cert != nil {
+		return cert.Subject.CommonName
 	}
-	return pc[0].Subject.CommonName
+	return ""
 }
@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-phorce/dolly/xhttp/marshal"
 	"github.com/go-phorce/dolly/xlog"
 )
 
@@ -48,6 +49,19 @@ func TestHttp_ResponseCapture(t *testing.T) {
 	assertRespEqual(t, w, http.StatusNotFound, "/foo not found/foo not found")
 }
 
+func TestHttp_ResponseCaptureWriteJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	rc := NewResponseCapture(w)
+	r, _ := http.NewRequest("POST", "/foo", nil)
+	// the status set by the handler is kept by WriteJSON
+	rc.WriteHeader(http.StatusCreated)
+	marshal.WriteJSON(rc, r, map[string]string{"id": "1"})
+	if rc.StatusCode() != http.StatusCreated {
+		t.Errorf("ResponseCapture should report the status set by the handler, got %d", rc.StatusCode())
+	}
+	assertRespEqual(t, w, http.StatusCreated, `{"id":"1"}`)
+}
+
 type testHandler struct {
 	t            *testing.T
 	statusCode   int
@@ -147,3 +161,27 @@ func TestHttp_RequestLoggerWithExtractor(t *testing.T) {
 		t.Errorf("Log Line should end with our custom extracted values, but was '%v'", logLine)
 	}
 }
+
+func TestHttp_RequestLoggerWithLogger(t *testing.T) {
+	handler := &testHandler{t, http.StatusOK, []byte(`Hello World`)}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/foo", nil)
+
+	app := bytes.Buffer{}
+	xlog.SetFormatter(xlog.NewStringFormatter(&app))
+	access := bytes.Buffer{}
+	l := xlog.NewFormatterLogger("access", xlog.NewStringFormatter(&access))
+
+	lg := NewRequestLoggerWithLogger(handler, "ASD", nil, time.Millisecond, l)
+	lg.ServeHTTP(w, r)
+	if !strings.Contains(access.String(), "ASD::GET:/foo:") {
+		t.Errorf("The request should be logged to the access logger, but was '%v'", access.String())
+	}
+	if app.Len() != 0 {
+		t.Errorf("The request should not be logged to the application logs, but was '%v'", app.String())
+	}
+
+	if NewRequestLoggerWithLogger(handler, "ASD", nil, time.Millisecond, nil) != handler {
+		t.Errorf("Without the logger the handler should not be wrapped")
+	}
+}
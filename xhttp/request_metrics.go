@@ -1,8 +1,11 @@
 package xhttp
 
 import (
+	"io"
 	"net/http"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-phorce/dolly/metrics"
@@ -14,6 +17,15 @@ import (
 type requestMetrics struct {
 	handler       http.Handler
 	responseCodes []string
+
+	// inFlight specifies the number of in-flight requests by method and URI
+	inFlight map[routeKey]int32
+	lock     sync.Mutex
+}
+
+type routeKey struct {
+	method string
+	uri    string
 }
 
 // NewRequestMetrics creates a wrapper handler to produce metrics for each request
@@ -21,6 +33,7 @@ func NewRequestMetrics(h http.Handler) http.Handler {
 	rm := requestMetrics{
 		handler:       h,
 		responseCodes: make([]string, 599),
+		inFlight:      map[routeKey]int32{},
 	}
 	for idx := range rm.responseCodes {
 		rm.responseCodes[idx] = strconv.Itoa(idx)
@@ -37,13 +50,59 @@ func (rm *requestMetrics) statusCode(statusCode int) string {
 }
 
 var (
-	keyForHTTPReqPerf       = []string{"http", "request", "perf"}
-	keyForHTTPReqSuccessful = []string{"http", "request", "status", "successful"}
-	keyForHTTPReqFailed     = []string{"http", "request", "status", "failed"}
+	keyForHTTPReqPerf        = []string{"http", "request", "perf"}
+	keyForHTTPReqSuccessful  = []string{"http", "request", "status", "successful"}
+	keyForHTTPReqFailed      = []string{"http", "request", "status", "failed"}
+	keyForHTTPReqInFlightURI = []string{"http", "request", "inflight", "uri"}
+	keyForHTTPReqBytesIn     = []string{"http", "request", "bytes", "in"}
+	keyForHTTPRespBytesOut   = []string{"http", "response", "bytes", "out"}
 )
 
+// trackInFlight updates the number of in-flight requests for the route by delta,
+// and publishes the gauge
+func (rm *requestMetrics) trackInFlight(r *http.Request, delta int32) {
+	key := routeKey{method: r.Method, uri: r.URL.Path}
+
+	rm.lock.Lock()
+	current := rm.inFlight[key] + delta
+	if current > 0 {
+		rm.inFlight[key] = current
+	} else {
+		delete(rm.inFlight, key)
+	}
+	rm.lock.Unlock()
+
+	metrics.SetGauge(keyForHTTPReqInFlightURI, float32(current),
+		metrics.Tag{Name: tags.Method, Value: r.Method},
+		metrics.Tag{Name: tags.URI, Value: r.URL.Path},
+	)
+}
+
+// bodyCounter counts the bytes read from the request body
+type bodyCounter struct {
+	io.ReadCloser
+	n uint64
+}
+
+func (b *bodyCounter) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddUint64(&b.n, uint64(n))
+	return n, err
+}
+
 func (rm *requestMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	start := time.Now().UTC()
+
+	// the gauge is decremented, even if the handler panics
+	rm.trackInFlight(r, 1)
+	defer rm.trackInFlight(r, -1)
+
+	var body *bodyCounter
+	if r.Body != nil && r.Body != http.NoBody {
+		body = &bodyCounter{ReadCloser: r.Body}
+		r.Body = body
+	}
+
 	rc := NewResponseCapture(w)
 	rm.handler.ServeHTTP(rc, r)
 	role := identity.ForRequest(r).Identity().Role()
@@ -58,6 +117,11 @@ func (rm *requestMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	metrics.MeasureSince(keyForHTTPReqPerf, start, tags...)
 
+	if body != nil {
+		metrics.IncrCounter(keyForHTTPReqBytesIn, float32(atomic.LoadUint64(&body.n)), tags...)
+	}
+	metrics.IncrCounter(keyForHTTPRespBytesOut, float32(rc.BodySize()), tags...)
+
 	if sc >= 400 {
 		metrics.IncrCounter(keyForHTTPReqFailed, 1, tags...)
 	} else {
@@ -2,8 +2,10 @@ package xhttp
 
 import (
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -90,3 +92,58 @@ func Test_RequestMetrics(t *testing.T) {
 	assertCounter("test.http.request.status.failed;method=POST;role=dolly;status=400;uri=/", 1)
 	assertCounter("test.http.request.status.failed;method=POST;role=dolly;status=400;uri=/bar", 2)
 }
+
+func Test_RequestMetricsInFlight(t *testing.T) {
+	im := metrics.NewInmemSink(time.Minute, time.Minute*5)
+	_, err := metrics.NewGlobal(metrics.DefaultConfig("test"), im)
+	require.NoError(t, err)
+
+	gauge := func(suffix string) (float32, bool) {
+		for k, g := range im.Data()[0].Gauges {
+			if strings.HasSuffix(k, suffix) {
+				return g.Value, true
+			}
+		}
+		return 0, false
+	}
+
+	var inFlight float32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		inFlight, _ = gauge("http.request.inflight.uri;method=POST;uri=/v1/upload")
+		if r.URL.Path == "/v1/panic" {
+			panic("handler failed")
+		}
+		ioutil.ReadAll(r.Body)
+		io.WriteString(w, `"Helo World"`)
+	}
+	rm := NewRequestMetrics(http.HandlerFunc(h))
+
+	r, err := http.NewRequest(http.MethodPost, "/v1/upload", strings.NewReader("0123456789"))
+	require.NoError(t, err)
+	r = identity.WithTestIdentity(r, identity.NewIdentity("dolly", "10.0.0.1", ""))
+	rm.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, float32(1), inFlight)
+	current, ok := gauge("http.request.inflight.uri;method=POST;uri=/v1/upload")
+	require.True(t, ok)
+	assert.Equal(t, float32(0), current)
+
+	counters := im.Data()[0].Counters
+	in, ok := counters["test.http.request.bytes.in;method=POST;role=dolly;status=200;uri=/v1/upload"]
+	require.True(t, ok)
+	assert.Equal(t, float64(10), in.Sum)
+	out, ok := counters["test.http.response.bytes.out;method=POST;role=dolly;status=200;uri=/v1/upload"]
+	require.True(t, ok)
+	assert.Equal(t, float64(12), out.Sum)
+
+	// the gauge is decremented when the handler panics
+	r, err = http.NewRequest(http.MethodGet, "/v1/panic", nil)
+	require.NoError(t, err)
+	assert.Panics(t, func() {
+		rm.ServeHTTP(httptest.NewRecorder(), r)
+	})
+	current, ok = gauge("http.request.inflight.uri;method=GET;uri=/v1/panic")
+	require.True(t, ok)
+	assert.Equal(t, float32(0), current)
+	assert.Empty(t, rm.(*requestMetrics).inFlight)
+}
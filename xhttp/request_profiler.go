@@ -4,9 +4,13 @@ import (
 	"io/ioutil"
 	h "net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/juju/errors"
 )
@@ -72,7 +76,7 @@ func NewRequestProfiler(delegate h.Handler, dir string, allow AllowProfiling, cr
 			return nil, errors.Trace(err)
 		}
 	} else {
-		if err := os.MkdirAll(dir, 0600); err != nil {
+		if err := os.MkdirAll(dir, 0700); err != nil {
 			return nil, errors.Trace(err)
 		}
 	}
@@ -117,7 +121,7 @@ func (rp *requestProfiler) ServeHTTP(w h.ResponseWriter, r *h.Request) {
 			rp.profileLock.Lock()
 			if err = pprof.StartCPUProfile(cpuf); err != nil {
 				rp.profileLock.Unlock()
-				logger.Infof("api=ServeHTTP, status=unable_start, profile=cpu, err=%v", err)
+				logger.Errorf("api=ServeHTTP, status=unable_start, profile=cpu, err=%v", err)
 				cpuf.Close()
 				os.Remove(cpuf.Name())
 			} else {
@@ -133,12 +137,17 @@ func (rp *requestProfiler) ServeHTTP(w h.ResponseWriter, r *h.Request) {
 	if mem && rp.allow(ProfileMem, r) {
 		memf, err := ioutil.TempFile(rp.dir, "mem_")
 		if err != nil {
-			logger.Infof("api=ServeHTTP, status=unable_create_file, profile=memory, err=%v", err)
+			logger.Errorf("api=ServeHTTP, status=unable_create_file, profile=memory, err=%v", err)
 		} else {
 			defer func() {
 				runtime.GC()
-				pprof.WriteHeapProfile(memf)
+				err := pprof.WriteHeapProfile(memf)
 				memf.Close()
+				if err != nil {
+					logger.Errorf("api=ServeHTTP, status=unable_write, profile=memory, err=%v", err)
+					os.Remove(memf.Name())
+					return
+				}
 				rp.created(ProfileMem, r, memf.Name())
 			}()
 		}
@@ -146,3 +155,78 @@ func (rp *requestProfiler) ServeHTTP(w h.ResponseWriter, r *h.Request) {
 
 	rp.delegate.ServeHTTP(w, r)
 }
+
+// ProfileRetention specifies the retention of the profile files,
+// the zero values are not limited
+type ProfileRetention struct {
+	// MaxFiles specifies the maximum number of the profile files
+	MaxFiles int
+	// MaxSize specifies the maximum total size of the profile files in bytes
+	MaxSize int64
+	// MaxAge specifies the maximum age of the profile files
+	MaxAge time.Duration
+}
+
+// IsZero returns true if the retention is not limited
+func (p ProfileRetention) IsZero() bool {
+	return p.MaxFiles <= 0 && p.MaxSize <= 0 && p.MaxAge <= 0
+}
+
+// WithProfileRetention returns a ProfileCreated callback, that calls created,
+// and then removes the oldest profiles exceeding the retention
+// in the directory of the created profile
+func WithProfileRetention(created ProfileCreated, retention ProfileRetention) ProfileCreated {
+	return func(t ProfileType, r *h.Request, f string) {
+		if created != nil {
+			created(t, r, f)
+		}
+		if err := PruneProfiles(filepath.Dir(f), retention); err != nil {
+			logger.Errorf("api=WithProfileRetention, dir=%q, err=%v", filepath.Dir(f), err)
+		}
+	}
+}
+
+// PruneProfiles removes the oldest profile files in dir,
+// exceeding the retention by the age, the number or the total size.
+// Only the files created by the profiler are removed.
+func PruneProfiles(dir string, retention ProfileRetention) error {
+	if retention.IsZero() {
+		return nil
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	var profiles []os.FileInfo
+	for _, fi := range entries {
+		name := fi.Name()
+		if fi.Mode().IsRegular() && (strings.HasPrefix(name, "cpu_") || strings.HasPrefix(name, "mem_")) {
+			profiles = append(profiles, fi)
+		}
+	}
+	// the newest first
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].ModTime().After(profiles[j].ModTime())
+	})
+
+	now := time.Now()
+	var size int64
+	var failed error
+	for i, fi := range profiles {
+		size += fi.Size()
+		if (retention.MaxFiles > 0 && i >= retention.MaxFiles) ||
+			(retention.MaxSize > 0 && size > retention.MaxSize) ||
+			(retention.MaxAge > 0 && now.Sub(fi.ModTime()) > retention.MaxAge) {
+			name := filepath.Join(dir, fi.Name())
+			if err := os.Remove(name); err != nil {
+				logger.Errorf("api=PruneProfiles, status=unable_remove, file=%q, err=%v", name, err)
+				failed = errors.Trace(err)
+				continue
+			}
+			logger.Infof("api=PruneProfiles, status=removed, file=%q", name)
+		}
+	}
+	return failed
+}
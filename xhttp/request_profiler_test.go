@@ -9,9 +9,11 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-phorce/dolly/xlog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -86,6 +88,74 @@ func TestProfiler_CreateDir(t *testing.T) {
 	}
 }
 
+func TestProfiler_PruneProfiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prune_profiles")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	create := func(name string, size int, age time.Duration) {
+		f := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(f, make([]byte, size), 0600))
+		mod := time.Now().Add(-age)
+		require.NoError(t, os.Chtimes(f, mod, mod))
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	create("cpu_1", 10, time.Minute)
+	create("mem_2", 10, 2*time.Minute)
+	create("cpu_3", 10, 3*time.Minute)
+	create("mem_4", 10, 2*time.Hour)
+	create("capture_5", 10, 3*time.Hour)
+
+	require.NoError(t, PruneProfiles(dir, ProfileRetention{}))
+	assert.True(t, exists("mem_4"))
+
+	// by age
+	require.NoError(t, PruneProfiles(dir, ProfileRetention{MaxAge: time.Hour}))
+	assert.False(t, exists("mem_4"))
+	assert.True(t, exists("cpu_3"))
+	assert.True(t, exists("capture_5"), "other files must not be removed")
+
+	// by size
+	require.NoError(t, PruneProfiles(dir, ProfileRetention{MaxSize: 25}))
+	assert.False(t, exists("cpu_3"))
+	assert.True(t, exists("mem_2"))
+
+	// by count
+	require.NoError(t, PruneProfiles(dir, ProfileRetention{MaxFiles: 1}))
+	assert.False(t, exists("mem_2"))
+	assert.True(t, exists("cpu_1"))
+	assert.True(t, exists("capture_5"))
+
+	assert.Error(t, PruneProfiles(filepath.Join(dir, "missing"), ProfileRetention{MaxFiles: 1}))
+}
+
+func TestProfiler_WithProfileRetention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profile_retention")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	created := 0
+	handler := testHandler{t, http.StatusOK, []byte("OK")}
+	ph, err := NewRequestProfiler(&handler, dir, nil, WithProfileRetention(func(ProfileType, *http.Request, string) {
+		created++
+	}, ProfileRetention{MaxFiles: 2}))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/foo?profile.mem", nil)
+		ph.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, 3, created)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+}
+
 // consumeProfileType verifies that c is in the pt slice, and sets that index to 0
 func consumeProfileType(t *testing.T, c ProfileType, pt []ProfileType) {
 	for i, p := range pt {
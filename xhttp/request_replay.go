@@ -0,0 +1,76 @@
+package xhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// LoadCapturedExchange returns the exchange from the file created by RequestCapture
+func LoadCapturedExchange(file string) (*CapturedExchange, error) {
+	js, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ex := new(CapturedExchange)
+	if err = json.Unmarshal(js, ex); err != nil {
+		return nil, errors.Annotatef(err, "unable to decode %q", file)
+	}
+	return ex, nil
+}
+
+// Replay re-issues the captured request against the target URL, such as https://localhost:8443,
+// and returns the response, use CompareResponse to detect the regression.
+// The redacted headers are not sent, so the client must provide the credentials,
+// for example with the TLS client certificate.
+// If client is nil, then http.DefaultClient is used.
+func (e *CapturedExchange) Replay(ctx context.Context, client *http.Client, target string) (*http.Response, error) {
+	if e.RequestTruncated {
+		return nil, errors.NotValidf("truncated request body")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, e.Method, strings.TrimSuffix(target, "/")+e.URI, bytes.NewReader(e.RequestBody))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for name, values := range e.RequestHeader {
+		if len(values) == 1 && values[0] == RedactedValue {
+			continue
+		}
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	req.ContentLength = int64(len(e.RequestBody))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return resp, nil
+}
+
+// CompareResponse returns an error if the status code or the body of the response
+// does not match the captured response.
+// The body is not compared if the captured body was truncated.
+func (e *CapturedExchange) CompareResponse(resp *http.Response) error {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if resp.StatusCode != e.StatusCode {
+		return errors.Errorf("status code %d does not match captured %d", resp.StatusCode, e.StatusCode)
+	}
+	if !e.ResponseTruncated && !bytes.Equal(body, e.ResponseBody) {
+		return errors.Errorf("response body does not match captured, correlation=%s", e.CorrelationID)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package xhttp
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RequestReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	version := "v1"
+	h := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set(header.XCorrelationID, "replay1")
+		w.Write([]byte(version + ":" + r.URL.RequestURI() + ":" + r.Header.Get(header.Authorization) + ":" + string(body)))
+	}
+	capture, err := NewRequestCapture(http.HandlerFunc(h), dir)
+	require.NoError(t, err)
+	capture.WithSampleRate(1)
+
+	r := httptest.NewRequest(http.MethodPut, "/v1/items/1?q=1", strings.NewReader("{}"))
+	r.Header.Set(header.Authorization, "Bearer secret")
+	capture.ServeHTTP(httptest.NewRecorder(), r)
+
+	ex, err := LoadCapturedExchange(filepath.Join(dir, "capture_replay1.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1:/v1/items/1?q=1:Bearer secret:{}", string(ex.ResponseBody))
+
+	target := httptest.NewServer(http.HandlerFunc(h))
+	defer target.Close()
+
+	resp, err := ex.Replay(context.Background(), nil, target.URL+"/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	// the redacted credentials are not replayed
+	err = ex.CompareResponse(resp)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "response body does not match captured")
+
+	ex.ResponseBody = []byte("v1:/v1/items/1?q=1::{}")
+	resp, err = ex.Replay(context.Background(), target.Client(), target.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.NoError(t, ex.CompareResponse(resp))
+
+	ex.StatusCode = http.StatusCreated
+	resp, err = ex.Replay(context.Background(), nil, target.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.EqualError(t, ex.CompareResponse(resp), "status code 200 does not match captured 201")
+
+	ex.RequestTruncated = true
+	_, err = ex.Replay(context.Background(), nil, target.URL)
+	assert.True(t, errors.IsNotValid(err))
+
+	_, err = LoadCapturedExchange(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
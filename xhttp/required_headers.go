@@ -0,0 +1,57 @@
+package xhttp
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+var keyForHTTPReqInvalidHeader = []string{"http", "request", "header", "invalid"}
+
+// RequiredHeader specifies the header, that must be present in the request
+type RequiredHeader struct {
+	// Name specifies the header name, such as X-Idempotency-Key
+	Name string
+	// Format specifies the optional pattern of the header value,
+	// the requests with the value not matching the pattern are rejected
+	Format *regexp.Regexp
+}
+
+// NewRequiredHeaders returns a handler that rejects the requests,
+// missing one of the headers, or with the value not matching the header Format,
+// with 400 Bad Request, before the delegate handler is called.
+func NewRequiredHeaders(delegate http.Handler, headers ...RequiredHeader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, h := range headers {
+			reason := ""
+			value := r.Header.Get(h.Name)
+			if value == "" {
+				reason = "missing"
+			} else if h.Format != nil && !h.Format.MatchString(value) {
+				reason = "invalid"
+			}
+			if reason == "" {
+				continue
+			}
+
+			metrics.IncrCounter(keyForHTTPReqInvalidHeader, 1,
+				metrics.Tag{Name: tags.Method, Value: r.Method},
+				metrics.Tag{Name: "reason", Value: reason},
+			)
+			logger.Warningf("api=RequiredHeaders, reason=%s, method=%s, path=%s, header=%q",
+				reason, r.Method, r.URL.Path, h.Name)
+
+			if reason == "missing" {
+				marshal.WriteJSON(w, r, httperror.WithInvalidRequest("missing required header: %s", h.Name))
+			} else {
+				marshal.WriteJSON(w, r, httperror.WithInvalidRequest("invalid format of header: %s", h.Name))
+			}
+			return
+		}
+		delegate.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,45 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RequiredHeaders(t *testing.T) {
+	h := NewRequiredHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}),
+		RequiredHeader{Name: "X-Idempotency-Key", Format: regexp.MustCompile(`^[0-9a-f-]{36}$`)},
+		RequiredHeader{Name: "X-Tenant"},
+	)
+
+	tcases := []struct {
+		headers map[string]string
+		status  int
+		msg     string
+	}{
+		{map[string]string{"X-Idempotency-Key": "7b0e4c3a-3f1e-4a9b-9d8c-2f6e5a4b3c2d", "X-Tenant": "t1"}, http.StatusOK, ""},
+		{map[string]string{"X-Tenant": "t1"}, http.StatusBadRequest, "missing required header: X-Idempotency-Key"},
+		{map[string]string{"X-Idempotency-Key": "not-uuid", "X-Tenant": "t1"}, http.StatusBadRequest, "invalid format of header: X-Idempotency-Key"},
+		{map[string]string{"X-Idempotency-Key": "7b0e4c3a-3f1e-4a9b-9d8c-2f6e5a4b3c2d"}, http.StatusBadRequest, "missing required header: X-Tenant"},
+	}
+
+	for _, tc := range tcases {
+		req, err := http.NewRequest(http.MethodPost, "/v1/test", nil)
+		require.NoError(t, err)
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, tc.status, w.Code, "headers=%v", tc.headers)
+		if tc.msg != "" {
+			assert.Contains(t, w.Body.String(), tc.msg)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package xhttp
+
+import (
+	"container/list"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-phorce/dolly/clock"
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/identity"
+)
+
+var (
+	keyForHTTPCacheHit  = []string{"http", "cache", "hit"}
+	keyForHTTPCacheMiss = []string{"http", "cache", "miss"}
+)
+
+const (
+	// CacheHit is the value of X-Cache header of the response served from the cache
+	CacheHit = "HIT"
+	// CacheMiss is the value of X-Cache header of the response served by the handler
+	CacheMiss = "MISS"
+)
+
+const (
+	// DefaultResponseCacheSize specifies the default max number of the responses in MemoryCacheStore
+	DefaultResponseCacheSize = 1024
+	// DefaultResponseCacheMaxBodySize specifies the default max size of the cached response body
+	DefaultResponseCacheMaxBodySize = 1 << 20
+)
+
+// CachedResponse is the response stored in ResponseCacheStore
+type CachedResponse struct {
+	// Status specifies the status code of the response
+	Status int
+	// Header specifies the headers set by the handler
+	Header http.Header
+	// Body specifies the response body
+	Body []byte
+	// StoredAt specifies the time when the response was stored
+	StoredAt time.Time
+	// Expires specifies the time when the response expires
+	Expires time.Time
+}
+
+// ResponseCacheStore is the storage of the cached responses,
+// the implementation must be safe for concurrent use.
+// The expired responses are ignored by ResponseCache,
+// the store may remove them at any time.
+type ResponseCacheStore interface {
+	// Get returns the response stored with the key, or nil if not found
+	Get(key string) *CachedResponse
+	// Set stores the response with the key
+	Set(key string, resp *CachedResponse)
+}
+
+// ResponseCache is a http.Handler that caches the responses of GET requests for TTL,
+// the cached response is replied without calling the delegate handler.
+// The responses have X-Cache header with HIT or MISS value.
+//
+// The responses are cached by method, host, path and query, the values of the Vary headers,
+// and, if the cache is not shared, by the caller's identity and tenant.
+// Only 200 responses within the max body size are cached, and not cached if
+// the response sets a cookie, has Cache-Control with no-store, no-cache or private
+// for the shared cache, or varies by the header not specified for the cache.
+// Cache-Control max-age of the response limits the TTL.
+//
+// The requests with Cache-Control no-cache or max-age=0 are served by the delegate,
+// and update the cache, no-store requests bypass the cache.
+type ResponseCache struct {
+	delegate    http.Handler
+	store       ResponseCacheStore
+	ttl         time.Duration
+	vary        []string
+	shared      bool
+	maxBodySize int
+	clock       clock.Clock
+}
+
+// NewResponseCache returns a handler that caches the responses of the delegate in the store for TTL,
+// varying by Accept and Accept-Encoding headers
+func NewResponseCache(delegate http.Handler, store ResponseCacheStore, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{
+		delegate:    delegate,
+		store:       store,
+		ttl:         ttl,
+		vary:        []string{header.Accept, header.AcceptEncoding},
+		maxBodySize: DefaultResponseCacheMaxBodySize,
+		clock:       clock.New(),
+	}
+}
+
+// WithVary adds the request headers, that select the cached response
+func (c *ResponseCache) WithVary(headers ...string) *ResponseCache {
+	for _, h := range headers {
+		c.vary = append(c.vary, http.CanonicalHeaderKey(h))
+	}
+	return c
+}
+
+// WithShared specifies to share the cached responses between the callers,
+// use it only if the response does not depend on the caller's identity and tenant
+func (c *ResponseCache) WithShared(shared bool) *ResponseCache {
+	c.shared = shared
+	return c
+}
+
+// WithMaxBodySize sets the max size of the response body to cache
+func (c *ResponseCache) WithMaxBodySize(size int) *ResponseCache {
+	c.maxBodySize = size
+	return c
+}
+
+// WithClock allows to specify the clock
+func (c *ResponseCache) WithClock(clock clock.Clock) *ResponseCache {
+	c.clock = clock
+	return c
+}
+
+// ServeHTTP implements http.Handler
+func (c *ResponseCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		c.delegate.ServeHTTP(w, r)
+		return
+	}
+	reqCC := parseCacheControl(r.Header.Get(header.CacheControl))
+	if reqCC.noStore {
+		c.delegate.ServeHTTP(w, r)
+		return
+	}
+
+	key := c.key(r)
+	now := c.clock.Now()
+	if !reqCC.noCache && reqCC.maxAge != 0 {
+		if resp := c.store.Get(key); resp != nil && now.Before(resp.Expires) {
+			metrics.IncrCounter(keyForHTTPCacheHit, 1,
+				metrics.Tag{Name: tags.Method, Value: r.Method},
+			)
+			h := w.Header()
+			for k, v := range resp.Header {
+				h[k] = append([]string(nil), v...)
+			}
+			h.Set(header.XCache, CacheHit)
+			h.Set(header.Age, strconv.Itoa(int(now.Sub(resp.StoredAt).Seconds())))
+			w.WriteHeader(resp.Status)
+			w.Write(resp.Body)
+			return
+		}
+	}
+
+	metrics.IncrCounter(keyForHTTPCacheMiss, 1,
+		metrics.Tag{Name: tags.Method, Value: r.Method},
+	)
+	// the headers set before the handler, such as X-Correlation-ID, are not cached
+	before := headerKeys(w.Header())
+	w.Header().Set(header.XCache, CacheMiss)
+
+	rec := &flightRecorder{
+		ResponseWriter: w,
+		status:         http.StatusOK,
+		max:            c.maxBodySize,
+	}
+	c.delegate.ServeHTTP(rec, r)
+	if rec.header == nil {
+		rec.header = w.Header().Clone()
+	}
+
+	ttl := c.responseTTL(rec)
+	if ttl <= 0 || r.Context().Err() != nil {
+		return
+	}
+	h := http.Header{}
+	for k, v := range rec.header {
+		if !before[k] && k != header.XCache {
+			h[k] = v
+		}
+	}
+	c.store.Set(key, &CachedResponse{
+		Status:   rec.status,
+		Header:   h,
+		Body:     rec.body.Bytes(),
+		StoredAt: now,
+		Expires:  now.Add(ttl),
+	})
+}
+
+// key returns the key of the cached response
+func (c *ResponseCache) key(r *http.Request) string {
+	parts := []string{r.Method, r.Host, r.URL.RequestURI()}
+	for _, h := range c.vary {
+		parts = append(parts, r.Header.Get(h))
+	}
+	if !c.shared {
+		ctx := identity.ForRequest(r)
+		var id string
+		if ctx.Identity() != nil {
+			id = ctx.Identity().String()
+		}
+		parts = append(parts, id, ctx.Tenant())
+	}
+	return strings.Join(parts, "\n")
+}
+
+// responseTTL returns the time to cache the response,
+// or zero if the response must not be cached
+func (c *ResponseCache) responseTTL(rec *flightRecorder) time.Duration {
+	if rec.overflow || rec.status != http.StatusOK || rec.header.Get(header.SetCookie) != "" {
+		return 0
+	}
+
+	cc := parseCacheControl(rec.header.Get(header.CacheControl))
+	if cc.noStore || cc.noCache || (cc.private && c.shared) {
+		return 0
+	}
+
+	for _, values := range rec.header.Values(header.Vary) {
+		for _, v := range strings.Split(values, ",") {
+			if !c.varies(strings.TrimSpace(v)) {
+				return 0
+			}
+		}
+	}
+
+	ttl := c.ttl
+	if cc.maxAge >= 0 && cc.maxAge < ttl {
+		ttl = cc.maxAge
+	}
+	return ttl
+}
+
+// varies returns true if the cached responses vary by the header
+func (c *ResponseCache) varies(name string) bool {
+	if name == "" {
+		return true
+	}
+	name = http.CanonicalHeaderKey(name)
+	for _, h := range c.vary {
+		if h == name {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheControl contains the directives of Cache-Control header
+type cacheControl struct {
+	noCache bool
+	noStore bool
+	private bool
+	// maxAge is negative if not specified
+	maxAge time.Duration
+}
+
+// parseCacheControl returns the directives of Cache-Control header
+func parseCacheControl(value string) cacheControl {
+	cc := cacheControl{maxAge: -1}
+	for _, d := range strings.Split(value, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		switch {
+		case d == "no-cache":
+			cc.noCache = true
+		case d == "no-store":
+			cc.noStore = true
+		case d == "private":
+			cc.private = true
+		case strings.HasPrefix(d, "max-age="):
+			if secs, err := strconv.Atoi(strings.Trim(d[len("max-age="):], `"`)); err == nil && secs >= 0 {
+				cc.maxAge = time.Duration(secs) * time.Second
+			}
+		}
+	}
+	return cc
+}
+
+// MemoryCacheStore is the in-memory ResponseCacheStore,
+// that keeps the max number of the responses, and evicts the least recently used
+type MemoryCacheStore struct {
+	size  int
+	lock  sync.Mutex
+	list  *list.List
+	items map[string]*list.Element
+}
+
+// memoryCacheEntry is the element of the LRU list
+type memoryCacheEntry struct {
+	key  string
+	resp *CachedResponse
+}
+
+// NewMemoryCacheStore returns the in-memory store of the max size of the responses,
+// or DefaultResponseCacheSize, if the size is not positive
+func NewMemoryCacheStore(size int) *MemoryCacheStore {
+	if size <= 0 {
+		size = DefaultResponseCacheSize
+	}
+	return &MemoryCacheStore{
+		size:  size,
+		list:  list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+// Get returns the response stored with the key, or nil if not found
+func (s *MemoryCacheStore) Get(key string) *CachedResponse {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e, ok := s.items[key]
+	if !ok {
+		return nil
+	}
+	s.list.MoveToFront(e)
+	return e.Value.(*memoryCacheEntry).resp
+}
+
+// Set stores the response with the key,
+// and evicts the least recently used response over the size
+func (s *MemoryCacheStore) Set(key string, resp *CachedResponse) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if e, ok := s.items[key]; ok {
+		e.Value.(*memoryCacheEntry).resp = resp
+		s.list.MoveToFront(e)
+		return
+	}
+	s.items[key] = s.list.PushFront(&memoryCacheEntry{key: key, resp: resp})
+	for s.list.Len() > s.size {
+		e := s.list.Back()
+		s.list.Remove(e)
+		delete(s.items, e.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Len returns the number of the stored responses
+func (s *MemoryCacheStore) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.list.Len()
+}
@@ -0,0 +1,133 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/clock"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ResponseCache(t *testing.T) {
+	var calls int32
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/nostore":
+			w.Header().Set(header.CacheControl, "no-store")
+		case "/maxage":
+			w.Header().Set(header.CacheControl, "max-age=2")
+		case "/cookie":
+			w.Header().Set(header.SetCookie, "session=1")
+		case "/vary":
+			w.Header().Set(header.Vary, "Authorization")
+		case "/failed":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Header().Set(header.ContentType, header.TextPlain)
+		w.Write([]byte(strconv.Itoa(int(n))))
+	})
+
+	mock := clock.NewMock(time.Date(2020, time.May, 1, 10, 0, 0, 0, time.UTC))
+	store := NewMemoryCacheStore(0)
+	c := NewResponseCache(h, store, 10*time.Second).WithClock(mock)
+
+	serve := func(method, path string, headers ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			r.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		w.Header().Set(header.XCorrelationID, "corr-"+path)
+		c.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("hit", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		w := serve(http.MethodGet, "/items?page=1")
+		assert.Equal(t, CacheMiss, w.Header().Get(header.XCache))
+		assert.Equal(t, "1", w.Body.String())
+
+		mock.Add(3 * time.Second)
+		w = serve(http.MethodGet, "/items?page=1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, CacheHit, w.Header().Get(header.XCache))
+		assert.Equal(t, "3", w.Header().Get(header.Age))
+		assert.Equal(t, header.TextPlain, w.Header().Get(header.ContentType))
+		assert.Equal(t, "corr-/items?page=1", w.Header().Get(header.XCorrelationID))
+		assert.Equal(t, "1", w.Body.String())
+
+		// the query and Vary headers select the response
+		assert.Equal(t, "2", serve(http.MethodGet, "/items?page=2").Body.String())
+		assert.Equal(t, "3", serve(http.MethodGet, "/items?page=1", header.Accept, "text/plain").Body.String())
+		assert.Equal(t, "4", serve(http.MethodPost, "/items?page=1").Body.String())
+
+		// expired
+		mock.Add(8 * time.Second)
+		assert.Equal(t, "5", serve(http.MethodGet, "/items?page=1").Body.String())
+		assert.Equal(t, "5", serve(http.MethodGet, "/items?page=1").Body.String())
+	})
+
+	t.Run("request", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		assert.Equal(t, "1", serve(http.MethodGet, "/request").Body.String())
+		// no-cache refreshes the cache
+		assert.Equal(t, "2", serve(http.MethodGet, "/request", header.CacheControl, "no-cache").Body.String())
+		assert.Equal(t, "2", serve(http.MethodGet, "/request").Body.String())
+		assert.Equal(t, "3", serve(http.MethodGet, "/request", header.CacheControl, "max-age=0").Body.String())
+		// no-store bypasses the cache
+		w := serve(http.MethodGet, "/request", header.CacheControl, "no-store")
+		assert.Equal(t, "4", w.Body.String())
+		assert.Empty(t, w.Header().Get(header.XCache))
+		assert.Equal(t, "3", serve(http.MethodGet, "/request").Body.String())
+	})
+
+	t.Run("response", func(t *testing.T) {
+		for _, path := range []string{"/nostore", "/cookie", "/vary", "/failed"} {
+			atomic.StoreInt32(&calls, 0)
+			assert.Equal(t, "1", serve(http.MethodGet, path).Body.String(), path)
+			assert.Equal(t, "2", serve(http.MethodGet, path).Body.String(), path)
+		}
+
+		// max-age limits TTL
+		atomic.StoreInt32(&calls, 0)
+		assert.Equal(t, "1", serve(http.MethodGet, "/maxage").Body.String())
+		assert.Equal(t, "1", serve(http.MethodGet, "/maxage").Body.String())
+		mock.Add(2 * time.Second)
+		assert.Equal(t, "2", serve(http.MethodGet, "/maxage").Body.String())
+	})
+
+	t.Run("identity", func(t *testing.T) {
+		resp := store.Get(c.key(httptest.NewRequest(http.MethodGet, "/items?page=1", nil)))
+		require.NotNil(t, resp)
+		assert.Empty(t, resp.Header.Get(header.XCorrelationID), "the headers set before the handler are not cached")
+		assert.Empty(t, resp.Header.Get(header.XCache))
+
+		c.WithShared(true)
+		defer c.WithShared(false)
+		assert.Nil(t, store.Get(c.key(httptest.NewRequest(http.MethodGet, "/items?page=1", nil))),
+			"the shared responses are not keyed by identity")
+	})
+}
+
+func Test_MemoryCacheStore(t *testing.T) {
+	s := NewMemoryCacheStore(2)
+	s.Set("1", &CachedResponse{Status: 1})
+	s.Set("2", &CachedResponse{Status: 2})
+	require.NotNil(t, s.Get("1"))
+	s.Set("3", &CachedResponse{Status: 3})
+	assert.Equal(t, 2, s.Len())
+	assert.Nil(t, s.Get("2"), "the least recently used must be evicted")
+	assert.Equal(t, 1, s.Get("1").Status)
+
+	s.Set("1", &CachedResponse{Status: 11})
+	assert.Equal(t, 11, s.Get("1").Status)
+	assert.Equal(t, 2, s.Len())
+}
@@ -54,3 +54,8 @@ func (r *ResponseCapture) Flush() {
 		flusher.Flush()
 	}
 }
+
+// Unwrap returns the underlying writer
+func (r *ResponseCapture) Unwrap() http.ResponseWriter {
+	return r.delegate
+}
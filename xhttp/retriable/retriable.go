@@ -535,9 +535,15 @@ func (c *Client) Do(r *http.Request) (*http.Response, error) {
 			c.consumeResponseBody(resp)
 		}
 
+		if d := retryAfter(resp); d > 0 {
+			sleepDuration = d
+		}
+
 		logger.Warningf("api=Do, name=%s, retries=%d, description=%q, reason=%q, sleep=[%v]",
 			c.Name, retries, desc, reason, sleepDuration.Seconds())
-		time.Sleep(sleepDuration)
+		if err = sleep(req.Request.Context(), sleepDuration); err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
 
 	debugRequest(req.Request, err != nil)
@@ -0,0 +1,251 @@
+package retriable
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/juju/errors"
+)
+
+// DefaultMaxBodySize specifies the default max size of the request body,
+// that is buffered to be resent on the retries
+const DefaultMaxBodySize = 1 << 20
+
+// idempotentMethods specifies the methods safe to retry by default
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// NewBackoff returns ShouldRetry with the exponential backoff and full jitter,
+// the wait before the retry is a random duration up to base * 2^retries,
+// capped by max.
+func NewBackoff(limit int, base, max time.Duration, reason string) ShouldRetry {
+	return func(r *http.Request, resp *http.Response, err error, retries int) (bool, time.Duration, string) {
+		if retries >= limit {
+			return false, 0, LimitExceeded
+		}
+		wait := max
+		if retries < 32 {
+			if d := base << uint(retries); d > 0 && d < max {
+				wait = d
+			}
+		}
+		return true, time.Duration(rand.Int63n(int64(wait) + 1)), reason
+	}
+}
+
+// RoundTripper is a http.RoundTripper that retries the idempotent requests,
+// according to the retriable policy.
+type RoundTripper struct {
+	delegate    http.RoundTripper
+	policy      *Policy
+	maxTime     time.Duration
+	methods     map[string]bool
+	maxBodySize int64
+}
+
+// NewRoundTripper returns a RoundTripper with the policy,
+// if delegate is nil, then http.DefaultTransport is used.
+// The policy specifies the retried status codes, where 0 code
+// indicates a connection error, and the limit of retries.
+func NewRoundTripper(delegate http.RoundTripper, policy *Policy) *RoundTripper {
+	if delegate == nil {
+		delegate = http.DefaultTransport
+	}
+	return &RoundTripper{
+		delegate:    delegate,
+		policy:      policy,
+		methods:     idempotentMethods,
+		maxBodySize: DefaultMaxBodySize,
+	}
+}
+
+// WithIdempotentMethods specifies the methods safe to retry,
+// by default GET, HEAD, OPTIONS, TRACE, PUT and DELETE are retried
+func (t *RoundTripper) WithIdempotentMethods(methods ...string) *RoundTripper {
+	t.methods = map[string]bool{}
+	for _, m := range methods {
+		t.methods[m] = true
+	}
+	return t
+}
+
+// WithMaxBodySize specifies the max size of the request body,
+// that is buffered to be resent on the retries.
+// The larger body is streamed, and the request is not retried.
+// If the size is 0, then only the requests with GetBody are retried.
+func (t *RoundTripper) WithMaxBodySize(size int64) *RoundTripper {
+	t.maxBodySize = size
+	return t
+}
+
+// WithMaxTime limits the total time of the request with retries,
+// the retry is not attempted if it would start after the limit
+func (t *RoundTripper) WithMaxTime(d time.Duration) *RoundTripper {
+	t.maxTime = d
+	return t
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+// The request is retried only if the method is idempotent,
+// and the body can be rewound with GetBody, or buffered up to the max body size,
+// the wait honors the Retry-After header and the request context.
+// The correlation ID from the request context is propagated
+// in X-Correlation-ID header, if not set in the request.
+func (t *RoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx := r.Context()
+	if r.Header.Get(header.XCorrelationID) == "" {
+		if rctx := identity.FromContext(ctx); rctx != nil && rctx.CorrelationID() != "" {
+			r = r.Clone(ctx)
+			r.Header.Set(header.XCorrelationID, rctx.CorrelationID())
+		}
+	}
+
+	retriable := t.methods[r.Method]
+	if retriable {
+		var err error
+		if r, retriable, err = t.bufferBody(r); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	started := time.Now()
+
+	req := r
+	for retries := 0; ; retries++ {
+		resp, err := t.delegate.RoundTrip(req)
+		if !retriable {
+			return resp, err
+		}
+
+		shouldRetry, wait, reason := t.policy.ShouldRetry(req, resp, err, retries)
+		if !shouldRetry {
+			return resp, err
+		}
+		if d := retryAfter(resp); d > 0 {
+			wait = d
+		}
+		if t.maxTime > 0 && time.Since(started)+wait > t.maxTime {
+			logger.Warningf("api=RoundTrip, reason=max_time, method=%s, url=%q, retries=%d",
+				r.Method, r.URL, retries)
+			return resp, err
+		}
+
+		if resp != nil {
+			drainBody(resp)
+		}
+
+		logger.Warningf("api=RoundTrip, method=%s, url=%q, retries=%d, reason=%q, sleep=[%v]",
+			r.Method, r.URL, retries, reason, wait.Seconds())
+
+		if err := sleep(ctx, wait); err != nil {
+			return nil, errors.Trace(err)
+		}
+
+		if r.GetBody != nil {
+			body, err := r.GetBody()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			req = r.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// bufferBody reads the request body up to the max body size,
+// and returns the request with GetBody to resend the body on the retries.
+// If the body is larger, then the request is returned with the streamed body,
+// and false to indicate that the request can not be retried.
+func (t *RoundTripper) bufferBody(r *http.Request) (*http.Request, bool, error) {
+	if r.Body == nil || r.Body == http.NoBody || r.GetBody != nil {
+		return r, true, nil
+	}
+	if t.maxBodySize <= 0 || r.ContentLength > t.maxBodySize {
+		return r, false, nil
+	}
+
+	buf, err := ioutil.ReadAll(io.LimitReader(r.Body, t.maxBodySize+1))
+	if err != nil {
+		r.Body.Close()
+		return nil, false, errors.Trace(err)
+	}
+
+	req := r.Clone(r.Context())
+	if int64(len(buf)) > t.maxBodySize {
+		logger.Debugf("api=RoundTrip, reason=body_too_large, method=%s, url=%q, max=%d",
+			r.Method, r.URL, t.maxBodySize)
+		req.Body = &readCloser{
+			Reader: io.MultiReader(bytes.NewReader(buf), r.Body),
+			Closer: r.Body,
+		}
+		return req, false, nil
+	}
+
+	r.Body.Close()
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(buf)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return req, true, nil
+}
+
+// readCloser reads the buffered part of the body before the rest,
+// and closes the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// retryAfter returns the duration from Retry-After header,
+// in delay-seconds or HTTP-date format
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	val := resp.Header.Get(header.RetryAfter)
+	if val == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(val); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(val); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// sleep waits for the duration, or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// drainBody reads the remaining body to reuse the connection, and closes it
+func drainBody(resp *http.Response) {
+	if resp.Body != nil {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,168 @@
+package retriable_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"github.com/go-phorce/dolly/xhttp/retriable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewBackoff(t *testing.T) {
+	fn := retriable.NewBackoff(3, 100*time.Millisecond, 250*time.Millisecond, "backoff")
+	for i, max := range []time.Duration{100, 200, 250} {
+		ok, wait, reason := fn(nil, nil, nil, i)
+		assert.True(t, ok)
+		assert.Equal(t, "backoff", reason)
+		assert.True(t, wait <= max*time.Millisecond, "retry %d: %v", i, wait)
+	}
+	ok, _, reason := fn(nil, nil, nil, 3)
+	assert.False(t, ok)
+	assert.Equal(t, retriable.LimitExceeded, reason)
+}
+
+func Test_RoundTripper(t *testing.T) {
+	var count int32
+	var body, correlationID string
+	h := func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		correlationID = r.Header.Get(header.XCorrelationID)
+		switch r.URL.Path {
+		case "/busy":
+			if atomic.AddInt32(&count, 1) < 3 {
+				w.Header().Set(header.RetryAfter, "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/limited":
+			atomic.AddInt32(&count, 1)
+			w.Header().Set(header.RetryAfter, "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	server := httptest.NewServer(http.HandlerFunc(h))
+	defer server.Close()
+
+	policy := &retriable.Policy{
+		Retries: map[int]retriable.ShouldRetry{
+			http.StatusServiceUnavailable: retriable.NewBackoff(5, time.Millisecond, 10*time.Millisecond, "unavailable"),
+			http.StatusTooManyRequests:    retriable.NewBackoff(5, time.Millisecond, 10*time.Millisecond, "rate-limit"),
+		},
+		TotalRetryLimit: 5,
+	}
+	client := &http.Client{
+		Transport: retriable.NewRoundTripper(nil, policy).WithMaxTime(500 * time.Millisecond),
+	}
+
+	t.Run("idempotent", func(t *testing.T) {
+		atomic.StoreInt32(&count, 0)
+		r, err := http.NewRequest(http.MethodPut, server.URL+"/busy", strings.NewReader("payload"))
+		require.NoError(t, err)
+		r = identity.WithTestIdentity(r, identity.NewIdentity("guest", "test", ""))
+		r.Header.Del(header.XCorrelationID)
+
+		resp, err := client.Do(r)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+		assert.Equal(t, "payload", body)
+		assert.Equal(t, identity.FromContext(r.Context()).CorrelationID(), correlationID)
+		assert.NotEmpty(t, correlationID)
+	})
+
+	t.Run("not idempotent", func(t *testing.T) {
+		atomic.StoreInt32(&count, 0)
+		resp, err := client.Post(server.URL+"/busy", "text/plain", strings.NewReader("payload"))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	})
+
+	t.Run("buffered body", func(t *testing.T) {
+		atomic.StoreInt32(&count, 0)
+		r, err := http.NewRequest(http.MethodDelete, server.URL+"/busy", ioutil.NopCloser(strings.NewReader("payload")))
+		require.NoError(t, err)
+		require.Nil(t, r.GetBody)
+
+		resp, err := client.Do(r)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+		assert.Equal(t, "payload", body)
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		atomic.StoreInt32(&count, 0)
+		rt := retriable.NewRoundTripper(nil, policy).WithMaxBodySize(4)
+		r, err := http.NewRequest(http.MethodPut, server.URL+"/busy", ioutil.NopCloser(strings.NewReader("payload")))
+		require.NoError(t, err)
+
+		resp, err := rt.RoundTrip(r)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+		assert.Equal(t, "payload", body)
+	})
+
+	t.Run("custom methods", func(t *testing.T) {
+		rt := retriable.NewRoundTripper(nil, policy).WithIdempotentMethods(http.MethodPost)
+
+		atomic.StoreInt32(&count, 0)
+		r, err := http.NewRequest(http.MethodPost, server.URL+"/busy", strings.NewReader("payload"))
+		require.NoError(t, err)
+		resp, err := rt.RoundTrip(r)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+
+		atomic.StoreInt32(&count, 0)
+		r, err = http.NewRequest(http.MethodGet, server.URL+"/busy", nil)
+		require.NoError(t, err)
+		resp, err = rt.RoundTrip(r)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	})
+
+	t.Run("max time", func(t *testing.T) {
+		atomic.StoreInt32(&count, 0)
+		// Retry-After of 1 second exceeds the max time
+		resp, err := client.Get(server.URL + "/limited")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		atomic.StoreInt32(&count, 0)
+		rt := retriable.NewRoundTripper(nil, policy)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		r, err := http.NewRequest(http.MethodGet, server.URL+"/limited", nil)
+		require.NoError(t, err)
+
+		_, err = rt.RoundTrip(r.WithContext(ctx))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), context.DeadlineExceeded.Error())
+		assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	})
+}
@@ -0,0 +1,148 @@
+package xhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+var (
+	keyForHTTPReqSchemaInvalid  = []string{"http", "request", "schema", "invalid"}
+	keyForHTTPRespSchemaInvalid = []string{"http", "response", "schema", "invalid"}
+)
+
+// Schema is a compiled JSON Schema, for example compiled from OpenAPI specification,
+// the implementation can be an adapter to any JSON Schema library
+type Schema interface {
+	// Validate returns an error if the decoded JSON value does not match the schema
+	Validate(v interface{}) error
+}
+
+// SchemaErrors is an optional interface of the error returned by Schema.Validate,
+// that provides the validation errors by the location in the document, such as /name
+type SchemaErrors interface {
+	// SchemaErrors returns the validation errors by the location
+	SchemaErrors() map[string]string
+}
+
+// SchemaValidator is a http.Handler that validates the request body against JSON Schema,
+// before the delegate handler is called
+type SchemaValidator struct {
+	delegate http.Handler
+	request  Schema
+	response Schema
+}
+
+// NewSchemaValidator returns a handler that rejects the requests,
+// which body does not match the request schema, with 400 Bad Request
+// and the validation errors.
+// The requests with GET, HEAD, OPTIONS methods, and the requests without body are not validated.
+func NewSchemaValidator(delegate http.Handler, request Schema) *SchemaValidator {
+	return &SchemaValidator{
+		delegate: delegate,
+		request:  request,
+	}
+}
+
+// WithResponseSchema enables the validation of the successful JSON responses,
+// the mismatches are logged, but the responses are not changed.
+// The response validation buffers the response body, so it's intended for debugging.
+func (v *SchemaValidator) WithResponseSchema(response Schema) *SchemaValidator {
+	v.response = response
+	return v
+}
+
+// ServeHTTP implements http.Handler
+func (v *SchemaValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if v.request != nil && hasBody(r) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			if httperror.IsRequestBodyTooLarge(err) {
+				marshal.WriteJSON(w, r, httperror.WithRequestEntityTooLarge("the request body is too large"))
+				return
+			}
+			marshal.WriteJSON(w, r, httperror.WithFailedToReadRequestBody("unable to read the request body: %s", err.Error()))
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		var doc interface{}
+		if err = json.Unmarshal(body, &doc); err != nil {
+			marshal.WriteJSON(w, r, httperror.WithInvalidJSON("unable to decode the request body: %s", err.Error()))
+			return
+		}
+		if err = v.request.Validate(doc); err != nil {
+			metrics.IncrCounter(keyForHTTPReqSchemaInvalid, 1,
+				metrics.Tag{Name: tags.Method, Value: r.Method},
+			)
+			logger.Warningf("api=SchemaValidator, reason=invalid_request, method=%s, path=%s, err=[%v]",
+				r.Method, r.URL.Path, err)
+
+			marshal.WriteJSON(w, r, schemaError(err))
+			return
+		}
+	}
+
+	if v.response == nil {
+		v.delegate.ServeHTTP(w, r)
+		return
+	}
+
+	rc := &responseBodyCapture{ResponseCapture: NewResponseCapture(w)}
+	v.delegate.ServeHTTP(rc, r)
+	v.validateResponse(rc, r)
+}
+
+// validateResponse logs the mismatch of the successful JSON response
+func (v *SchemaValidator) validateResponse(rc *responseBodyCapture, r *http.Request) {
+	if rc.StatusCode() < 200 || rc.StatusCode() >= 300 || rc.body.Len() == 0 ||
+		!header.MatchContentType(rc.Header().Get(header.ContentType), header.ApplicationJSON) {
+		return
+	}
+
+	var doc interface{}
+	err := json.Unmarshal(rc.body.Bytes(), &doc)
+	if err == nil {
+		err = v.response.Validate(doc)
+	}
+	if err != nil {
+		metrics.IncrCounter(keyForHTTPRespSchemaInvalid, 1,
+			metrics.Tag{Name: tags.Method, Value: r.Method},
+		)
+		logger.Warningf("api=SchemaValidator, reason=invalid_response, method=%s, path=%s, status=%d, err=[%v]",
+			r.Method, r.URL.Path, rc.StatusCode(), err)
+	}
+}
+
+// schemaError returns 400 error with the validation errors
+func schemaError(err error) error {
+	se, ok := err.(SchemaErrors)
+	if !ok {
+		return httperror.WithInvalidRequest("the request does not match the schema: %s", err.Error())
+	}
+
+	res := httperror.NewMany(http.StatusBadRequest, httperror.InvalidRequest, "the request does not match the schema")
+	for loc, msg := range se.SchemaErrors() {
+		res.Add(loc, httperror.WithInvalidRequest("%s", msg))
+	}
+	return res
+}
+
+// responseBodyCapture captures the response body in addition to the status code
+type responseBodyCapture struct {
+	*ResponseCapture
+	body bytes.Buffer
+}
+
+// Write the supplied data to the response and the captured body
+func (r *responseBodyCapture) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseCapture.Write(data)
+}
@@ -0,0 +1,83 @@
+package xhttp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requiredFields is a schema that requires the object fields
+type requiredFields []string
+
+type fieldErrors map[string]string
+
+func (e fieldErrors) Error() string                   { return fmt.Sprintf("%d errors", len(e)) }
+func (e fieldErrors) SchemaErrors() map[string]string { return e }
+
+func (s requiredFields) Validate(v interface{}) error {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected object")
+	}
+	errs := fieldErrors{}
+	for _, f := range s {
+		if _, ok := obj[f]; !ok {
+			errs["/"+f] = "required"
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func Test_SchemaValidator(t *testing.T) {
+	h := NewSchemaValidator(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.Write(body)
+	}), requiredFields{"name"})
+
+	tcases := []struct {
+		method string
+		body   string
+		status int
+		exp    string
+	}{
+		{http.MethodPost, `{"name":"test"}`, http.StatusOK, `{"name":"test"}`},
+		{http.MethodPost, `{"id":1}`, http.StatusBadRequest, `{"code":"invalid_request","errors":{"/name":{"code":"invalid_request","message":"required"}},"message":"the request does not match the schema"}`},
+		{http.MethodPost, `[]`, http.StatusBadRequest, `{"code":"invalid_request","message":"the request does not match the schema: expected object"}`},
+		{http.MethodPost, `{`, http.StatusBadRequest, `{"code":"invalid_json","message":"unable to decode the request body: unexpected end of JSON input"}`},
+		{http.MethodGet, `{}`, http.StatusOK, `{}`},
+	}
+
+	for _, tc := range tcases {
+		req, err := http.NewRequest(tc.method, "/v1/test", strings.NewReader(tc.body))
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, tc.status, w.Code, "%s %s", tc.method, tc.body)
+		assert.Equal(t, tc.exp, w.Body.String(), "%s %s", tc.method, tc.body)
+	}
+}
+
+func Test_SchemaValidatorResponse(t *testing.T) {
+	h := NewSchemaValidator(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1}`))
+	}), nil).WithResponseSchema(requiredFields{"name"})
+
+	req, err := http.NewRequest(http.MethodPost, "/v1/test", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	// the response is not changed
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"id":1}`, w.Body.String())
+}
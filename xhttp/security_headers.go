@@ -0,0 +1,112 @@
+package xhttp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+)
+
+const (
+	// DefaultHSTSMaxAge specifies the default max-age of Strict-Transport-Security header
+	DefaultHSTSMaxAge = 365 * 24 * time.Hour
+	// DefaultContentTypeOptions specifies the default value of X-Content-Type-Options header
+	DefaultContentTypeOptions = "nosniff"
+	// DefaultFrameOptions specifies the default value of X-Frame-Options header
+	DefaultFrameOptions = "DENY"
+	// DefaultContentSecurityPolicy specifies the default value of Content-Security-Policy header,
+	// that disallows any content to be loaded or framed, as the API responses are not rendered
+	DefaultContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+	// DefaultReferrerPolicy specifies the default value of Referrer-Policy header
+	DefaultReferrerPolicy = "no-referrer"
+)
+
+// SecurityHeadersConfig specifies the security headers of the responses.
+// The empty value of a header disables it.
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge specifies max-age of Strict-Transport-Security header,
+	// if not positive, then the header is not set.
+	// The header is set only on TLS connections.
+	HSTSMaxAge time.Duration
+	// HSTSIncludeSubDomains specifies to add includeSubDomains directive
+	HSTSIncludeSubDomains bool
+	// HSTSPreload specifies to add preload directive,
+	// it should be set only after the domain is submitted to the preload list
+	HSTSPreload bool
+	// ContentTypeOptions specifies the value of X-Content-Type-Options header
+	ContentTypeOptions string
+	// FrameOptions specifies the value of X-Frame-Options header
+	FrameOptions string
+	// ContentSecurityPolicy specifies the value of Content-Security-Policy header
+	ContentSecurityPolicy string
+	// ReferrerPolicy specifies the value of Referrer-Policy header
+	ReferrerPolicy string
+}
+
+// DefaultSecurityHeadersConfig returns the configuration with the conservative defaults:
+// HSTS for one year, without includeSubDomains and preload,
+// as those affect other hosts of the domain,
+// nosniff, DENY framing, the CSP that disallows any content, and no-referrer.
+func DefaultSecurityHeadersConfig() *SecurityHeadersConfig {
+	return &SecurityHeadersConfig{
+		HSTSMaxAge:            DefaultHSTSMaxAge,
+		ContentTypeOptions:    DefaultContentTypeOptions,
+		FrameOptions:          DefaultFrameOptions,
+		ContentSecurityPolicy: DefaultContentSecurityPolicy,
+		ReferrerPolicy:        DefaultReferrerPolicy,
+	}
+}
+
+// SecurityHeaders is a http.Handler that sets the security headers of the responses
+type SecurityHeaders struct {
+	delegate http.Handler
+	hsts     string
+	headers  map[string]string
+}
+
+// NewSecurityHeaders returns a handler that sets the security headers,
+// before the delegate handler is called.
+// The delegate can override or remove any header for its responses.
+func NewSecurityHeaders(delegate http.Handler, cfg SecurityHeadersConfig) *SecurityHeaders {
+	s := &SecurityHeaders{
+		delegate: delegate,
+		headers:  map[string]string{},
+	}
+
+	if cfg.HSTSMaxAge > 0 {
+		s.hsts = "max-age=" + strconv.FormatInt(int64(cfg.HSTSMaxAge/time.Second), 10)
+		if cfg.HSTSIncludeSubDomains {
+			s.hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			s.hsts += "; preload"
+		}
+	}
+
+	for name, value := range map[string]string{
+		header.XContentTypeOptions:   cfg.ContentTypeOptions,
+		header.XFrameOptions:         cfg.FrameOptions,
+		header.ContentSecurityPolicy: cfg.ContentSecurityPolicy,
+		header.ReferrerPolicy:        cfg.ReferrerPolicy,
+	} {
+		if value != "" {
+			s.headers[name] = value
+		}
+	}
+	return s
+}
+
+// ServeHTTP implements the http.Handler interface
+func (s *SecurityHeaders) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	for name, value := range s.headers {
+		h.Set(name, value)
+	}
+	// browsers ignore HSTS over plain HTTP,
+	// and the plaintext response can be altered anyway
+	if s.hsts != "" && r.TLS != nil {
+		h.Set(header.StrictTransportSecurity, s.hsts)
+	}
+	s.delegate.ServeHTTP(w, r)
+}
@@ -0,0 +1,95 @@
+package xhttp
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SecurityHeaders(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}
+	override := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(header.XFrameOptions, "SAMEORIGIN")
+		w.Header().Del(header.ContentSecurityPolicy)
+		w.Write([]byte("OK"))
+	}
+
+	tcases := []struct {
+		name     string
+		handler  http.HandlerFunc
+		cfg      SecurityHeadersConfig
+		tls      bool
+		expected map[string]string
+	}{
+		{
+			name:    "default",
+			handler: ok,
+			cfg:     *DefaultSecurityHeadersConfig(),
+			tls:     true,
+			expected: map[string]string{
+				header.StrictTransportSecurity: "max-age=31536000",
+				header.XContentTypeOptions:     "nosniff",
+				header.XFrameOptions:           "DENY",
+				header.ContentSecurityPolicy:   "default-src 'none'; frame-ancestors 'none'",
+				header.ReferrerPolicy:          "no-referrer",
+			},
+		},
+		{
+			name:    "plaintext",
+			handler: ok,
+			cfg:     *DefaultSecurityHeadersConfig(),
+			expected: map[string]string{
+				header.StrictTransportSecurity: "",
+				header.XContentTypeOptions:     "nosniff",
+			},
+		},
+		{
+			name:    "hsts",
+			handler: ok,
+			cfg: SecurityHeadersConfig{
+				HSTSMaxAge:            time.Hour,
+				HSTSIncludeSubDomains: true,
+				HSTSPreload:           true,
+			},
+			tls: true,
+			expected: map[string]string{
+				header.StrictTransportSecurity: "max-age=3600; includeSubDomains; preload",
+				header.XContentTypeOptions:     "",
+				header.XFrameOptions:           "",
+				header.ContentSecurityPolicy:   "",
+				header.ReferrerPolicy:          "",
+			},
+		},
+		{
+			name:    "override",
+			handler: override,
+			cfg:     *DefaultSecurityHeadersConfig(),
+			expected: map[string]string{
+				header.XFrameOptions:         "SAMEORIGIN",
+				header.ContentSecurityPolicy: "",
+				header.XContentTypeOptions:   "nosniff",
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			NewSecurityHeaders(tc.handler, tc.cfg).ServeHTTP(w, r)
+			for name, value := range tc.expected {
+				assert.Equal(t, value, w.Header().Get(name), name)
+			}
+		})
+	}
+}
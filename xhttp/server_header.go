@@ -0,0 +1,70 @@
+package xhttp
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+)
+
+// ServerHeader is a http.Handler that controls the Server response header
+type ServerHeader struct {
+	delegate http.Handler
+	value    string
+}
+
+// NewServerHeader returns a handler that sets the Server response header to the value,
+// if value is empty, then the Server header is removed from the responses,
+// including the header set by the delegate, such as a reverse proxy.
+func NewServerHeader(delegate http.Handler, value string) *ServerHeader {
+	return &ServerHeader{
+		delegate: delegate,
+		value:    value,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (s *ServerHeader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sw := &serverHeaderWriter{ResponseWriter: w, value: s.value}
+	s.delegate.ServeHTTP(sw, r)
+	// the delegate may not write the response at all
+	sw.apply()
+}
+
+// serverHeaderWriter applies the Server header before the response headers are sent
+type serverHeaderWriter struct {
+	http.ResponseWriter
+	value       string
+	wroteHeader bool
+}
+
+func (w *serverHeaderWriter) apply() {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if w.value != "" {
+		w.Header().Set(header.Server, w.value)
+	} else {
+		w.Header().Del(header.Server)
+	}
+}
+
+// WriteHeader sets the HTTP status code of the response
+func (w *serverHeaderWriter) WriteHeader(sc int) {
+	w.apply()
+	w.ResponseWriter.WriteHeader(sc)
+}
+
+// Write the supplied data to the response
+func (w *serverHeaderWriter) Write(data []byte) (int, error) {
+	w.apply()
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush sends any buffered data to the client.
+func (w *serverHeaderWriter) Flush() {
+	w.apply()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,40 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ServerHeader(t *testing.T) {
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(header.Server, "nginx/1.19.0")
+		w.Write([]byte("OK"))
+	}
+	blank := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+	empty := func(w http.ResponseWriter, r *http.Request) {}
+
+	tcases := []struct {
+		handler  http.HandlerFunc
+		value    string
+		expected []string
+	}{
+		{upstream, "dolly", []string{"dolly"}},
+		{upstream, "", nil},
+		{blank, "dolly", []string{"dolly"}},
+		{blank, "", nil},
+		{empty, "dolly", []string{"dolly"}},
+	}
+
+	for _, tc := range tcases {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		NewServerHeader(tc.handler, tc.value).ServeHTTP(w, r)
+		assert.Equal(t, tc.expected, w.Header()[header.Server], "value=%q", tc.value)
+	}
+}
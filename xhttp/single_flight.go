@@ -0,0 +1,210 @@
+package xhttp
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/identity"
+	"golang.org/x/sync/singleflight"
+)
+
+var keyForHTTPReqCoalesced = []string{"http", "request", "coalesced"}
+
+// DefaultSingleFlightMaxBodySize specifies the default max size of the shared response body
+const DefaultSingleFlightMaxBodySize = 1 << 20
+
+// SingleFlight is a http.Handler that coalesces the concurrent identical GET requests:
+// one request is served by the delegate, while the others wait,
+// and are replied with the copy of its response.
+//
+// The requests are identical, if they have the same path and query,
+// the caller's identity and tenant, and Accept, Accept-Encoding headers.
+// Use it only for the routes, where the response is the same for such requests.
+//
+// The responses with the body larger than the max size, or flushed by the handler,
+// are not shared, and the waiting requests are served by the delegate.
+type SingleFlight struct {
+	delegate    http.Handler
+	maxBodySize int
+	group       singleflight.Group
+}
+
+// flightResponse is the response to share with the waiting requests
+type flightResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// NewSingleFlight returns a handler that coalesces the identical GET requests,
+// with DefaultSingleFlightMaxBodySize of the shared response
+func NewSingleFlight(delegate http.Handler) *SingleFlight {
+	return &SingleFlight{
+		delegate:    delegate,
+		maxBodySize: DefaultSingleFlightMaxBodySize,
+	}
+}
+
+// WithMaxBodySize sets the max size of the response body to share
+func (s *SingleFlight) WithMaxBodySize(size int) *SingleFlight {
+	s.maxBodySize = size
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *SingleFlight) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.delegate.ServeHTTP(w, r)
+		return
+	}
+
+	var leader bool
+	var panicked interface{}
+	v, _, _ := s.group.Do(singleFlightKey(r), func() (interface{}, error) {
+		leader = true
+		// the group does not release the waiters on panic,
+		// the panic is raised after the waiters are served by the delegate
+		defer func() {
+			panicked = recover()
+		}()
+		return s.serve(w, r), nil
+	})
+	if leader {
+		if panicked != nil {
+			panic(panicked)
+		}
+		return
+	}
+
+	resp, _ := v.(*flightResponse)
+	if resp == nil {
+		s.delegate.ServeHTTP(w, r)
+		return
+	}
+	metrics.IncrCounter(keyForHTTPReqCoalesced, 1,
+		metrics.Tag{Name: tags.Method, Value: r.Method},
+	)
+	resp.replay(w)
+}
+
+// serve serves the request by the delegate,
+// and returns the response to share, or nil if it can not be shared
+func (s *SingleFlight) serve(w http.ResponseWriter, r *http.Request) *flightResponse {
+	// the headers set before the handler, such as X-Correlation-ID, are not shared
+	before := headerKeys(w.Header())
+	rec := &flightRecorder{
+		ResponseWriter: w,
+		status:         http.StatusOK,
+		max:            s.maxBodySize,
+	}
+	s.delegate.ServeHTTP(rec, r)
+
+	// the response of the disconnected client may be incomplete
+	if rec.overflow || r.Context().Err() != nil {
+		return nil
+	}
+	if rec.header == nil {
+		rec.header = w.Header().Clone()
+	}
+	resp := &flightResponse{
+		status: rec.status,
+		header: http.Header{},
+		body:   rec.body.Bytes(),
+	}
+	for k, v := range rec.header {
+		if !before[k] {
+			resp.header[k] = v
+		}
+	}
+	return resp
+}
+
+// replay writes the shared response
+func (c *flightResponse) replay(w http.ResponseWriter) {
+	h := w.Header()
+	for k, v := range c.header {
+		h[k] = append([]string(nil), v...)
+	}
+	w.WriteHeader(c.status)
+	w.Write(c.body)
+}
+
+// headerKeys returns the set of the header keys
+func headerKeys(h http.Header) map[string]bool {
+	keys := make(map[string]bool, len(h))
+	for k := range h {
+		keys[k] = true
+	}
+	return keys
+}
+
+// singleFlightKey returns the key of the identical requests
+func singleFlightKey(r *http.Request) string {
+	ctx := identity.ForRequest(r)
+	var id string
+	if ctx.Identity() != nil {
+		id = ctx.Identity().String()
+	}
+	return strings.Join([]string{
+		r.Method,
+		r.Host,
+		r.URL.RequestURI(),
+		id,
+		ctx.Tenant(),
+		r.Header.Get(header.Accept),
+		r.Header.Get(header.AcceptEncoding),
+	}, "\n")
+}
+
+// flightRecorder writes the response, and records its copy to share
+type flightRecorder struct {
+	http.ResponseWriter
+	status   int
+	header   http.Header
+	body     bytes.Buffer
+	max      int
+	overflow bool
+}
+
+// WriteHeader sets the HTTP status code of the response
+func (r *flightRecorder) WriteHeader(sc int) {
+	if r.header == nil {
+		r.status = sc
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+	r.ResponseWriter.WriteHeader(sc)
+}
+
+// Write the supplied data to the response, and to the copy within the max size
+func (r *flightRecorder) Write(data []byte) (int, error) {
+	if r.header == nil {
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+	if !r.overflow {
+		if r.body.Len()+len(data) > r.max {
+			r.overflow = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(data)
+		}
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// Flush sends any buffered data to the client,
+// the streamed responses are not shared
+func (r *flightRecorder) Flush() {
+	r.overflow = true
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer
+func (r *flightRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
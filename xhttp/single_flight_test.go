@@ -0,0 +1,173 @@
+package xhttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SingleFlight(t *testing.T) {
+	im := metrics.NewInmemSink(time.Minute, time.Minute*5)
+	_, err := metrics.NewGlobal(metrics.DefaultConfig("singleflight"), im)
+	require.NoError(t, err)
+
+	var calls int32
+	entered := make(chan struct{}, 10)
+	release := make(chan struct{})
+	delegate := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		entered <- struct{}{}
+		<-release
+		w.Header().Set("X-Test", "value")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("expensive result"))
+	})
+
+	run := func(h http.Handler, n int, method string) []*httptest.ResponseRecorder {
+		atomic.StoreInt32(&calls, 0)
+		release = make(chan struct{})
+
+		res := make([]*httptest.ResponseRecorder, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			res[i] = httptest.NewRecorder()
+			wg.Add(1)
+			go func(w *httptest.ResponseRecorder) {
+				defer wg.Done()
+				h.ServeHTTP(w, httptest.NewRequest(method, "/v1/expensive?q=1", nil))
+			}(res[i])
+			if i == 0 {
+				// the first request is in flight
+				<-entered
+			}
+		}
+		// let the other requests to join the flight
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		for len(entered) > 0 {
+			<-entered
+		}
+		return res
+	}
+
+	t.Run("shared", func(t *testing.T) {
+		res := run(NewSingleFlight(delegate), 5, http.MethodGet)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		for _, w := range res {
+			assert.Equal(t, http.StatusAccepted, w.Code)
+			assert.Equal(t, "value", w.Header().Get("X-Test"))
+			assert.Equal(t, "expensive result", w.Body.String())
+		}
+
+		c, ok := im.Data()[0].Counters["singleflight.http.request.coalesced;method=GET"]
+		require.True(t, ok)
+		assert.Equal(t, 4, c.Count)
+	})
+
+	t.Run("too_large", func(t *testing.T) {
+		res := run(NewSingleFlight(delegate).WithMaxBodySize(4), 3, http.MethodGet)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+		for _, w := range res {
+			assert.Equal(t, "expensive result", w.Body.String())
+		}
+	})
+
+	t.Run("not_get", func(t *testing.T) {
+		entered = make(chan struct{}, 10)
+		h := NewSingleFlight(delegate)
+		atomic.StoreInt32(&calls, 0)
+		release = make(chan struct{})
+		close(release)
+		for i := 0; i < 2; i++ {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/expensive", nil))
+			assert.Equal(t, "expensive result", w.Body.String())
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+}
+
+func Test_SingleFlightCorrelationID(t *testing.T) {
+	entered := make(chan struct{}, 10)
+	release := make(chan struct{})
+	h := NewSingleFlight(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.Header().Set("X-Test", "value")
+		w.Write([]byte("result"))
+	}))
+
+	res := make([]*httptest.ResponseRecorder, 3)
+	var wg sync.WaitGroup
+	for i := range res {
+		res[i] = httptest.NewRecorder()
+		// the header is set before the handler, as by identity.NewContextHandler
+		res[i].Header().Set(header.XCorrelationID, fmt.Sprintf("cid-%d", i))
+		wg.Add(1)
+		go func(w *httptest.ResponseRecorder) {
+			defer wg.Done()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/expensive", nil))
+		}(res[i])
+		if i == 0 {
+			<-entered
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i, w := range res {
+		assert.Equal(t, "result", w.Body.String())
+		assert.Equal(t, "value", w.Header().Get("X-Test"))
+		assert.Equal(t, []string{fmt.Sprintf("cid-%d", i)}, w.Header().Values(header.XCorrelationID),
+			"the waiter must keep its own correlation ID")
+	}
+}
+
+func Test_SingleFlightPanic(t *testing.T) {
+	var calls int32
+	entered := make(chan struct{}, 10)
+	release := make(chan struct{})
+	h := NewSingleFlight(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			entered <- struct{}{}
+			<-release
+			panic("handler failed")
+		}
+		w.Write([]byte("result"))
+	}))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.PanicsWithValue(t, "handler failed", func() {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/expensive", nil))
+		})
+	}()
+	<-entered
+
+	w := httptest.NewRecorder()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/expensive", nil))
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// the waiter is served by the delegate
+	assert.Equal(t, "result", w.Body.String())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,211 @@
+package xhttp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+var keyForHTTPReqTimeout = []string{"http", "request", "timeout"}
+
+// Timeout is a http.Handler that limits the time allowed for the delegate handler
+// to process a request.
+type Timeout struct {
+	delegate http.Handler
+	timeout  time.Duration
+	// routes specifies timeout overrides by path prefix
+	routes map[string]time.Duration
+	// maxClient specifies the max timeout allowed to be requested by the client
+	maxClient time.Duration
+}
+
+// NewTimeout returns a handler that runs the delegate with the request context deadline.
+// If the delegate does not complete within the timeout,
+// then the request context is canceled, so the handler can bail,
+// and 503 Service Unavailable response is returned to the client.
+//
+// Streaming requests, such as SSE (Accept: text/event-stream) or WebSocket upgrade,
+// are not limited by the timeout.
+func NewTimeout(delegate http.Handler, timeout time.Duration) *Timeout {
+	return &Timeout{
+		delegate: delegate,
+		timeout:  timeout,
+		routes:   map[string]time.Duration{},
+	}
+}
+
+// WithRoute allows to override the timeout for the requests
+// with the specified path prefix. The longest matching prefix is used.
+// Zero timeout disables the limit for the route, for example for streaming end-points.
+func (t *Timeout) WithRoute(prefix string, timeout time.Duration) *Timeout {
+	t.routes[prefix] = timeout
+	return t
+}
+
+// WithClientTimeout allows the clients to specify the timeout of the request
+// in X-Request-Timeout header, in Go duration format, such as 2s or 500ms.
+// The timeout requested by the client is applied, if it does not exceed max,
+// otherwise for the invalid or exceeding value the route or default timeout is used.
+// Zero max disables the header, that is the default.
+func (t *Timeout) WithClientTimeout(max time.Duration) *Timeout {
+	t.maxClient = max
+	return t
+}
+
+// timeoutFor returns the timeout for the request
+func (t *Timeout) timeoutFor(r *http.Request) time.Duration {
+	if isStreamingRequest(r) {
+		return 0
+	}
+
+	if d := t.clientTimeout(r); d > 0 {
+		return d
+	}
+
+	timeout := t.timeout
+	matched := -1
+	for prefix, d := range t.routes {
+		if len(prefix) > matched && strings.HasPrefix(r.URL.Path, prefix) {
+			matched = len(prefix)
+			timeout = d
+		}
+	}
+	return timeout
+}
+
+// clientTimeout returns the timeout requested by the client,
+// or zero if it's not allowed, not specified, or invalid
+func (t *Timeout) clientTimeout(r *http.Request) time.Duration {
+	if t.maxClient <= 0 {
+		return 0
+	}
+	v := r.Header.Get(header.XRequestTimeout)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 || d > t.maxClient {
+		logger.Debugf("api=Timeout, reason=client_timeout_ignored, method=%s, path=%s, timeout=%q, max=%v",
+			r.Method, r.URL.Path, v, t.maxClient)
+		return 0
+	}
+	return d
+}
+
+// isStreamingRequest returns true for SSE and WebSocket requests
+func isStreamingRequest(r *http.Request) bool {
+	return strings.Contains(r.Header.Get(header.Accept), header.TextEventStream) ||
+		strings.EqualFold(r.Header.Get(header.Upgrade), "websocket")
+}
+
+// ServeHTTP implements the http.Handler interface
+func (t *Timeout) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	timeout := t.timeoutFor(r)
+	if timeout <= 0 {
+		t.delegate.ServeHTTP(w, r)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	tw := &timeoutWriter{
+		h: make(http.Header),
+	}
+
+	done := make(chan struct{})
+	panicChan := make(chan interface{}, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicChan <- p
+			}
+		}()
+		t.delegate.ServeHTTP(tw, r)
+		close(done)
+	}()
+
+	select {
+	case p := <-panicChan:
+		panic(p)
+	case <-done:
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		dst := w.Header()
+		for k, vv := range tw.h {
+			dst[k] = vv
+		}
+		if !tw.wroteHeader {
+			tw.code = http.StatusOK
+		}
+		w.WriteHeader(tw.code)
+		w.Write(tw.wbuf.Bytes())
+	case <-ctx.Done():
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		tw.timedOut = true
+
+		metrics.IncrCounter(keyForHTTPReqTimeout, 1,
+			metrics.Tag{Name: tags.Method, Value: r.Method},
+			metrics.Tag{Name: tags.URI, Value: r.URL.Path},
+		)
+		logger.Warningf("api=Timeout, reason=timeout, method=%s, path=%s, timeout=%v",
+			r.Method, r.URL.Path, timeout)
+
+		marshal.WriteJSON(w, r, httperror.WithTimeout("the request was not processed within %v", timeout))
+	}
+}
+
+// timeoutWriter buffers the response until the handler completes
+type timeoutWriter struct {
+	h    http.Header
+	wbuf bytes.Buffer
+
+	mu          sync.Mutex
+	timedOut    bool
+	wroteHeader bool
+	code        int
+}
+
+// Header returns the buffered header
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+// Write buffers the data
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeader(http.StatusOK)
+	}
+	return tw.wbuf.Write(p)
+}
+
+// WriteHeader sets the HTTP status code of the response
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeader(code)
+}
+
+func (tw *timeoutWriter) writeHeader(code int) {
+	tw.wroteHeader = true
+	tw.code = code
+}
@@ -0,0 +1,122 @@
+package xhttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Timeout(t *testing.T) {
+	im := metrics.NewInmemSink(time.Minute, time.Minute*5)
+	_, err := metrics.NewGlobal(metrics.DefaultConfig("test"), im)
+	require.NoError(t, err)
+
+	canceled := make(chan bool, 1)
+	h := func(w http.ResponseWriter, r *http.Request) {
+		d, _ := time.ParseDuration(r.URL.Query().Get("sleep"))
+		select {
+		case <-time.After(d):
+			w.Header().Set(header.ContentType, header.TextPlain)
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, "done")
+		case <-r.Context().Done():
+			canceled <- true
+		}
+	}
+
+	handler := NewTimeout(http.HandlerFunc(h), 100*time.Millisecond).
+		WithRoute("/long", time.Second).
+		WithRoute("/stream", 0)
+
+	t.Run("completed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, "/v1/fast?sleep=1ms", nil)
+		require.NoError(t, err)
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, header.TextPlain, w.Header().Get(header.ContentType))
+		assert.Equal(t, "done", w.Body.String())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, "/v1/slow?sleep=1s", nil)
+		require.NoError(t, err)
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, `{"code":"timeout","message":"the request was not processed within 100ms"}`, w.Body.String())
+
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			assert.Fail(t, "the request context must be canceled")
+		}
+
+		data := im.Data()
+		require.NotEmpty(t, data)
+		s, exists := data[0].Counters["test.http.request.timeout;method=GET;uri=/v1/slow"]
+		require.True(t, exists)
+		assert.Equal(t, 1, s.Count)
+	})
+
+	t.Run("route override", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, "/long/op?sleep=200ms", nil)
+		require.NoError(t, err)
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		w = httptest.NewRecorder()
+		r, err = http.NewRequest(http.MethodGet, "/stream?sleep=200ms", nil)
+		require.NoError(t, err)
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, "/v1/events?sleep=200ms", nil)
+		require.NoError(t, err)
+		r.Header.Set(header.Accept, header.TextEventStream)
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("client timeout", func(t *testing.T) {
+		handler := NewTimeout(http.HandlerFunc(h), 500*time.Millisecond).
+			WithClientTimeout(time.Second)
+
+		tcases := []struct {
+			timeout string
+			sleep   string
+			status  int
+		}{
+			{"50ms", "200ms", http.StatusServiceUnavailable},
+			{"800ms", "600ms", http.StatusCreated},
+			// over the cap, the default timeout is used
+			{"2s", "600ms", http.StatusServiceUnavailable},
+			{"invalid", "1ms", http.StatusCreated},
+			{"", "1ms", http.StatusCreated},
+		}
+		for _, tc := range tcases {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest(http.MethodGet, "/v1/op?sleep="+tc.sleep, nil)
+			require.NoError(t, err)
+			if tc.timeout != "" {
+				r.Header.Set(header.XRequestTimeout, tc.timeout)
+			}
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tc.status, w.Code, tc.timeout)
+			if tc.status == http.StatusServiceUnavailable {
+				<-canceled
+			}
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package xhttp
+
+import (
+	"net/http"
+	"strings"
+)
+
+// TrailingSlashPolicy specifies how the requests with the trailing slash are handled
+type TrailingSlashPolicy int
+
+const (
+	// TrailingSlashKeep passes the requests as is
+	TrailingSlashKeep TrailingSlashPolicy = iota
+	// TrailingSlashStrip removes the trailing slash from the path before routing
+	TrailingSlashStrip
+	// TrailingSlashRedirect redirects the client to the path without the trailing slash
+	TrailingSlashRedirect
+)
+
+// TrailingSlash is a http.Handler that normalizes the trailing slash of the request path,
+// so /v1/items/ and /v1/items are served by the same route
+type TrailingSlash struct {
+	delegate http.Handler
+	policy   TrailingSlashPolicy
+	exclude  []string
+}
+
+// NewTrailingSlash returns a handler that applies the trailing slash policy,
+// before the delegate handler is called.
+// The root path, and ACME HTTP-01 challenge requests are never changed.
+func NewTrailingSlash(delegate http.Handler, policy TrailingSlashPolicy) *TrailingSlash {
+	return &TrailingSlash{
+		delegate: delegate,
+		policy:   policy,
+		exclude:  []string{ACMEChallengePrefix},
+	}
+}
+
+// WithExclude excludes the slash-sensitive paths from the policy,
+// the path ending with slash excludes all paths with the prefix,
+// otherwise the path is matched exactly.
+func (t *TrailingSlash) WithExclude(paths ...string) *TrailingSlash {
+	t.exclude = append(t.exclude, paths...)
+	return t
+}
+
+// isExcluded returns true if the path is excluded from the policy
+func (t *TrailingSlash) isExcluded(path string) bool {
+	for _, p := range t.exclude {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP implements the http.Handler interface
+func (t *TrailingSlash) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if t.policy == TrailingSlashKeep || len(path) <= 1 || !strings.HasSuffix(path, "/") || t.isExcluded(path) {
+		t.delegate.ServeHTTP(w, r)
+		return
+	}
+
+	canonical := strings.TrimRight(path, "/")
+	if canonical == "" {
+		canonical = "/"
+	}
+
+	if t.policy == TrailingSlashRedirect {
+		u := *r.URL
+		u.Path = canonical
+		u.RawPath = strings.TrimRight(u.RawPath, "/")
+
+		// 308 preserves the method and body of non-GET requests
+		code := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, u.RequestURI(), code)
+		return
+	}
+
+	r2 := new(http.Request)
+	*r2 = *r
+	u := *r.URL
+	u.Path = canonical
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+	r2.URL = &u
+	t.delegate.ServeHTTP(w, r2)
+}
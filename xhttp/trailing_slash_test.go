@@ -0,0 +1,55 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-phorce/dolly/xhttp/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TrailingSlash(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RequestURI()))
+	})
+
+	keep := NewTrailingSlash(h, TrailingSlashKeep)
+	strip := NewTrailingSlash(h, TrailingSlashStrip).WithExclude("/v1/dav/")
+	redirect := NewTrailingSlash(h, TrailingSlashRedirect).WithExclude("/v1/exact/")
+
+	tcases := []struct {
+		handler  http.Handler
+		method   string
+		url      string
+		status   int
+		expected string
+	}{
+		{keep, http.MethodGet, "/v1/items/", http.StatusOK, "/v1/items/"},
+		{strip, http.MethodGet, "/v1/items/?q=1", http.StatusOK, "/v1/items?q=1"},
+		{strip, http.MethodGet, "/v1/items//", http.StatusOK, "/v1/items"},
+		{strip, http.MethodGet, "/", http.StatusOK, "/"},
+		{strip, http.MethodGet, "/v1/dav/files/", http.StatusOK, "/v1/dav/files/"},
+		{strip, http.MethodGet, ACMEChallengePrefix, http.StatusOK, ACMEChallengePrefix},
+		{redirect, http.MethodGet, "/v1/items/?q=1", http.StatusMovedPermanently, "/v1/items?q=1"},
+		{redirect, http.MethodPost, "/v1/items/", http.StatusPermanentRedirect, "/v1/items"},
+		{redirect, http.MethodPost, "/v1/items", http.StatusOK, "/v1/items"},
+		{redirect, http.MethodGet, "/v1/exact/", http.StatusOK, "/v1/exact/"},
+		{redirect, http.MethodGet, "/v1/exact/sub/", http.StatusOK, "/v1/exact/sub/"},
+		{redirect, http.MethodGet, "/v1/exactly/", http.StatusMovedPermanently, "/v1/exactly"},
+	}
+
+	for _, tc := range tcases {
+		r, err := http.NewRequest(tc.method, tc.url, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		tc.handler.ServeHTTP(w, r)
+		assert.Equal(t, tc.status, w.Code, tc.url)
+		if tc.status == http.StatusOK {
+			assert.Equal(t, tc.expected, w.Body.String(), tc.url)
+		} else {
+			assert.Equal(t, tc.expected, w.Header().Get(header.Location), tc.url)
+		}
+	}
+}
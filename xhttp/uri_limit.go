@@ -0,0 +1,31 @@
+package xhttp
+
+import (
+	"net/http"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/go-phorce/dolly/metrics/tags"
+	"github.com/go-phorce/dolly/xhttp/httperror"
+	"github.com/go-phorce/dolly/xhttp/marshal"
+)
+
+var keyForHTTPReqURITooLong = []string{"http", "request", "uri", "too_long"}
+
+// NewMaxURILength returns a handler that rejects the requests,
+// with the request URI longer than max bytes, with 414 Request-URI Too Long,
+// before the delegate handler is called
+func NewMaxURILength(delegate http.Handler, max int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.RequestURI) > max || len(r.URL.RequestURI()) > max {
+			metrics.IncrCounter(keyForHTTPReqURITooLong, 1,
+				metrics.Tag{Name: tags.Method, Value: r.Method},
+			)
+			logger.Warningf("api=MaxURILength, reason=too_long, method=%s, length=%d, limit=%d",
+				r.Method, len(r.RequestURI), max)
+
+			marshal.WriteJSON(w, r, httperror.WithRequestURITooLong("the request URI is too long"))
+			return
+		}
+		delegate.ServeHTTP(w, r)
+	})
+}
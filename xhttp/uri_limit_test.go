@@ -0,0 +1,41 @@
+package xhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-phorce/dolly/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MaxURILength(t *testing.T) {
+	im := metrics.NewInmemSink(time.Minute, time.Minute*5)
+	_, err := metrics.NewGlobal(metrics.DefaultConfig("test"), im)
+	require.NoError(t, err)
+
+	delegate := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := NewMaxURILength(delegate, 32)
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/short?q=1", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/v1/long?q="+strings.Repeat("a", 32), nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestURITooLong, w.Code)
+	assert.Equal(t, `{"code":"request_uri_too_long","message":"the request URI is too long"}`, w.Body.String())
+
+	data := im.Data()
+	require.NotEmpty(t, data)
+	c, exists := data[0].Counters["test.http.request.uri.too_long;method=GET"]
+	require.True(t, exists)
+	assert.Equal(t, 1, c.Count)
+}
@@ -0,0 +1,74 @@
+// Copyright 2018, Denis Issoupov
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KV logs the key/value pairs at the level, in the same format
+// as the printf-style logs of the packages: key1=value1, key2=value2.
+// The string values with spaces, commas, quotes or equal signs are quoted,
+// and the errors are logged in brackets: err=[error message].
+// The key without value is logged with <missing> value.
+func (p *PackageLogger) KV(l LogLevel, kv ...interface{}) {
+	if l != CRITICAL && !p.LevelAt(l) {
+		return
+	}
+	p.internalLog(calldepth, l, FormatKV(kv...))
+}
+
+// FormatKV returns the key/value pairs formatted as key1=value1, key2=value2
+func FormatKV(kv ...interface{}) string {
+	var b strings.Builder
+	for i := 0; i < len(kv); i += 2 {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(fmt.Sprint(kv[i]))
+		b.WriteByte('=')
+		if i+1 >= len(kv) {
+			b.WriteString("<missing>")
+			break
+		}
+		b.WriteString(formatValue(kv[i+1]))
+	}
+	return b.String()
+}
+
+// formatValue returns the value, quoted if needed
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case error:
+		return "[" + val.Error() + "]"
+	case string:
+		return quoteIfNeeded(val)
+	case fmt.Stringer:
+		return quoteIfNeeded(val.String())
+	default:
+		return quoteIfNeeded(fmt.Sprint(v))
+	}
+}
+
+// quoteIfNeeded returns the quoted string,
+// if it's empty or contains the separators
+func quoteIfNeeded(s string) string {
+	if s == "" || strings.ContainsAny(s, " ,=\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
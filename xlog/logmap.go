@@ -247,6 +247,27 @@ func NewPackageLogger(repo string, pkg string) (p *PackageLogger) {
 	return
 }
 
+// NewFormatterLogger creates a logger object, that writes to its own formatter,
+// instead of the global formatter, for example to write the access logs
+// to a separate file from the application logs.
+// The logger is not registered with the repo, and logs at INFO level.
+func NewFormatterLogger(pkg string, f Formatter) *PackageLogger {
+	return &PackageLogger{
+		pkg:       pkg,
+		level:     INFO,
+		formatter: f,
+	}
+}
+
+// getFormatter returns the formatter of the logger, or the global formatter,
+// must be called with the global lock held
+func (p *PackageLogger) getFormatter() Formatter {
+	if p.formatter != nil {
+		return p.formatter
+	}
+	return logger.formatter
+}
+
 // getRepoLogger wraps the call to capnlog.GetRepoLogger
 func getRepoLogger(repo string) (RepoLogger, error) {
 	repoLogger, err := GetRepoLogger(repo)
@@ -1,6 +1,7 @@
 package xlog_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -126,3 +127,19 @@ func Test_GetRepoLogger(t *testing.T) {
 	assert.Equal(t, xlog.NOTICE, mm["pkg2"])
 	assert.Equal(t, xlog.DEBUG, mm["pkg3"])
 }
+
+func Test_NewFormatterLogger(t *testing.T) {
+	var global, own bytes.Buffer
+	prev := xlog.GetFormatter()
+	xlog.SetFormatter(xlog.NewStringFormatter(&global))
+	defer xlog.SetFormatter(prev)
+
+	l := xlog.NewFormatterLogger("access", xlog.NewStringFormatter(&own))
+	l.Infof("GET /v1/status")
+	l.Debugf("not logged at INFO")
+	l.Flush()
+
+	assert.Contains(t, own.String(), "GET /v1/status")
+	assert.NotContains(t, own.String(), "not logged")
+	assert.Empty(t, global.String())
+}
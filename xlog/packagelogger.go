@@ -23,6 +23,8 @@ import (
 type PackageLogger struct {
 	pkg   string
 	level LogLevel
+	// formatter overrides the global formatter, if set
+	formatter Formatter
 }
 
 const calldepth = 2
@@ -33,8 +35,8 @@ func (p *PackageLogger) internalLog(depth int, inLevel LogLevel, entries ...inte
 	if inLevel != CRITICAL && p.level < inLevel {
 		return
 	}
-	if logger.formatter != nil {
-		logger.formatter.Format(p.pkg, inLevel, depth+1, entries...)
+	if f := p.getFormatter(); f != nil {
+		f.Format(p.pkg, inLevel, depth+1, entries...)
 	}
 }
 
@@ -214,5 +216,7 @@ func (p *PackageLogger) Trace(entries ...interface{}) {
 func (p *PackageLogger) Flush() {
 	logger.Lock()
 	defer logger.Unlock()
-	logger.formatter.Flush()
+	if f := p.getFormatter(); f != nil {
+		f.Flush()
+	}
 }
@@ -291,7 +291,7 @@ func Test_PrettyFormatterDebug(t *testing.T) {
 	logger.Debug("Test Debug\n")
 	logger.Debugf("Test Debug\n")
 	result = string(b.Bytes())
-	expected = "[packagelogger.go:184] D | xlog_test: Test Debug\n"
+	expected = "[packagelogger.go:186] D | xlog_test: Test Debug\n"
 	assert.Contains(t, result, expected, "Log format does not match")
 	b.Reset()
 
@@ -335,7 +335,7 @@ func Test_StringFormatter(t *testing.T) {
 	// Debug level is disabled
 	logger.Debugf("Test Debug\n")
 	result = string(b.Bytes())
-	expected = "[packagelogger.go:166] xlog_test: Test Debug\n"
+	expected = "[packagelogger.go:168] xlog_test: Test Debug\n"
 	assert.NotContains(t, result, expected, "Log format does not match")
 	b.Reset()
 
@@ -400,7 +400,7 @@ func Test_LogFormatter(t *testing.T) {
 	logger.Debugf("Test Debug\n")
 	writer.Flush()
 	result = string(b.Bytes())
-	expected = "[packagelogger.go:166] xlog_test: Test Debug\n"
+	expected = "[packagelogger.go:168] xlog_test: Test Debug\n"
 	assert.NotContains(t, result, expected, "Log format does not match")
 	b.Reset()
 
@@ -468,3 +468,21 @@ func Test_ColorFormatterDebug(t *testing.T) {
 	assert.Contains(t, result, expected)
 	b.Reset()
 }
+
+func Test_KV(t *testing.T) {
+	var b bytes.Buffer
+	writer := bufio.NewWriter(&b)
+
+	xlog.SetFormatter(xlog.NewStringFormatter(writer))
+	xlog.SetGlobalLogLevel(xlog.INFO)
+
+	logger.KV(xlog.INFO, "api", "Test_KV", "count", 2, "reason", "not found", "empty", "", "err", errors.New("failed"))
+	assert.Contains(t, b.String(), ` xlog_test: api=Test_KV, count=2, reason="not found", empty="", err=[failed]`+"\n")
+	b.Reset()
+
+	logger.KV(xlog.DEBUG, "api", "Test_KV")
+	assert.Empty(t, b.String())
+
+	assert.Equal(t, `k1=v1, k2=<missing>`, xlog.FormatKV("k1", "v1", "k2"))
+	assert.Equal(t, `msg="a=b, c", d=1.5`, xlog.FormatKV("msg", "a=b, c", "d", 1.5))
+}